@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	packets := r.NewCounterVec("test_packets_total", "Packets.", "opcode")
+	sessions := r.NewGaugeVec("test_sessions", "Sessions.", "server")
+	latency := r.NewHistogramVec("test_latency_seconds", "Latency.", []float64{0.1, 1}, "opcode")
+
+	packets.Inc("MSG_SYS_PING")
+	packets.Add(2, "MSG_SYS_PING")
+	sessions.Set(3, "1")
+	latency.Observe(0.05, "MSG_SYS_PING")
+	latency.Observe(0.5, "MSG_SYS_PING")
+	latency.Observe(5, "MSG_SYS_PING")
+
+	var buf bytes.Buffer
+	r.Expose(&buf)
+	expected := `# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{opcode="MSG_SYS_PING",le="0.1"} 1
+test_latency_seconds_bucket{opcode="MSG_SYS_PING",le="1"} 2
+test_latency_seconds_bucket{opcode="MSG_SYS_PING",le="+Inf"} 3
+test_latency_seconds_sum{opcode="MSG_SYS_PING"} 5.55
+test_latency_seconds_count{opcode="MSG_SYS_PING"} 3
+# HELP test_packets_total Packets.
+# TYPE test_packets_total counter
+test_packets_total{opcode="MSG_SYS_PING"} 3
+# HELP test_sessions Sessions.
+# TYPE test_sessions gauge
+test_sessions{server="1"} 3
+`
+	if buf.String() != expected {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), expected)
+	}
+}
@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// WrapDriver wraps a database/sql driver, counting the failed statements in errors.
+func WrapDriver(d driver.Driver, errors *CounterVec) driver.Driver {
+	return &countingDriver{d, errors}
+}
+
+type countingDriver struct {
+	driver.Driver
+	errors *CounterVec
+}
+
+func (d *countingDriver) count(err error, op string) error {
+	if err != nil && err != driver.ErrSkip {
+		d.errors.Inc(op)
+	}
+	return err
+}
+
+func (d *countingDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err = d.count(err, "open"); err != nil {
+		return nil, err
+	}
+	return &countingConn{conn, d}, nil
+}
+
+type countingConn struct {
+	driver.Conn
+	d *countingDriver
+}
+
+func (c *countingConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.Prepare(query)
+	if err = c.d.count(err, "prepare"); err != nil {
+		return nil, err
+	}
+	return &countingStmt{stmt, c.d}, nil
+}
+
+func (c *countingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	p, ok := c.Conn.(driver.ConnPrepareContext)
+	if !ok {
+		return c.Prepare(query)
+	}
+	stmt, err := p.PrepareContext(ctx, query)
+	if err = c.d.count(err, "prepare"); err != nil {
+		return nil, err
+	}
+	return &countingStmt{stmt, c.d}, nil
+}
+
+func (c *countingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err := b.BeginTx(ctx, opts)
+		return tx, c.d.count(err, "begin")
+	}
+	tx, err := c.Conn.Begin()
+	return tx, c.d.count(err, "begin")
+}
+
+func (c *countingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	res, err := e.ExecContext(ctx, query, args)
+	return res, c.d.count(err, "exec")
+}
+
+func (c *countingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	rows, err := q.QueryContext(ctx, query, args)
+	return rows, c.d.count(err, "query")
+}
+
+func (c *countingConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return c.d.count(p.Ping(ctx), "ping")
+	}
+	return nil
+}
+
+func (c *countingConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+type countingStmt struct {
+	driver.Stmt
+	d *countingDriver
+}
+
+func (s *countingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	res, err := s.Stmt.Exec(args)
+	return res, s.d.count(err, "exec")
+}
+
+func (s *countingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	rows, err := s.Stmt.Query(args)
+	return rows, s.d.count(err, "query")
+}
+
+func (s *countingStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err := e.ExecContext(ctx, args)
+		return res, s.d.count(err, "exec")
+	}
+	values, err := namedToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Exec(values)
+}
+
+func (s *countingStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err := q.QueryContext(ctx, args)
+		return rows, s.d.count(err, "query")
+	}
+	values, err := namedToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Query(values)
+}
+
+func namedToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, driver.ErrSkip
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,195 @@
+// Package mhfmon lists the monsters of the game by ID.
+package mhfmon
+
+// Monster is a monster of the game, Large telling it apart from the small
+// monsters hunted alongside the targets of a quest.
+type Monster struct {
+	Name  string
+	Large bool
+}
+
+// Monsters is indexed by monster ID, the unnamed IDs being unused.
+var Monsters = []Monster{
+	{"Mon0", false},
+	{"Rathian", true},
+	{"Fatalis", true},
+	{"Kelbi", false},
+	{"Mosswine", false},
+	{"Bullfango", false},
+	{"Yian Kut-Ku", true},
+	{"Lao-Shan Lung", true},
+	{"Cephadrome", true},
+	{"Felyne", false},
+	{"Veggie Elder", false},
+	{"Rathalos", true},
+	{"Aptonoth", false},
+	{"Genprey", false},
+	{"Diablos", true},
+	{"Khezu", true},
+	{"Velociprey", false},
+	{"Gravios", true},
+	{"Mon18", false},
+	{"Vespoid", false},
+	{"Gypceros", true},
+	{"Plesioth", true},
+	{"Basarios", true},
+	{"Melynx", false},
+	{"Hornetaur", false},
+	{"Apceros", false},
+	{"Monoblos", true},
+	{"Velocidrome", true},
+	{"Gendrome", true},
+	{"Mon29", false},
+	{"Ioprey", false},
+	{"Iodrome", true},
+	{"Mon32", false},
+	{"Kirin", true},
+	{"Cephalos", false},
+	{"Giaprey", false},
+	{"Crimson Fatalis", true},
+	{"Pink Rathian", true},
+	{"Blue Yian Kut-Ku", true},
+	{"Purple Gypceros", true},
+	{"Yian Garuga", true},
+	{"Silver Rathalos", true},
+	{"Gold Rathian", true},
+	{"Black Diablos", true},
+	{"White Monoblos", true},
+	{"Red Khezu", true},
+	{"Green Plesioth", true},
+	{"Black Gravios", true},
+	{"Daimyo Hermitaur", true},
+	{"Azure Rathalos", true},
+	{"Ashen Lao-Shan Lung", true},
+	{"Blangonga", true},
+	{"Congalala", true},
+	{"Rajang", true},
+	{"Kushala Daora", true},
+	{"Shen Gaoren", true},
+	{"Great Thunderbug", false},
+	{"Shakalaka", false},
+	{"Yama Tsukami", true},
+	{"Chameleos", true},
+	{"Rusted Kushala Daora", true},
+	{"Blango", false},
+	{"Conga", false},
+	{"Remobra", false},
+	{"Lunastra", true},
+	{"Teostra", true},
+	{"Hermitaur", false},
+	{"Shogun Ceanataur", true},
+	{"Bulldrome", true},
+	{"Anteka", false},
+	{"Popo", false},
+	{"White Fatalis", true},
+	{"Mon72", false},
+	{"Ceanataur", false},
+	{"Hypnocatrice", true},
+	{"Lavasioth", true},
+	{"Tigrex", true},
+	{"Akantor", true},
+	{"Bright Hypnoc", true},
+	{"Lavasioth Subspecies", true},
+	{"Espinas", true},
+	{"Orange Espinas", true},
+	{"White Hypnoc", true},
+	{"Akura Vashimu", true},
+	{"Akura Jebia", true},
+	{"Berukyurosu", true},
+	{"Mon86", false},
+	{"Mon87", false},
+	{"Mon88", false},
+	{"Pariapuria", true},
+	{"White Espinas", true},
+	{"Kamu Orugaron", true},
+	{"Nono Orugaron", true},
+	{"Raviente", true},
+	{"Dyuragaua", true},
+	{"Doragyurosu", true},
+	{"Gurenzeburu", true},
+	{"Burukku", false},
+	{"Erupe", false},
+	{"Rukodiora", true},
+	{"Unknown", true},
+	{"Gogomoa", true},
+	{"Kokomoa", false},
+	{"Taikun Zamuza", true},
+	{"Abiorugu", true},
+	{"Kuarusepusu", true},
+	{"Odibatorasu", true},
+	{"Disufiroa", true},
+	{"Rebidiora", true},
+	{"Anorupatisu", true},
+	{"Hyujikiki", true},
+	{"Midogaron", true},
+	{"Giaorugu", true},
+	{"Mi Ru", true},
+	{"Farunokku", true},
+	{"Pokaradon", true},
+	{"Shantien", true},
+	{"Pokara", false},
+	{"Mon118", false},
+	{"Goruganosu", true},
+	{"Aruganosu", true},
+	{"Baruragaru", true},
+	{"Zerureusu", true},
+	{"Gougarf", true},
+	{"Uruki", false},
+	{"Forokururu", true},
+	{"Meraginasu", true},
+	{"Diorekkusu", true},
+	{"Garuba Daora", true},
+	{"Inagami", true},
+	{"Varusaburosu", true},
+	{"Poborubarumu", true},
+	{"1st District Duremudira", true},
+	{"Mon133", false},
+	{"Mon134", false},
+	{"Mon135", false},
+	{"Mon136", false},
+	{"Mon137", false},
+	{"Mon138", false},
+	{"Gureadomosu", true},
+	{"Harudomerugu", true},
+	{"Toridcless", true},
+	{"Gasurabazura", true},
+	{"Kusubami", false},
+	{"Yama Kurai", true},
+	{"2nd District Duremudira", true},
+	{"Zinogre", true},
+	{"Deviljho", true},
+	{"Brachydios", true},
+	{"Berserk Raviente", true},
+	{"Toa Tesukatora", true},
+	{"Barioth", true},
+	{"Uragaan", true},
+	{"Stygian Zinogre", true},
+	{"Guanzorumu", true},
+	{"Starving Deviljho", true},
+	{"Mon156", false},
+	{"Egyurasu", false},
+	{"Voljang", true},
+	{"Nargacuga", true},
+	{"Keoaruboru", true},
+	{"Zenaserisu", true},
+	{"Gore Magala", true},
+	{"Blinking Nargacuga", true},
+	{"Shagaru Magala", true},
+	{"Amatsu", true},
+	{"Elzelion", true},
+	{"Musou Duremudira", true},
+	{"Mon169", false},
+	{"Seregios", true},
+	{"Bogabadorumu", true},
+	{"Mon172", false},
+	{"Blitzkrieg Bogabadorumu", true},
+	{"Costumed Uruki", false},
+	{"Sparkling Zerureusu", true},
+	{"PSO2 Rappy", false},
+	{"King Shakalaka", true},
+}
+
+// IsLarge reports whether id is a known large monster.
+func IsLarge(id uint32) bool {
+	return int(id) < len(Monsters) && Monsters[id].Large
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"net"
+	"regexp"
 
 	"github.com/spf13/viper"
 )
@@ -16,7 +18,16 @@ type Config struct {
 	DevModeOptions DevModeOptions
 	Discord        Discord
 	Database       Database
+	Bus            Bus
 	Launcher       Launcher
+	AdminAPI       AdminAPI
+	Metrics        Metrics
+	Moderation     Moderation
+	Quests         Quests
+	Saves          Saves
+	Raviente       Raviente
+	Titles         Titles
+	Guilds         Guilds
 	Sign           Sign
 	Entrance       Entrance
 }
@@ -29,8 +40,6 @@ type DevModeOptions struct {
 	FixedStageID        bool   // Causes all move_stage to use the ID sl1Ns200p0a0u0 to get you into all stages
 	LogInboundMessages  bool   // Log all messages sent to the server
 	LogOutboundMessages bool   // Log all messages sent to the clients
-	DivaEvent           int    // Diva Defense event status
-	FestaEvent          int    // Hunter's Festa event status
 	TournamentEvent     int    // VS Tournament event status
 	MezFesEvent         bool   // MezFes status
 	SaveDumps           SaveDumpOptions
@@ -41,6 +50,43 @@ type SaveDumpOptions struct {
 	OutputDir string
 }
 
+// Quests holds the quest and scenario file config.
+type Quests struct {
+	CacheEntries int  // Number of quest and scenario files kept in memory.
+	HotReload    bool // Drop cached files as soon as they change on disk.
+}
+
+// Saves holds the character savedata config.
+type Saves struct {
+	Snapshots int // Number of savedata snapshots kept per character for rollbacks, 0 disables them.
+}
+
+// Raviente holds the Great Slaying config, shared by the channels of a world.
+type Raviente struct {
+	Hours    []int // Hours of the day a Great Slaying is scheduled at, empty leaves starting it to the players.
+	Duration int   // Minutes after its start a Great Slaying is reset, 0 only resets it once everyone left.
+}
+
+// Titles holds the guild card title config.
+type Titles struct {
+	UnlockAll    bool               // Lists every title as unlocked regardless of what the character acquired, meant for private servers.
+	Achievements []AchievementTitle // Titles granted when an achievement ranks up.
+}
+
+// AchievementTitle grants a title once an achievement reaches a rank.
+type AchievementTitle struct {
+	Achievement uint8
+	Rank        uint8 // 1 to 8.
+	Title       uint16
+}
+
+// Guilds holds the guild activity config.
+type Guilds struct {
+	MissionRotationDays int   // Days a guild keeps its mission set before unfinished missions are replaced.
+	WeeklyBonusHunts    []int // Monsters the members of a guild must hunt in a week to reach each weekly bonus tier, at most 10.
+	WeeklyBonusActive   int   // Members that must be active in a week for the guild to get a weekly bonus.
+}
+
 // Discord holds the discord integration config.
 type Discord struct {
 	Enabled   		  bool
@@ -60,21 +106,52 @@ type Database struct {
 	Database string
 }
 
+// Bus holds the config of the bus connecting the servers.
+type Bus struct {
+	Driver  string // "local" when every server runs in one process, "postgres" to reach the servers of other processes through LISTEN/NOTIFY.
+	Channel string // Notification channel used by the "postgres" driver.
+}
+
 // Launcher holds the launcher server config.
 type Launcher struct {
 	Port                     int
 	UseOriginalLauncherFiles bool
 }
 
+// AdminAPI holds the config for the admin REST API served by the launcher server.
+type AdminAPI struct {
+	Enabled bool
+	Token   string // Bearer token required on every /api/admin/ request.
+}
+
+// Metrics holds the config for the Prometheus metrics endpoint.
+type Metrics struct {
+	Enabled bool
+	Port    int // Serves the metrics on http://<host>:<port>/metrics.
+}
+
+// Moderation holds the in-game moderation config.
+type Moderation struct {
+	Rights uint32 // Rights bits an account needs to use moderation chat commands, 0 disables them.
+}
+
 // Sign holds the sign server config.
 type Sign struct {
-	Port int
+	Port                        int
+	Registration                string // What happens on an unknown username: "open" creates the account, "closed" refuses it, "invite" requires "username:code".
+	UsernamePattern             string // Regular expression new usernames must match, empty allows any.
+	MinPasswordLength           int
+	PasswordNeedsLetterAndDigit bool
+
+	UsernameRegexp *regexp.Regexp `mapstructure:"-"` // UsernamePattern compiled by LoadConfig, nil allows any.
 }
 
 // Entrance holds the entrance server config.
 type Entrance struct {
-	Port    uint16
-	Entries []EntranceServerInfo
+	Port              uint16
+	HeartbeatTimeout  int  // Seconds without a heartbeat after which a channel is considered down.
+	HideStaleChannels bool // Hides down channels from the list instead of listing them as full.
+	Entries           []EntranceServerInfo
 }
 
 // EntranceServerInfo represents an entry in the serverlist.
@@ -95,6 +172,7 @@ type EntranceServerInfo struct {
 // EntranceChannelInfo represents an entry in a server's channel list.
 type EntranceChannelInfo struct {
 	Port           uint16
+	QuestOverrides string // Directory of quest files taking precedence on this channel, a quest_override.bin in it replaces every quest.
 	MaxPlayers     uint16
 	CurrentPlayers uint16
 	Unk0           uint16
@@ -126,6 +204,28 @@ func LoadConfig() (*Config, error) {
 		OutputDir: "savedata",
 	})
 
+	viper.SetDefault("Bus.Driver", "local")
+	viper.SetDefault("Bus.Channel", "erupe_bus")
+
+	viper.SetDefault("Metrics.Port", 9110)
+
+	viper.SetDefault("Quests.CacheEntries", 256)
+	viper.SetDefault("Quests.HotReload", true)
+
+	viper.SetDefault("Saves.Snapshots", 10)
+
+	viper.SetDefault("Raviente.Duration", 120)
+
+	viper.SetDefault("Guilds.MissionRotationDays", 7)
+	viper.SetDefault("Guilds.WeeklyBonusHunts", []int{10, 30, 60, 100, 150})
+	viper.SetDefault("Guilds.WeeklyBonusActive", 3)
+
+	viper.SetDefault("Entrance.HeartbeatTimeout", 30)
+
+	viper.SetDefault("Sign.Registration", "open")
+	viper.SetDefault("Sign.UsernamePattern", "^[A-Za-z0-9_.-]{3,16}$")
+	viper.SetDefault("Sign.MinPasswordLength", 6)
+
 	err := viper.ReadInConfig()
 	if err != nil {
 		return nil, err
@@ -141,5 +241,12 @@ func LoadConfig() (*Config, error) {
 		c.HostIP = getOutboundIP4().To4().String()
 	}
 
+	if c.Sign.UsernamePattern != "" {
+		c.Sign.UsernameRegexp, err = regexp.Compile(c.Sign.UsernamePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sign.usernamepattern: %w", err)
+		}
+	}
+
 	return c, nil
 }
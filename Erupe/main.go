@@ -28,6 +28,35 @@ func cleanDB(db *sqlx.DB) {
 	_ = db.MustExec("DELETE FROM users")
 }
 
+// connectString returns the postgres connection string of the config.
+func connectString(erupeConfig *config.Config) string {
+	return fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname= %s sslmode=disable",
+		erupeConfig.Database.Host,
+		erupeConfig.Database.Port,
+		erupeConfig.Database.User,
+		erupeConfig.Database.Password,
+		erupeConfig.Database.Database,
+	)
+}
+
+// openDB creates the postgres DB pool and checks the connection.
+func openDB(erupeConfig *config.Config) (*sqlx.DB, error) {
+	db, err := sqlx.Open(metricsDriverName, connectString(erupeConfig))
+	if err != nil {
+		return nil, err
+	}
+	// Keep the postgres bind variables with the wrapped driver.
+	db = sqlx.NewDb(db.DB, "postgres")
+
+	// Test the DB connection.
+	err = db.Ping()
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
 func main() {
 	zapLogger, _ := zap.NewDevelopment()
 	defer zapLogger.Sync()
@@ -41,6 +70,54 @@ func main() {
 		logger.Fatal("Failed to load config", zap.Error(err))
 	}
 
+	// `erupe migrate ...` manages the schema and exits without starting any server.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		db, err := openDB(erupeConfig)
+		if err != nil {
+			logger.Fatal("Failed to connect to database", zap.Error(err))
+		}
+		err = runMigrateCommand(db, logger.Named("migrate"), os.Args[2:])
+		if err != nil {
+			logger.Fatal("Migration command failed", zap.Error(err))
+		}
+		return
+	}
+
+	// `erupe quests import` fills the quest catalogue from the quest list files.
+	if len(os.Args) > 1 && os.Args[1] == "quests" {
+		db, err := openDB(erupeConfig)
+		if err != nil {
+			logger.Fatal("Failed to connect to database", zap.Error(err))
+		}
+		err = runQuestsCommand(db, erupeConfig, os.Args[2:])
+		if err != nil {
+			logger.Fatal("Quests command failed", zap.Error(err))
+		}
+		return
+	}
+
+	// `erupe snapshots ...` lists and restores savedata snapshots.
+	if len(os.Args) > 1 && os.Args[1] == "snapshots" {
+		db, err := openDB(erupeConfig)
+		if err != nil {
+			logger.Fatal("Failed to connect to database", zap.Error(err))
+		}
+		err = runSnapshotsCommand(db, erupeConfig, logger.Named("snapshots"), os.Args[2:])
+		if err != nil {
+			logger.Fatal("Snapshots command failed", zap.Error(err))
+		}
+		return
+	}
+
+	// `erupe serve ...` only runs some of the servers, the others running in other processes.
+	sv := allServices(erupeConfig.Entrance.Entries)
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		sv, err = parseServices(os.Args[2:], erupeConfig.Entrance.Entries)
+		if err != nil {
+			logger.Fatal("Invalid serve command", zap.Error(err))
+		}
+	}
+
 	// Discord bot
 	var discordBot *discordbot.DiscordBot = nil
 
@@ -66,83 +143,108 @@ func main() {
 		logger.Info("Discord bot is disabled")
 	}
 
-	// Create the postgres DB pool.
-	connectString := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname= %s sslmode=disable",
-		erupeConfig.Database.Host,
-		erupeConfig.Database.Port,
-		erupeConfig.Database.User,
-		erupeConfig.Database.Password,
-		erupeConfig.Database.Database,
-	)
-
-	db, err := sqlx.Open("postgres", connectString)
+	db, err := openDB(erupeConfig)
 	if err != nil {
-		logger.Fatal("Failed to open sql database", zap.Error(err))
+		logger.Fatal("Failed to connect to database", zap.Error(err))
 	}
+	logger.Info("Connected to database")
 
-	// Test the DB connection.
-	err = db.Ping()
+	// Bring the schema up to date before anything touches the database.
+	err = migrateOnStartup(db, logger.Named("migrate"))
 	if err != nil {
-		logger.Fatal("Failed to ping database", zap.Error(err))
+		logger.Fatal("Failed to migrate database", zap.Error(err))
 	}
-	logger.Info("Connected to database")
 
-	// Clear existing tokens
-	_ = db.MustExec("DELETE FROM sign_sessions")
-	_ = db.MustExec("DELETE FROM servers")
+	// The sign server owns the sign sessions, the tokens of a previous run are stale.
+	if sv.sign {
+		_ = db.MustExec("DELETE FROM sign_sessions")
+
+		// Clean the DB if the option is on.
+		if erupeConfig.DevMode && erupeConfig.DevModeOptions.CleanDB {
+			logger.Info("Cleaning DB")
+			cleanDB(db)
+			logger.Info("Done cleaning DB")
+		}
+	}
 
-	// Clean the DB if the option is on.
-	if erupeConfig.DevMode && erupeConfig.DevModeOptions.CleanDB {
-		logger.Info("Cleaning DB")
-		cleanDB(db)
-		logger.Info("Done cleaning DB")
+	// Bus connecting the servers, across processes unless it is local.
+	serverBus, err := openBus(erupeConfig, db, logger.Named("bus"))
+	if err != nil {
+		logger.Fatal("Failed to open bus", zap.Error(err))
+	}
+	if erupeConfig.Bus.Driver != "postgres" && !sv.isAll(erupeConfig.Entrance.Entries) {
+		logger.Warn("Running only some servers with a local bus, they won't reach the servers of other processes")
 	}
 
 	// Now start our server(s).
 
 	// Launcher HTTP server.
-	launcherServer := launcherserver.NewServer(
-		&launcherserver.Config{
-			Logger:                   logger.Named("launcher"),
-			ErupeConfig:              erupeConfig,
-			DB:                       db,
-			UseOriginalLauncherFiles: erupeConfig.Launcher.UseOriginalLauncherFiles,
-		})
-	err = launcherServer.Start()
-	if err != nil {
-		logger.Fatal("Failed to start launcher server", zap.Error(err))
+	var launcherServer *launcherserver.Server
+	if sv.launcher {
+		launcherServer = launcherserver.NewServer(
+			&launcherserver.Config{
+				Logger:                   logger.Named("launcher"),
+				ErupeConfig:              erupeConfig,
+				DB:                       db,
+				UseOriginalLauncherFiles: erupeConfig.Launcher.UseOriginalLauncherFiles,
+				Bus:                      serverBus,
+			})
+		err = launcherServer.Start()
+		if err != nil {
+			logger.Fatal("Failed to start launcher server", zap.Error(err))
+		}
+		logger.Info("Started launcher server")
 	}
-	logger.Info("Started launcher server")
 
 	// Entrance server.
-	entranceServer := entranceserver.NewServer(
-		&entranceserver.Config{
-			Logger:      logger.Named("entrance"),
-			ErupeConfig: erupeConfig,
-			DB:          db,
-		})
-	err = entranceServer.Start()
-	if err != nil {
-		logger.Fatal("Failed to start entrance server", zap.Error(err))
+	var entranceServer *entranceserver.Server
+	if sv.entrance {
+		entranceServer = entranceserver.NewServer(
+			&entranceserver.Config{
+				Logger:      logger.Named("entrance"),
+				ErupeConfig: erupeConfig,
+				DB:          db,
+				Bus:         serverBus,
+			})
+		err = entranceServer.Start()
+		if err != nil {
+			logger.Fatal("Failed to start entrance server", zap.Error(err))
+		}
+		logger.Info("Started entrance server")
 	}
-	logger.Info("Started entrance server")
 
 	// Sign server.
-	signServer := signserver.NewServer(
-		&signserver.Config{
-			Logger:      logger.Named("sign"),
-			ErupeConfig: erupeConfig,
-			DB:          db,
-		})
-	err = signServer.Start()
-	if err != nil {
-		logger.Fatal("Failed to start sign server", zap.Error(err))
+	var signServer *signserver.Server
+	if sv.sign {
+		signServer = signserver.NewServer(
+			&signserver.Config{
+				Logger:      logger.Named("sign"),
+				ErupeConfig: erupeConfig,
+				DB:          db,
+			})
+		err = signServer.Start()
+		if err != nil {
+			logger.Fatal("Failed to start sign server", zap.Error(err))
+		}
+		logger.Info("Started sign server")
+	}
+
+	// Quest and scenario files, shared by every channel.
+	questFiles := channelserver.NewQuestFileProvider(logger.Named("quests"), erupeConfig.BinPath, erupeConfig.Quests.CacheEntries)
+	if erupeConfig.Quests.HotReload {
+		var overrideDirs []string
+		for _, ee := range erupeConfig.Entrance.Entries {
+			for _, ce := range ee.Channels {
+				overrideDirs = append(overrideDirs, ce.QuestOverrides)
+			}
+		}
+		err = questFiles.Watch(overrideDirs...)
+		if err != nil {
+			logger.Warn("Failed to watch quest files, changes need a restart", zap.Error(err))
+		}
 	}
-	logger.Info("Started sign server")
 
 	var channels []*channelserver.Server
-	channelQuery := ""
 	si := 0
 	ci := 0
 	count := 1
@@ -150,20 +252,33 @@ func main() {
 		rand.Seed(time.Now().UnixNano())
 		// Randomly generate a season for the World
 		season := rand.Intn(3)+1
+		// The Great Slaying of a world is kept in the database, shared with its channels run by other processes.
+		raviente := channelserver.NewRaviente(db, logger.Named("raviente"), channelserver.ServerID(si, 0), erupeConfig.Raviente)
 		for _, ce := range ee.Channels {
-			sid := (4096 + si * 256) + (16 + ci)
+			// Keep the IDs of the channels run by other processes free.
+			if !sv.channels[channelIndex{si, ci}] {
+				ci++
+				count++
+				continue
+			}
+			sid := channelserver.ServerID(si, ci)
 			c := *channelserver.NewServer(&channelserver.Config{
-				ID:           uint16(sid),
+				ID:           sid,
 				Logger:       logger.Named("channel-"+fmt.Sprint(count)),
 				ErupeConfig:  erupeConfig,
 				DB:           db,
 				DiscordBot:   discordBot,
+				QuestFiles:   questFiles,
+				QuestOverridePath: ce.QuestOverrides,
+				Raviente:     raviente,
+				Bus:          serverBus,
+				MaxPlayers:   ce.MaxPlayers,
+				Season:       uint8(season),
 			})
 			err = c.Start(int(ce.Port))
 			if err != nil {
 				logger.Fatal("Failed to start channel", zap.Error(err))
 			} else {
-				channelQuery += fmt.Sprintf("INSERT INTO servers (server_id, season, current_players) VALUES (%d, %d, 0);", sid, season)
 				channels = append(channels, &c)
 				logger.Info(fmt.Sprintf("Started channel server %d on port %d", count, ce.Port))
 				ci++
@@ -174,13 +289,12 @@ func main() {
 		si++
 	}
 
-	// Register all servers in DB
-	_ = db.MustExec(channelQuery)
-
 	for _, c := range channels {
 		c.Channels = channels
 	}
 
+	metricsServer := startMetricsServer(erupeConfig.Metrics, logger.Named("metrics"))
+
 	// Wait for exit or interrupt with ctrl+C.
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
@@ -191,9 +305,20 @@ func main() {
 	for _, c := range channels {
 		c.Shutdown()
 	}
-	signServer.Shutdown()
-	entranceServer.Shutdown()
-	launcherServer.Shutdown()
+	questFiles.Close()
+	if signServer != nil {
+		signServer.Shutdown()
+	}
+	if entranceServer != nil {
+		entranceServer.Shutdown()
+	}
+	if launcherServer != nil {
+		launcherServer.Shutdown()
+	}
+	serverBus.Close()
+	if metricsServer != nil {
+		metricsServer.Close()
+	}
 
 	time.Sleep(1 * time.Second)
 }
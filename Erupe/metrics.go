@@ -0,0 +1,39 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"erupe-ce/common/metrics"
+	"erupe-ce/config"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// metricsDriverName is the postgres driver counting failed statements.
+const metricsDriverName = "erupe-postgres"
+
+var metricDBErrors = metrics.NewCounterVec(
+	"erupe_db_errors_total", "Failed database operations by kind.", "op")
+
+func init() {
+	sql.Register(metricsDriverName, metrics.WrapDriver(&pq.Driver{}, metricDBErrors))
+}
+
+// startMetricsServer serves the metrics registry when enabled, it returns nil otherwise.
+func startMetricsServer(cfg config.Metrics, logger *zap.Logger) *http.Server {
+	if !cfg.Enabled {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Default.Handler())
+	server := &http.Server{Addr: fmt.Sprintf(":%d", cfg.Port), Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Metrics server failed", zap.Error(err))
+		}
+	}()
+	logger.Info(fmt.Sprintf("Serving metrics on :%d/metrics", cfg.Port))
+	return server
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"erupe-ce/migrations"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const migrateUsage = "usage: erupe migrate up|down [steps]|status|force <version>"
+
+// migrateOnStartup applies any pending migrations, refusing to continue if the
+// database is ahead of this binary or has a schema that was never versioned.
+func migrateOnStartup(db *sqlx.DB, logger *zap.Logger) error {
+	migrator, err := migrations.NewMigrator(db, logger)
+	if err != nil {
+		return err
+	}
+
+	untracked, err := migrator.HasUntrackedSchema()
+	if err != nil {
+		return err
+	}
+	if untracked {
+		return errors.New("database has tables but no recorded schema version, run `erupe migrate force <version>` with the last migration it already has")
+	}
+
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return migrations.ErrDirty
+	}
+	if version > migrator.Latest() {
+		return fmt.Errorf("database schema version %d is newer than this binary supports (%d), refusing to start", version, migrator.Latest())
+	}
+
+	applied, err := migrator.Up()
+	if err != nil {
+		return err
+	}
+	if applied > 0 {
+		logger.Info("Applied migrations", zap.Int("count", applied), zap.Uint("version", migrator.Latest()))
+	} else {
+		logger.Info("Database schema is up to date", zap.Uint("version", version))
+	}
+	return nil
+}
+
+// runMigrateCommand handles the `erupe migrate` subcommand.
+func runMigrateCommand(db *sqlx.DB, logger *zap.Logger, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := migrations.NewMigrator(db, logger)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s)\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return errors.New(migrateUsage)
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migration(s)\n", reverted)
+	case "status":
+		version, dirty, err := migrator.Version()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Database version: %d (dirty: %t), binary version: %d\n", version, dirty, migrator.Latest())
+		for _, m := range migrator.Migrations() {
+			state := "pending"
+			if m.Version <= version {
+				state = "applied"
+			}
+			fmt.Printf("  %06d %-40s %s\n", m.Version, m.Name, state)
+		}
+	case "force":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.ParseUint(args[1], 10, 32)
+		if err != nil {
+			return errors.New(migrateUsage)
+		}
+		err = migrator.Force(uint(version))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Forced schema version to %d\n", version)
+	default:
+		return errors.New(migrateUsage)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+BEGIN;
+
+ALTER TABLE IF EXISTS public.account_ban
+    DROP COLUMN IF EXISTS expires;
+
+ALTER TABLE IF EXISTS public.account_ban
+    DROP COLUMN IF EXISTS admin_id;
+
+ALTER TABLE IF EXISTS public.account_history
+    DROP COLUMN IF EXISTS admin_id;
+
+END;
@@ -0,0 +1,12 @@
+BEGIN;
+
+ALTER TABLE IF EXISTS public.account_ban
+    ADD COLUMN IF NOT EXISTS expires timestamp with time zone;
+
+ALTER TABLE IF EXISTS public.account_ban
+    ADD COLUMN IF NOT EXISTS admin_id integer;
+
+ALTER TABLE IF EXISTS public.account_history
+    ADD COLUMN IF NOT EXISTS admin_id integer;
+
+END;
@@ -0,0 +1,12 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS public.invite_codes
+(
+    code text NOT NULL PRIMARY KEY,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    expires timestamp with time zone,
+    used_by integer REFERENCES users (id),
+    used_at timestamp with time zone
+);
+
+END;
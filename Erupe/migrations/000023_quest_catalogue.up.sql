@@ -0,0 +1,18 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS public.quests
+(
+    id serial NOT NULL PRIMARY KEY,
+    event_id bigint NOT NULL,
+    max_players integer NOT NULL DEFAULT 4,
+    quest_type integer NOT NULL,
+    mark bigint NOT NULL DEFAULT 0,
+    quest_data bytea NOT NULL,
+    enabled boolean NOT NULL DEFAULT true,
+    enable_weeks character varying(8),
+    start_time timestamp with time zone,
+    end_time timestamp with time zone,
+    sort_order integer NOT NULL DEFAULT 0
+);
+
+END;
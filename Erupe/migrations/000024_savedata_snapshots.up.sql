@@ -0,0 +1,14 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS public.savedata_snapshots
+(
+    id serial NOT NULL PRIMARY KEY,
+    char_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    savedata bytea NOT NULL,
+    save_type integer NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS savedata_snapshots_char_id_idx ON public.savedata_snapshots (char_id, id);
+
+END;
@@ -0,0 +1,13 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS public.savedata_quarantine
+(
+    id serial NOT NULL PRIMARY KEY,
+    char_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    save_type integer NOT NULL,
+    payload bytea NOT NULL,
+    reason text NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+END;
@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS public.notices
+(
+    id serial NOT NULL PRIMARY KEY,
+    title text NOT NULL,
+    body text NOT NULL DEFAULT '',
+    priority integer NOT NULL DEFAULT 0,
+    enabled boolean NOT NULL DEFAULT true,
+    start_time timestamp with time zone,
+    end_time timestamp with time zone,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+END;
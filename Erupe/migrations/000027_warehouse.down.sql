@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS public.warehouse_boxes;
+DROP TABLE IF EXISTS public.warehouse;
+
+END;
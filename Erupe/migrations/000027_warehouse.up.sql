@@ -0,0 +1,20 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS public.warehouse
+(
+    character_id integer NOT NULL PRIMARY KEY REFERENCES public.characters (id) ON DELETE CASCADE,
+    usages integer NOT NULL DEFAULT 10000,
+    usages_renewal timestamp with time zone
+);
+
+CREATE TABLE IF NOT EXISTS public.warehouse_boxes
+(
+    character_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    box_type smallint NOT NULL,
+    box_index smallint NOT NULL,
+    name text NOT NULL DEFAULT '',
+    data bytea,
+    PRIMARY KEY (character_id, box_type, box_index)
+);
+
+END;
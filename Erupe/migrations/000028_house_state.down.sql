@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE public.characters
+    DROP COLUMN IF EXISTS house_state,
+    DROP COLUMN IF EXISTS house_password;
+
+END;
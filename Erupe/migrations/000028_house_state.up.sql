@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE public.characters
+    ADD COLUMN IF NOT EXISTS house_state smallint NOT NULL DEFAULT 2,
+    ADD COLUMN IF NOT EXISTS house_password text NOT NULL DEFAULT '';
+
+END;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS public.gem_history;
+DROP TABLE IF EXISTS public.tower;
+
+END;
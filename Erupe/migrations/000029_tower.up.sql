@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS public.tower
+(
+    char_id integer NOT NULL PRIMARY KEY REFERENCES public.characters (id) ON DELETE CASCADE,
+    tr integer NOT NULL DEFAULT 0,
+    trp integer NOT NULL DEFAULT 0,
+    tsp integer NOT NULL DEFAULT 0,
+    block1 integer NOT NULL DEFAULT 0,
+    block2 integer NOT NULL DEFAULT 0,
+    skills integer[] NOT NULL DEFAULT array_fill(0, ARRAY[64]),
+    history integer[] NOT NULL DEFAULT array_fill(0, ARRAY[10]),
+    gems integer[] NOT NULL DEFAULT array_fill(0, ARRAY[30])
+);
+
+CREATE TABLE IF NOT EXISTS public.gem_history
+(
+    id serial NOT NULL PRIMARY KEY,
+    char_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    sender_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    gem integer NOT NULL,
+    quantity integer NOT NULL,
+    message integer NOT NULL DEFAULT 0,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS gem_history_char_id_index ON public.gem_history (char_id, created_at DESC);
+
+END;
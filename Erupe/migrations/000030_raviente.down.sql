@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS public.raviente_contributions;
+DROP TABLE IF EXISTS public.raviente_state;
+
+END;
@@ -0,0 +1,32 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS public.raviente_state
+(
+    world_id integer NOT NULL PRIMARY KEY,
+    siege integer NOT NULL DEFAULT 0,
+    next_time bigint NOT NULL DEFAULT 0,
+    start_time bigint NOT NULL DEFAULT 0,
+    killed_time bigint NOT NULL DEFAULT 0,
+    post_time bigint NOT NULL DEFAULT 0,
+    raviente_type bigint NOT NULL DEFAULT 0,
+    max_players bigint NOT NULL DEFAULT 0,
+    carve_quest bigint NOT NULL DEFAULT 0,
+    register bigint[] NOT NULL,
+    state bigint[] NOT NULL,
+    support bigint[] NOT NULL,
+    damage_multiplier bigint NOT NULL DEFAULT 1,
+    updated_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS public.raviente_contributions
+(
+    world_id integer NOT NULL,
+    siege integer NOT NULL,
+    char_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    damage bigint NOT NULL DEFAULT 0,
+    support bigint NOT NULL DEFAULT 0,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (world_id, siege, char_id)
+);
+
+END;
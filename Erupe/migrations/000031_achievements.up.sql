@@ -0,0 +1,12 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS public.achievements
+(
+    char_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    achievement_id smallint NOT NULL,
+    points integer NOT NULL DEFAULT 0,
+    displayed_level smallint NOT NULL DEFAULT 0,
+    PRIMARY KEY (char_id, achievement_id)
+);
+
+END;
@@ -0,0 +1,12 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS public.titles
+(
+    char_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    title_id smallint NOT NULL,
+    unlocked_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (char_id, title_id)
+);
+
+END;
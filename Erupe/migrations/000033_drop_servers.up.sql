@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Channel population and seasons are reported through the bus heartbeats.
+DROP TABLE IF EXISTS public.servers;
+
+END;
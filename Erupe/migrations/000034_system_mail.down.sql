@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TABLE IF EXISTS public.mail_attachments;
+
+DELETE FROM public.mail WHERE sender_id IS NULL;
+ALTER TABLE public.mail DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE public.mail DROP COLUMN IF EXISTS is_sys_message;
+ALTER TABLE public.mail ALTER COLUMN sender_id SET NOT NULL;
+
+END;
@@ -0,0 +1,22 @@
+BEGIN;
+
+-- Used by mail locking but missing from earlier migrations.
+ALTER TABLE public.mail ADD COLUMN IF NOT EXISTS locked bool NOT NULL DEFAULT false;
+
+-- System mail has no sending character.
+ALTER TABLE public.mail ALTER COLUMN sender_id DROP NOT NULL;
+ALTER TABLE public.mail ADD COLUMN IF NOT EXISTS is_sys_message bool NOT NULL DEFAULT false;
+ALTER TABLE public.mail ADD COLUMN IF NOT EXISTS expires_at timestamp with time zone;
+
+CREATE TABLE IF NOT EXISTS public.mail_attachments
+(
+    id serial NOT NULL PRIMARY KEY,
+    mail_id integer NOT NULL REFERENCES public.mail (id) ON DELETE CASCADE,
+    item_id integer NOT NULL,
+    amount integer NOT NULL DEFAULT 1,
+    claimed bool NOT NULL DEFAULT false
+);
+
+CREATE INDEX IF NOT EXISTS mail_attachments_mail_id_index ON public.mail_attachments (mail_id);
+
+END;
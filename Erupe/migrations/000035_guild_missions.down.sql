@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE public.guilds DROP COLUMN IF EXISTS mission_target;
+DROP TABLE IF EXISTS public.guild_missions;
+DROP TABLE IF EXISTS public.guild_mission_catalogue;
+
+END;
@@ -0,0 +1,49 @@
+BEGIN;
+
+-- Missions guilds are assigned from, seeded with the missions of a capture.
+CREATE TABLE IF NOT EXISTS public.guild_mission_catalogue
+(
+    id serial NOT NULL PRIMARY KEY,
+    mission_id integer NOT NULL,
+    target integer NOT NULL,
+    goal integer NOT NULL,
+    rank integer NOT NULL DEFAULT 1,
+    category integer NOT NULL DEFAULT 1,
+    reward_rp integer NOT NULL DEFAULT 0,
+    reward_item integer NOT NULL DEFAULT 0,
+    reward_amount integer NOT NULL DEFAULT 0,
+    enabled bool NOT NULL DEFAULT true
+);
+
+INSERT INTO public.guild_mission_catalogue (mission_id, target, goal, rank, category, reward_rp) VALUES
+    (574, 70297, 35, 1, 2, 10),
+    (755, 95, 12, 2, 3, 20),
+    (746, 95, 6, 1, 1, 10),
+    (581, 83, 16, 2, 4, 20),
+    (694, 70299, 25, 1, 2, 10),
+    (988, 27, 16, 1, 6, 10),
+    (730, 70304, 25, 1, 4, 10),
+    (680, 69103, 50, 2, 2, 20),
+    (1109, 34, 60, 2, 6, 20),
+    (128, 74457, 70, 2, 3, 20),
+    (406, 59, 10, 1, 1, 10),
+    (1170, 70, 90, 3, 6, 30),
+    (164, 38, 24, 2, 6, 20),
+    (378, 69092, 150, 3, 1, 30),
+    (446, 94, 20, 2, 4, 20);
+
+CREATE TABLE IF NOT EXISTS public.guild_missions
+(
+    id serial NOT NULL PRIMARY KEY,
+    guild_id integer NOT NULL REFERENCES public.guilds (id) ON DELETE CASCADE,
+    catalogue_id integer NOT NULL REFERENCES public.guild_mission_catalogue (id) ON DELETE CASCADE,
+    progress integer NOT NULL DEFAULT 0,
+    assigned_at timestamp with time zone NOT NULL,
+    completed_at timestamp with time zone
+);
+
+CREATE INDEX IF NOT EXISTS guild_missions_guild_id_index ON public.guild_missions (guild_id);
+
+ALTER TABLE public.guilds ADD COLUMN mission_target integer REFERENCES public.guild_missions (id) ON DELETE SET NULL;
+
+END;
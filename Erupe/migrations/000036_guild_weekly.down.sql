@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE public.guild_characters DROP COLUMN IF EXISTS hunts_claimed_at;
+DROP TABLE IF EXISTS public.guild_weekly_bonus;
+DROP TABLE IF EXISTS public.guild_kill_logs;
+
+END;
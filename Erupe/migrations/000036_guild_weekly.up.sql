@@ -0,0 +1,26 @@
+BEGIN;
+
+-- Monsters hunted by guild members, counted towards the guild's weekly bonus.
+CREATE TABLE IF NOT EXISTS public.guild_kill_logs
+(
+    id serial NOT NULL PRIMARY KEY,
+    guild_id integer NOT NULL REFERENCES public.guilds (id) ON DELETE CASCADE,
+    character_id integer NOT NULL,
+    monster integer NOT NULL,
+    quantity integer NOT NULL DEFAULT 1,
+    hunted_at timestamp with time zone NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS guild_kill_logs_guild_id_index ON public.guild_kill_logs (guild_id, hunted_at);
+
+CREATE TABLE IF NOT EXISTS public.guild_weekly_bonus
+(
+    guild_id integer NOT NULL REFERENCES public.guilds (id) ON DELETE CASCADE,
+    week_start timestamp with time zone NOT NULL,
+    exceptional_users integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (guild_id, week_start)
+);
+
+ALTER TABLE public.guild_characters ADD COLUMN hunts_claimed_at timestamp with time zone;
+
+END;
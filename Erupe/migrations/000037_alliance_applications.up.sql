@@ -0,0 +1,25 @@
+BEGIN;
+
+-- Created by guild-additions.sql on older installs.
+CREATE TABLE IF NOT EXISTS public.guild_alliances
+(
+    id serial NOT NULL PRIMARY KEY,
+    name varchar(24) NOT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT now(),
+    parent_id int NOT NULL,
+    sub1_id int,
+    sub2_id int
+);
+
+-- Applications of guilds to alliances, and invitations of alliances to guilds.
+CREATE TABLE IF NOT EXISTS public.guild_alliance_applications
+(
+    id serial NOT NULL PRIMARY KEY,
+    alliance_id integer NOT NULL REFERENCES public.guild_alliances (id) ON DELETE CASCADE,
+    guild_id integer NOT NULL REFERENCES public.guilds (id) ON DELETE CASCADE,
+    application_type guild_application_type NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    UNIQUE (alliance_id, guild_id)
+);
+
+END;
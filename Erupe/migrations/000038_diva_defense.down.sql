@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS public.diva_kiju;
+DROP TABLE IF EXISTS public.diva_points;
+DROP TABLE IF EXISTS public.diva_events;
+
+END;
@@ -0,0 +1,31 @@
+BEGIN;
+
+-- Diva Defense events, each going through the prayer, interception and song phases.
+CREATE TABLE IF NOT EXISTS public.diva_events
+(
+    id serial NOT NULL PRIMARY KEY,
+    start_time timestamp with time zone NOT NULL,
+    interception_time timestamp with time zone NOT NULL,
+    song_time timestamp with time zone NOT NULL,
+    end_time timestamp with time zone NOT NULL,
+    CHECK (start_time < interception_time AND interception_time < song_time AND song_time < end_time)
+);
+
+CREATE TABLE IF NOT EXISTS public.diva_points
+(
+    event_id integer NOT NULL REFERENCES public.diva_events (id) ON DELETE CASCADE,
+    character_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    phase smallint NOT NULL,
+    points bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (event_id, character_id, phase)
+);
+
+CREATE TABLE IF NOT EXISTS public.diva_kiju
+(
+    event_id integer NOT NULL REFERENCES public.diva_events (id) ON DELETE CASCADE,
+    character_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    kiju_id smallint NOT NULL,
+    PRIMARY KEY (event_id, character_id)
+);
+
+END;
@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP TABLE IF EXISTS public.festa_rewards_claimed;
+DROP TABLE IF EXISTS public.festa_votes;
+DROP TABLE IF EXISTS public.festa_souls;
+DROP TABLE IF EXISTS public.festa_registrations;
+DROP TABLE IF EXISTS public.festa_prizes_claimed;
+DROP TABLE IF EXISTS public.festa_prizes;
+DROP TYPE IF EXISTS festa_prize_type;
+DROP TABLE IF EXISTS public.festa_trials;
+DROP TABLE IF EXISTS public.festa_events;
+
+END;
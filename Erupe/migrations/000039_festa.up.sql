@@ -0,0 +1,80 @@
+BEGIN;
+
+-- Hunter Festa events, each going through the registration, trial, tally and reward phases.
+CREATE TABLE IF NOT EXISTS public.festa_events
+(
+    id serial NOT NULL PRIMARY KEY,
+    start_time timestamp with time zone NOT NULL,
+    trial_time timestamp with time zone NOT NULL,
+    tally_time timestamp with time zone NOT NULL,
+    reward_time timestamp with time zone NOT NULL,
+    end_time timestamp with time zone NOT NULL,
+    CHECK (start_time < trial_time AND trial_time < tally_time AND tally_time <= reward_time AND reward_time < end_time)
+);
+
+CREATE TABLE IF NOT EXISTS public.festa_trials
+(
+    id serial NOT NULL PRIMARY KEY,
+    festa_id integer NOT NULL REFERENCES public.festa_events (id) ON DELETE CASCADE,
+    objective smallint NOT NULL,
+    target_id integer NOT NULL,
+    times_req smallint NOT NULL DEFAULT 1,
+    locale_req smallint NOT NULL DEFAULT 0,
+    souls_reward smallint NOT NULL DEFAULT 1
+);
+
+CREATE TYPE festa_prize_type AS ENUM ('personal', 'intermediate');
+
+CREATE TABLE IF NOT EXISTS public.festa_prizes
+(
+    id serial NOT NULL PRIMARY KEY,
+    festa_id integer NOT NULL REFERENCES public.festa_events (id) ON DELETE CASCADE,
+    prize_type festa_prize_type NOT NULL,
+    tier integer NOT NULL DEFAULT 1,
+    souls_req integer NOT NULL,
+    item_id integer NOT NULL,
+    amount integer NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS public.festa_prizes_claimed
+(
+    prize_id integer NOT NULL REFERENCES public.festa_prizes (id) ON DELETE CASCADE,
+    character_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    PRIMARY KEY (prize_id, character_id)
+);
+
+CREATE TABLE IF NOT EXISTS public.festa_registrations
+(
+    festa_id integer NOT NULL REFERENCES public.festa_events (id) ON DELETE CASCADE,
+    guild_id integer NOT NULL REFERENCES public.guilds (id) ON DELETE CASCADE,
+    team festival_colour NOT NULL,
+    registered_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (festa_id, guild_id)
+);
+
+CREATE TABLE IF NOT EXISTS public.festa_souls
+(
+    festa_id integer NOT NULL REFERENCES public.festa_events (id) ON DELETE CASCADE,
+    character_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    guild_id integer NOT NULL REFERENCES public.guilds (id) ON DELETE CASCADE,
+    souls integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (festa_id, character_id, guild_id)
+);
+
+CREATE TABLE IF NOT EXISTS public.festa_votes
+(
+    festa_id integer NOT NULL REFERENCES public.festa_events (id) ON DELETE CASCADE,
+    character_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    trial_id integer NOT NULL REFERENCES public.festa_trials (id) ON DELETE CASCADE,
+    PRIMARY KEY (festa_id, character_id)
+);
+
+-- Rewards of the festa claimed by characters once it has been tallied.
+CREATE TABLE IF NOT EXISTS public.festa_rewards_claimed
+(
+    festa_id integer NOT NULL REFERENCES public.festa_events (id) ON DELETE CASCADE,
+    character_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    PRIMARY KEY (festa_id, character_id)
+);
+
+END;
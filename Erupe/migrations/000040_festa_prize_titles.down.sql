@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE public.festa_prizes DROP COLUMN IF EXISTS title_id;
+
+END;
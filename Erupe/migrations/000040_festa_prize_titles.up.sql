@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Title granted along with the item of a festa prize.
+ALTER TABLE public.festa_prizes ADD COLUMN IF NOT EXISTS title_id integer;
+
+END;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS public.house_visits;
+
+END;
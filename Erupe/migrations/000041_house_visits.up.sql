@@ -0,0 +1,12 @@
+BEGIN;
+
+-- Last visit of a character to the house of another, listed as recent houses.
+CREATE TABLE IF NOT EXISTS public.house_visits
+(
+    visitor_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    owner_id integer NOT NULL REFERENCES public.characters (id) ON DELETE CASCADE,
+    visited_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (visitor_id, owner_id)
+);
+
+END;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TABLE IF EXISTS public.quest_tune_values;
+ALTER TABLE public.quests DROP COLUMN IF EXISTS unk4;
+ALTER TABLE public.quests DROP COLUMN IF EXISTS unk3;
+ALTER TABLE public.quests DROP COLUMN IF EXISTS unk2;
+ALTER TABLE public.quests DROP COLUMN IF EXISTS unk1;
+ALTER TABLE public.quests DROP COLUMN IF EXISTS unk0;
+
+END;
@@ -0,0 +1,20 @@
+BEGIN;
+
+-- Unknown fields of the quest list entries, kept as imported so lists are rebuilt byte for byte.
+ALTER TABLE public.quests ADD COLUMN IF NOT EXISTS unk0 bigint NOT NULL DEFAULT 0;
+ALTER TABLE public.quests ADD COLUMN IF NOT EXISTS unk1 smallint NOT NULL DEFAULT 0;
+ALTER TABLE public.quests ADD COLUMN IF NOT EXISTS unk2 smallint NOT NULL DEFAULT 0;
+ALTER TABLE public.quests ADD COLUMN IF NOT EXISTS unk3 integer NOT NULL DEFAULT 0;
+ALTER TABLE public.quests ADD COLUMN IF NOT EXISTS unk4 integer NOT NULL DEFAULT 0;
+
+-- Quests imported before were listed with this value, run `quests import` again to restore the original ones.
+UPDATE public.quests SET unk2 = CASE WHEN quest_type <> 9 THEN 1 ELSE 0 END;
+
+-- Tune values sent with each quest list page.
+CREATE TABLE IF NOT EXISTS public.quest_tune_values
+(
+    list_offset integer NOT NULL PRIMARY KEY,
+    tune_values bytea NOT NULL
+);
+
+END;
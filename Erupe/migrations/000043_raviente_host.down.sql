@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE public.raviente_state DROP COLUMN IF EXISTS host_id;
+
+END;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Channel the current Great Slaying of the world is fought on, 0 for none.
+ALTER TABLE public.raviente_state ADD COLUMN IF NOT EXISTS host_id integer NOT NULL DEFAULT 0;
+
+END;
@@ -0,0 +1,8 @@
+BEGIN;
+
+UPDATE public.guild_kill_logs SET hunted_at = hunted_at - interval '7 years';
+UPDATE public.guild_weekly_bonus SET week_start = week_start - interval '7 years';
+UPDATE public.guild_characters SET hunts_claimed_at = hunts_claimed_at - interval '7 years' WHERE hunts_claimed_at IS NOT NULL;
+UPDATE public.guild_missions SET assigned_at = assigned_at - interval '7 years', completed_at = completed_at - interval '7 years';
+
+END;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE public.festa_events DROP COLUMN IF EXISTS ended;
+
+END;
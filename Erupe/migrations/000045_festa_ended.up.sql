@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Whether the guilds were taken out of the teams of the festa once it ended.
+ALTER TABLE public.festa_events ADD COLUMN IF NOT EXISTS ended boolean NOT NULL DEFAULT false;
+
+END;
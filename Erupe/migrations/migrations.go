@@ -0,0 +1,290 @@
+// Package migrations embeds the numbered SQL schema migrations and applies them
+// to the database, keeping track of the current schema version.
+//
+// The version table uses the same layout as golang-migrate's `schema_migrations`,
+// so databases that were previously migrated by hand with the `migrate` CLI are
+// picked up at the correct version.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+//go:embed *.sql
+var sqlFiles embed.FS
+
+// advisoryLockID is the pg_advisory_lock key held while migrating, so that
+// several Erupe processes sharing a database never migrate concurrently.
+const advisoryLockID = 0x45525550 // "ERUP"
+
+var fileNameRegex = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// ErrDirty is returned when a previous migration failed halfway through.
+var ErrDirty = errors.New("database schema is dirty, fix it by hand and use `migrate force`")
+
+// Migration is a single numbered schema change.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load reads the embedded migration files, sorted by version.
+func Load() ([]Migration, error) {
+	return load(sqlFiles)
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		match := fileNameRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has mismatched names %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d (%s) is missing its up or down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	db         *sqlx.DB
+	logger     *zap.Logger
+	migrations []Migration
+}
+
+// NewMigrator creates a Migrator using the embedded migrations.
+func NewMigrator(db *sqlx.DB, logger *zap.Logger) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	m := &Migrator{
+		db:         db,
+		logger:     logger,
+		migrations: migrations,
+	}
+	return m, nil
+}
+
+// Latest returns the highest migration version known to this binary.
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Migrations returns the migrations known to this binary.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+func (m *Migrator) ensureVersionTable() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`)
+	return err
+}
+
+// Version returns the current schema version of the database. A version of 0
+// means no migration has been recorded yet.
+func (m *Migrator) Version() (uint, bool, error) {
+	if err := m.ensureVersionTable(); err != nil {
+		return 0, false, err
+	}
+	var (
+		version int64
+		dirty   bool
+	)
+	err := m.db.QueryRow("SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		// No rows means nothing has been applied yet.
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return uint(version), dirty, nil
+}
+
+// HasUntrackedSchema reports whether the database already contains Erupe
+// tables but no recorded version, i.e. it was set up from a dump or by hand.
+func (m *Migrator) HasUntrackedSchema() (bool, error) {
+	version, _, err := m.Version()
+	if err != nil || version != 0 {
+		return false, err
+	}
+	var exists bool
+	err = m.db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_schema = 'public' AND table_name = 'users')").Scan(&exists)
+	return exists, err
+}
+
+func (m *Migrator) setVersion(version uint, dirty bool) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec("TRUNCATE schema_migrations"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if version > 0 {
+		if _, err = tx.Exec("INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)", version, dirty); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) lock() (func(), error) {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", advisoryLockID); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return func() {
+		_, _ = conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", advisoryLockID)
+		conn.Close()
+	}, nil
+}
+
+// Up applies every pending migration and returns how many were applied.
+func (m *Migrator) Up() (int, error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, ErrDirty
+	}
+	if version > m.Latest() {
+		return 0, fmt.Errorf("database schema version %d is newer than this binary (%d)", version, m.Latest())
+	}
+
+	applied := 0
+	for _, mig := range m.migrations {
+		if mig.Version <= version {
+			continue
+		}
+		m.logger.Info("Applying migration", zap.Uint("version", mig.Version), zap.String("name", mig.Name))
+		if err = m.setVersion(mig.Version, true); err != nil {
+			return applied, err
+		}
+		if _, err = m.db.Exec(mig.Up); err != nil {
+			return applied, fmt.Errorf("migration %d (%s) failed: %w", mig.Version, mig.Name, err)
+		}
+		if err = m.setVersion(mig.Version, false); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// Down reverts the given number of applied migrations, newest first.
+func (m *Migrator) Down(steps int) (int, error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, ErrDirty
+	}
+
+	reverted := 0
+	for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+		mig := m.migrations[i]
+		if mig.Version > version {
+			continue
+		}
+		var previous uint
+		if i > 0 {
+			previous = m.migrations[i-1].Version
+		}
+		m.logger.Info("Reverting migration", zap.Uint("version", mig.Version), zap.String("name", mig.Name))
+		if err = m.setVersion(mig.Version, true); err != nil {
+			return reverted, err
+		}
+		if _, err = m.db.Exec(mig.Down); err != nil {
+			return reverted, fmt.Errorf("reverting migration %d (%s) failed: %w", mig.Version, mig.Name, err)
+		}
+		if err = m.setVersion(previous, false); err != nil {
+			return reverted, err
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+// Force records the given version as cleanly applied without running any SQL.
+// It is used to adopt databases created from a dump, or to recover from a dirty state.
+func (m *Migrator) Force(version uint) error {
+	if err := m.ensureVersionTable(); err != nil {
+		return err
+	}
+	return m.setVersion(version, false)
+}
@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrationsAreContiguous(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, m := range migrations {
+		if m.Version != uint(i+1) {
+			t.Errorf("migration %q has version %d, expected %d", m.Name, m.Version, i+1)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000002_second.up.sql":   {Data: []byte("UP 2")},
+		"000002_second.down.sql": {Data: []byte("DOWN 2")},
+		"000001_first.up.sql":    {Data: []byte("UP 1")},
+		"000001_first.down.sql":  {Data: []byte("DOWN 1")},
+		"migrations.go":          {Data: []byte("package migrations")},
+	}
+	migrations, err := load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("got %d migrations, expected 2", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "first" || migrations[0].Up != "UP 1" || migrations[0].Down != "DOWN 1" {
+		t.Errorf("unexpected first migration %+v", migrations[0])
+	}
+	if migrations[1].Version != 2 || migrations[1].Name != "second" {
+		t.Errorf("unexpected second migration %+v", migrations[1])
+	}
+}
+
+func TestLoadMissingDown(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000001_first.up.sql": {Data: []byte("UP 1")},
+	}
+	if _, err := load(fsys); err == nil {
+		t.Error("expected an error for a migration without a down file")
+	}
+}
@@ -1,15 +1,16 @@
 package mhfpacket
 
-import ( 
- "errors" 
-
- 	"erupe-ce/network/clientctx"
-	"erupe-ce/network"
+import (
 	"erupe-ce/common/byteframe"
+	"erupe-ce/network"
+	"erupe-ce/network/clientctx"
 )
 
 // MsgMhfAcquireTitle represents the MSG_MHF_ACQUIRE_TITLE
-type MsgMhfAcquireTitle struct{}
+type MsgMhfAcquireTitle struct {
+	AckHandle uint32
+	TitleIDs  []uint16
+}
 
 // Opcode returns the ID associated with this packet type.
 func (m *MsgMhfAcquireTitle) Opcode() network.PacketID {
@@ -18,10 +19,23 @@ func (m *MsgMhfAcquireTitle) Opcode() network.PacketID {
 
 // Parse parses the packet from binary
 func (m *MsgMhfAcquireTitle) Parse(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	m.AckHandle = bf.ReadUint32()
+	count := bf.ReadUint16()
+	bf.ReadUint16() // Zeroed
+	m.TitleIDs = make([]uint16, count)
+	for i := range m.TitleIDs {
+		m.TitleIDs[i] = bf.ReadUint16()
+	}
+	return nil
 }
 
 // Build builds a binary packet from the current data.
 func (m *MsgMhfAcquireTitle) Build(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	bf.WriteUint32(m.AckHandle)
+	bf.WriteUint16(uint16(len(m.TitleIDs)))
+	bf.WriteUint16(0)
+	for _, id := range m.TitleIDs {
+		bf.WriteUint16(id)
+	}
+	return nil
 }
@@ -12,7 +12,7 @@ import (
 type MsgMhfAddUdPoint struct {
 	AckHandle uint32
 	Unk1      uint32
-	Unk2      uint32
+	Points    uint32
 }
 
 // Opcode returns the ID associated with this packet type.
@@ -24,7 +24,7 @@ func (m *MsgMhfAddUdPoint) Opcode() network.PacketID {
 func (m *MsgMhfAddUdPoint) Parse(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
 	m.AckHandle = bf.ReadUint32()
 	m.Unk1 = bf.ReadUint32()
-	m.Unk2 = bf.ReadUint32()
+	m.Points = bf.ReadUint32()
 
 	return nil
 	//panic("Not implemented")
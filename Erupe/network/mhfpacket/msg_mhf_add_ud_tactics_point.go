@@ -10,7 +10,7 @@ import (
 type MsgMhfAddUdTacticsPoint struct {
 	AckHandle uint32
 	Unk0      uint16
-	Unk1      uint32
+	Points    uint32
 }
 
 // Opcode returns the ID associated with this packet type.
@@ -22,7 +22,7 @@ func (m *MsgMhfAddUdTacticsPoint) Opcode() network.PacketID {
 func (m *MsgMhfAddUdTacticsPoint) Parse(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
 	m.AckHandle = bf.ReadUint32()
 	m.Unk0 = bf.ReadUint16()
-	m.Unk1 = bf.ReadUint32()
+	m.Points = bf.ReadUint32()
 	return nil
 }
 
@@ -30,6 +30,6 @@ func (m *MsgMhfAddUdTacticsPoint) Parse(bf *byteframe.ByteFrame, ctx *clientctx.
 func (m *MsgMhfAddUdTacticsPoint) Build(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
 	bf.WriteUint32(m.AckHandle)
 	bf.WriteUint16(m.Unk0)
-	bf.WriteUint32(m.Unk1)
+	bf.WriteUint32(m.Points)
 	return nil
 }
@@ -1,15 +1,17 @@
 package mhfpacket
 
-import ( 
- "errors" 
-
- 	"erupe-ce/network/clientctx"
-	"erupe-ce/network"
+import (
 	"erupe-ce/common/byteframe"
+	"erupe-ce/network"
+	"erupe-ce/network/clientctx"
 )
 
 // MsgMhfEnumerateWarehouse represents the MSG_MHF_ENUMERATE_WAREHOUSE
-type MsgMhfEnumerateWarehouse struct{}
+type MsgMhfEnumerateWarehouse struct {
+	AckHandle uint32
+	BoxType   uint8 // 0 = items, 1 = equipment
+	BoxIndex  uint8
+}
 
 // Opcode returns the ID associated with this packet type.
 func (m *MsgMhfEnumerateWarehouse) Opcode() network.PacketID {
@@ -18,10 +20,18 @@ func (m *MsgMhfEnumerateWarehouse) Opcode() network.PacketID {
 
 // Parse parses the packet from binary
 func (m *MsgMhfEnumerateWarehouse) Parse(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	m.AckHandle = bf.ReadUint32()
+	m.BoxType = bf.ReadUint8()
+	m.BoxIndex = bf.ReadUint8()
+	_ = bf.ReadUint16() // Zeroed
+	return nil
 }
 
 // Build builds a binary packet from the current data.
 func (m *MsgMhfEnumerateWarehouse) Build(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	bf.WriteUint32(m.AckHandle)
+	bf.WriteUint8(m.BoxType)
+	bf.WriteUint8(m.BoxIndex)
+	bf.WriteUint16(0)
+	return nil
 }
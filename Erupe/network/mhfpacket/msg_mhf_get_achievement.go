@@ -1,18 +1,16 @@
 package mhfpacket
 
 import (
- "errors"
-
- 	"erupe-ce/network/clientctx"
-	"erupe-ce/network"
 	"erupe-ce/common/byteframe"
+	"erupe-ce/network"
+	"erupe-ce/network/clientctx"
 )
 
 // MsgMhfGetAchievement represents the MSG_MHF_GET_ACHIEVEMENT
-type MsgMhfGetAchievement struct{
-  AckHandle      uint32
-	Unk0           uint32 // id?
-	Unk1           uint32 // char?
+type MsgMhfGetAchievement struct {
+	AckHandle uint32
+	CharID    uint32 // Own character, or the owner of a guild card.
+	Unk1      uint32
 }
 
 // Opcode returns the ID associated with this packet type.
@@ -22,13 +20,16 @@ func (m *MsgMhfGetAchievement) Opcode() network.PacketID {
 
 // Parse parses the packet from binary
 func (m *MsgMhfGetAchievement) Parse(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-  m.AckHandle = bf.ReadUint32()
-	m.Unk0 = bf.ReadUint32()
+	m.AckHandle = bf.ReadUint32()
+	m.CharID = bf.ReadUint32()
 	m.Unk1 = bf.ReadUint32()
 	return nil
 }
 
 // Build builds a binary packet from the current data.
 func (m *MsgMhfGetAchievement) Build(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	bf.WriteUint32(m.AckHandle)
+	bf.WriteUint32(m.CharID)
+	bf.WriteUint32(m.Unk1)
+	return nil
 }
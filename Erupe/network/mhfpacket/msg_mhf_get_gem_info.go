@@ -1,15 +1,28 @@
 package mhfpacket
 
-import ( 
- "errors" 
-
- 	"erupe-ce/network/clientctx"
-	"erupe-ce/network"
+import (
 	"erupe-ce/common/byteframe"
+	"erupe-ce/network"
+	"erupe-ce/network/clientctx"
+)
+
+// Gem lists requested by MsgMhfGetGemInfo.
+const (
+	GemInfoTypeGems    = 1
+	GemInfoTypeHistory = 2
 )
 
 // MsgMhfGetGemInfo represents the MSG_MHF_GET_GEM_INFO
-type MsgMhfGetGemInfo struct{}
+type MsgMhfGetGemInfo struct {
+	AckHandle uint32
+	InfoType  uint32
+	Unk1      uint32
+	Unk2      int32
+	Unk3      int32
+	Unk4      int32
+	Unk5      int32
+	Unk6      int32
+}
 
 // Opcode returns the ID associated with this packet type.
 func (m *MsgMhfGetGemInfo) Opcode() network.PacketID {
@@ -18,10 +31,26 @@ func (m *MsgMhfGetGemInfo) Opcode() network.PacketID {
 
 // Parse parses the packet from binary
 func (m *MsgMhfGetGemInfo) Parse(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	m.AckHandle = bf.ReadUint32()
+	m.InfoType = bf.ReadUint32()
+	m.Unk1 = bf.ReadUint32()
+	m.Unk2 = bf.ReadInt32()
+	m.Unk3 = bf.ReadInt32()
+	m.Unk4 = bf.ReadInt32()
+	m.Unk5 = bf.ReadInt32()
+	m.Unk6 = bf.ReadInt32()
+	return nil
 }
 
 // Build builds a binary packet from the current data.
 func (m *MsgMhfGetGemInfo) Build(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	bf.WriteUint32(m.AckHandle)
+	bf.WriteUint32(m.InfoType)
+	bf.WriteUint32(m.Unk1)
+	bf.WriteInt32(m.Unk2)
+	bf.WriteInt32(m.Unk3)
+	bf.WriteInt32(m.Unk4)
+	bf.WriteInt32(m.Unk5)
+	bf.WriteInt32(m.Unk6)
+	return nil
 }
@@ -1,11 +1,9 @@
 package mhfpacket
 
-import ( 
- "errors" 
-
- 	"erupe-ce/network/clientctx"
-	"erupe-ce/network"
+import (
 	"erupe-ce/common/byteframe"
+	"erupe-ce/network"
+	"erupe-ce/network/clientctx"
 )
 
 // The server sends different responses based on these values.
@@ -13,7 +11,7 @@ const (
 	TowerInfoTypeUnk0 = iota
 	TowerInfoTypeTowerRankPoint
 	TowerInfoTypeGetOwnTowerSkill
-	TowerInfoTypeUnk3
+	TowerInfoTypeUnk3 // Tower levels, like TowerInfoTypeUnk5.
 	TowerInfoTypeTowerTouhaHistory
 	TowerInfoTypeUnk5
 )
@@ -43,5 +41,9 @@ func (m *MsgMhfGetTowerInfo) Parse(bf *byteframe.ByteFrame, ctx *clientctx.Clien
 
 // Build builds a binary packet from the current data.
 func (m *MsgMhfGetTowerInfo) Build(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	bf.WriteUint32(m.AckHandle)
+	bf.WriteUint32(m.InfoType)
+	bf.WriteUint32(m.Unk0)
+	bf.WriteUint32(m.Unk1)
+	return nil
 }
@@ -11,7 +11,7 @@ import (
 // MsgMhfGuildHuntdata represents the MSG_MHF_GUILD_HUNTDATA
 type MsgMhfGuildHuntdata struct{
 	AckHandle      uint32
-	Unk0           uint8
+	Operation      uint8 // 0 claims the hunts listed so far, 1 lists them, 2 checks for new ones
 }
 
 // Opcode returns the ID associated with this packet type.
@@ -22,7 +22,7 @@ func (m *MsgMhfGuildHuntdata) Opcode() network.PacketID {
 // Parse parses the packet from binary
 func (m *MsgMhfGuildHuntdata) Parse(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
 	m.AckHandle = bf.ReadUint32()
-	m.Unk0 = bf.ReadUint8()
+	m.Operation = bf.ReadUint8()
 	return nil
 }
 
@@ -9,7 +9,11 @@ import (
 )
 
 // MsgMhfInfoJoint represents the MSG_MHF_INFO_JOINT
-type MsgMhfInfoJoint struct{}
+type MsgMhfInfoJoint struct {
+	AckHandle  uint32
+	AllianceID uint32
+	Unk0       uint32
+}
 
 // Opcode returns the ID associated with this packet type.
 func (m *MsgMhfInfoJoint) Opcode() network.PacketID {
@@ -18,7 +22,10 @@ func (m *MsgMhfInfoJoint) Opcode() network.PacketID {
 
 // Parse parses the packet from binary
 func (m *MsgMhfInfoJoint) Parse(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	m.AckHandle = bf.ReadUint32()
+	m.AllianceID = bf.ReadUint32()
+	m.Unk0 = bf.ReadUint32()
+	return nil
 }
 
 // Build builds a binary packet from the current data.
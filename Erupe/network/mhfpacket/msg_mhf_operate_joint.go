@@ -14,6 +14,12 @@ const (
   OPERATE_JOINT_DISBAND = 0x01
   OPERATE_JOINT_LEAVE = 0x03
   OPERATE_JOINT_KICK = 0x09
+  // Unconfirmed, no capture of these actions is available.
+  OPERATE_JOINT_APPLY = 0x02
+  OPERATE_JOINT_INVITE = 0x04
+  OPERATE_JOINT_ACCEPT = 0x05
+  OPERATE_JOINT_REJECT = 0x06
+  OPERATE_JOINT_TRANSFER = 0x08
 )
 
 // MsgMhfOperateJoint represents the MSG_MHF_OPERATE_JOINT
@@ -22,7 +28,7 @@ type MsgMhfOperateJoint struct {
   AllianceID uint32
   GuildID uint32
   Action OperateJointAction
-  UnkData []byte
+  Data []byte // Starts with the target guild ID of kick, invite, accept, reject and transfer
 }
 
 // Opcode returns the ID associated with this packet type.
@@ -36,7 +42,7 @@ func (m *MsgMhfOperateJoint) Parse(bf *byteframe.ByteFrame, ctx *clientctx.Clien
   m.AllianceID = bf.ReadUint32()
   m.GuildID = bf.ReadUint32()
   m.Action = OperateJointAction(bf.ReadUint8())
-  m.UnkData = bf.DataFromCurrent()
+  m.Data = bf.DataFromCurrent()
   bf.Seek(int64(len(bf.Data()) - 2), 0)
   return nil
 }
@@ -1,15 +1,29 @@
 package mhfpacket
 
-import ( 
- "errors" 
-
- 	"erupe-ce/network/clientctx"
-	"erupe-ce/network"
+import (
 	"erupe-ce/common/byteframe"
+	"erupe-ce/common/stringsupport"
+	"erupe-ce/network"
+	"erupe-ce/network/clientctx"
+)
+
+// Warehouse operations.
+const (
+	OperateWarehouseGet       = 0 // Page names and usages.
+	OperateWarehouseUnk1      = 1
+	OperateWarehouseRename    = 2
+	OperateWarehouseGetUsages = 3
+	OperateWarehouseUse       = 4
 )
 
 // MsgMhfOperateWarehouse represents the MSG_MHF_OPERATE_WAREHOUSE
-type MsgMhfOperateWarehouse struct{}
+type MsgMhfOperateWarehouse struct {
+	AckHandle uint32
+	Operation uint8
+	BoxType   uint8 // 0 = items, 1 = equipment
+	BoxIndex  uint8
+	Name      string // Only set by OperateWarehouseRename.
+}
 
 // Opcode returns the ID associated with this packet type.
 func (m *MsgMhfOperateWarehouse) Opcode() network.PacketID {
@@ -18,10 +32,32 @@ func (m *MsgMhfOperateWarehouse) Opcode() network.PacketID {
 
 // Parse parses the packet from binary
 func (m *MsgMhfOperateWarehouse) Parse(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	m.AckHandle = bf.ReadUint32()
+	m.Operation = bf.ReadUint8()
+	m.BoxType = bf.ReadUint8()
+	m.BoxIndex = bf.ReadUint8()
+	nameLen := bf.ReadUint8()
+	_ = bf.ReadUint16() // Zeroed
+	if nameLen > 0 {
+		m.Name = stringsupport.SJISToUTF8(bf.ReadNullTerminatedBytes())
+	}
+	return nil
 }
 
 // Build builds a binary packet from the current data.
 func (m *MsgMhfOperateWarehouse) Build(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	bf.WriteUint32(m.AckHandle)
+	bf.WriteUint8(m.Operation)
+	bf.WriteUint8(m.BoxType)
+	bf.WriteUint8(m.BoxIndex)
+	if m.Name == "" {
+		bf.WriteUint8(0)
+		bf.WriteUint16(0)
+		return nil
+	}
+	name := stringsupport.UTF8ToSJIS(m.Name)
+	bf.WriteUint8(uint8(len(name) + 1))
+	bf.WriteUint16(0)
+	bf.WriteNullTerminatedBytes(name)
+	return nil
 }
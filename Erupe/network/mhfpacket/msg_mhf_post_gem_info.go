@@ -1,15 +1,28 @@
 package mhfpacket
 
-import ( 
- "errors" 
-
- 	"erupe-ce/network/clientctx"
-	"erupe-ce/network"
+import (
 	"erupe-ce/common/byteframe"
+	"erupe-ce/network"
+	"erupe-ce/network/clientctx"
+)
+
+// Gem operations.
+const (
+	PostGemInfoAdd      = 1
+	PostGemInfoTransfer = 2
 )
 
 // MsgMhfPostGemInfo represents the MSG_MHF_POST_GEM_INFO
-type MsgMhfPostGemInfo struct{}
+type MsgMhfPostGemInfo struct {
+	AckHandle uint32
+	Op        uint32
+	Unk1      uint32
+	Gem       int32 // Category in the high byte, 1 to 5 in the low byte.
+	Quantity  int32
+	CID       int32 // Recipient of a transfer.
+	Message   int32 // Message sent with a transfer.
+	Unk6      int32
+}
 
 // Opcode returns the ID associated with this packet type.
 func (m *MsgMhfPostGemInfo) Opcode() network.PacketID {
@@ -18,10 +31,26 @@ func (m *MsgMhfPostGemInfo) Opcode() network.PacketID {
 
 // Parse parses the packet from binary
 func (m *MsgMhfPostGemInfo) Parse(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	m.AckHandle = bf.ReadUint32()
+	m.Op = bf.ReadUint32()
+	m.Unk1 = bf.ReadUint32()
+	m.Gem = bf.ReadInt32()
+	m.Quantity = bf.ReadInt32()
+	m.CID = bf.ReadInt32()
+	m.Message = bf.ReadInt32()
+	m.Unk6 = bf.ReadInt32()
+	return nil
 }
 
 // Build builds a binary packet from the current data.
 func (m *MsgMhfPostGemInfo) Build(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	bf.WriteUint32(m.AckHandle)
+	bf.WriteUint32(m.Op)
+	bf.WriteUint32(m.Unk1)
+	bf.WriteInt32(m.Gem)
+	bf.WriteInt32(m.Quantity)
+	bf.WriteInt32(m.CID)
+	bf.WriteInt32(m.Message)
+	bf.WriteInt32(m.Unk6)
+	return nil
 }
@@ -1,28 +1,31 @@
 package mhfpacket
 
-import ( 
- "errors" 
-
- 	"erupe-ce/network/clientctx"
-	"erupe-ce/network"
+import (
 	"erupe-ce/common/byteframe"
+	"erupe-ce/network"
+	"erupe-ce/network/clientctx"
+)
+
+// Tower updates posted by the client.
+const (
+	PostTowerInfoTypeProgress = 1 // After a tower quest.
+	PostTowerInfoTypeSkill    = 2 // A skill levelled up with TSP.
+	PostTowerInfoTypeClear    = 7 // After clearing a block.
 )
 
 // MsgMhfPostTowerInfo represents the MSG_MHF_POST_TOWER_INFO
 type MsgMhfPostTowerInfo struct {
-	// Communicator type, multi-format. This might be valid for only one type.
 	AckHandle uint32
-	Unk0      uint32
+	InfoType  uint32
 	Unk1      uint32
-	Unk2      uint32
-	Unk3      uint32
-	Unk4      uint32
-	Unk5      uint32
-	Unk6      uint32
-	Unk7      uint32
-	Unk8      uint32
-	Unk9      uint32
-	Unk10     uint32
+	Skill     int32 // Index of the skill levelled up.
+	TR        int32 // Tower rank.
+	TRP       int32 // Tower rank points earned.
+	Cost      int32 // TSP earned, or spent on Skill.
+	Unk6      int32
+	Unk7      int32
+	Block1    int32 // Floors climbed.
+	Unk9      int64
 }
 
 // Opcode returns the ID associated with this packet type.
@@ -33,21 +36,31 @@ func (m *MsgMhfPostTowerInfo) Opcode() network.PacketID {
 // Parse parses the packet from binary
 func (m *MsgMhfPostTowerInfo) Parse(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
 	m.AckHandle = bf.ReadUint32()
-	m.Unk0 = bf.ReadUint32()
+	m.InfoType = bf.ReadUint32()
 	m.Unk1 = bf.ReadUint32()
-	m.Unk2 = bf.ReadUint32()
-	m.Unk3 = bf.ReadUint32()
-	m.Unk4 = bf.ReadUint32()
-	m.Unk5 = bf.ReadUint32()
-	m.Unk6 = bf.ReadUint32()
-	m.Unk7 = bf.ReadUint32()
-	m.Unk8 = bf.ReadUint32()
-	m.Unk9 = bf.ReadUint32()
-	m.Unk10 = bf.ReadUint32()
+	m.Skill = bf.ReadInt32()
+	m.TR = bf.ReadInt32()
+	m.TRP = bf.ReadInt32()
+	m.Cost = bf.ReadInt32()
+	m.Unk6 = bf.ReadInt32()
+	m.Unk7 = bf.ReadInt32()
+	m.Block1 = bf.ReadInt32()
+	m.Unk9 = bf.ReadInt64()
 	return nil
 }
 
 // Build builds a binary packet from the current data.
 func (m *MsgMhfPostTowerInfo) Build(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	bf.WriteUint32(m.AckHandle)
+	bf.WriteUint32(m.InfoType)
+	bf.WriteUint32(m.Unk1)
+	bf.WriteInt32(m.Skill)
+	bf.WriteInt32(m.TR)
+	bf.WriteInt32(m.TRP)
+	bf.WriteInt32(m.Cost)
+	bf.WriteInt32(m.Unk6)
+	bf.WriteInt32(m.Unk7)
+	bf.WriteInt32(m.Block1)
+	bf.WriteInt64(m.Unk9)
+	return nil
 }
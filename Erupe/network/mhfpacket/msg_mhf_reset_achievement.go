@@ -1,15 +1,17 @@
 package mhfpacket
 
-import ( 
- "errors" 
-
- 	"erupe-ce/network/clientctx"
-	"erupe-ce/network"
+import (
 	"erupe-ce/common/byteframe"
+	"erupe-ce/network"
+	"erupe-ce/network/clientctx"
 )
 
 // MsgMhfResetAchievement represents the MSG_MHF_RESET_ACHIEVEMENT
-type MsgMhfResetAchievement struct{}
+type MsgMhfResetAchievement struct {
+	AchievementID uint8
+	Unk1          uint16
+	Unk2          uint16
+}
 
 // Opcode returns the ID associated with this packet type.
 func (m *MsgMhfResetAchievement) Opcode() network.PacketID {
@@ -18,10 +20,17 @@ func (m *MsgMhfResetAchievement) Opcode() network.PacketID {
 
 // Parse parses the packet from binary
 func (m *MsgMhfResetAchievement) Parse(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	m.AchievementID = bf.ReadUint8()
+	m.Unk1 = bf.ReadUint16()
+	m.Unk2 = bf.ReadUint16()
+	// Like MsgMhfAddAchievement, doesn't expect a response
+	return nil
 }
 
 // Build builds a binary packet from the current data.
 func (m *MsgMhfResetAchievement) Build(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	bf.WriteUint8(m.AchievementID)
+	bf.WriteUint16(m.Unk1)
+	bf.WriteUint16(m.Unk2)
+	return nil
 }
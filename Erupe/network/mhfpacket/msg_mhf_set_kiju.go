@@ -11,7 +11,7 @@ import (
 // MsgMhfSetKiju represents the MSG_MHF_SET_KIJU
 type MsgMhfSetKiju struct {
 	AckHandle uint32
-	Unk1      uint16
+	KijuID    uint16
 }
 
 // Opcode returns the ID associated with this packet type.
@@ -22,7 +22,7 @@ func (m *MsgMhfSetKiju) Opcode() network.PacketID {
 // Parse parses the packet from binary
 func (m *MsgMhfSetKiju) Parse(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
 	m.AckHandle = bf.ReadUint32()
-	m.Unk1 = bf.ReadUint16()
+	m.KijuID = bf.ReadUint16()
 	return nil
 	//panic("Not implemented")
 }
@@ -1,15 +1,31 @@
 package mhfpacket
 
-import ( 
- "errors" 
-
- 	"erupe-ce/network/clientctx"
-	"erupe-ce/network"
+import (
 	"erupe-ce/common/byteframe"
+	"erupe-ce/network"
+	"erupe-ce/network/clientctx"
 )
 
+// WarehouseEquipDataSize is the size of the equipment data (decorations, sigils...) following an equipment.
+const WarehouseEquipDataSize = 56
+
+// WarehouseStack is an item stack or a piece of equipment in a warehouse page.
+type WarehouseStack struct {
+	ID        uint32 // 0 for a stack new to the page.
+	Index     uint16 // Slot in the page.
+	EquipType uint16 // Equipment only.
+	ItemID    uint16 // 0 removes an equipment.
+	Quantity  uint16 // Items only, 0 removes the stack.
+	Data      []byte // Equipment only.
+}
+
 // MsgMhfUpdateWarehouse represents the MSG_MHF_UPDATE_WAREHOUSE
-type MsgMhfUpdateWarehouse struct{}
+type MsgMhfUpdateWarehouse struct {
+	AckHandle uint32
+	BoxType   uint8 // 0 = items, 1 = equipment
+	BoxIndex  uint8
+	Stacks    []WarehouseStack // The changed stacks only.
+}
 
 // Opcode returns the ID associated with this packet type.
 func (m *MsgMhfUpdateWarehouse) Opcode() network.PacketID {
@@ -18,10 +34,50 @@ func (m *MsgMhfUpdateWarehouse) Opcode() network.PacketID {
 
 // Parse parses the packet from binary
 func (m *MsgMhfUpdateWarehouse) Parse(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	m.AckHandle = bf.ReadUint32()
+	m.BoxType = bf.ReadUint8()
+	m.BoxIndex = bf.ReadUint8()
+	changes := int(bf.ReadUint16())
+	m.Stacks = make([]WarehouseStack, changes)
+	for i := range m.Stacks {
+		stack := &m.Stacks[i]
+		stack.ID = bf.ReadUint32()
+		stack.Index = bf.ReadUint16()
+		if m.BoxType == 0 {
+			stack.ItemID = bf.ReadUint16()
+			stack.Quantity = bf.ReadUint16()
+			_ = bf.ReadUint16() // Unk
+		} else {
+			stack.EquipType = bf.ReadUint16()
+			stack.ItemID = bf.ReadUint16()
+			stack.Data = bf.ReadBytes(WarehouseEquipDataSize)
+		}
+	}
+	_ = bf.ReadUint16() // Zeroed
+	return nil
 }
 
 // Build builds a binary packet from the current data.
 func (m *MsgMhfUpdateWarehouse) Build(bf *byteframe.ByteFrame, ctx *clientctx.ClientContext) error {
-	return errors.New("NOT IMPLEMENTED")
+	bf.WriteUint32(m.AckHandle)
+	bf.WriteUint8(m.BoxType)
+	bf.WriteUint8(m.BoxIndex)
+	bf.WriteUint16(uint16(len(m.Stacks)))
+	for _, stack := range m.Stacks {
+		bf.WriteUint32(stack.ID)
+		bf.WriteUint16(stack.Index)
+		if m.BoxType == 0 {
+			bf.WriteUint16(stack.ItemID)
+			bf.WriteUint16(stack.Quantity)
+			bf.WriteUint16(0)
+		} else {
+			data := make([]byte, WarehouseEquipDataSize)
+			copy(data, stack.Data)
+			bf.WriteUint16(stack.EquipType)
+			bf.WriteUint16(stack.ItemID)
+			bf.WriteBytes(data)
+		}
+	}
+	bf.WriteUint16(0)
+	return nil
 }
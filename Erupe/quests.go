@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"erupe-ce/config"
+	"erupe-ce/server/channelserver"
+	"github.com/jmoiron/sqlx"
+)
+
+const questsUsage = "usage: erupe quests import [questlists dir]"
+
+// runQuestsCommand handles the `erupe quests` subcommand.
+func runQuestsCommand(db *sqlx.DB, erupeConfig *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "import" {
+		return errors.New(questsUsage)
+	}
+
+	dir := filepath.Join(erupeConfig.BinPath, "questlists")
+	if len(args) > 1 {
+		dir = args[1]
+	}
+	imported, err := channelserver.ImportQuestLists(db, dir)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d quest(s) from %s\n", imported, dir)
+	return nil
+}
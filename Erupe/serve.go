@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"erupe-ce/config"
+	"erupe-ce/server/bus"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const serveUsage = "usage: erupe serve <launcher|entrance|sign|world:<world>|channel:<world>:<channel>>..."
+
+// channelIndex locates a channel by its indexes in the entrance entries.
+type channelIndex struct {
+	world   int
+	channel int
+}
+
+// services lists the servers run by this process.
+type services struct {
+	launcher bool
+	entrance bool
+	sign     bool
+	channels map[channelIndex]bool
+}
+
+// allServices runs every configured server, as when no `erupe serve` is given.
+func allServices(entries []config.EntranceServerInfo) services {
+	sv := services{launcher: true, entrance: true, sign: true, channels: make(map[channelIndex]bool)}
+	for wi, ee := range entries {
+		for ci := range ee.Channels {
+			sv.channels[channelIndex{wi, ci}] = true
+		}
+	}
+	return sv
+}
+
+// parseServices reads the arguments of `erupe serve`, world and channel
+// numbers being indexes in entrance.entries starting from 0.
+func parseServices(args []string, entries []config.EntranceServerInfo) (services, error) {
+	sv := services{channels: make(map[channelIndex]bool)}
+	if len(args) == 0 {
+		return sv, errors.New(serveUsage)
+	}
+	for _, arg := range args {
+		parts := strings.Split(arg, ":")
+		switch {
+		case arg == "launcher":
+			sv.launcher = true
+		case arg == "entrance":
+			sv.entrance = true
+		case arg == "sign":
+			sv.sign = true
+		case parts[0] == "world" && len(parts) == 2:
+			wi, err := strconv.Atoi(parts[1])
+			if err != nil || wi < 0 || wi >= len(entries) {
+				return sv, fmt.Errorf("unknown world %q", parts[1])
+			}
+			for ci := range entries[wi].Channels {
+				sv.channels[channelIndex{wi, ci}] = true
+			}
+		case parts[0] == "channel" && len(parts) == 3:
+			wi, err := strconv.Atoi(parts[1])
+			if err != nil || wi < 0 || wi >= len(entries) {
+				return sv, fmt.Errorf("unknown world %q", parts[1])
+			}
+			ci, err := strconv.Atoi(parts[2])
+			if err != nil || ci < 0 || ci >= len(entries[wi].Channels) {
+				return sv, fmt.Errorf("unknown channel %q of world %d", parts[2], wi)
+			}
+			sv.channels[channelIndex{wi, ci}] = true
+		default:
+			return sv, errors.New(serveUsage)
+		}
+	}
+	return sv, nil
+}
+
+// isAll reports whether the process runs every configured server.
+func (sv services) isAll(entries []config.EntranceServerInfo) bool {
+	return sv.launcher && sv.entrance && sv.sign && len(sv.channels) == len(allServices(entries).channels)
+}
+
+// openBus creates the bus configured to connect the servers.
+func openBus(erupeConfig *config.Config, db *sqlx.DB, logger *zap.Logger) (bus.Bus, error) {
+	switch erupeConfig.Bus.Driver {
+	case "", "local":
+		return bus.NewLocal(logger), nil
+	case "postgres":
+		return bus.NewPostgres(db, connectString(erupeConfig), erupeConfig.Bus.Channel, logger)
+	default:
+		return nil, fmt.Errorf("unknown bus driver %q", erupeConfig.Bus.Driver)
+	}
+}
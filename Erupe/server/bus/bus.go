@@ -0,0 +1,68 @@
+// Package bus carries the messages exchanged between the sign, entrance,
+// launcher and channel servers, whether they share a process or not.
+package bus
+
+import "errors"
+
+// Kind identifies what a Message carries.
+type Kind uint8
+
+const (
+	// KindWorldcast sends Packet to every session, except the one playing Exclude.
+	KindWorldcast Kind = iota + 1
+	// KindTargeted sends Packet to the sessions playing CharIDs.
+	KindTargeted
+	// KindChat shows Text as a server chat message on every channel.
+	KindChat
+	// KindKick disconnects the sessions playing CharIDs.
+	KindKick
+	// KindPresence reports CharID coming online, changing stage or going offline on ServerID.
+	KindPresence
+	// KindPresenceSync asks every channel to publish the presence of its sessions again.
+	KindPresenceSync
+	// KindRegister reports the channel ServerID starting.
+	KindRegister
+	// KindUnregister reports the channel ServerID stopping.
+	KindUnregister
+	// KindHeartbeat reports the status of the channel ServerID, sent periodically.
+	KindHeartbeat
+	// KindHeartbeatSync asks every channel to send a heartbeat right away.
+	KindHeartbeatSync
+	// KindMaintenance puts the channel ServerID in maintenance, or takes it out of it.
+	KindMaintenance
+	// KindRaviente tells the Great Slaying participants of the world of ServerID to reload its registers.
+	KindRaviente
+)
+
+// ErrMessageTooLarge is returned when a message can't fit the transport of a bus.
+var ErrMessageTooLarge = errors.New("bus message too large")
+
+// Message is a notification published on a bus.
+type Message struct {
+	Kind     Kind     `json:"kind"`
+	ServerID uint16   `json:"server_id,omitempty"` // Channel publishing or targeted by the message, 0 for none.
+	CharID   uint32   `json:"char_id,omitempty"`
+	CharIDs  []uint32 `json:"char_ids,omitempty"`
+	Exclude  uint32   `json:"exclude,omitempty"`
+	Packet   []byte   `json:"packet,omitempty"` // Opcode followed by the built packet.
+	Text     string   `json:"text,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	StageID  string   `json:"stage_id,omitempty"`
+	Online   bool     `json:"online,omitempty"`
+
+	Players     uint16 `json:"players,omitempty"`
+	MaxPlayers  uint16 `json:"max_players,omitempty"`
+	Season      uint8  `json:"season,omitempty"`
+	Maintenance bool   `json:"maintenance,omitempty"`
+}
+
+// Bus delivers every published message to every subscriber, including the
+// ones of the publishing process.
+type Bus interface {
+	// Publish sends a message to the subscribers without waiting for them to handle it.
+	Publish(msg Message) error
+	// Subscribe calls handler with every message, in publishing order, until unsubscribe is called.
+	Subscribe(handler func(Message)) (unsubscribe func())
+	// Close stops delivering messages.
+	Close() error
+}
@@ -0,0 +1,76 @@
+package bus
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func receive(t *testing.T, messages chan Message) Message {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+	return Message{}
+}
+
+func TestLocal(t *testing.T) {
+	b := NewLocal(zap.NewNop())
+	defer b.Close()
+
+	first := make(chan Message, 4)
+	second := make(chan Message, 4)
+	unsubscribe := b.Subscribe(func(msg Message) { first <- msg })
+	b.Subscribe(func(msg Message) { second <- msg })
+
+	b.Publish(Message{Kind: KindChat, Text: "hello"})
+	if msg := receive(t, first); msg.Text != "hello" {
+		t.Errorf("first subscriber got %+v", msg)
+	}
+	if msg := receive(t, second); msg.Text != "hello" {
+		t.Errorf("second subscriber got %+v", msg)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	b.Publish(Message{Kind: KindChat, Text: "again"})
+	receive(t, second)
+	select {
+	case msg := <-first:
+		t.Errorf("unsubscribed handler got %+v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestPresence(t *testing.T) {
+	b := NewLocal(zap.NewNop())
+	defer b.Close()
+	p := NewPresence(b)
+	defer p.Close()
+
+	p.handle(Message{Kind: KindPresence, ServerID: 1, CharID: 10, Name: "A", Online: true})
+	p.handle(Message{Kind: KindPresence, ServerID: 1, CharID: 11, Name: "B", Online: true})
+	p.handle(Message{Kind: KindPresence, ServerID: 2, CharID: 12, Name: "C", Online: true})
+	if sessions := p.Sessions(); len(sessions) != 3 || sessions[0].CharID != 10 {
+		t.Fatalf("Sessions() = %+v", sessions)
+	}
+
+	// A character moving to another channel isn't removed by the logout of the first.
+	p.handle(Message{Kind: KindPresence, ServerID: 2, CharID: 10, Name: "A", Online: true})
+	p.handle(Message{Kind: KindPresence, ServerID: 1, CharID: 10, Online: false})
+	if !p.IsOnline(10) {
+		t.Error("character 10 went offline after moving channel")
+	}
+
+	p.handle(Message{Kind: KindUnregister, ServerID: 1})
+	if p.IsOnline(11) {
+		t.Error("character 11 still online after its channel stopped")
+	}
+	if !p.IsOnline(12) || !p.IsOnline(10) {
+		t.Error("characters of another channel went offline")
+	}
+}
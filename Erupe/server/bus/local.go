@@ -0,0 +1,91 @@
+package bus
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// subscriberQueueSize is the number of messages a subscriber can lag behind
+// before messages are dropped for it.
+const subscriberQueueSize = 1024
+
+type subscriber struct {
+	queue chan Message
+}
+
+// Local is a Bus reaching the subscribers of the current process only.
+type Local struct {
+	sync.RWMutex
+	logger      *zap.Logger
+	subscribers map[int]*subscriber
+	nextID      int
+	closed      bool
+}
+
+// NewLocal creates a bus for servers running in the same process.
+func NewLocal(logger *zap.Logger) *Local {
+	return &Local{
+		logger:      logger,
+		subscribers: make(map[int]*subscriber),
+	}
+}
+
+// Publish queues the message for every subscriber, dropping it for the ones
+// too far behind rather than blocking the publisher.
+func (b *Local) Publish(msg Message) error {
+	b.RLock()
+	defer b.RUnlock()
+	for id, sub := range b.subscribers {
+		select {
+		case sub.queue <- msg:
+		default:
+			b.logger.Warn("Dropped bus message for a full subscriber", zap.Int("subscriber", id), zap.Uint8("kind", uint8(msg.Kind)))
+		}
+	}
+	return nil
+}
+
+// Subscribe starts delivering messages to handler from its own goroutine.
+func (b *Local) Subscribe(handler func(Message)) func() {
+	sub := &subscriber{queue: make(chan Message, subscriberQueueSize)}
+	go func() {
+		for msg := range sub.queue {
+			handler(msg)
+		}
+	}()
+
+	b.Lock()
+	defer b.Unlock()
+	if b.closed {
+		close(sub.queue)
+		return func() {}
+	}
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = sub
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.Lock()
+			defer b.Unlock()
+			if _, ok := b.subscribers[id]; ok {
+				delete(b.subscribers, id)
+				close(sub.queue)
+			}
+		})
+	}
+}
+
+// Close unsubscribes every subscriber.
+func (b *Local) Close() error {
+	b.Lock()
+	defer b.Unlock()
+	for id, sub := range b.subscribers {
+		delete(b.subscribers, id)
+		close(sub.queue)
+	}
+	b.closed = true
+	return nil
+}
@@ -0,0 +1,81 @@
+package bus
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// maxNotifyPayload is the largest payload postgres accepts in a notification.
+const maxNotifyPayload = 7999
+
+// Postgres is a Bus relaying messages between processes through postgres
+// LISTEN/NOTIFY on a notification channel.
+type Postgres struct {
+	*Local
+	db       *sqlx.DB
+	listener *pq.Listener
+	channel  string
+	logger   *zap.Logger
+}
+
+// NewPostgres listens to the notification channel with a dedicated
+// connection to connString, and publishes through db.
+func NewPostgres(db *sqlx.DB, connString string, channel string, logger *zap.Logger) (*Postgres, error) {
+	b := &Postgres{
+		Local:   NewLocal(logger),
+		db:      db,
+		channel: channel,
+		logger:  logger,
+	}
+	b.listener = pq.NewListener(connString, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			b.logger.Warn("Bus listener connection event", zap.Int("event", int(event)), zap.Error(err))
+		}
+	})
+	if err := b.listener.Listen(channel); err != nil {
+		b.listener.Close()
+		return nil, err
+	}
+	go b.receive()
+	return b, nil
+}
+
+func (b *Postgres) receive() {
+	for notification := range b.listener.Notify {
+		// A nil notification follows a reconnection, anything sent meanwhile is lost.
+		if notification == nil {
+			b.logger.Warn("Bus listener reconnected, messages may have been lost")
+			continue
+		}
+		var msg Message
+		if err := json.Unmarshal([]byte(notification.Extra), &msg); err != nil {
+			b.logger.Warn("Failed to decode bus message", zap.Error(err))
+			continue
+		}
+		b.Local.Publish(msg)
+	}
+}
+
+// Publish notifies every process listening to the channel, this one included.
+func (b *Postgres) Publish(msg Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotifyPayload {
+		return ErrMessageTooLarge
+	}
+	_, err = b.db.Exec("SELECT pg_notify($1, $2)", b.channel, string(payload))
+	return err
+}
+
+// Close stops listening and unsubscribes every subscriber.
+func (b *Postgres) Close() error {
+	err := b.listener.Close()
+	b.Local.Close()
+	return err
+}
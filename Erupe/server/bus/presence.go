@@ -0,0 +1,83 @@
+package bus
+
+import (
+	"sort"
+	"sync"
+)
+
+// Session describes a character online on a channel.
+type Session struct {
+	CharID   uint32 `json:"char_id"`
+	Name     string `json:"name"`
+	StageID  string `json:"stage_id"`
+	ServerID uint16 `json:"server_id"`
+}
+
+// Presence tracks the characters online on every channel from the presence
+// messages of a bus.
+type Presence struct {
+	sync.RWMutex
+	sessions    map[uint32]Session
+	unsubscribe func()
+}
+
+// NewPresence starts tracking presence on the bus, asking the channels
+// already running for their sessions.
+func NewPresence(b Bus) *Presence {
+	p := &Presence{sessions: make(map[uint32]Session)}
+	p.unsubscribe = b.Subscribe(p.handle)
+	b.Publish(Message{Kind: KindPresenceSync})
+	return p
+}
+
+func (p *Presence) handle(msg Message) {
+	p.Lock()
+	defer p.Unlock()
+	switch msg.Kind {
+	case KindPresence:
+		if !msg.Online {
+			// A late logout from a channel mustn't hide the character online elsewhere.
+			if current, ok := p.sessions[msg.CharID]; ok && current.ServerID == msg.ServerID {
+				delete(p.sessions, msg.CharID)
+			}
+			return
+		}
+		p.sessions[msg.CharID] = Session{
+			CharID:   msg.CharID,
+			Name:     msg.Name,
+			StageID:  msg.StageID,
+			ServerID: msg.ServerID,
+		}
+	case KindRegister, KindUnregister:
+		for charID, session := range p.sessions {
+			if session.ServerID == msg.ServerID {
+				delete(p.sessions, charID)
+			}
+		}
+	}
+}
+
+// Sessions returns the characters online, ordered by character ID.
+func (p *Presence) Sessions() []Session {
+	p.RLock()
+	defer p.RUnlock()
+	sessions := make([]Session, 0, len(p.sessions))
+	for _, session := range p.sessions {
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].CharID < sessions[j].CharID })
+	return sessions
+}
+
+// IsOnline reports whether the character is online on any channel.
+func (p *Presence) IsOnline(charID uint32) bool {
+	p.RLock()
+	defer p.RUnlock()
+	_, ok := p.sessions[charID]
+	return ok
+}
+
+// Close stops tracking presence.
+func (p *Presence) Close() {
+	p.unsubscribe()
+}
@@ -0,0 +1,97 @@
+package bus
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ChannelStatus is the last status reported by a channel.
+type ChannelStatus struct {
+	ServerID    uint16    `json:"server_id"`
+	Players     uint16    `json:"players"`
+	MaxPlayers  uint16    `json:"max_players"`
+	Season      uint8     `json:"season"`
+	Maintenance bool      `json:"maintenance"`
+	LastSeen    time.Time `json:"last_seen"`
+	Alive       bool      `json:"alive"` // Heartbeat received within the registry timeout.
+}
+
+// Full reports whether the channel can't take more players, down and
+// maintenance channels being full.
+func (c ChannelStatus) Full() bool {
+	return !c.Alive || c.Maintenance || c.Players >= c.MaxPlayers
+}
+
+// Registry tracks the status of every channel from the heartbeats of a bus.
+type Registry struct {
+	sync.RWMutex
+	channels    map[uint16]ChannelStatus
+	timeout     time.Duration
+	unsubscribe func()
+}
+
+// NewRegistry starts tracking the channels on the bus, considering them down
+// after timeout without a heartbeat.
+func NewRegistry(b Bus, timeout time.Duration) *Registry {
+	r := &Registry{
+		channels: make(map[uint16]ChannelStatus),
+		timeout:  timeout,
+	}
+	r.unsubscribe = b.Subscribe(r.handle)
+	b.Publish(Message{Kind: KindHeartbeatSync})
+	return r
+}
+
+func (r *Registry) handle(msg Message) {
+	r.Lock()
+	defer r.Unlock()
+	switch msg.Kind {
+	case KindRegister, KindHeartbeat:
+		r.channels[msg.ServerID] = ChannelStatus{
+			ServerID:    msg.ServerID,
+			Players:     msg.Players,
+			MaxPlayers:  msg.MaxPlayers,
+			Season:      msg.Season,
+			Maintenance: msg.Maintenance,
+			LastSeen:    time.Now(),
+		}
+	case KindUnregister:
+		delete(r.channels, msg.ServerID)
+	}
+}
+
+func (r *Registry) alive(status ChannelStatus, now time.Time) ChannelStatus {
+	status.Alive = now.Sub(status.LastSeen) <= r.timeout
+	return status
+}
+
+// Channel returns the status of a channel, ok is false if it never reported
+// or stopped.
+func (r *Registry) Channel(serverID uint16) (status ChannelStatus, ok bool) {
+	r.RLock()
+	defer r.RUnlock()
+	status, ok = r.channels[serverID]
+	if !ok {
+		return status, false
+	}
+	return r.alive(status, time.Now()), true
+}
+
+// Channels returns the status of every known channel, ordered by server ID.
+func (r *Registry) Channels() []ChannelStatus {
+	r.RLock()
+	defer r.RUnlock()
+	now := time.Now()
+	channels := make([]ChannelStatus, 0, len(r.channels))
+	for _, status := range r.channels {
+		channels = append(channels, r.alive(status, now))
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].ServerID < channels[j].ServerID })
+	return channels
+}
+
+// Close stops tracking the channels.
+func (r *Registry) Close() {
+	r.unsubscribe()
+}
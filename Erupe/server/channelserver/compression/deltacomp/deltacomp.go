@@ -2,8 +2,8 @@ package deltacomp
 
 import (
 	"bytes"
+	"errors"
 	"io"
-	"fmt"
 )
 
 func checkReadUint8(r *bytes.Reader) (uint8, error) {
@@ -46,8 +46,26 @@ func readCount(r *bytes.Reader) (int, error) {
 	return int(count), nil
 }
 
+// ErrInvalidPatch is returned for a patch that doesn't follow the diff format.
+var ErrInvalidPatch = errors.New("invalid or misunderstood patch format")
+
+// ErrPatchTooLarge is returned for a patch that would grow the data past the allowed size.
+var ErrPatchTooLarge = errors.New("patch grows data past the allowed size")
+
 // ApplyDataDiff applies a delta data diff patch onto given base data.
+// It panics on malformed patches, see ApplyDataDiffChecked.
 func ApplyDataDiff(diff []byte, baseData []byte) []byte {
+	data, err := ApplyDataDiffChecked(diff, baseData, 0)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// ApplyDataDiffChecked applies a delta data diff patch onto given base data,
+// refusing malformed patches and, when maxSize is positive, patches that
+// would grow the data past maxSize bytes.
+func ApplyDataDiffChecked(diff []byte, baseData []byte, maxSize int) ([]byte, error) {
 	// Make a copy of the base data to return,
 	// (probably just make this modify the given slice in the future).
 	baseCopy := make([]byte, len(baseData))
@@ -75,33 +93,30 @@ func ApplyDataDiff(diff []byte, baseData []byte) []byte {
 		}
 		differentCount--
 
-		// Grow slice if it's required
-		if(len(baseCopy) < dataOffset){
-			fmt.Printf("Slice smaller than data offset, growing slice...")
- 			baseCopy = append(baseCopy, make([]byte, (dataOffset + differentCount) - len(baseData))...)
-		} else {
-			length := len(baseCopy[dataOffset:])
-			if length < differentCount {
-				length -= differentCount
-				baseCopy = append(baseCopy, make([]byte, length)...)
-			}
+		if dataOffset < 0 || differentCount < 0 {
+			return nil, ErrInvalidPatch
 		}
 
+		// Grow slice if it's required
+		end := dataOffset + differentCount
+		if maxSize > 0 && end > maxSize {
+			return nil, ErrPatchTooLarge
+		}
+		if len(baseCopy) < end {
+			baseCopy = append(baseCopy, make([]byte, end-len(baseCopy))...)
+		}
 
 		// Apply the patch bytes.
 		for i := 0; i < differentCount; i++ {
 			b, err := checkReadUint8(patch)
 			if err != nil {
-				panic("Invalid or misunderstood patch format!")
+				return nil, ErrInvalidPatch
 			}
-
-
 			baseCopy[dataOffset+i] = b
 		}
 
 		dataOffset += differentCount - 1
-
 	}
 
-	return baseCopy
+	return baseCopy, nil
 }
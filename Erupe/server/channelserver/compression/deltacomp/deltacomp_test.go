@@ -111,3 +111,21 @@ func TestDeltaPatch(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyDataDiffChecked(t *testing.T) {
+	base := []byte{0, 1, 2, 3}
+
+	// Skip 1 byte (+1), write 2 bytes (+1).
+	data, err := ApplyDataDiffChecked([]byte{2, 3, 0xAA, 0xBB}, base, 0)
+	if err != nil || !bytes.Equal(data, []byte{0, 0xAA, 0xBB, 3}) {
+		t.Errorf("got %x, %v", data, err)
+	}
+
+	if _, err = ApplyDataDiffChecked([]byte{2, 3, 0xAA}, base, 0); err != ErrInvalidPatch {
+		t.Errorf("expected ErrInvalidPatch for a truncated patch, got %v", err)
+	}
+
+	if _, err = ApplyDataDiffChecked([]byte{4, 3, 0xAA, 0xBB}, base, len(base)); err != ErrPatchTooLarge {
+		t.Errorf("expected ErrPatchTooLarge for a growing patch, got %v", err)
+	}
+}
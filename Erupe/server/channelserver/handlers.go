@@ -14,6 +14,7 @@ import (
 	"erupe-ce/common/byteframe"
 	"erupe-ce/network/mhfpacket"
 	"go.uber.org/zap"
+	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/japanese"
 	"golang.org/x/text/transform"
 )
@@ -37,6 +38,19 @@ func stubGetNoResults(s *Session, ackHandle uint32) {
 	doAckBufSucceed(s, ackHandle, resp.Data())
 }
 
+// doAckEarthSucceed answers the MSG_MHF_GET_* packets sharing the stubGetNoResults header with the given entries.
+func doAckEarthSucceed(s *Session, ackHandle uint32, entries []*byteframe.ByteFrame) {
+	resp := byteframe.NewByteFrame()
+	resp.WriteUint32(0x0A218EAD)
+	resp.WriteUint32(0)
+	resp.WriteUint32(0)
+	resp.WriteUint32(uint32(len(entries)))
+	for _, entry := range entries {
+		resp.WriteBytes(entry.Data())
+	}
+	doAckBufSucceed(s, ackHandle, resp.Data())
+}
+
 func doAckBufSucceed(s *Session, ackHandle uint32, data []byte) {
 	s.QueueSendMHF(&mhfpacket.MsgSysAck{
 		AckHandle:        ackHandle,
@@ -98,41 +112,43 @@ func updateRights(s *Session) {
 
 func fixedSizeShiftJIS(text string, size int) []byte {
 	r := bytes.NewBuffer([]byte(text))
-	encoded, err := ioutil.ReadAll(transform.NewReader(r, japanese.ShiftJIS.NewEncoder()))
-	if err != nil {
-		panic(err)
-	}
+	// Characters Shift-JIS can't represent are replaced rather than failing the whole text.
+	encoded, _ := ioutil.ReadAll(transform.NewReader(r, encoding.ReplaceUnsupported(japanese.ShiftJIS.NewEncoder())))
 
 	out := make([]byte, size)
 	copy(out, encoded)
 	out[len(out)-1] = 0
 	return out
 }
-func handleMsgHead(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgHead(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysExtendThreshold(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysExtendThreshold(s *Session, p mhfpacket.MHFPacket) error {
 	// No data aside from header, no resp required.
+	return nil
 }
 
-func handleMsgSysEnd(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysEnd(s *Session, p mhfpacket.MHFPacket) error {
 	// No data aside from header, no resp required.
+	return nil
 }
 
-func handleMsgSysNop(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysNop(s *Session, p mhfpacket.MHFPacket) error {
 	// No data aside from header, no resp required.
+	return nil
 }
 
-func handleMsgSysAck(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysAck(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysTerminalLog(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysTerminalLog(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysTerminalLog)
 	resp := byteframe.NewByteFrame()
 
 	resp.WriteUint32(0x98bd51a9) // LogID to use for requests after this.
 	doAckSimpleSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgSysLogin(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysLogin(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysLogin)
 	name := ""
 
@@ -147,7 +163,7 @@ func handleMsgSysLogin(s *Session, p mhfpacket.MHFPacket) {
 	// 0C = N Boost course, ultra luxury course that ruins the game if in use
 	err := s.server.db.QueryRow("SELECT rights FROM users u INNER JOIN characters c ON u.id = c.user_id WHERE c.id = $1", pkt.CharID0).Scan(&rights)
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 
 	s.server.db.QueryRow("SELECT name FROM characters WHERE id = $1", pkt.CharID0).Scan(&name)
@@ -160,46 +176,46 @@ func handleMsgSysLogin(s *Session, p mhfpacket.MHFPacket) {
 	bf := byteframe.NewByteFrame()
 	bf.WriteUint32(uint32(Time_Current_Adjusted().Unix())) // Unix timestamp
 
-	_, err = s.server.db.Exec("UPDATE servers SET current_players=$1 WHERE server_id=$2", len(s.server.sessions), s.server.ID)
-	if err != nil {
-		panic(err)
-	}
-
 	_, err = s.server.db.Exec("UPDATE sign_sessions SET server_id=$1, char_id=$2 WHERE token=$3", s.server.ID, s.charID, s.token)
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 
 	_, err = s.server.db.Exec("UPDATE characters SET last_login=$1 WHERE id=$2", Time_Current().Unix(), s.charID)
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 
 	_, err = s.server.db.Exec("UPDATE users u SET last_character=$1 WHERE u.id=(SELECT c.user_id FROM characters c WHERE c.id=$1)", s.charID)
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 
+	s.server.publishPresence(s, true)
+	s.server.publishHeartbeat()
 	doAckSimpleSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgSysLogout(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysLogout(s *Session, p mhfpacket.MHFPacket) error {
 	logoutPlayer(s)
+	return nil
 }
 
 func logoutPlayer(s *Session) {
+	s.server.Lock()
 	delete(s.server.sessions, s.rawConn)
+	s.server.updateSessionsMetric()
+	s.server.Unlock()
 	s.rawConn.Close()
+	s.server.publishPresence(s, false)
 
 	_, err := s.server.db.Exec("UPDATE sign_sessions SET server_id=NULL, char_id=NULL WHERE token=$1", s.token)
 	if err != nil {
-		panic(err)
+		s.logger.Error("Failed to clear sign session", zap.Error(err))
 	}
 
-	_, err = s.server.db.Exec("UPDATE servers SET current_players=$1 WHERE server_id=$2", len(s.server.sessions), s.server.ID)
-	if err != nil {
-		panic(err)
-	}
+	s.server.publishHeartbeat()
 
 	var timePlayed int
 	_ = s.server.db.QueryRow("SELECT time_played FROM characters WHERE id = $1", s.charID).Scan(&timePlayed)
@@ -218,7 +234,7 @@ func logoutPlayer(s *Session) {
 
 	_, err = s.server.db.Exec("UPDATE characters SET time_played = $1 WHERE id = $2", timePlayed, s.charID)
 	if err != nil {
-		panic(err)
+		s.logger.Error("Failed to update time played", zap.Error(err))
 	}
 
 	if s.stage == nil {
@@ -242,28 +258,34 @@ func logoutPlayer(s *Session) {
 	treasureHuntUnregister(s)
 
 	saveData, err := GetCharacterSaveData(s, s.charID)
-	if err != nil {
-		panic(err)
+	if err != nil || saveData == nil {
+		s.logger.Error("Failed to load savedata to add RP", zap.Error(err))
+		return
 	}
 	saveData.RP += uint16(rpGained)
 	transaction, err := s.server.db.Begin()
+	if err != nil {
+		s.logger.Error("Failed to begin RP transaction", zap.Error(err))
+		return
+	}
 	err = saveData.Save(s, transaction)
 	if err != nil {
+		s.logger.Error("Failed to save RP", zap.Error(err))
 		transaction.Rollback()
-		panic(err)
 	} else {
 		transaction.Commit()
 	}
 }
 
-func handleMsgSysSetStatus(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysSetStatus(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysPing(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysPing(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysPing)
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgSysTime(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysTime(s *Session, p mhfpacket.MHFPacket) error {
 	//pkt := p.(*mhfpacket.MsgSysTime)
 
 	resp := &mhfpacket.MsgSysTime{
@@ -273,16 +295,17 @@ func handleMsgSysTime(s *Session, p mhfpacket.MHFPacket) {
 	s.QueueSendMHF(resp)
 
 	s.notifyticker()
+	return nil
 }
 
-func handleMsgSysIssueLogkey(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysIssueLogkey(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysIssueLogkey)
 
 	// Make a random log key for this session.
 	logKey := make([]byte, 16)
 	_, err := rand.Read(logKey)
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 
 	// TODO(Andoryuuta): In the offical client, the log key index is off by one,
@@ -295,18 +318,28 @@ func handleMsgSysIssueLogkey(s *Session, p mhfpacket.MHFPacket) {
 	resp := byteframe.NewByteFrame()
 	resp.WriteBytes(logKey)
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgSysRecordLog(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysRecordLog(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysRecordLog)
 	// remove a client returning to town from reserved slots to make sure the stage is hidden from board
 	delete(s.stage.reservedClientSlots, s.charID)
+	// count the monsters hunted towards the guild's weekly bonus
+	member, err := GetCharacterGuildMember(s, s.charID)
+	if err == nil && member != nil {
+		err = recordGuildHunts(s.server.db, member.GuildID, s.charID, recordLogKills(pkt.DataBuf), time.Now())
+	}
+	if err != nil {
+		s.logger.Error("Failed to record guild hunts", zap.Error(err))
+	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgSysEcho(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysEcho(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysLockGlobalSema(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysLockGlobalSema(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysLockGlobalSema)
 
 	bf := byteframe.NewByteFrame()
@@ -322,75 +355,79 @@ func handleMsgSysLockGlobalSema(s *Session, p mhfpacket.MHFPacket) {
 		bf.WriteBytes([]byte(pkt.ServerChannelIDString))
 	}
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgSysUnlockGlobalSema(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysUnlockGlobalSema(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysUnlockGlobalSema)
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 8))
+	return nil
 }
 
-func handleMsgSysUpdateRight(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysUpdateRight(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysAuthQuery(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysAuthQuery(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysAuthTerminal(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysAuthTerminal(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysRightsReload(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysRightsReload(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysRightsReload)
 	updateRights(s)
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfTransitMessage(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfTransitMessage(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfTransitMessage)
 	// TODO: figure out what this is supposed to return
 	// probably what world+land the targeted character is on?
 	// stubbed response will just say user not found
 	doAckBufSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgCaExchangeItem(s *Session, p mhfpacket.MHFPacket) {}
-
-func handleMsgMhfResetTitle(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgCaExchangeItem(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfPresentBox(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfPresentBox(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfServerCommand(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfServerCommand(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfAnnounce(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfAnnounce(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfAnnounce)
 	s.server.BroadcastRaviente(pkt.IPAddress, pkt.Port, pkt.StageID, pkt.Type)
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfSetLoginwindow(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfSetLoginwindow(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysTransBinary(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysTransBinary(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysCollectBinary(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysCollectBinary(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysGetState(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysGetState(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysSerialize(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysSerialize(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysEnumlobby(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysEnumlobby(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysEnumuser(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysEnumuser(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysInfokyserver(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysInfokyserver(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetCaUniqueID(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGetCaUniqueID(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfEnumerateItem(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfEnumerateItem(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfAcquireItem(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfAcquireItem(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfTransferItem(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfTransferItem(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfTransferItem)
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfEnumeratePrice(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumeratePrice(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumeratePrice)
 	//resp := byteframe.NewByteFrame()
 	//resp.WriteUint16(0) // Entry type 1 count
@@ -398,24 +435,24 @@ func handleMsgMhfEnumeratePrice(s *Session, p mhfpacket.MHFPacket) {
 	// directly lifted for now because lacking it crashes the counter on having actual events present
 	data, _ := hex.DecodeString("0000000066000003E800000000007300640100000320000000000006006401000003200000000000300064010000044C00000000007200640100000384000000000034006401000003840000000000140064010000051400000000006E006401000003E8000000000016006401000003E8000000000001006401000003200000000000430064010000057800000000006F006401000003840000000000330064010000044C00000000000B006401000003E800000000000F006401000006400000000000700064010000044C0000000000110064010000057800000000004C006401000003E8000000000059006401000006A400000000006D006401000005DC00000000004B006401000005DC000000000050006401000006400000000000350064010000070800000000006C0064010000044C000000000028006401000005DC00000000005300640100000640000000000060006401000005DC00000000005E0064010000051400000000007B006401000003E80000000000740064010000070800000000006B0064010000025800000000001B0064010000025800000000001C006401000002BC00000000001F006401000006A400000000007900640100000320000000000008006401000003E80000000000150064010000070800000000007A0064010000044C00000000000E00640100000640000000000055006401000007D0000000000002006401000005DC00000000002F0064010000064000000000002A0064010000076C00000000007E006401000002BC0000000000440064010000038400000000005C0064010000064000000000005B006401000006A400000000007D0064010000076C00000000007F006401000005DC0000000000540064010000064000000000002900640100000960000000000024006401000007D0000000000081006401000008340000000000800064010000038400000000001A006401000003E800000000002D0064010000038400000000004A006401000006A400000000005A00640100000384000000000027006401000007080000000000830064010000076C000000000040006401000006400000000000690064010000044C000000000025006401000004B000000000003100640100000708000000000082006401000003E800000000006500640100000640000000000051006401000007D000000000008C0064010000070800000000004D0064010000038400000000004E0064010000089800000000008B006401000004B000000000002E006401000009600000000000920064010000076C00000000008E00640100000514000000000068006401000004B000000000002B006401000003E800000000002C00640100000BB8000000000093006401000008FC00000000009000640100000AF0000000000094006401000006A400000000008D0064010000044C000000000052006401000005DC00000000004F006401000008980000000000970064010000070800000000006A0064010000064000000000005F00640100000384000000000026006401000008FC000000000096006401000007D00000000000980064010000076C000000000041006401000006A400000000003B006401000007080000000000360064010000083400000000009F00640100000A2800000000009A0064010000076C000000000021006401000007D000000000006300640100000A8C0000000000990064010000089800000000009E006401000007080000000000A100640100000C1C0000000000A200640100000C800000000000A400640100000DAC0000000000A600640100000C800000000000A50064010010")
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
 }
 
-func handleMsgMhfEnumerateOrder(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateOrder(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateOrder)
 	stubEnumerateNoResults(s, pkt.AckHandle)
+	return nil
 }
 
-func handleMsgMhfGetExtraInfo(s *Session, p mhfpacket.MHFPacket) {}
-
-func handleMsgMhfAcquireTitle(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGetExtraInfo(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfEnumerateUnionItem(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateUnionItem(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateUnionItem)
 	var boxContents []byte
 	bf := byteframe.NewByteFrame()
 	err := s.server.db.QueryRow("SELECT item_box FROM users, characters WHERE characters.id = $1 AND users.id = characters.user_id", int(s.charID)).Scan(&boxContents)
 	if err != nil {
-		s.logger.Fatal("Failed to get shared item box contents from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get shared item box contents from db", err)
 	} else {
 		if len(boxContents) == 0 {
 			bf.WriteUint32(0x00)
@@ -433,9 +470,10 @@ func handleMsgMhfEnumerateUnionItem(s *Session, p mhfpacket.MHFPacket) {
 		}
 	}
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfUpdateUnionItem(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfUpdateUnionItem(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfUpdateUnionItem)
 	// Get item cache from DB
 	var boxContents []byte
@@ -443,7 +481,7 @@ func handleMsgMhfUpdateUnionItem(s *Session, p mhfpacket.MHFPacket) {
 
 	err := s.server.db.QueryRow("SELECT item_box FROM users, characters WHERE characters.id = $1 AND users.id = characters.user_id", int(s.charID)).Scan(&boxContents)
 	if err != nil {
-		s.logger.Fatal("Failed to get shared item box contents from db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to get shared item box contents from db", err)
 	} else {
 		amount := len(boxContents) / 4
 		oldItems = make([]Item, amount)
@@ -491,37 +529,40 @@ func handleMsgMhfUpdateUnionItem(s *Session, p mhfpacket.MHFPacket) {
 	// Upload new item cache
 	_, err = s.server.db.Exec("UPDATE users SET item_box = $1 FROM characters WHERE  users.id = characters.user_id AND characters.id = $2", bf.Data(), int(s.charID))
 	if err != nil {
-		s.logger.Fatal("Failed to update shared item box contents in db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to update shared item box contents in db", err)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfAcquireCafeItem(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfAcquireCafeItem(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfAcquireCafeItem)
 	var netcafe_points int
 	err := s.server.db.QueryRow("UPDATE characters SET netcafe_points = netcafe_points - $1 WHERE id = $2 RETURNING netcafe_points", pkt.PointCost, s.charID).Scan(&netcafe_points)
 	if err != nil {
-		s.logger.Fatal("Failed to get plate data savedata from db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to get plate data savedata from db", err)
 	}
 	resp := byteframe.NewByteFrame()
 	resp.WriteUint32(uint32(netcafe_points))
 	doAckSimpleSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfUpdateCafepoint(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfUpdateCafepoint(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfUpdateCafepoint)
 	var netcafe_points int
 	err := s.server.db.QueryRow("SELECT COALESCE(netcafe_points, 0) FROM characters WHERE id = $1", s.charID).Scan(&netcafe_points)
 	if err != nil {
-		s.logger.Fatal("Failed to get plate data savedata from db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to get plate data savedata from db", err)
 	}
 	resp := byteframe.NewByteFrame()
 	resp.WriteUint32(0)
 	resp.WriteUint32(uint32(netcafe_points))
 	doAckSimpleSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfCheckDailyCafepoint(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfCheckDailyCafepoint(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfCheckDailyCafepoint)
 
 	// I am not sure exactly what this does, but all responses I have seen include this exact sequence of bytes
@@ -540,28 +581,29 @@ func handleMsgMhfCheckDailyCafepoint(s *Session, p mhfpacket.MHFPacket) {
 	var dailyTime time.Time
 	err := s.server.db.QueryRow("SELECT COALESCE(daily_time, $2) FROM characters WHERE id = $1", s.charID, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).Scan(&dailyTime)
 	if err != nil {
-		s.logger.Fatal("Failed to get daily_time savedata from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get daily_time savedata from db", err)
 	}
 
 	if t.After(dailyTime) {
 		// +5 netcafe points and setting next valid window
 		_, err := s.server.db.Exec("UPDATE characters SET daily_time=$1, netcafe_points=netcafe_points::int + 5 WHERE id=$2", midday, s.charID)
 		if err != nil {
-			s.logger.Fatal("Failed to update daily_time and netcafe_points savedata in db", zap.Error(err))
+			return errBufFail(pkt.AckHandle, "Failed to update daily_time and netcafe_points savedata in db", err)
 		}
 		doAckBufSucceed(s, pkt.AckHandle, []byte{0x01, 0x00, 0x00, 0x00, 0x05, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01})
 	} else {
 		doAckBufSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
 	}
+	return nil
 }
 
-func handleMsgMhfGetCogInfo(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGetCogInfo(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfCheckMonthlyItem(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfCheckMonthlyItem(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfAcquireMonthlyItem(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfAcquireMonthlyItem(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfCheckWeeklyStamp(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfCheckWeeklyStamp(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfCheckWeeklyStamp)
 
 	resp := byteframe.NewByteFrame()
@@ -573,11 +615,12 @@ func handleMsgMhfCheckWeeklyStamp(s *Session, p mhfpacket.MHFPacket) {
 	resp.WriteUint32(0x5dddcbb3) // Timestamp
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfExchangeWeeklyStamp(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfExchangeWeeklyStamp(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfEnumerateGuacot(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateGuacot(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateGuacot)
 	var data bool
 	err := s.server.db.QueryRow("SELECT gook0status FROM gook WHERE id = $1", s.charID).Scan(&data)
@@ -643,9 +686,10 @@ func handleMsgMhfEnumerateGuacot(s *Session, p mhfpacket.MHFPacket) {
 	} else {
 		doAckBufSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
 	}
+	return nil
 }
 
-func handleMsgMhfUpdateGuacot(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfUpdateGuacot(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfUpdateGuacot)
 	count := int(pkt.EntryCount)
 	fmt.Printf("handleMsgMhfUpdateGuacot:%d\n", count)
@@ -699,9 +743,10 @@ func handleMsgMhfUpdateGuacot(s *Session, p mhfpacket.MHFPacket) {
 		}
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfInfoScenarioCounter(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfInfoScenarioCounter(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfInfoScenarioCounter)
 	scenarioCounter := []struct {
 		MainID uint32
@@ -1422,13 +1467,14 @@ func handleMsgMhfInfoScenarioCounter(s *Session, p mhfpacket.MHFPacket) {
 	}
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfGetBbsSnsStatus(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGetBbsSnsStatus(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfApplyBbsArticle(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfApplyBbsArticle(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetEtcPoints(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetEtcPoints(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetEtcPoints)
 
 	resp := byteframe.NewByteFrame()
@@ -1438,25 +1484,28 @@ func handleMsgMhfGetEtcPoints(s *Session, p mhfpacket.MHFPacket) {
 	resp.WriteUint32(14)
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfUpdateEtcPoint(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfUpdateEtcPoint(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfUpdateEtcPoint)
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfStampcardStamp(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfStampcardStamp(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfStampcardStamp)
 	// TODO: Work out where it gets existing stamp count from, its format and then
 	// update the actual sent values to be correct
 	doAckBufSucceed(s, pkt.AckHandle, []byte{0x03, 0xe7, 0x03, 0xe7, 0x02, 0x99, 0x02, 0x9c, 0x00, 0x00, 0x00, 0x00, 0x14, 0xf8, 0x69, 0x54})
+	return nil
 }
 
-func handleMsgMhfStampcardPrize(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfStampcardPrize(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfUnreserveSrg(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfUnreserveSrg(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfReadBeatLevel(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfReadBeatLevel(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfReadBeatLevel)
 
 	// This response is fixed and will never change on JP,
@@ -1470,25 +1519,27 @@ func handleMsgMhfReadBeatLevel(s *Session, p mhfpacket.MHFPacket) {
 	}
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfUpdateBeatLevel(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfUpdateBeatLevel(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfUpdateBeatLevel)
 
 	doAckBufSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfReadBeatLevelAllRanking(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfReadBeatLevelAllRanking(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfReadBeatLevelMyRanking(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfReadBeatLevelMyRanking(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfReadLastWeekBeatRanking(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfReadLastWeekBeatRanking(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetFixedSeibatuRankingTable(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGetFixedSeibatuRankingTable(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfKickExportForce(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfKickExportForce(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetEarthStatus(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetEarthStatus(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetEarthStatus)
 
 	// TODO(Andoryuuta): Track down format for this data,
@@ -1502,11 +1553,12 @@ func handleMsgMhfGetEarthStatus(s *Session, p mhfpacket.MHFPacket) {
 			s.QueueAck(pkt.AckHandle, resp.Data())
 	*/
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfRegistSpabiTime(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfRegistSpabiTime(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetEarthValue(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetEarthValue(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetEarthValue)
 	var earthValues []struct{ Unk0, Unk1, Unk2, Unk3, Unk4, Unk5 uint32 }
 	if pkt.ReqType == 3 {
@@ -1573,34 +1625,37 @@ func handleMsgMhfGetEarthValue(s *Session, p mhfpacket.MHFPacket) {
 	}
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfDebugPostValue(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfDebugPostValue(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetNotice(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGetNotice(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfPostNotice(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfPostNotice(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetRandFromTable(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGetRandFromTable(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetTinyBin(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetTinyBin(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetTinyBin)
 	// requested after conquest quests
 	doAckBufSucceed(s, pkt.AckHandle, []byte{})
+	return nil
 }
 
-func handleMsgMhfPostTinyBin(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfPostTinyBin(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetSenyuDailyCount(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGetSenyuDailyCount(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetSeibattle(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetSeibattle(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetSeibattle)
 	stubGetNoResults(s, pkt.AckHandle)
+	return nil
 }
 
-func handleMsgMhfPostSeibattle(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfPostSeibattle(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetRyoudama(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetRyoudama(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetRyoudama)
 	// likely guild related
 	// REQ: 00 04 13 53 8F 18 00
@@ -1611,11 +1666,12 @@ func handleMsgMhfGetRyoudama(s *Session, p mhfpacket.MHFPacket) {
 	// RSP: 0A 21 8E AD 00 00 00 00 00 00 00 00 00 00 00 0E 2A 15 9E CC 00 00 00 01 82 79 83 4E 83 8A 81 5B 83 69 00 00 00 00 1E 55 B0 2F 00 00 00 01 8D F7 00 00 00 00 00 00 00 00 00 00 00 00 2A 15 9E CC 00 00 00 02 82 79 83 4E 83 8A 81 5B 83 69 00 00 00 00 03 D5 30 56 00 00 00 02 95 BD 91 F2 97 42 00 00 00 00 00 00 00 00 3F 57 76 9F 00 00 00 03 93 56 92 6E 96 B3 97 70 00 00 00 00 00 00 38 D9 0E C4 00 00 00 03 87 64 83 78 83 42 00 00 00 00 00 00 00 00 23 F3 B9 77 00 00 00 04 82 B3 82 CC 82 DC 82 E9 81 99 00 00 00 00 3F 1B 17 9C 00 00 00 04 82 B1 82 A4 82 BD 00 00 00 00 00 00 00 00 00 B9 F9 C0 00 00 00 05 82 CD 82 E9 82 A9 00 00 00 00 00 00 00 00 23 9F 9A EA 00 00 00 05 83 70 83 62 83 4C 83 83 83 49 00 00 00 00 38 D9 0E C4 00 00 00 06 87 64 83 78 83 42 00 00 00 00 00 00 00 00 1E 55 B0 2F 00 00 00 06 8D F7 00 00 00 00 00 00 00 00 00 00 00 00 03 D5 30 56 00 00 00 07 95 BD 91 F2 97 42 00 00 00 00 00 00 00 00 02 D3 B8 77 00 00 00 07 6F 77 6C 32 35 32 35 00 00 00 00 00 00 00
 	data, _ := hex.DecodeString("0A218EAD0000000000000000000000010000000000000000")
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
 }
 
-func handleMsgMhfPostRyoudama(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfPostRyoudama(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetTenrouirai(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetTenrouirai(s *Session, p mhfpacket.MHFPacket) error {
 	// if the game gets bad responses for this it breaks the ability to save
 	pkt := p.(*mhfpacket.MsgMhfGetTenrouirai)
 	var data []byte
@@ -1629,23 +1685,25 @@ func handleMsgMhfGetTenrouirai(s *Session, p mhfpacket.MHFPacket) {
 		s.logger.Info("GET_TENROUIRAI request for unknown type")
 	}
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
 }
 
-func handleMsgMhfPostTenrouirai(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfPostTenrouirai(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfPostTenrouirai)
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfGetDailyMissionMaster(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGetDailyMissionMaster(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetDailyMissionPersonal(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGetDailyMissionPersonal(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfSetDailyMissionPersonal(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfSetDailyMissionPersonal(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetEquipSkinHist(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetEquipSkinHist(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetEquipSkinHist)
 	// Transmog / reskin system,  bitmask of 3200 bytes length
 	// presumably divided by 5 sections for 5120 armour IDs covered
@@ -1654,18 +1712,19 @@ func handleMsgMhfGetEquipSkinHist(s *Session, p mhfpacket.MHFPacket) {
 	var data []byte
 	err := s.server.db.QueryRow("SELECT COALESCE(skin_hist::bytea, $2::bytea) FROM characters WHERE id = $1", s.charID, make([]byte, 0xC80)).Scan(&data)
 	if err != nil {
-		s.logger.Fatal("Failed to get skin_hist savedata from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get skin_hist savedata from db", err)
 	}
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
 }
 
-func handleMsgMhfUpdateEquipSkinHist(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfUpdateEquipSkinHist(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfUpdateEquipSkinHist)
 	// sends a raw armour ID back that needs to be mapped into the persistent bitmask above (-10,000)
 	var data []byte
 	err := s.server.db.QueryRow("SELECT COALESCE(skin_hist, $2) FROM characters WHERE id = $1", s.charID, make([]byte, 0xC80)).Scan(&data)
 	if err != nil {
-		s.logger.Fatal("Failed to get skin_hist from db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to get skin_hist from db", err)
 	}
 
 	var bit int
@@ -1693,20 +1752,22 @@ func handleMsgMhfUpdateEquipSkinHist(s *Session, p mhfpacket.MHFPacket) {
 	data[startByte+byteInd] |= bits.Reverse8((1 << uint(bitInByte)))
 	_, err = s.server.db.Exec("UPDATE characters SET skin_hist=$1 WHERE id=$2", data, s.charID)
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfGetUdShopCoin(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetUdShopCoin(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetUdShopCoin)
 	data, _ := hex.DecodeString("0000000000000001")
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
 }
 
-func handleMsgMhfUseUdShopCoin(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfUseUdShopCoin(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetEnhancedMinidata(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetEnhancedMinidata(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetEnhancedMinidata)
 	// this looks to be the detailed chunk of information you can pull up on players in town
 	var data []byte
@@ -1716,18 +1777,20 @@ func handleMsgMhfGetEnhancedMinidata(s *Session, p mhfpacket.MHFPacket) {
 		//s.logger.Fatal("Failed to get minidata from db", zap.Error(err))
 	}
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
 }
 
-func handleMsgMhfSetEnhancedMinidata(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSetEnhancedMinidata(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSetEnhancedMinidata)
 	_, err := s.server.db.Exec("UPDATE characters SET minidata=$1 WHERE id=$2", pkt.RawDataPayload, s.charID)
 	if err != nil {
-		s.logger.Fatal("Failed to update minidata in db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to update minidata in db", err)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfGetLobbyCrowd(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetLobbyCrowd(s *Session, p mhfpacket.MHFPacket) error {
 	// this requests a specific server's population but seems to have been
 	// broken at some point on live as every example response across multiple
 	// servers sends back the exact same information?
@@ -1737,9 +1800,10 @@ func handleMsgMhfGetLobbyCrowd(s *Session, p mhfpacket.MHFPacket) {
 	blankData := make([]byte, 0x320)
 	doAckBufSucceed(s, pkt.AckHandle, blankData)
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfGetTrendWeapon(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetTrendWeapon(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetTrendWeapon)
 	// TODO (Fist): Work out actual format limitations, seems to be final upgrade
 	// for weapons and it traverses its upgrade tree to recommend base as final
@@ -1750,9 +1814,11 @@ func handleMsgMhfGetTrendWeapon(s *Session, p mhfpacket.MHFPacket) {
 	// 24 08 3D 37 08 3F 66 08 41 EC 09 3D 38 09 3F 8A 09 41 EE 0A 0E 78 0A 0F
 	// AA 0A 0F F9 0B 3E 2E 0B 41 EF 0B 42 FB 0C 41 F0 0C 43 3F 0C 43 EE 0D 41 F1 0D 42 10 0D 42 3C 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00
 	doAckBufSucceed(s, pkt.AckHandle, make([]byte, 0xA9))
+	return nil
 }
 
-func handleMsgMhfUpdateUseTrendWeaponLog(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfUpdateUseTrendWeaponLog(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfUpdateUseTrendWeaponLog)
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
@@ -1,104 +1,219 @@
 package channelserver
 
 import (
+	"fmt"
+
 	"erupe-ce/common/byteframe"
 	"erupe-ce/network/mhfpacket"
 )
 
-func handleMsgMhfGetAchievement(s *Session, p mhfpacket.MHFPacket) {
+// achievementCount is the number of achievements known by the client.
+const achievementCount = 33
+
+// Points needed for each of the 8 ranks of an achievement, counted from the previous rank.
+var (
+	achievementCurveUse       = []int32{5, 15, 30, 50, 100, 150, 200, 300}
+	achievementCurveCollector = []int32{1, 5, 10, 15, 30, 50, 75, 100}
+	achievementCurveFesta     = []int32{1, 2, 3, 4, 5, 6, 7, 8}
+)
+
+func achievementCurve(id uint8) []int32 {
+	switch {
+	case id == 7 || id == 16 || id >= 27:
+		return achievementCurveCollector
+	case id == 8:
+		return achievementCurveFesta
+	default:
+		return achievementCurveUse
+	}
+}
+
+// Trophy bitfields shown on the guild card.
+const (
+	achievementTrophyBronze = 0x40
+	achievementTrophySilver = 0x60
+	achievementTrophyGold   = 0x7F
+)
+
+// achievement is the rank of an achievement computed from its points.
+type achievement struct {
+	Level     uint8
+	Value     uint32 // Achievement points granted by the ranks reached.
+	NextValue uint16 // Achievement points granted by the next rank.
+	Required  uint32 // Points needed for the next rank.
+	Progress  uint32 // Points towards the next rank.
+	Trophy    uint8
+}
+
+func getAchievement(id uint8, points int32) achievement {
+	var ach achievement
+	curve := achievementCurve(id)
+	for _, required := range curve {
+		if points < required {
+			ach.Progress = uint32(points)
+			ach.Required = uint32(required)
+			switch ach.Level {
+			case 0:
+				ach.NextValue = 5
+			case 1, 2, 3:
+				ach.NextValue = 10
+			case 4, 5:
+				ach.NextValue = 15
+			case 6:
+				ach.NextValue = 15
+				ach.Trophy = achievementTrophyBronze
+			case 7:
+				ach.NextValue = 20
+				ach.Trophy = achievementTrophySilver
+			}
+			return ach
+		}
+		points -= required
+		ach.Level++
+		switch ach.Level {
+		case 1:
+			ach.Value += 5
+		case 2, 3, 4:
+			ach.Value += 10
+		case 5, 6, 7:
+			ach.Value += 15
+		case 8:
+			ach.Value += 20
+		}
+	}
+	ach.Required = uint32(curve[len(curve)-1])
+	ach.Progress = ach.Required
+	ach.Trophy = achievementTrophyGold
+	return ach
+}
+
+type achievementRow struct {
+	ID             uint8 `db:"achievement_id"`
+	Points         int32 `db:"points"`
+	DisplayedLevel uint8 `db:"displayed_level"`
+}
+
+func loadAchievements(s *Session, charID uint32) ([achievementCount]achievementRow, error) {
+	var achievements [achievementCount]achievementRow
+	for i := range achievements {
+		achievements[i].ID = uint8(i)
+	}
+	var rows []achievementRow
+	err := s.server.db.Select(&rows, "SELECT achievement_id, points, displayed_level FROM achievements WHERE char_id=$1", charID)
+	if err != nil {
+		return achievements, err
+	}
+	for _, row := range rows {
+		if int(row.ID) < achievementCount {
+			achievements[row.ID] = row
+		}
+	}
+	return achievements, nil
+}
+
+func handleMsgMhfGetAchievement(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetAchievement)
+	achievements, err := loadAchievements(s, pkt.CharID)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to load achievements", err)
+	}
 
-	achievementStruct := []struct {
-		ID   uint8  // Main ID
-		Unk0 uint8  // always FF
-		Unk1 uint16 // 0x05 0x00
-		Unk2 uint32 // 0x01 0x0A 0x05 0x00
-	}{
-		{ID: 0, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 1, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 2, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 3, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 4, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 5, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 6, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 7, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 8, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 9, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 10, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 11, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 12, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 13, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 14, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 15, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 16, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 17, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 18, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 19, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 20, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 21, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 22, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 23, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 24, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 25, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 26, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 27, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 28, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 29, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 30, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 31, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 32, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 33, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 34, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 35, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 36, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 37, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 38, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 39, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 40, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 41, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 42, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 43, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 44, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 45, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 46, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 47, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 48, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 49, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 50, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 51, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 52, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 53, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 54, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 55, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 56, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 57, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 58, Unk0: 0xFF, Unk1: 0, Unk2: 0},
-		{ID: 59, Unk0: 0xFF, Unk1: 0, Unk2: 0},
+	entries := byteframe.NewByteFrame()
+	var points uint32
+	for _, row := range achievements {
+		ach := getAchievement(row.ID, row.Points)
+		points += ach.Value
+		entries.WriteUint8(row.ID)
+		entries.WriteUint8(ach.Level)
+		entries.WriteUint16(ach.NextValue)
+		entries.WriteUint32(ach.Required)
+		// Plays the rank up notification until MsgMhfDisplayedAchievement.
+		entries.WriteBool(pkt.CharID == s.charID && ach.Level > row.DisplayedLevel)
+		entries.WriteUint8(ach.Trophy)
+		entries.WriteUint16(0) // Unk
+		entries.WriteUint32(ach.Progress)
 	}
+
 	resp := byteframe.NewByteFrame()
-	resp.WriteUint8(uint8(len(achievementStruct))) // Entry count
-	for _, entry := range achievementStruct {
-		resp.WriteUint8(entry.ID)
-		resp.WriteUint8(entry.Unk0)
-		resp.WriteUint16(entry.Unk1)
-		resp.WriteUint32(entry.Unk2)
+	for i := 0; i < 4; i++ {
+		resp.WriteUint32(points)
 	}
+	resp.WriteBytes([]byte{0x02, 0x00, 0x00}) // Unk
+	resp.WriteUint8(achievementCount)
+	resp.WriteBytes(entries.Data())
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfSetCaAchievementHist(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSetCaAchievementHist(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSetCaAchievementHist)
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfResetAchievement(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfResetAchievement(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfResetAchievement)
+	if pkt.AchievementID >= achievementCount {
+		return errNoAck(fmt.Sprintf("Invalid achievement %d", pkt.AchievementID), nil)
+	}
+	_, err := s.server.db.Exec("DELETE FROM achievements WHERE char_id=$1 AND achievement_id=$2", s.charID, pkt.AchievementID)
+	if err != nil {
+		return errNoAck("Failed to reset achievement", err)
+	}
+	return nil
+}
 
-func handleMsgMhfAddAchievement(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfAddAchievement(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfAddAchievement)
+	if pkt.AchievementID >= achievementCount {
+		return errNoAck(fmt.Sprintf("Invalid achievement %d", pkt.AchievementID), nil)
+	}
+	var points int32
+	err := s.server.db.QueryRow(`
+		INSERT INTO achievements (char_id, achievement_id, points) VALUES ($1, $2, 1)
+		ON CONFLICT (char_id, achievement_id) DO UPDATE SET points = achievements.points + 1
+		RETURNING points`,
+		s.charID, pkt.AchievementID,
+	).Scan(&points)
+	if err != nil {
+		return errNoAck("Failed to add achievement", err)
+	}
+	rank := getAchievement(pkt.AchievementID, points).Level
+	if rank == getAchievement(pkt.AchievementID, points-1).Level {
+		return nil
+	}
+	titles := achievementTitles(s.server.erupeConfig.Titles.Achievements, pkt.AchievementID, rank)
+	if len(titles) > 0 {
+		if err = GrantTitles(s.server.db, s.charID, titles); err != nil {
+			return errNoAck("Failed to grant achievement titles", err)
+		}
+	}
+	return nil
+}
 
-func handleMsgMhfPaymentAchievement(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfPaymentAchievement(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfDisplayedAchievement(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfDisplayedAchievement(s *Session, p mhfpacket.MHFPacket) error {
+	achievements, err := loadAchievements(s, s.charID)
+	if err != nil {
+		return errNoAck("Failed to load achievements", err)
+	}
+	for _, row := range achievements {
+		level := getAchievement(row.ID, row.Points).Level
+		if level == row.DisplayedLevel {
+			continue
+		}
+		_, err = s.server.db.Exec(
+			"UPDATE achievements SET displayed_level=$1 WHERE char_id=$2 AND achievement_id=$3",
+			level, s.charID, row.ID,
+		)
+		if err != nil {
+			return errNoAck("Failed to mark achievements as displayed", err)
+		}
+	}
+	return nil
+}
 
-func handleMsgMhfGetCaAchievementHist(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGetCaAchievementHist(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfSetCaAchievement(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfSetCaAchievement(s *Session, p mhfpacket.MHFPacket) error { return nil }
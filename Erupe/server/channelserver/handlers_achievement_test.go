@@ -0,0 +1,25 @@
+package channelserver
+
+import "testing"
+
+func TestGetAchievement(t *testing.T) {
+	tests := []struct {
+		id     uint8
+		points int32
+		want   achievement
+	}{
+		{0, 0, achievement{Level: 0, Value: 0, NextValue: 5, Required: 5, Progress: 0}},
+		{0, 4, achievement{Level: 0, Value: 0, NextValue: 5, Required: 5, Progress: 4}},
+		{0, 5, achievement{Level: 1, Value: 5, NextValue: 10, Required: 15, Progress: 0}},
+		{0, 350, achievement{Level: 6, Value: 65, NextValue: 15, Required: 200, Progress: 0, Trophy: achievementTrophyBronze}},
+		{0, 550, achievement{Level: 7, Value: 80, NextValue: 20, Required: 300, Progress: 0, Trophy: achievementTrophySilver}},
+		{0, 9999, achievement{Level: 8, Value: 100, Required: 300, Progress: 300, Trophy: achievementTrophyGold}},
+		{7, 1, achievement{Level: 1, Value: 5, NextValue: 10, Required: 5, Progress: 0}},
+		{8, 2, achievement{Level: 1, Value: 5, NextValue: 10, Required: 2, Progress: 1}},
+	}
+	for _, tt := range tests {
+		if got := getAchievement(tt.id, tt.points); got != tt.want {
+			t.Errorf("getAchievement(%d, %d) = %+v, expected %+v", tt.id, tt.points, got, tt.want)
+		}
+	}
+}
@@ -2,17 +2,20 @@ package channelserver
 
 import "erupe-ce/network/mhfpacket"
 
-func handleMsgMhfEnumerateCampaign(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateCampaign(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateCampaign)
 	doAckSimpleFail(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfStateCampaign(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfStateCampaign(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfStateCampaign)
 	doAckSimpleFail(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfApplyCampaign(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfApplyCampaign(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfApplyCampaign)
 	doAckSimpleFail(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
@@ -4,8 +4,8 @@ import (
 	"erupe-ce/network/mhfpacket"
 )
 
-func handleMsgMhfCaravanMyScore(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfCaravanMyScore(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfCaravanRanking(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfCaravanRanking(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfCaravanMyRank(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfCaravanMyRank(s *Session, p mhfpacket.MHFPacket) error { return nil }
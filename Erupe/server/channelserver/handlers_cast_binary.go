@@ -10,6 +10,7 @@ import (
 	"erupe-ce/common/byteframe"
 	"erupe-ce/network/binpacket"
 	"erupe-ce/network/mhfpacket"
+	"go.uber.org/zap"
 )
 
 // MSG_SYS_CAST[ED]_BINARY types enum
@@ -50,7 +51,7 @@ func sendServerChatMessage(s *Session, message string) {
 	s.QueueSendMHF(castedBin)
 }
 
-func handleMsgSysCastBinary(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysCastBinary(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysCastBinary)
 	tmp := byteframe.NewByteFrameFromBytes(pkt.RawDataPayload)
 
@@ -87,7 +88,7 @@ func handleMsgSysCastBinary(s *Session, p mhfpacket.MHFPacket) {
 		err := msgBinTargeted.Parse(tmp)
 		if err != nil {
 			s.logger.Warn("Failed to parse targeted cast binary")
-			return
+			return nil
 		}
 		realPayload = msgBinTargeted.RawDataPayload
 	} else if pkt.MessageType == BinaryMessageTypeChat {
@@ -144,13 +145,7 @@ func handleMsgSysCastBinary(s *Session, p mhfpacket.MHFPacket) {
 			s.Unlock()
 		}
 	case BroadcastTypeTargeted:
-		for _, targetID := range (*msgBinTargeted).TargetCharIDs {
-			char := s.server.FindSessionByCharID(targetID)
-
-			if char != nil {
-				char.QueueSendMHF(resp)
-			}
-		}
+		s.server.SendToCharacters(resp, (*msgBinTargeted).TargetCharIDs...)
 	default:
 		s.Lock()
 		haveStage := s.stage != nil
@@ -179,6 +174,8 @@ func handleMsgSysCastBinary(s *Session, p mhfpacket.MHFPacket) {
 			n, err := fmt.Sscanf(chatMessage.Message, "!rights %d", &v)
 			if err != nil || n != 1 {
 				sendServerChatMessage(s, "Error in command. Format: !rights n")
+			} else if mask := s.server.erupeConfig.Moderation.Rights; mask != 0 && v&mask != 0 && !s.isModerator() {
+				sendServerChatMessage(s, "You can't grant yourself moderation rights")
 			} else {
 				_, err = s.server.db.Exec("UPDATE users u SET rights=$1 WHERE u.id=(SELECT c.user_id FROM characters c WHERE c.id=$2)", v, s.charID)
 				if err == nil {
@@ -187,6 +184,13 @@ func handleMsgSysCastBinary(s *Session, p mhfpacket.MHFPacket) {
 			}
 		}
 
+		// Moderation
+		if strings.HasPrefix(chatMessage.Message, "!ban ") {
+			handleBanCommand(s, chatMessage.Message)
+		} else if strings.HasPrefix(chatMessage.Message, "!unban ") {
+			handleUnbanCommand(s, chatMessage.Message)
+		}
+
 		// Discord integration
 		if chatMessage.Type == binpacket.ChatTypeLocal || chatMessage.Type == binpacket.ChatTypeParty {
 			s.server.DiscordChannelSend(chatMessage.SenderName, chatMessage.Message)
@@ -194,62 +198,75 @@ func handleMsgSysCastBinary(s *Session, p mhfpacket.MHFPacket) {
 
 		// RAVI COMMANDS V2
 		if strings.HasPrefix(chatMessage.Message, "!ravi") {
+			notify := false
 			if checkRaviSemaphore(s) {
-				s.server.raviente.Lock()
-				if !strings.HasPrefix(chatMessage.Message, "!ravi ") {
-					sendServerChatMessage(s, "No Raviente command specified!")
-				} else {
-					if strings.HasPrefix(chatMessage.Message, "!ravi start") {
-						if s.server.raviente.register.startTime == 0 {
-							s.server.raviente.register.startTime = s.server.raviente.register.postTime
-							sendServerChatMessage(s, "The Great Slaying will begin in a moment")
-							s.notifyall()
-						} else {
-							sendServerChatMessage(s, "The Great Slaying has already begun!")
-						}
-					} else if strings.HasPrefix(chatMessage.Message, "!ravi sm") || strings.HasPrefix(chatMessage.Message, "!ravi setmultiplier") {
-						var num uint16
-						n, numerr := fmt.Sscanf(chatMessage.Message, "!ravi sm %d", &num)
-						if numerr != nil || n != 1 {
-							sendServerChatMessage(s, "Error in command. Format: !ravi sm n")
-						} else if s.server.raviente.state.damageMultiplier == 1 {
-							if num > 65535 {
-								sendServerChatMessage(s, "Raviente multiplier too high, defaulting to 20x")
-								s.server.raviente.state.damageMultiplier = 65535
+				var replies []string
+				_, err := s.server.raviente.update(Time_Current_Adjusted(), func(d *ravienteData) bool {
+					if !strings.HasPrefix(chatMessage.Message, "!ravi ") {
+						replies = append(replies, "No Raviente command specified!")
+					} else {
+						if strings.HasPrefix(chatMessage.Message, "!ravi start") {
+							if d.register.startTime == 0 {
+								d.register.startTime = d.register.postTime
+								replies = append(replies, "The Great Slaying will begin in a moment")
+								notify = true
 							} else {
-								sendServerChatMessage(s, fmt.Sprintf("Raviente multiplier set to %dx", num))
-								s.server.raviente.state.damageMultiplier = uint32(num)
+								replies = append(replies, "The Great Slaying has already begun!")
 							}
+						} else if strings.HasPrefix(chatMessage.Message, "!ravi sm") || strings.HasPrefix(chatMessage.Message, "!ravi setmultiplier") {
+							var num uint16
+							n, numerr := fmt.Sscanf(chatMessage.Message, "!ravi sm %d", &num)
+							if numerr != nil || n != 1 {
+								replies = append(replies, "Error in command. Format: !ravi sm n")
+							} else if d.state.damageMultiplier == 1 {
+								if num > 65535 {
+									replies = append(replies, "Raviente multiplier too high, defaulting to 20x")
+									d.state.damageMultiplier = 65535
+								} else {
+									replies = append(replies, fmt.Sprintf("Raviente multiplier set to %dx", num))
+									d.state.damageMultiplier = uint32(num)
+								}
+							} else {
+								replies = append(replies, fmt.Sprintf("Raviente multiplier is already set to %dx!", d.state.damageMultiplier))
+							}
+						} else if strings.HasPrefix(chatMessage.Message, "!ravi cm") || strings.HasPrefix(chatMessage.Message, "!ravi checkmultiplier") {
+							replies = append(replies, fmt.Sprintf("Raviente multiplier is currently %dx", d.state.damageMultiplier))
+						} else if strings.HasPrefix(chatMessage.Message, "!ravi sr") || strings.HasPrefix(chatMessage.Message, "!ravi sendres") {
+							if d.state.stateData[28] > 0 {
+								replies = append(replies, "Sending resurrection support!")
+								d.state.stateData[28] = 0
+							} else {
+								replies = append(replies, "Resurrection support has not been requested!")
+							}
+						} else if strings.HasPrefix(chatMessage.Message, "!ravi ss") || strings.HasPrefix(chatMessage.Message, "!ravi sendsed") {
+							replies = append(replies, "Sending sedation support if requested!")
+							// Total BerRavi HP
+							HP := d.state.stateData[0] + d.state.stateData[1] + d.state.stateData[2] + d.state.stateData[3] + d.state.stateData[4]
+							d.support.supportData[1] = HP
+						} else if strings.HasPrefix(chatMessage.Message, "!ravi rs") || strings.HasPrefix(chatMessage.Message, "!ravi reqsed") {
+							replies = append(replies, "Requesting sedation support!")
+							// Total BerRavi HP
+							HP := d.state.stateData[0] + d.state.stateData[1] + d.state.stateData[2] + d.state.stateData[3] + d.state.stateData[4]
+							d.support.supportData[1] = HP + 12
 						} else {
-							sendServerChatMessage(s, fmt.Sprintf("Raviente multiplier is already set to %dx!", s.server.raviente.state.damageMultiplier))
-						}
-					} else if strings.HasPrefix(chatMessage.Message, "!ravi cm") || strings.HasPrefix(chatMessage.Message, "!ravi checkmultiplier") {
-						sendServerChatMessage(s, fmt.Sprintf("Raviente multiplier is currently %dx", s.server.raviente.state.damageMultiplier))
-					} else if strings.HasPrefix(chatMessage.Message, "!ravi sr") || strings.HasPrefix(chatMessage.Message, "!ravi sendres") {
-						if s.server.raviente.state.stateData[28] > 0 {
-							sendServerChatMessage(s, "Sending resurrection support!")
-							s.server.raviente.state.stateData[28] = 0
-						} else {
-							sendServerChatMessage(s, "Resurrection support has not been requested!")
+							replies = append(replies, "Raviente command not recognised!")
 						}
-					} else if strings.HasPrefix(chatMessage.Message, "!ravi ss") || strings.HasPrefix(chatMessage.Message, "!ravi sendsed") {
-						sendServerChatMessage(s, "Sending sedation support if requested!")
-						// Total BerRavi HP
-						HP := s.server.raviente.state.stateData[0] + s.server.raviente.state.stateData[1] + s.server.raviente.state.stateData[2] + s.server.raviente.state.stateData[3] + s.server.raviente.state.stateData[4]
-						s.server.raviente.support.supportData[1] = HP
-					} else if strings.HasPrefix(chatMessage.Message, "!ravi rs") || strings.HasPrefix(chatMessage.Message, "!ravi reqsed") {
-						sendServerChatMessage(s, "Requesting sedation support!")
-						// Total BerRavi HP
-						HP := s.server.raviente.state.stateData[0] + s.server.raviente.state.stateData[1] + s.server.raviente.state.stateData[2] + s.server.raviente.state.stateData[3] + s.server.raviente.state.stateData[4]
-						s.server.raviente.support.supportData[1] = HP + 12
-					} else {
-						sendServerChatMessage(s, "Raviente command not recognised!")
 					}
+					return true
+				})
+				if err != nil {
+					s.logger.Error("Failed to update Raviente", zap.Error(err))
+					replies, notify = []string{"Failed to update the Great Slaying!"}, false
+				}
+				for _, reply := range replies {
+					sendServerChatMessage(s, reply)
 				}
 			} else {
 				sendServerChatMessage(s, "No one has joined the Great Slaying!")
 			}
-			s.server.raviente.Unlock()
+			if notify {
+				s.notifyall()
+			}
 		}
 		// END RAVI COMMANDS V2
 
@@ -277,6 +294,7 @@ func handleMsgSysCastBinary(s *Session, p mhfpacket.MHFPacket) {
 			}
 		}
 	}
+	return nil
 }
 
-func handleMsgSysCastedBinary(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysCastedBinary(s *Session, p mhfpacket.MHFPacket) error { return nil }
@@ -126,7 +126,8 @@ func (save *CharacterSaveData) updateStructWithSaveData() {
 	save.RP = binary.LittleEndian.Uint16(save.baseSaveData[CharacterSaveRPPointer : CharacterSaveRPPointer+2])
 }
 
-func handleMsgMhfSexChanger(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSexChanger(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSexChanger)
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
@@ -7,7 +7,7 @@ import (
 	"go.uber.org/zap"
 )
 
-func handleMsgSysEnumerateClient(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysEnumerateClient(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysEnumerateClient)
 
 	s.server.stagesLock.RLock()
@@ -16,7 +16,7 @@ func handleMsgSysEnumerateClient(s *Session, p mhfpacket.MHFPacket) {
 		s.server.stagesLock.RUnlock()
 		s.logger.Warn("Can't enumerate clients for stage that doesn't exist!", zap.String("stageID", pkt.StageID))
 		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	}
 	s.server.stagesLock.RUnlock()
 
@@ -46,18 +46,20 @@ func handleMsgSysEnumerateClient(s *Session, p mhfpacket.MHFPacket) {
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
 	s.logger.Debug("MsgSysEnumerateClient Done!")
+	return nil
 }
 
-func handleMsgMhfListMember(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfListMember(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfListMember)
 
 	resp := byteframe.NewByteFrame()
 	resp.WriteUint32(0) // Members count. (Unsure of what kind of members these actually are, guild, party, COG subscribers, etc.)
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfOprMember(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfOprMember(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfOprMember)
 	var csv string
 	if pkt.Blacklist {
@@ -69,7 +71,7 @@ func handleMsgMhfOprMember(s *Session, p mhfpacket.MHFPacket) {
 	} else { // Friendlist
 		err := s.server.db.QueryRow("SELECT friends FROM characters WHERE id=$1", s.charID).Scan(&csv)
 		if err != nil {
-			panic(err)
+			return errSimpleFail(pkt.AckHandle, "", err)
 		}
 		if pkt.Operation {
 			csv = stringsupport.CSVRemove(csv, int(pkt.CharID))
@@ -79,8 +81,9 @@ func handleMsgMhfOprMember(s *Session, p mhfpacket.MHFPacket) {
 		_, _ = s.server.db.Exec("UPDATE characters SET friends=$1 WHERE id=$2", csv, s.charID)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfShutClient(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfShutClient(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysHideClient(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysHideClient(s *Session, p mhfpacket.MHFPacket) error { return nil }
@@ -2,105 +2,77 @@ package channelserver
 
 import (
 	"encoding/hex"
-	"encoding/binary"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 
 	"erupe-ce/common/byteframe"
-	"erupe-ce/common/bfutil"
 	"erupe-ce/network/mhfpacket"
-	"erupe-ce/server/channelserver/compression/deltacomp"
-	"erupe-ce/server/channelserver/compression/nullcomp"
 	"go.uber.org/zap"
 )
 
-func handleMsgMhfSavedata(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSavedata(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSavedata)
 	characterSaveData, err := GetCharacterSaveData(s, s.charID)
+	if err != nil || characterSaveData == nil {
+		return errSimpleFail(pkt.AckHandle, "failed to retrieve character save data from db", err)
+	}
+
+	saveData, fields, err := validateSave(pkt.SaveType, pkt.RawDataPayload, characterSaveData.BaseSaveData(), s.clientContext.StrConv)
 	if err != nil {
-		s.logger.Error("failed to retrieve character save data from db", zap.Error(err), zap.Uint32("charID", s.charID))
-		return
+		quarantineSaveData(s, pkt.SaveType, pkt.RawDataPayload, err)
+		return errSimpleFail(pkt.AckHandle, "Rejected savedata", err)
 	}
-	// Var to hold the decompressed savedata for updating the launcher response fields.
-	var decompressedData []byte
-	if pkt.SaveType == 1 {
-		// Diff-based update.
-		// diffs themselves are also potentially compressed
-		diff, err := nullcomp.Decompress(pkt.RawDataPayload)
-		if err != nil {
-			s.logger.Fatal("Failed to decompress diff", zap.Error(err))
-		}
-		// Perform diff.
+	if pkt.SaveType == saveDiffType {
 		s.logger.Info("Diffing...")
-		characterSaveData.SetBaseSaveData(deltacomp.ApplyDataDiff(diff, characterSaveData.BaseSaveData()))
 	} else {
-		// Regular blob update.
-		saveData, err := nullcomp.Decompress(pkt.RawDataPayload)
-		if err != nil {
-			s.logger.Fatal("Failed to decompress savedata from packet", zap.Error(err))
-		}
 		s.logger.Info("Updating save with blob")
-		characterSaveData.SetBaseSaveData(saveData)
 	}
+	characterSaveData.SetBaseSaveData(saveData)
 	characterSaveData.IsNewCharacter = false
-	characterBaseSaveData := characterSaveData.BaseSaveData()
-	// Make a copy for updating the launcher fields.
-	decompressedData = make([]byte, len(characterBaseSaveData))
-	copy(decompressedData, characterBaseSaveData)
-	err = characterSaveData.Save(s, nil)
-	if err != nil {
-		s.logger.Fatal("Failed to update savedata in db", zap.Error(err))
-	}
-	s.logger.Info("Wrote recompressed savedata back to DB.")
-	dumpSaveData(s, pkt.RawDataPayload, "")
 
-	_, err = s.server.db.Exec("UPDATE characters SET weapon_type=$1 WHERE id=$2", uint16(decompressedData[128789]), s.charID)
+	tx, err := s.server.db.Begin()
 	if err != nil {
-		s.logger.Fatal("Failed to character weapon type in db", zap.Error(err))
-	}
-
-	isMale := uint8(decompressedData[80]) // 0x50
-	if isMale == 1 {
-		_, err = s.server.db.Exec("UPDATE characters SET is_female=true WHERE id=$1", s.charID)
-	} else {
-		_, err = s.server.db.Exec("UPDATE characters SET is_female=false WHERE id=$1", s.charID)
+		return errSimpleFail(pkt.AckHandle, "Failed to begin savedata transaction", err)
 	}
+	defer tx.Rollback()
+	err = characterSaveData.Save(s, tx)
 	if err != nil {
-		s.logger.Fatal("Failed to character gender in db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to update savedata in db", err)
 	}
-
-	weaponId := binary.LittleEndian.Uint16(decompressedData[128522:128524]) // 0x1F60A
-	_, err = s.server.db.Exec("UPDATE characters SET weapon_id=$1 WHERE id=$2", weaponId, s.charID)
+	err = snapshotSaveData(tx, s.charID, pkt.SaveType, s.server.erupeConfig.Saves.Snapshots)
 	if err != nil {
-		s.logger.Fatal("Failed to update character weapon id in db", zap.Error(err))
+		// Losing a snapshot is no reason to lose the save itself.
+		s.logger.Error("Failed to snapshot savedata", zap.Error(err))
 	}
-
-	hrp := binary.LittleEndian.Uint16(decompressedData[130550:130552]) // 0x1FDF6
-	_, err = s.server.db.Exec("UPDATE characters SET hrp=$1 WHERE id=$2", hrp, s.charID)
+	_, err = tx.Exec(
+		"UPDATE characters SET weapon_type=$1, is_female=$2, weapon_id=$3, hrp=$4, gr=$5, name=$6 WHERE id=$7",
+		fields.WeaponType, fields.IsFemale, fields.WeaponID, fields.HRP, fields.GR, fields.Name, s.charID,
+	)
 	if err != nil {
-		s.logger.Fatal("Failed to update character hrp in db", zap.Error(err))
-	}
-
-	grp := binary.LittleEndian.Uint32(decompressedData[130556:130560]) // 0x1FDFC
-	var gr uint16
-	if grp > 0 {
-		gr = grpToGR(grp)
-	} else {
-		gr = 0
+		return errSimpleFail(pkt.AckHandle, "Failed to update character fields in db", err)
 	}
-	_, err = s.server.db.Exec("UPDATE characters SET gr=$1 WHERE id=$2", gr, s.charID)
+	err = tx.Commit()
 	if err != nil {
-		s.logger.Fatal("Failed to update character gr in db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to commit savedata", err)
 	}
+	s.logger.Info("Wrote recompressed savedata back to DB.")
+	dumpSaveData(s, pkt.RawDataPayload, "")
+	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
+}
 
-	characterName := s.clientContext.StrConv.MustDecode(bfutil.UpToNull(decompressedData[88:100]))
-	_, err = s.server.db.Exec("UPDATE characters SET name=$1 WHERE id=$2", characterName, s.charID)
+// quarantineSaveData keeps a rejected savedata payload for inspection.
+func quarantineSaveData(s *Session, saveType uint8, payload []byte, reason error) {
+	_, err := s.server.db.Exec(
+		"INSERT INTO savedata_quarantine (char_id, save_type, payload, reason) VALUES ($1, $2, $3, $4)",
+		s.charID, saveType, payload, reason.Error(),
+	)
 	if err != nil {
-		s.logger.Fatal("Failed to update character name in db", zap.Error(err))
+		s.logger.Error("Failed to quarantine savedata", zap.Error(err), zap.Uint32("charID", s.charID))
 	}
-	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	dumpSaveData(s, payload, "_rejected")
 }
 
 func grpToGR(n uint32) uint16 {
@@ -162,12 +134,12 @@ func dumpSaveData(s *Session, data []byte, suffix string) {
 		}
 		err := ioutil.WriteFile(path, data, 0644)
 		if err != nil {
-			s.logger.Fatal("Error dumping savedata", zap.Error(err))
+			s.logger.Error("Error dumping savedata", zap.Error(err))
 		}
 	}
 }
 
-func handleMsgMhfLoaddata(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfLoaddata(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfLoaddata)
 	overrideFile := filepath.Join(".", "bin", "save_override.bin")
 	var data []byte
@@ -175,27 +147,28 @@ func handleMsgMhfLoaddata(s *Session, p mhfpacket.MHFPacket) {
 	if _, err := os.Stat(overrideFile); err == nil {
 		file, err := os.Open(overrideFile)
 		if err != nil {
-			panic(err)
+			return errBufFail(pkt.AckHandle, "", err)
 		}
 		data, err := ioutil.ReadAll(file)
 		if err != nil {
-			panic(err)
+			return errBufFail(pkt.AckHandle, "", err)
 		}
 		doAckBufSucceed(s, pkt.AckHandle, data)
 	}
 
 	err := s.server.db.QueryRow("SELECT savedata FROM characters WHERE id = $1", s.charID).Scan(&data)
 	if err != nil {
-		s.logger.Fatal("Failed to get savedata from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get savedata from db", err)
 	}
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
 }
 
-func handleMsgMhfSaveScenarioData(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSaveScenarioData(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSaveScenarioData)
 	_, err := s.server.db.Exec("UPDATE characters SET scenariodata = $1 WHERE characters.id = $2", pkt.RawDataPayload, int(s.charID))
   if err != nil {
-    s.logger.Fatal("Failed to update scenario data in db", zap.Error(err))
+    return errSimpleFail(pkt.AckHandle, "Failed to update scenario data in db", err)
   }
 	// Do this ack manually because it uses a non-(0|1) error code
 	s.QueueSendMHF(&mhfpacket.MsgSysAck{
@@ -204,15 +177,16 @@ func handleMsgMhfSaveScenarioData(s *Session, p mhfpacket.MHFPacket) {
 		ErrorCode:        0x40,
 		AckData:          []byte{0x00, 0x00, 0x00, 0x40},
 	})
+	return nil
 }
 
-func handleMsgMhfLoadScenarioData(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfLoadScenarioData(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfLoadScenarioData)
 	var scenarioData []byte
 	bf := byteframe.NewByteFrame()
 	err := s.server.db.QueryRow("SELECT scenariodata FROM characters WHERE characters.id = $1", int(s.charID)).Scan(&scenarioData)
 	if err != nil {
-		s.logger.Fatal("Failed to get scenario data contents in db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get scenario data contents in db", err)
 	} else {
 		if len(scenarioData) == 0 {
 			bf.WriteUint32(0x00)
@@ -221,9 +195,10 @@ func handleMsgMhfLoadScenarioData(s *Session, p mhfpacket.MHFPacket) {
 		}
 	}
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfGetPaperData(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetPaperData(s *Session, p mhfpacket.MHFPacket) error {
 	// if the game gets bad responses for this it breaks the ability to save
 	pkt := p.(*mhfpacket.MsgMhfGetPaperData)
 	var data []byte
@@ -257,9 +232,10 @@ func handleMsgMhfGetPaperData(s *Session, p mhfpacket.MHFPacket) {
 		s.logger.Info("GET_PAPER request for unknown type")
 	}
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
 }
 
-func handleMsgSysAuthData(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysAuthData(s *Session, p mhfpacket.MHFPacket) error { return nil }
@@ -23,7 +23,7 @@ type ItemDist struct {
 	Data            []byte `db:"data"`
 }
 
-func handleMsgMhfEnumerateDistItem(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateDistItem(s *Session, p mhfpacket.MHFPacket) error {
   pkt := p.(*mhfpacket.MsgMhfEnumerateDistItem)
 	bf := byteframe.NewByteFrame()
 	distCount := 0
@@ -77,9 +77,10 @@ func handleMsgMhfEnumerateDistItem(s *Session, p mhfpacket.MHFPacket) {
 		resp.WriteUint8(0)
 		doAckBufSucceed(s, pkt.AckHandle, resp.Data())
 	}
+	return nil
 }
 
-func handleMsgMhfApplyDistItem(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfApplyDistItem(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfApplyDistItem)
 
   if pkt.DistributionID == 0 {
@@ -91,7 +92,7 @@ func handleMsgMhfApplyDistItem(s *Session, p mhfpacket.MHFPacket) {
 		if err != nil {
 			s.logger.Error("Error parsing item distribution data", zap.Error(err))
 			doAckBufSucceed(s, pkt.AckHandle, make([]byte, 6))
-			return
+			return nil
 		}
 
 		bf := byteframe.NewByteFrame()
@@ -107,24 +108,27 @@ func handleMsgMhfApplyDistItem(s *Session, p mhfpacket.MHFPacket) {
 			s.logger.Error("Error updating accepted dist count", zap.Error(err))
 		}
   }
+	return nil
 }
 
-func handleMsgMhfAcquireDistItem(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfAcquireDistItem(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfAcquireDistItem)
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfGetDistDescription(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetDistDescription(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetDistDescription)
 	var desc string
 	err := s.server.db.QueryRow("SELECT description FROM distribution WHERE id = $1", pkt.DistributionID).Scan(&desc)
 	if err != nil {
 		s.logger.Error("Error parsing item distribution description", zap.Error(err))
 		doAckBufSucceed(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	}
 	bf := byteframe.NewByteFrame()
 	ps.Uint16(bf, desc, true)
 	ps.Uint16(bf, "", false)
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
@@ -1,51 +1,445 @@
 package channelserver
 
 import (
+	"database/sql"
 	"encoding/hex"
+	"errors"
+	"time"
 
-	"erupe-ce/network/mhfpacket"
 	"erupe-ce/common/byteframe"
+	"erupe-ce/common/stringsupport"
+	"erupe-ce/network/mhfpacket"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Diva Defense phases, an event going through each of them in order.
+const (
+	divaPhaseNone = iota
+	divaPhasePrayer
+	divaPhaseInterception
+	divaPhaseSong
+	divaPhaseResults
+)
+
+const (
+	// divaPhases is the number of phases of a Diva Defense event.
+	divaPhases = 3
+	// divaPointRows is the number of point rows sent to the client, personal and guild ones.
+	divaPointRows = 8
+	// divaRewardTierSlots is the number of reward tiers the client expects.
+	divaRewardTierSlots = 64
+	// divaRankingSize is the number of entries sent in a Diva Defense ranking.
+	divaRankingSize = 100
 )
 
-func handleMsgMhfGetKijuInfo(s *Session, p mhfpacket.MHFPacket) {
+// divaRewardTiers are the world point totals unlocking the Diva Defense rewards,
+// as found in captures.
+var divaRewardTiers = []struct {
+	Points uint64
+	Kind   uint8
+}{
+	{500000, 0}, {1000000, 0}, {2000000, 0}, {3000000, 0}, {4000000, 0},
+	{5000000, 0}, {6000000, 0}, {7000000, 0}, {8000000, 0}, {9000000, 0},
+	{10000000, 0}, {15000000, 0}, {20000000, 0}, {25000000, 0}, {30000000, 0},
+	{35000000, 0}, {40000000, 0}, {45000000, 0}, {50000000, 0}, {55000000, 0},
+	{60000000, 0}, {70000000, 0}, {80000000, 0}, {90000000, 0}, {100000000, 0},
+	{9000000, 1}, {30000000, 2}, {55000000, 3},
+}
+
+// ErrInvalidDivaEvent is returned when the phases of a Diva Defense event are out of order.
+var ErrInvalidDivaEvent = errors.New("diva event phases are out of order")
+
+// ErrDivaEventNotFound is returned when deleting an unknown Diva Defense event.
+var ErrDivaEventNotFound = errors.New("diva event not found")
+
+// DivaEvent is a scheduled Diva Defense event. The prayer phase runs from the
+// start to the interception, then the interception to the song and the song
+// to the end, after which its results stay shown for a while.
+type DivaEvent struct {
+	ID               int       `db:"id" json:"id"`
+	StartTime        time.Time `db:"start_time" json:"start_time"`
+	InterceptionTime time.Time `db:"interception_time" json:"interception_time"`
+	SongTime         time.Time `db:"song_time" json:"song_time"`
+	EndTime          time.Time `db:"end_time" json:"end_time"`
+}
+
+// divaEventColumns are the columns a DivaEvent is loaded from.
+const divaEventColumns = "id, start_time, interception_time, song_time, end_time"
+
+func (e *DivaEvent) window() eventWindow {
+	return eventWindow{e.StartTime, e.InterceptionTime, e.SongTime, e.EndTime}
+}
+
+// Phase returns the phase the event is in at t, divaPhaseNone outside of it.
+func (e *DivaEvent) Phase(t time.Time) int {
+	return e.window().phase(t)
+}
+
+// ListDivaEvents returns the scheduled Diva Defense events.
+func ListDivaEvents(db *sqlx.DB) ([]DivaEvent, error) {
+	events := make([]DivaEvent, 0)
+	err := listEvents(db, &events, "diva_events", divaEventColumns)
+	return events, err
+}
+
+// CreateDivaEvent schedules a Diva Defense event and sets its ID.
+func CreateDivaEvent(db *sqlx.DB, e *DivaEvent) error {
+	if !e.StartTime.Before(e.InterceptionTime) || !e.InterceptionTime.Before(e.SongTime) || !e.SongTime.Before(e.EndTime) {
+		return ErrInvalidDivaEvent
+	}
+	return db.QueryRow(`
+		INSERT INTO diva_events (start_time, interception_time, song_time, end_time)
+		VALUES ($1, $2, $3, $4) RETURNING id`,
+		e.StartTime, e.InterceptionTime, e.SongTime, e.EndTime,
+	).Scan(&e.ID)
+}
+
+// DeleteDivaEvent deletes a Diva Defense event along with its points.
+func DeleteDivaEvent(db *sqlx.DB, id int) error {
+	return deleteEvent(db, "diva_events", id, ErrDivaEventNotFound)
+}
+
+// divaEventAt returns the event running or showing its results at t, or the
+// next one, nil when none is scheduled.
+func divaEventAt(db *sqlx.DB, t time.Time) (*DivaEvent, error) {
+	var e DivaEvent
+	found, err := eventAt(db, &e, "diva_events", divaEventColumns, t)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// currentDivaEvent returns the event running now along with its phase, nil when none is.
+func currentDivaEvent(db *sqlx.DB) (*DivaEvent, int, error) {
+	var e DivaEvent
+	phase, err := currentEvent(db, &e, "diva_events", divaEventColumns)
+	if err != nil || phase == divaPhaseNone {
+		return nil, divaPhaseNone, err
+	}
+	return &e, phase, nil
+}
+
+// addDivaPoints adds points earned by a character during a phase of an event.
+func addDivaPoints(db *sqlx.DB, eventID int, charID uint32, phase int, points uint32) error {
+	_, err := db.Exec(`
+		INSERT INTO diva_points (event_id, character_id, phase, points) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (event_id, character_id, phase) DO UPDATE SET points = diva_points.points + $4`,
+		eventID, charID, phase, points,
+	)
+	return err
+}
+
+// divaPhasePoints are the points of a character and of its guild during a phase.
+type divaPhasePoints struct {
+	Phase    int    `db:"phase"`
+	Personal uint64 `db:"personal"`
+	Guild    uint64 `db:"guild"`
+}
+
+// loadDivaPoints returns the points of a character and of its guild per phase,
+// indexed by phase minus one.
+func loadDivaPoints(db *sqlx.DB, eventID int, charID uint32, guildID uint32) ([divaPhases]divaPhasePoints, error) {
+	var points [divaPhases]divaPhasePoints
+	rows := make([]divaPhasePoints, 0, divaPhases)
+	err := db.Select(&rows, `
+		SELECT dp.phase,
+		       COALESCE(SUM(dp.points) FILTER (WHERE dp.character_id=$2), 0) AS personal,
+		       COALESCE(SUM(dp.points) FILTER (WHERE gc.guild_id=$3), 0) AS guild
+		FROM diva_points dp LEFT JOIN guild_characters gc ON gc.character_id = dp.character_id
+		WHERE dp.event_id=$1 AND (dp.character_id=$2 OR gc.guild_id=$3)
+		GROUP BY dp.phase`,
+		eventID, charID, guildID,
+	)
+	if err != nil {
+		return points, err
+	}
+	for _, row := range rows {
+		if row.Phase >= divaPhasePrayer && row.Phase <= divaPhaseSong {
+			points[row.Phase-1] = row
+		}
+	}
+	return points, nil
+}
+
+// divaWorldPoints returns the points earned by every character during an event.
+func divaWorldPoints(db *sqlx.DB, eventID int) (uint64, error) {
+	var total uint64
+	err := db.QueryRow("SELECT COALESCE(SUM(points), 0) FROM diva_points WHERE event_id=$1", eventID).Scan(&total)
+	return total, err
+}
+
+// divaRank is the ranking of a character or a guild in an event.
+type divaRank struct {
+	ID     uint32 `db:"id"`
+	Name   string `db:"name"`
+	Points uint64 `db:"points"`
+	Rank   uint32 `db:"rank"`
+}
+
+// Rankings of the characters and the guilds of an event, every phase counted
+// when the phase given is divaPhaseNone.
+const (
+	divaCharacterRanking = `
+		SELECT dp.character_id AS id, c.name, SUM(dp.points) AS points, RANK() OVER (ORDER BY SUM(dp.points) DESC) AS rank
+		FROM diva_points dp JOIN characters c ON c.id = dp.character_id
+		WHERE dp.event_id=$1 AND ($2=0 OR dp.phase=$2)
+		GROUP BY dp.character_id, c.name`
+	divaGuildRanking = `
+		SELECT g.id, g.name, SUM(dp.points) AS points, RANK() OVER (ORDER BY SUM(dp.points) DESC) AS rank
+		FROM diva_points dp
+		JOIN guild_characters gc ON gc.character_id = dp.character_id
+		JOIN guilds g ON g.id = gc.guild_id
+		WHERE dp.event_id=$1 AND ($2=0 OR dp.phase=$2)
+		GROUP BY g.id, g.name`
+)
+
+// divaRankOf returns the ranking of a character or a guild, zeroed when it has no points.
+func divaRankOf(db *sqlx.DB, ranking string, eventID int, phase int, id uint32) (divaRank, error) {
+	var rank divaRank
+	if id == 0 {
+		return rank, nil
+	}
+	err := db.Get(&rank, "SELECT id, name, points, rank FROM ("+ranking+") r WHERE id=$3", eventID, phase, id)
+	if err == sql.ErrNoRows {
+		return divaRank{}, nil
+	}
+	return rank, err
+}
+
+// divaTopRanks returns the best ranked characters or guilds.
+func divaTopRanks(db *sqlx.DB, ranking string, eventID int, phase int) ([]divaRank, error) {
+	ranks := make([]divaRank, 0)
+	err := db.Select(&ranks, "SELECT id, name, points, rank FROM ("+ranking+") r ORDER BY rank, id LIMIT $3", eventID, phase, divaRankingSize)
+	return ranks, err
+}
+
+// writeDivaPoints writes a point total, clamped to what fits the client fields.
+func writeDivaPoints(bf *byteframe.ByteFrame, points uint64) {
+	if points > 0xFFFFFFFF {
+		points = 0xFFFFFFFF
+	}
+	bf.WriteUint32(uint32(points))
+}
+
+// writeDivaRanks writes the ranking of a character and of its guild.
+func writeDivaRanks(bf *byteframe.ByteFrame, character divaRank, guild divaRank) {
+	for _, rank := range []divaRank{character, guild} {
+		bf.WriteUint32(rank.Rank)
+		bf.WriteUint32(rank.Rank) // Unk, equal to the rank in captures
+		writeDivaPoints(bf, rank.Points)
+	}
+	bf.WriteBytes(stringsupport.PaddedString(guild.Name, 25, true))
+}
+
+// buildDivaMyPoint builds the point rows of a character, the personal ones then
+// the guild ones for each phase.
+func buildDivaMyPoint(points [divaPhases]divaPhasePoints) []byte {
+	bf := byteframe.NewByteFrame()
+	bf.WriteUint8(0) // Unk
+	row := func(kind uint8, n uint64) {
+		if n == 0 {
+			kind = 0
+		}
+		bf.WriteUint8(kind)
+		writeDivaPoints(bf, n)
+		writeDivaPoints(bf, n) // Unk, lower than the points in captures
+		bf.WriteUint8(0)       // Unk
+		bf.WriteUint64(0)      // Unk
+	}
+	for _, p := range points {
+		row(4, p.Personal)
+	}
+	for _, p := range points {
+		row(2, p.Guild)
+	}
+	for i := 2 * divaPhases; i < divaPointRows; i++ {
+		row(0, 0)
+	}
+	return bf.Data()
+}
+
+// buildDivaTotalPointInfo builds the reward tiers along with the world point total.
+func buildDivaTotalPointInfo(total uint64) []byte {
+	bf := byteframe.NewByteFrame()
+	bf.WriteUint8(0) // Unk
+	for i := 0; i < divaRewardTierSlots; i++ {
+		if i < len(divaRewardTiers) {
+			bf.WriteUint64(divaRewardTiers[i].Points)
+		} else {
+			bf.WriteUint64(0)
+		}
+	}
+	for i := 0; i < divaRewardTierSlots; i++ {
+		if i < len(divaRewardTiers) {
+			bf.WriteUint8(divaRewardTiers[i].Kind)
+		} else {
+			bf.WriteUint8(0)
+		}
+	}
+	bf.WriteUint64(total)
+	return bf.Data()
+}
+
+// guildIDOf returns the guild of a character, 0 when it has none.
+func guildIDOf(s *Session, charID uint32) (uint32, error) {
+	member, err := GetCharacterGuildMember(s, charID)
+	if err != nil || member == nil {
+		return 0, err
+	}
+	return member.GuildID, nil
+}
+
+// divaKiju is a kiju (prayer gem) offered during Diva Defense.
+type divaKiju struct {
+	ID          uint8
+	Name        string
+	Description string
+	Unk         uint8
+}
+
+// divaKijus are the kiju offered during Diva Defense, as found in captures.
+var divaKijus = []divaKiju{
+	{1, "暴風の祈珠", "―あらしまかぜのきじゅ―\n暴風とは猛る思い。\n聞く者に勇気を与える。", 0x01},
+	{3, "強撃の祈珠", "―きょうげきのきじゅ―\n強撃とは強い声色。\n聞く者の力を研ぎ澄ます。 ", 0x03},
+	{4, "結集の祈珠", "―けっしゅうのきじゅ―\n結集とは確固たる信頼。\n集めるほどに狩人たちの精神力となる。", 0x01},
+	{2, "歌護の祈珠", "―うたまもりのきじゅ―\n歌護とは歌姫の護り。\n集めるほどに狩人たちの支えとなる。 ", 0x02},
+}
+
+// divaKijuNotChosen flags the kiju a character doesn't pray with. Only the kiju
+// of the capturing character lacked it, so this is inferred from one capture.
+const divaKijuNotChosen = 0x10
+
+// buildDivaKijuInfo builds the kiju list, the one chosen by the character
+// flagged as such, none when it is 0.
+func buildDivaKijuInfo(chosen uint16) []byte {
+	bf := byteframe.NewByteFrame()
+	bf.WriteUint8(uint8(len(divaKijus)))
+	for _, kiju := range divaKijus {
+		bf.WriteBytes(stringsupport.PaddedString(kiju.Name, 32, true))
+		bf.WriteBytes(stringsupport.PaddedString(kiju.Description, 512, true))
+		bf.WriteUint8(kiju.ID)
+		if uint16(kiju.ID) == chosen {
+			bf.WriteUint8(kiju.Unk)
+		} else {
+			bf.WriteUint8(kiju.Unk | divaKijuNotChosen)
+		}
+	}
+	return bf.Data()
+}
+
+// isDivaKiju reports whether a kiju is offered during Diva Defense.
+func isDivaKiju(id uint16) bool {
+	for _, kiju := range divaKijus {
+		if uint16(kiju.ID) == id {
+			return true
+		}
+	}
+	return false
+}
+
+func handleMsgMhfGetKijuInfo(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetKijuInfo)
-	// Temporary canned response
-	data, _ := hex.DecodeString("04965C959782CC8B468EEC00000000000000000000000000000000000000000000815C82A082E782B582DC82A982BA82CC82AB82B682E3815C0A965C959782C682CD96D282E98E7682A281420A95B782AD8ED282C997458B4382F0975E82A682E98142000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001018BAD8C8282CC8B468EEC00000000000000000000000000000000000000000000815C82AB82E582A482B082AB82CC82AB82B682E3815C0A8BAD8C8282C682CD8BAD82A290BA904681420A95B782AD8ED282CC97CD82F08CA482AC909F82DC82B78142200000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000003138C8B8F5782CC8B468EEC00000000000000000000000000000000000000000000815C82AF82C182B582E382A482CC82AB82B682E3815C0A8C8B8F5782C682CD8A6D8CC582BD82E9904D978A81420A8F5782DF82E982D982C782C98EEB906C82BD82BF82CC90B8905F97CD82C682C882E9814200000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000041189CC8CEC82CC8B468EEC00000000000000000000000000000000000000000000815C82A482BD82DC82E082E882CC82AB82B682E3815C0A89CC8CEC82C682CD89CC955082CC8CEC82E881420A8F5782DF82E982D982C782C98EEB906C82BD82BF82CC8E7882A682C682C882E9814220000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000212")
-	doAckBufSucceed(s, pkt.AckHandle, data)
+	var chosen uint16
+	event, _, err := currentDivaEvent(s.server.db)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get Diva Defense event", err)
+	}
+	if event != nil {
+		err = s.server.db.QueryRow("SELECT kiju_id FROM diva_kiju WHERE event_id=$1 AND character_id=$2", event.ID, s.charID).Scan(&chosen)
+		if err != nil && err != sql.ErrNoRows {
+			return errBufFail(pkt.AckHandle, "Failed to get kiju", err)
+		}
+	}
+	doAckBufSucceed(s, pkt.AckHandle, buildDivaKijuInfo(chosen))
+	return nil
 }
 
-func handleMsgMhfSetKiju(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSetKiju(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSetKiju)
-	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	event, _, err := currentDivaEvent(s.server.db)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to get Diva Defense event", err)
+	} else if event == nil || !isDivaKiju(pkt.KijuID) {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
+	_, err = s.server.db.Exec(`
+		INSERT INTO diva_kiju (event_id, character_id, kiju_id) VALUES ($1, $2, $3)
+		ON CONFLICT (event_id, character_id) DO UPDATE SET kiju_id = $3`,
+		event.ID, s.charID, pkt.KijuID,
+	)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to set kiju", err)
+	}
+	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfAddUdPoint(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfAddUdPoint(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfAddUdPoint)
-	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	event, phase, err := currentDivaEvent(s.server.db)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to get Diva Defense event", err)
+	} else if event == nil {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
+	if err = addDivaPoints(s.server.db, event.ID, s.charID, phase, pkt.Points); err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to add Diva Defense points", err)
+	}
+	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfGetUdMyPoint(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetUdMyPoint(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetUdMyPoint)
-	// Temporary canned response
-	data, _ := hex.DecodeString("00040000013C000000FA000000000000000000040000007E0000003C02000000000000000000000000000000000000000000000000000002000004CC00000438000000000000000000000000000000000000000000000000000000020000026E00000230000000000000000000020000007D0000007D000000000000000000000000000000000000000000000000000000")
-	doAckBufSucceed(s, pkt.AckHandle, data)
+	var points [divaPhases]divaPhasePoints
+	event, err := divaEventAt(s.server.db, time.Now())
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get Diva Defense event", err)
+	}
+	if event != nil {
+		guildID, err := guildIDOf(s, s.charID)
+		if err != nil {
+			return errBufFail(pkt.AckHandle, "Failed to get guild membership", err)
+		}
+		points, err = loadDivaPoints(s.server.db, event.ID, s.charID, guildID)
+		if err != nil {
+			return errBufFail(pkt.AckHandle, "Failed to get Diva Defense points", err)
+		}
+	}
+	doAckBufSucceed(s, pkt.AckHandle, buildDivaMyPoint(points))
+	return nil
 }
 
-func handleMsgMhfGetUdTotalPointInfo(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetUdTotalPointInfo(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetUdTotalPointInfo)
-	// Temporary canned response
-	data, _ := hex.DecodeString("00000000000007A12000000000000F424000000000001E848000000000002DC6C000000000003D090000000000004C4B4000000000005B8D8000000000006ACFC000000000007A1200000000000089544000000000009896800000000000E4E1C00000000001312D0000000000017D78400000000001C9C3800000000002160EC00000000002625A000000000002AEA5400000000002FAF0800000000003473BC0000000000393870000000000042C1D800000000004C4B40000000000055D4A800000000005F5E10000000000008954400000000001C9C3800000000003473BC00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001020300000000000000000000000000000000000000000000000000000000000000000000000000000000101F1420")
-	doAckBufSucceed(s, pkt.AckHandle, data)
+	var total uint64
+	event, err := divaEventAt(s.server.db, time.Now())
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get Diva Defense event", err)
+	}
+	if event != nil {
+		total, err = divaWorldPoints(s.server.db, event.ID)
+		if err != nil {
+			return errBufFail(pkt.AckHandle, "Failed to get Diva Defense points", err)
+		}
+	}
+	doAckBufSucceed(s, pkt.AckHandle, buildDivaTotalPointInfo(total))
+	return nil
 }
 
-func handleMsgMhfGetUdSelectedColorInfo(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetUdSelectedColorInfo(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetUdSelectedColorInfo)
 
 	// Unk
 	doAckBufSucceed(s, pkt.AckHandle, []byte{0x00, 0x01, 0x01, 0x01, 0x02, 0x03, 0x02, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfGetUdMonsterPoint(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetUdMonsterPoint(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetUdMonsterPoint)
 
 	monsterPoints := []struct {
@@ -175,35 +569,93 @@ func handleMsgMhfGetUdMonsterPoint(s *Session, p mhfpacket.MHFPacket) {
 	}
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfGetUdDailyPresentList(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetUdDailyPresentList(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetUdDailyPresentList)
 	// Temporary canned response
 	data, _ := hex.DecodeString("0100001600000A5397DF00000000000000000000000000000000")
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
 }
 
-func handleMsgMhfGetUdNormaPresentList(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetUdNormaPresentList(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetUdNormaPresentList)
 	// Temporary canned response
 	data, _ := hex.DecodeString("0100001600000A5397DF00000000000000000000000000000000")
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
 }
 
-func handleMsgMhfAcquireUdItem(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfAcquireUdItem(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfAcquireUdItem)
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfGetUdRanking(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetUdRanking(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetUdRanking)
-	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	ranks := make([]divaRank, 0)
+	event, err := divaEventAt(s.server.db, time.Now())
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get Diva Defense event", err)
+	}
+	if event != nil {
+		// Unk0 is assumed to pick the guild ranking when set, the format is unconfirmed.
+		ranking := divaCharacterRanking
+		if pkt.Unk0 != 0 {
+			ranking = divaGuildRanking
+		}
+		ranks, err = divaTopRanks(s.server.db, ranking, event.ID, divaPhaseNone)
+		if err != nil {
+			return errBufFail(pkt.AckHandle, "Failed to get Diva Defense ranking", err)
+		}
+	}
+	bf := byteframe.NewByteFrame()
+	bf.WriteUint8(uint8(len(ranks)))
+	for _, rank := range ranks {
+		bf.WriteUint32(rank.Rank)
+		writeDivaPoints(bf, rank.Points)
+		bf.WriteBytes(stringsupport.PaddedString(rank.Name, 25, true))
+	}
+	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfGetUdMyRanking(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetUdMyRanking(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetUdMyRanking)
-	// Temporary canned response
-	data, _ := hex.DecodeString("00000515000005150000CEB4000003CE000003CE0000CEB44D49444E494748542D414E47454C0000000000000000000000")
+	guildID, err := guildIDOf(s, s.charID)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get guild membership", err)
+	}
+	data, err := buildDivaMyRanking(s.server.db, s.charID, guildID, divaPhaseNone)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get Diva Defense ranking", err)
+	}
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
+}
+
+// buildDivaMyRanking builds the ranking of a character and of a guild in the
+// current event, restricted to a phase unless it is divaPhaseNone.
+func buildDivaMyRanking(db *sqlx.DB, charID uint32, guildID uint32, phase int) ([]byte, error) {
+	var character, guild divaRank
+	event, err := divaEventAt(db, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if event != nil {
+		character, err = divaRankOf(db, divaCharacterRanking, event.ID, phase, charID)
+		if err != nil {
+			return nil, err
+		}
+		guild, err = divaRankOf(db, divaGuildRanking, event.ID, phase, guildID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	bf := byteframe.NewByteFrame()
+	writeDivaRanks(bf, character, guild)
+	return bf.Data(), nil
 }
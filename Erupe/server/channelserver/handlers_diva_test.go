@@ -0,0 +1,58 @@
+package channelserver
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestBuildDivaMyPoint(t *testing.T) {
+	var points [divaPhases]divaPhasePoints
+	points[0] = divaPhasePoints{Phase: divaPhasePrayer, Personal: 316, Guild: 1228}
+	data := buildDivaMyPoint(points)
+	if len(data) != 145 {
+		t.Fatalf("length = %d, expected 145", len(data))
+	}
+	row := func(i int) []byte { return data[1+18*i : 1+18*(i+1)] }
+	if row(0)[0] != 4 || binary.BigEndian.Uint32(row(0)[1:]) != 316 {
+		t.Errorf("unexpected personal row % x", row(0))
+	}
+	if row(1)[0] != 0 {
+		t.Errorf("empty personal row has kind %d", row(1)[0])
+	}
+	if row(3)[0] != 2 || binary.BigEndian.Uint32(row(3)[1:]) != 1228 {
+		t.Errorf("unexpected guild row % x", row(3))
+	}
+}
+
+func TestBuildDivaTotalPointInfo(t *testing.T) {
+	data := buildDivaTotalPointInfo(270472224)
+	if len(data) != 585 {
+		t.Fatalf("length = %d, expected 585", len(data))
+	}
+	if got := binary.BigEndian.Uint64(data[1:]); got != 500000 {
+		t.Errorf("first tier = %d, expected 500000", got)
+	}
+	if kind := data[1+8*divaRewardTierSlots+25]; kind != 1 {
+		t.Errorf("tier 25 kind = %d, expected 1", kind)
+	}
+	if got := binary.BigEndian.Uint64(data[len(data)-8:]); got != 270472224 {
+		t.Errorf("world total = %d, expected 270472224", got)
+	}
+}
+
+func TestBuildDivaKijuInfo(t *testing.T) {
+	data := buildDivaKijuInfo(3)
+	if len(data) != 1+546*len(divaKijus) {
+		t.Fatalf("length = %d, expected %d", len(data), 1+546*len(divaKijus))
+	}
+	for i, kiju := range divaKijus {
+		entry := data[1+546*i : 1+546*(i+1)]
+		chosen := entry[545]&divaKijuNotChosen == 0
+		if entry[544] != kiju.ID || chosen != (kiju.ID == 3) {
+			t.Errorf("unexpected kiju %d trailer % x", kiju.ID, entry[544:])
+		}
+	}
+	if !isDivaKiju(4) || isDivaKiju(5) {
+		t.Error("unexpected kiju IDs")
+	}
+}
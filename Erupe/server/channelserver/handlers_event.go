@@ -7,19 +7,19 @@ import (
 
 	"erupe-ce/common/byteframe"
 	"erupe-ce/network/mhfpacket"
-	timeServerFix "erupe-ce/server/channelserver/timeserver"
 )
 
-func handleMsgMhfRegisterEvent(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfRegisterEvent(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfRegisterEvent)
 	bf := byteframe.NewByteFrame()
 	bf.WriteUint8(pkt.Unk2)
 	bf.WriteUint8(pkt.Unk4)
 	bf.WriteUint16(0x1142)
 	doAckSimpleSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfReleaseEvent(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfReleaseEvent(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfReleaseEvent)
 
 	// Do this ack manually because it uses a non-(0|1) error code
@@ -46,11 +46,13 @@ func handleMsgMhfReleaseEvent(s *Session, p mhfpacket.MHFPacket) {
 		ErrorCode:        0x41,
 		AckData:          []byte{0x00, 0x00, 0x00, 0x00},
 	})
+	return nil
 }
 
-func handleMsgMhfEnumerateEvent(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateEvent(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateEvent)
 	stubEnumerateNoResults(s, pkt.AckHandle)
+	return nil
 }
 
 type activeFeature struct {
@@ -59,7 +61,7 @@ type activeFeature struct {
 	Unk1           uint16
 }
 
-func handleMsgMhfGetWeeklySchedule(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetWeeklySchedule(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetWeeklySchedule)
 	persistentEventSchedule := make([]activeFeature, 8) // generate day after weekly restart
 	for x := -1; x < 7; x++ {
@@ -83,6 +85,7 @@ func handleMsgMhfGetWeeklySchedule(s *Session, p mhfpacket.MHFPacket) {
 		resp.WriteUint16(es.Unk1)
 	}
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
 func generateActiveWeapons(count int) int {
@@ -114,20 +117,20 @@ type loginBoost struct {
 	Expiration         uint32
 }
 
-func handleMsgMhfGetKeepLoginBoostStatus(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetKeepLoginBoostStatus(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetKeepLoginBoostStatus)
 
 	var loginBoostStatus []loginBoost
 	insert := false
 	boostState, err := s.server.db.Query("SELECT week_req, week_count, available, end_time FROM login_boost_state WHERE char_id=$1 ORDER BY week_req ASC", s.charID)
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	for boostState.Next() {
 		var boost loginBoost
 		err = boostState.Scan(&boost.WeekReq, &boost.WeekCount, &boost.Available, &boost.Expiration)
 		if err != nil {
-			panic(err)
+			return errBufFail(pkt.AckHandle, "", err)
 		}
 		loginBoostStatus = append(loginBoostStatus, boost)
 	}
@@ -182,7 +185,7 @@ func handleMsgMhfGetKeepLoginBoostStatus(s *Session, p mhfpacket.MHFPacket) {
 		if !insert {
 			_, err := s.server.db.Exec(`UPDATE login_boost_state SET week_count=$1, end_time=$2 WHERE char_id=$3 AND week_req=$4`, loginBoostStatus[d].WeekCount, loginBoostStatus[d].Expiration, s.charID, loginBoostStatus[d].WeekReq)
 			if err != nil {
-				panic(err)
+				return errBufFail(pkt.AckHandle, "", err)
 			}
 		}
 	}
@@ -190,7 +193,7 @@ func handleMsgMhfGetKeepLoginBoostStatus(s *Session, p mhfpacket.MHFPacket) {
 		if insert {
 			_, err := s.server.db.Exec(`INSERT INTO login_boost_state (char_id, week_req, week_count, available, end_time) VALUES ($1,$2,$3,$4,$5)`, s.charID, v.WeekReq, v.WeekCount, v.Available, v.Expiration)
 			if err != nil {
-				panic(err)
+				return errBufFail(pkt.AckHandle, "", err)
 			}
 		}
 		resp.WriteUint8(v.WeekReq)
@@ -199,9 +202,10 @@ func handleMsgMhfGetKeepLoginBoostStatus(s *Session, p mhfpacket.MHFPacket) {
 		resp.WriteUint32(v.Expiration)
 	}
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfUseKeepLoginBoost(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfUseKeepLoginBoost(s *Session, p mhfpacket.MHFPacket) error {
 	// Directly interacts with MhfGetKeepLoginBoostStatus
 	// TODO: make these states persistent on a per character basis
 	pkt := p.(*mhfpacket.MsgMhfUseKeepLoginBoost)
@@ -229,44 +233,31 @@ func handleMsgMhfUseKeepLoginBoost(s *Session, p mhfpacket.MHFPacket) {
 	}
 	_, err := s.server.db.Exec(`UPDATE login_boost_state SET available='false', end_time=$1 WHERE char_id=$2 AND week_req=$3`, uint32(t.Unix()), s.charID, pkt.BoostWeekUsed)
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfGetUdSchedule(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetUdSchedule(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetUdSchedule)
-	var t = timeServerFix.Tstatic_midnight()
-	var event int = s.server.erupeConfig.DevModeOptions.DivaEvent
-
-	year, month, day := t.Date()
-	midnight := time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	event, err := divaEventAt(s.server.db, time.Now())
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get Diva Defense event", err)
+	}
 	// Events with time limits are Festival with Sign up, Soul Week and Winners Weeks
 	// Diva Defense with Prayer, Interception and Song weeks
 	// Mezeporta Festival with simply 'available' being a weekend thing
 	resp := byteframe.NewByteFrame()
 	resp.WriteUint32(0x1d5fda5c) // Unk (1d5fda5c, 0b5397df)
-
-	if event == 1 {
-		resp.WriteUint32(uint32(midnight.Add(24 * 21 * time.Hour).Unix())) // Week 1 Timestamp, Festi start?
-	} else {
-		resp.WriteUint32(uint32(midnight.Add(-24 * 21 * time.Hour).Unix())) // Week 1 Timestamp, Festi start?
-	}
-
-	if event == 2 {
-		resp.WriteUint32(uint32(midnight.Add(24 * 14 * time.Hour).Unix())) // Week 2 Timestamp
-		resp.WriteUint32(uint32(midnight.Add(24 * 14 * time.Hour).Unix())) // Week 2 Timestamp
-	} else {
-		resp.WriteUint32(uint32(midnight.Add(-24 * 14 * time.Hour).Unix())) // Week 2 Timestamp
-		resp.WriteUint32(uint32(midnight.Add(-24 * 14 * time.Hour).Unix())) // Week 2 Timestamp
-	}
-
-	if event == 3 {
-		resp.WriteUint32(uint32(midnight.Add((24) * 7 * time.Hour).Unix()))  // Diva Defense Interception
-		resp.WriteUint32(uint32(midnight.Add((24) * 14 * time.Hour).Unix())) // Diva Defense Greeting Song
+	if event != nil {
+		resp.WriteUint32(uint32(Time_Adjusted(event.StartTime).Unix()))        // Week 1 Timestamp, Prayer start
+		resp.WriteUint32(uint32(Time_Adjusted(event.StartTime).Unix()))        // Week 2 Timestamp
+		resp.WriteUint32(uint32(Time_Adjusted(event.StartTime).Unix()))        // Week 2 Timestamp
+		resp.WriteUint32(uint32(Time_Adjusted(event.InterceptionTime).Unix())) // Diva Defense Interception
+		resp.WriteUint32(uint32(Time_Adjusted(event.SongTime).Unix()))         // Diva Defense Greeting Song
 	} else {
-		resp.WriteUint32(uint32(midnight.Add((-24) * 7 * time.Hour).Unix()))  // Diva Defense Interception
-		resp.WriteUint32(uint32(midnight.Add((-24) * 14 * time.Hour).Unix())) // Diva Defense Greeting Song
+		resp.WriteBytes(make([]byte, 20)) // No event scheduled
 	}
 
 	resp.WriteUint16(0x19) // Unk 00011001
@@ -275,9 +266,10 @@ func handleMsgMhfGetUdSchedule(s *Session, p mhfpacket.MHFPacket) {
 	resp.WriteUint16(0x02) // Unk 00000010
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfGetUdInfo(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetUdInfo(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetUdInfo)
 	// Message that appears on the Diva Defense NPC and triggers the green exclamation mark
 	udInfos := []struct {
@@ -301,39 +293,45 @@ func handleMsgMhfGetUdInfo(s *Session, p mhfpacket.MHFPacket) {
 	}
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfGetBoostTime(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetBoostTime(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetBoostTime)
 
 	doAckBufSucceed(s, pkt.AckHandle, []byte{})
 	updateRights(s)
+	return nil
 }
 
-func handleMsgMhfGetBoostTimeLimit(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetBoostTimeLimit(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetBoostTimeLimit)
 	doAckBufSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfGetBoostRight(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetBoostRight(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetBoostRight)
 	doAckBufSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfPostBoostTimeQuestReturn(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfPostBoostTimeQuestReturn(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfPostBoostTimeQuestReturn)
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfStartBoostTime(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfStartBoostTime(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfPostBoostTime(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfPostBoostTime(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfPostBoostTimeLimit(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfPostBoostTimeLimit(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetRestrictionEvent(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGetRestrictionEvent(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfSetRestrictionEvent(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSetRestrictionEvent(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSetRestrictionEvent)
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
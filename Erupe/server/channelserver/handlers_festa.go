@@ -1,21 +1,418 @@
 package channelserver
 
 import (
+	"database/sql"
 	"encoding/hex"
-	"math/rand"
+	"errors"
 	"time"
 
 	"erupe-ce/common/byteframe"
 	ps "erupe-ce/common/pascalstring"
 	"erupe-ce/network/mhfpacket"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Hunter Festa phases, a festa going through each of them in order.
+const (
+	festaPhaseNone = iota
+	festaPhaseRegistration
+	festaPhaseTrial
+	festaPhaseTally
+	festaPhaseReward
+	festaPhaseResults
+)
+
+// festaTopGuilds is the number of best guilds shown once a festa is tallied.
+const festaTopGuilds = 4
+
+// Hunter Festa prize types, personal prizes unlocked by the souls of a
+// character and intermediate ones by the souls of its guild.
+const (
+	FestaPrizePersonal     = "personal"
+	FestaPrizeIntermediate = "intermediate"
 )
 
-func handleMsgMhfSaveMezfesData(s *Session, p mhfpacket.MHFPacket) {
+// ErrInvalidFestaEvent is returned when the phases of a festa are out of order.
+var ErrInvalidFestaEvent = errors.New("festa phases are out of order")
+
+// ErrFestaEventNotFound is returned when deleting an unknown festa.
+var ErrFestaEventNotFound = errors.New("festa not found")
+
+// FestaEvent is a scheduled Hunter Festa. Guilds register until the trial time,
+// charge souls until the tally time, and claim their rewards from the reward
+// time until the end, after which its results stay shown for a while.
+type FestaEvent struct {
+	ID         uint32       `db:"id" json:"id"`
+	StartTime  time.Time    `db:"start_time" json:"start_time"`
+	TrialTime  time.Time    `db:"trial_time" json:"trial_time"`
+	TallyTime  time.Time    `db:"tally_time" json:"tally_time"`
+	RewardTime time.Time    `db:"reward_time" json:"reward_time"`
+	EndTime    time.Time    `db:"end_time" json:"end_time"`
+	Trials     []FestaTrial `db:"-" json:"trials"`
+	Prizes     []FestaPrize `db:"-" json:"prizes"`
+}
+
+// FestaTrial is a trial guilds vote on and hunt for souls during a festa.
+type FestaTrial struct {
+	ID          uint32 `db:"id" json:"id"`
+	Objective   uint8  `db:"objective" json:"objective"`
+	TargetID    uint32 `db:"target_id" json:"target_id"` // Monster ID, item ID when delivering
+	TimesReq    uint16 `db:"times_req" json:"times_req"`
+	LocaleReq   uint16 `db:"locale_req" json:"locale_req"`
+	SoulsReward uint16 `db:"souls_reward" json:"souls_reward"`
+}
+
+// FestaPrize is an item unlocked once enough souls are charged during a festa.
+type FestaPrize struct {
+	ID       uint32  `db:"id" json:"id"`
+	Type     string  `db:"prize_type" json:"type"`
+	Tier     uint32  `db:"tier" json:"tier"` // 1/2/3, 3 = GR
+	SoulsReq uint32  `db:"souls_req" json:"souls_req"`
+	ItemID   uint32  `db:"item_id" json:"item_id"`
+	Amount   uint32  `db:"amount" json:"amount"`
+	TitleID  *uint16 `db:"title_id" json:"title_id,omitempty"` // Title granted along with the item.
+	Claimed  bool    `db:"claimed" json:"-"`
+}
+
+// festaGuild is the standing of a guild in a festa.
+type festaGuild struct {
+	ID    uint32         `db:"id"`
+	Name  string         `db:"name"`
+	Team  FestivalColour `db:"team"`
+	Souls uint32         `db:"souls"`
+	Rank  uint32         `db:"rank"`
+}
+
+// festaEventColumns are the columns a FestaEvent is loaded from.
+const festaEventColumns = "id, start_time, trial_time, tally_time, reward_time, end_time"
+
+func (e *FestaEvent) window() eventWindow {
+	return eventWindow{e.StartTime, e.TrialTime, e.TallyTime, e.RewardTime, e.EndTime}
+}
+
+// Phase returns the phase the festa is in at t, festaPhaseNone outside of it.
+func (e *FestaEvent) Phase(t time.Time) int {
+	return e.window().phase(t)
+}
+
+// ListFestaEvents returns the scheduled festas with their trials and prizes.
+func ListFestaEvents(db *sqlx.DB) ([]FestaEvent, error) {
+	events := make([]FestaEvent, 0)
+	err := listEvents(db, &events, "festa_events", festaEventColumns)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		if err = loadFestaTables(db, &events[i], 0); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// CreateFestaEvent schedules a festa along with its trials and prizes, and sets their IDs.
+func CreateFestaEvent(db *sqlx.DB, e *FestaEvent) error {
+	if !e.StartTime.Before(e.TrialTime) || !e.TrialTime.Before(e.TallyTime) || e.RewardTime.Before(e.TallyTime) || !e.RewardTime.Before(e.EndTime) {
+		return ErrInvalidFestaEvent
+	}
+	for _, prize := range e.Prizes {
+		if prize.Type != FestaPrizePersonal && prize.Type != FestaPrizeIntermediate {
+			return ErrInvalidFestaEvent
+		}
+		if prize.TitleID != nil && *prize.TitleID >= titleCount {
+			return ErrInvalidFestaEvent
+		}
+	}
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	err = tx.QueryRow(`
+		INSERT INTO festa_events (start_time, trial_time, tally_time, reward_time, end_time)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		e.StartTime, e.TrialTime, e.TallyTime, e.RewardTime, e.EndTime,
+	).Scan(&e.ID)
+	if err != nil {
+		return err
+	}
+	for i, trial := range e.Trials {
+		err = tx.QueryRow(`
+			INSERT INTO festa_trials (festa_id, objective, target_id, times_req, locale_req, souls_reward)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+			e.ID, trial.Objective, trial.TargetID, trial.TimesReq, trial.LocaleReq, trial.SoulsReward,
+		).Scan(&e.Trials[i].ID)
+		if err != nil {
+			return err
+		}
+	}
+	for i, prize := range e.Prizes {
+		err = tx.QueryRow(`
+			INSERT INTO festa_prizes (festa_id, prize_type, tier, souls_req, item_id, amount, title_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+			e.ID, prize.Type, prize.Tier, prize.SoulsReq, prize.ItemID, prize.Amount, prize.TitleID,
+		).Scan(&e.Prizes[i].ID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteFestaEvent deletes a festa along with everything recorded during it,
+// and takes the guilds that registered for it out of their team.
+func DeleteFestaEvent(db *sqlx.DB, id uint32) error {
+	err := deleteEvent(db, "festa_events", id, ErrFestaEventNotFound)
+	if err != nil {
+		return err
+	}
+	return resetFestivalColours(db, time.Now())
+}
+
+// resetFestivalColours takes the guilds out of their team once no festa they
+// registered for is running at t anymore.
+func resetFestivalColours(db sqlx.Execer, t time.Time) error {
+	_, err := db.Exec(`
+		UPDATE guilds g SET festival_colour='none'
+		WHERE g.festival_colour <> 'none' AND NOT EXISTS (
+			SELECT 1 FROM festa_registrations fr JOIN festa_events fe ON fe.id = fr.festa_id
+			WHERE fr.guild_id = g.id AND fe.end_time > $1
+		)`, t)
+	return err
+}
+
+// endFestaEvents takes the guilds out of their team once per festa ended by t.
+func endFestaEvents(db *sqlx.DB, t time.Time) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec("UPDATE festa_events SET ended=true WHERE NOT ended AND end_time <= $1", t)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	if err = resetFestivalColours(tx, t); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// endFestas ends the festas that are over, any channel being up to it.
+func (s *Server) endFestas() {
+	if s.db == nil {
+		return
+	}
+	if err := endFestaEvents(s.db, time.Now()); err != nil {
+		s.logger.Error("Failed to end festas", zap.Error(err))
+	}
+}
+
+// festaEventAt returns the festa running or showing its results at t, or the
+// next one, nil when none is scheduled.
+func festaEventAt(db *sqlx.DB, t time.Time) (*FestaEvent, error) {
+	var e FestaEvent
+	found, err := eventAt(db, &e, "festa_events", festaEventColumns, t)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// currentFestaEvent returns the festa running now along with its phase, nil when none is.
+func currentFestaEvent(db *sqlx.DB) (*FestaEvent, int, error) {
+	var e FestaEvent
+	phase, err := currentEvent(db, &e, "festa_events", festaEventColumns)
+	if err != nil || phase == festaPhaseNone {
+		return nil, festaPhaseNone, err
+	}
+	return &e, phase, nil
+}
+
+// loadFestaTables loads the trials and prizes of a festa, the prizes flagged
+// as claimed by charID.
+func loadFestaTables(db *sqlx.DB, e *FestaEvent, charID uint32) error {
+	e.Trials = make([]FestaTrial, 0)
+	err := db.Select(&e.Trials, "SELECT id, objective, target_id, times_req, locale_req, souls_reward FROM festa_trials WHERE festa_id=$1 ORDER BY id", e.ID)
+	if err != nil {
+		return err
+	}
+	e.Prizes = make([]FestaPrize, 0)
+	return db.Select(&e.Prizes, `
+		SELECT id, prize_type, tier, souls_req, item_id, amount, title_id,
+		       EXISTS (SELECT 1 FROM festa_prizes_claimed c WHERE c.prize_id = p.id AND c.character_id=$2) AS claimed
+		FROM festa_prizes p WHERE festa_id=$1 ORDER BY prize_type, tier, souls_req, id`,
+		e.ID, charID,
+	)
+}
+
+// festaTeamFor returns the team a guild entering a festa joins, the one with
+// fewer guilds registered.
+func festaTeamFor(blue int, red int) FestivalColour {
+	if red < blue {
+		return FestivalColourRed
+	}
+	return FestivalColourBlue
+}
+
+// festaRegistration returns the team a guild registered for in a festa, none when it did not.
+func festaRegistration(db *sqlx.DB, festaID uint32, guildID uint32) (FestivalColour, error) {
+	team := FestivalColourNone
+	err := db.QueryRow("SELECT team FROM festa_registrations WHERE festa_id=$1 AND guild_id=$2", festaID, guildID).Scan(&team)
+	if err == sql.ErrNoRows {
+		return FestivalColourNone, nil
+	}
+	return team, err
+}
+
+// registerFesta registers a guild for a festa in the team with fewer guilds,
+// and returns its team, the one it already joined when registered twice.
+func registerFesta(db *sqlx.DB, festaID uint32, guildID uint32) (FestivalColour, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return FestivalColourNone, err
+	}
+	defer tx.Rollback()
+	// Serialize registrations so that teams stay balanced.
+	_, err = tx.Exec("SELECT id FROM festa_events WHERE id=$1 FOR UPDATE", festaID)
+	if err != nil {
+		return FestivalColourNone, err
+	}
+	var blue, red int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FILTER (WHERE team='blue'), COUNT(*) FILTER (WHERE team='red')
+		FROM festa_registrations WHERE festa_id=$1`, festaID,
+	).Scan(&blue, &red)
+	if err != nil {
+		return FestivalColourNone, err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO festa_registrations (festa_id, guild_id, team) VALUES ($1, $2, $3)
+		ON CONFLICT (festa_id, guild_id) DO NOTHING`,
+		festaID, guildID, festaTeamFor(blue, red),
+	)
+	if err != nil {
+		return FestivalColourNone, err
+	}
+	var team FestivalColour
+	err = tx.QueryRow("SELECT team FROM festa_registrations WHERE festa_id=$1 AND guild_id=$2", festaID, guildID).Scan(&team)
+	if err != nil {
+		return FestivalColourNone, err
+	}
+	_, err = tx.Exec("UPDATE guilds SET festival_colour=$1 WHERE id=$2", team, guildID)
+	if err != nil {
+		return FestivalColourNone, err
+	}
+	return team, tx.Commit()
+}
+
+// festaTeamSouls returns the souls charged by each team during a festa.
+func festaTeamSouls(db *sqlx.DB, festaID uint32) (uint32, uint32, error) {
+	var blue, red uint32
+	err := db.QueryRow(`
+		SELECT COALESCE(SUM(fs.souls) FILTER (WHERE fr.team='blue'), 0), COALESCE(SUM(fs.souls) FILTER (WHERE fr.team='red'), 0)
+		FROM festa_souls fs JOIN festa_registrations fr ON fr.festa_id = fs.festa_id AND fr.guild_id = fs.guild_id
+		WHERE fs.festa_id=$1`, festaID,
+	).Scan(&blue, &red)
+	return blue, red, err
+}
+
+// festaMonopoly returns the team monopolising a trial from the votes cast on
+// it by each team, none on a tie.
+func festaMonopoly(blue int, red int) FestivalColour {
+	switch {
+	case blue > red:
+		return FestivalColourBlue
+	case red > blue:
+		return FestivalColourRed
+	default:
+		return FestivalColourNone
+	}
+}
+
+// festaMonopolies returns the team monopolising each voted trial of a festa,
+// counting the votes of the members of registered guilds.
+func festaMonopolies(db *sqlx.DB, festaID uint32) (map[uint32]FestivalColour, error) {
+	votes := []struct {
+		TrialID uint32 `db:"trial_id"`
+		Blue    int    `db:"blue"`
+		Red     int    `db:"red"`
+	}{}
+	err := db.Select(&votes, `
+		SELECT fv.trial_id, COUNT(*) FILTER (WHERE fr.team='blue') AS blue, COUNT(*) FILTER (WHERE fr.team='red') AS red
+		FROM festa_votes fv
+		JOIN guild_characters gc ON gc.character_id = fv.character_id
+		JOIN festa_registrations fr ON fr.festa_id = fv.festa_id AND fr.guild_id = gc.guild_id
+		WHERE fv.festa_id=$1
+		GROUP BY fv.trial_id`, festaID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	monopolies := make(map[uint32]FestivalColour, len(votes))
+	for _, v := range votes {
+		monopolies[v.TrialID] = festaMonopoly(v.Blue, v.Red)
+	}
+	return monopolies, nil
+}
+
+// characterFestaSouls returns the souls charged by a character during a festa.
+func characterFestaSouls(db *sqlx.DB, festaID uint32, charID uint32) (uint32, error) {
+	var souls uint32
+	err := db.QueryRow("SELECT COALESCE(SUM(souls), 0) FROM festa_souls WHERE festa_id=$1 AND character_id=$2", festaID, charID).Scan(&souls)
+	return souls, err
+}
+
+// festaGuildRanking ranks the guilds registered for a festa by the souls they charged.
+const festaGuildRanking = `
+	SELECT g.id, g.name, fr.team, COALESCE(SUM(fs.souls), 0) AS souls,
+	       RANK() OVER (ORDER BY COALESCE(SUM(fs.souls), 0) DESC) AS rank
+	FROM festa_registrations fr
+	JOIN guilds g ON g.id = fr.guild_id
+	LEFT JOIN festa_souls fs ON fs.festa_id = fr.festa_id AND fs.guild_id = fr.guild_id
+	WHERE fr.festa_id=$1
+	GROUP BY g.id, g.name, fr.team`
+
+// festaGuildStanding returns the standing of a guild in a festa, nil when it did not register.
+func festaGuildStanding(db *sqlx.DB, festaID uint32, guildID uint32) (*festaGuild, error) {
+	var guild festaGuild
+	err := db.Get(&guild, "SELECT id, name, team, souls, rank FROM ("+festaGuildRanking+") r WHERE id=$2", festaID, guildID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &guild, err
+}
+
+// festaTopGuildsOf returns the guilds that charged the most souls during a festa.
+func festaTopGuildsOf(db *sqlx.DB, festaID uint32) ([]festaGuild, error) {
+	guilds := make([]festaGuild, 0)
+	err := db.Select(&guilds, "SELECT id, name, team, souls, rank FROM ("+festaGuildRanking+") r WHERE souls > 0 ORDER BY rank, id LIMIT $2", festaID, festaTopGuilds)
+	return guilds, err
+}
+
+// writeFestaGuilds writes a list of guilds shown on the festa board.
+func writeFestaGuilds(bf *byteframe.ByteFrame, guilds []festaGuild) {
+	bf.WriteUint16(uint16(len(guilds)))
+	for i, guild := range guilds {
+		bf.WriteUint32(guild.ID)
+		bf.WriteUint16(uint16(i + 1))
+		bf.WriteUint16(uint16(FestivalColourCodes[guild.Team]))
+		ps.Uint8(bf, guild.Name, true)
+	}
+}
+
+func handleMsgMhfSaveMezfesData(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSaveMezfesData)
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfLoadMezfesData(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfLoadMezfesData(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfLoadMezfesData)
 
 	resp := byteframe.NewByteFrame()
@@ -28,9 +425,10 @@ func handleMsgMhfLoadMezfesData(s *Session, p mhfpacket.MHFPacket) {
 	resp.WriteUint32(0) // Unk
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfEnumerateRanking(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateRanking(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateRanking)
 	bf := byteframe.NewByteFrame()
 	state := s.server.erupeConfig.DevModeOptions.TournamentEvent
@@ -61,68 +459,74 @@ func handleMsgMhfEnumerateRanking(s *Session, p mhfpacket.MHFPacket) {
 		bf.WriteUint16(1)
 		bf.WriteUint32(0)
 		doAckBufSucceed(s, pkt.AckHandle, bf.Data())
-		return
+		return nil
 	}
 	bf.WriteUint32(uint32(Time_Current_Adjusted().Unix())) // TS Current Time
 	d, _ := hex.DecodeString("031491E631353089F18CF68EAE8EEB97C291E589EF00001200000A54001000000000ED130D949A96B697B393A294B081490000000A55001000010000ED130D949A96B697B393A294B081490000000A56001000020000ED130D949A96B697B393A294B081490000000A57001000030000ED130D949A96B697B393A294B081490000000A58001000040000ED130D949A96B697B393A294B081490000000A59001000050000ED130D949A96B697B393A294B081490000000A5A001000060000ED130D949A96B697B393A294B081490000000A5B001000070000ED130D949A96B697B393A294B081490000000A5C001000080000ED130D949A96B697B393A294B081490000000A5D001000090000ED130D949A96B697B393A294B081490000000A5E0010000A0000ED130D949A96B697B393A294B081490000000A5F0010000B0000ED130D949A96B697B393A294B081490000000A600010000C0000ED130D949A96B697B393A294B081490000000A610010000D0000ED130D949A96B697B393A294B081490000000A620011FFFF0000ED121582DD82F182C882C5949A96B697B393A294B081490000000A63000600EA0000000009834C838C834183570000000A64000600ED000000000B836E838A837D834F838D0000000A65000600EF0000000011834A834E8354839383668381834C83930003000002390006000600000E8CC2906C208B9091E58B9B94740001617E43303581798BA38B5A93E09765817A0A7E433030834E83478358836782C592DE82C182BD8B9B82CC83548343835982F08BA382A40A7E433034817991CE8FDB8B9B817A0A7E433030834C838C8341835781410A836E838A837D834F838D8141834A834E8354839383668381834C83930A7E433037817993FC8FDC8FDC9569817A0A7E4330308B9B947482CC82B582E982B58141835E838B836C835290B68E598C9481410A834F815B834E90B68E598C948141834F815B834E91AB90B68E598C9481410A834F815B834E89F095FA8C94283181603388CA290A2F97C29263837C8343839383672831816031303088CA290A2F8FA08360835083628367817B836E815B8374836083508362836794920A2831816035303088CA290A7E43303381798A4A8DC38AFA8AD4817A0A7E43303032303139944E31318C8E323293FA2031343A303082A982E70A32303139944E31318C8E323593FA2031343A303082DC82C5000000023A0011000700001297C292632082668B89E8E891CA935694740000ED7E43303581798BA38B5A93E09765817A0A7E43303081E182DD82F182C882C5949A96B697B393A294B0814981E282F00A93AF82B697C2926382C98F8A91AE82B782E934906C82DC82C582CC0A97C2926388F582C582A282A982C9918182AD834E838A834182B782E982A90A82F08BA382A40A0A7E433037817993FC8FDC8FDC9569817A0A7E43303091E631343789F18EEB906C8DD582CC8DB02831816032303088CA290A0A7E43303381798A4A8DC38AFA8AD4817A0A7E43303032303139944E31318C8E323293FA2031343A303082A982E70A32303139944E31318C8E323593FA2031343A303082DC82C50A000000023B001000070000128CC2906C2082668B89E8E891CA935694740001497E43303581798BA38B5A93E09765817A0A7E43303081E1949A96B697B393A294B0814981E282F00A82A282A982C9918182AD834E838A834182B782E982A982F08BA382A40A0A7E433037817993FC8FDC8FDC9569817A0A7E43303089A48ED282CC8381835F838B283188CA290A2F8CF68EAE82CC82B582E982B58141835E838B836C835290B68E598C9481410A834F815B834E90B68E598C948141834F815B834E91AB90B68E598C9481410A834F815B834E89F095FA8C94283181603388CA290A2F97C29263837C8343839383672831816031303088CA290A2F8FA08360835083628367817B836E815B8374836083508362836794920A2831816035303088CA290A7E43303381798A4A8DC38AFA8AD4817A0A7E43303032303139944E31318C8E323293FA2031343A303082A982E70A32303139944E31318C8E323593FA2031343A303082DC82C500")
 	bf.WriteBytes(d)
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfInfoFesta(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfInfoFesta(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfInfoFesta)
-	bf := byteframe.NewByteFrame()
-	state := s.server.erupeConfig.DevModeOptions.FestaEvent
-	bf.WriteUint32(0xdeadbeef) // festaID
-	// Registration Week Start
-	// Introductory Week Start
-	// Totalling Time
-	// Reward Festival Start (2.5hrs after totalling)
-	// 2 weeks after RewardFes (next fes?)
-	midnight := Time_Current_Midnight()
-	switch state {
-	case 1:
-		bf.WriteUint32(uint32(midnight.Unix()))
-		bf.WriteUint32(uint32(midnight.Add(24 * 7 * time.Hour).Unix()))
-		bf.WriteUint32(uint32(midnight.Add(24 * 14 * time.Hour).Unix()))
-		bf.WriteUint32(uint32(midnight.Add(24*14*time.Hour + 150*time.Minute).Unix()))
-		bf.WriteUint32(uint32(midnight.Add(24*28*time.Hour + 11*time.Hour).Unix()))
-	case 2:
-		bf.WriteUint32(uint32(midnight.Add(-24 * 7 * time.Hour).Unix()))
-		bf.WriteUint32(uint32(midnight.Unix()))
-		bf.WriteUint32(uint32(midnight.Add(24 * 7 * time.Hour).Unix()))
-		bf.WriteUint32(uint32(midnight.Add(24*7*time.Hour + 150*time.Minute).Unix()))
-		bf.WriteUint32(uint32(midnight.Add(24 * 21 * time.Hour).Add(11 * time.Hour).Unix()))
-	case 3:
-		bf.WriteUint32(uint32(midnight.Add(-24 * 14 * time.Hour).Unix()))
-		bf.WriteUint32(uint32(midnight.Add(-24*7*time.Hour + 11*time.Hour).Unix()))
-		bf.WriteUint32(uint32(midnight.Unix()))
-		bf.WriteUint32(uint32(midnight.Add(150 * time.Minute).Unix()))
-		bf.WriteUint32(uint32(midnight.Add(24*14*time.Hour + 11*time.Hour).Unix()))
-	default:
+	event, err := festaEventAt(s.server.db, time.Now())
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get festa", err)
+	} else if event == nil {
 		doAckBufSucceed(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	}
-	bf.WriteUint32(uint32(Time_Current_Adjusted().Unix())) // TS Current Time
+	if err = loadFestaTables(s.server.db, event, s.charID); err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get festa trials", err)
+	}
+	blueSouls, redSouls, err := festaTeamSouls(s.server.db, event.ID)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get festa souls", err)
+	}
+	topGuilds := make([]festaGuild, 0)
+	monopolies := make(map[uint32]FestivalColour)
+	if event.Phase(time.Now()) >= festaPhaseTally {
+		topGuilds, err = festaTopGuildsOf(s.server.db, event.ID)
+		if err != nil {
+			return errBufFail(pkt.AckHandle, "Failed to get festa ranking", err)
+		}
+		monopolies, err = festaMonopolies(s.server.db, event.ID)
+		if err != nil {
+			return errBufFail(pkt.AckHandle, "Failed to get festa votes", err)
+		}
+	}
+
+	bf := byteframe.NewByteFrame()
+	bf.WriteUint32(event.ID)
+	bf.WriteUint32(uint32(Time_Adjusted(event.StartTime).Unix()))  // Registration Week Start
+	bf.WriteUint32(uint32(Time_Adjusted(event.TrialTime).Unix()))  // Introductory Week Start
+	bf.WriteUint32(uint32(Time_Adjusted(event.TallyTime).Unix()))  // Totalling Time
+	bf.WriteUint32(uint32(Time_Adjusted(event.RewardTime).Unix())) // Reward Festival Start
+	bf.WriteUint32(uint32(Time_Adjusted(event.EndTime).Unix()))    // Next festa
+	bf.WriteUint32(uint32(Time_Current_Adjusted().Unix()))         // TS Current Time
 	bf.WriteUint8(4)
 	ps.Uint8(bf, "", false)
 	bf.WriteUint32(0)
-	bf.WriteUint32(0) // Blue souls
-	bf.WriteUint32(0) // Red souls
-
-	trials := 0
-	bf.WriteUint16(uint16(trials))
-	for i := 0; i < trials; i++ {
-		bf.WriteUint32(uint32(i + 1)) // trialID
-		bf.WriteUint8(0xFF)           // unk
-		bf.WriteUint8(uint8(i))       // objective
-		bf.WriteUint32(0x1B)          // monID, itemID if deliver
-		bf.WriteUint16(1)             // huntsRemain?
-		bf.WriteUint16(0)             // location
-		bf.WriteUint16(1)             // numSoulsReward
-		bf.WriteUint8(0xFF)           // unk
-		bf.WriteUint8(0xFF)           // monopolised
-		bf.WriteUint16(0)             // unk
+	bf.WriteUint32(blueSouls)
+	bf.WriteUint32(redSouls)
+
+	bf.WriteUint16(uint16(len(event.Trials)))
+	for _, trial := range event.Trials {
+		monopoly, ok := monopolies[trial.ID]
+		if !ok {
+			monopoly = FestivalColourNone
+		}
+		bf.WriteUint32(trial.ID)
+		bf.WriteUint8(0xFF) // unk
+		bf.WriteUint8(trial.Objective)
+		bf.WriteUint32(trial.TargetID)
+		bf.WriteUint16(trial.TimesReq)
+		bf.WriteUint16(trial.LocaleReq)
+		bf.WriteUint16(trial.SoulsReward)
+		bf.WriteUint8(0xFF) // unk
+		bf.WriteUint8(FestivalColourCodes[monopoly])
+		bf.WriteUint16(0) // unk
 	}
 
 	unk := 0 // static rewards?
@@ -135,80 +539,309 @@ func handleMsgMhfInfoFesta(s *Session, p mhfpacket.MHFPacket) {
 		bf.WriteBool(false)
 	}
 
-	d, _ := hex.DecodeString("0001D4C001F4000411B6648100010001152A81F589A497A793C196B18B528E6D926381F52A0011B6648100020001152A81F589A497A793C196B18B528E6D926381F52A000C952CE10003000109E54BE54E89B38F970029FDCE04000400001381818D84836C8352819993A294B091D1818100000811B6648100010001152A81F589A497A793C196B18B528E6D926381F52A0011B6648100020001152A81F589A497A793C196B18B528E6D926381F52A0011B6648100030001152A81F589A497A793C196B18B528E6D926381F52A0011B6648100040001152A81F589A497A793C196B18B528E6D926381F52A0011B6648100050001152A81F589A497A793C196B18B528E6D926381F52A0011B6648100060001152A81F589A497A793C196B18B528E6D926381F52A000C952CE10007000109E54BE54E89B38F9700000000000008000001000000000100001388000007D0000003E800000064012C00C8009600640032")
+	bf.WriteUint32(120000) // Unk
+	bf.WriteUint16(500)    // Unk
+	// Guild ID, position, team and name of the best guilds, a capture listed 4 of them.
+	writeFestaGuilds(bf, topGuilds)
+	// Same layout, a capture listed 8 of them numbered 1 to 8.
+	writeFestaGuilds(bf, nil)
+	d, _ := hex.DecodeString("0000000100001388000007D0000003E800000064012C00C8009600640032")
 	bf.WriteBytes(d)
 	ps.Uint16(bf, "", false)
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
 // state festa (U)ser
-func handleMsgMhfStateFestaU(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfStateFestaU(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfStateFestaU)
+	souls, err := characterFestaSouls(s.server.db, pkt.FestaID, s.charID)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get festa souls", err)
+	}
 	bf := byteframe.NewByteFrame()
-	bf.WriteUint32(0) // souls
+	bf.WriteUint32(souls)
 	bf.WriteUint32(0) // unk
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
 // state festa (G)uild
-func handleMsgMhfStateFestaG(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfStateFestaG(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfStateFestaG)
+	guild, err := festaGuildStanding(s.server.db, pkt.FestaID, pkt.GuildID)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get festa guild standing", err)
+	}
 	resp := byteframe.NewByteFrame()
-	resp.WriteUint32(0) // souls
-	resp.WriteUint32(1) // unk
-	resp.WriteUint32(1) // unk
-	resp.WriteUint32(1) // unk, rank?
-	resp.WriteUint32(1) // unk
+	if guild == nil {
+		resp.WriteUint32(0) // souls
+		resp.WriteUint32(1) // unk
+		resp.WriteUint32(1) // unk
+		resp.WriteUint32(1) // unk, rank?
+		resp.WriteUint32(1) // unk
+	} else {
+		resp.WriteUint32(guild.Souls)
+		resp.WriteUint32(1) // unk
+		resp.WriteUint32(1) // unk
+		resp.WriteUint32(guild.Rank)
+		resp.WriteUint32(1) // unk
+	}
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfEnumerateFestaMember(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateFestaMember(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateFestaMember)
+	members := []struct {
+		CharID uint32 `db:"character_id"`
+		Souls  uint32 `db:"souls"`
+	}{}
+	err := s.server.db.Select(&members, `
+		SELECT gc.character_id, COALESCE(fs.souls, 0) AS souls FROM guild_characters gc
+		LEFT JOIN festa_souls fs ON fs.festa_id=$1 AND fs.guild_id = gc.guild_id AND fs.character_id = gc.character_id
+		WHERE gc.guild_id=$2 ORDER BY souls DESC, gc.character_id`,
+		pkt.FestaID, pkt.GuildID,
+	)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get festa members", err)
+	}
 	bf := byteframe.NewByteFrame()
-	bf.WriteUint16(0) // numMembers
-	// uint16 unk
-	// uint32 charID
-	// uint32 souls
+	bf.WriteUint16(uint16(len(members)))
+	bf.WriteUint16(0) // Unk
+	for _, member := range members {
+		bf.WriteUint32(member.CharID)
+		bf.WriteUint32(member.Souls)
+	}
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfVoteFesta(s *Session, p mhfpacket.MHFPacket) {
-	pkt := p.(*mhfpacket.MsgMhfEntryFesta)
+func handleMsgMhfVoteFesta(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfVoteFesta)
+	event, phase, err := currentFestaEvent(s.server.db)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to get festa", err)
+	} else if event == nil || phase > festaPhaseTrial {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
+	res, err := s.server.db.Exec(`
+		INSERT INTO festa_votes (festa_id, character_id, trial_id)
+		SELECT festa_id, $2, id FROM festa_trials WHERE festa_id=$1 AND id=$3
+		ON CONFLICT (festa_id, character_id) DO UPDATE SET trial_id = EXCLUDED.trial_id`,
+		event.ID, s.charID, pkt.TrialID,
+	)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to vote on festa trial", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfEntryFesta(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEntryFesta(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEntryFesta)
+	event, phase, err := currentFestaEvent(s.server.db)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to get festa", err)
+	}
+	guildID, err := guildIDOf(s, s.charID)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to get guild membership", err)
+	}
+	if event == nil || event.ID != pkt.FestaID || phase != festaPhaseRegistration || guildID == 0 || guildID != pkt.GuildID {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
+	team, err := registerFesta(s.server.db, event.ID, guildID)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to register for festa", err)
+	}
 	bf := byteframe.NewByteFrame()
-	rand.Seed(time.Now().UnixNano())
-	bf.WriteUint32(uint32(rand.Intn(2)))
-	// Update guild table
+	bf.WriteUint32(uint32(FestivalColourCodes[team]))
 	doAckSimpleSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfChargeFesta(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfChargeFesta(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfChargeFesta)
-	// Update festa state table
+	event, phase, err := currentFestaEvent(s.server.db)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to get festa", err)
+	} else if event == nil || event.ID != pkt.FestaID || phase != festaPhaseTrial {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
+	guildID, err := guildIDOf(s, s.charID)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to get guild membership", err)
+	}
+	team, err := festaRegistration(s.server.db, event.ID, guildID)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to get festa registration", err)
+	} else if team == FestivalColourNone {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
+	_, err = s.server.db.Exec(`
+		INSERT INTO festa_souls (festa_id, character_id, guild_id, souls) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (festa_id, character_id, guild_id) DO UPDATE SET souls = festa_souls.souls + $4`,
+		event.ID, s.charID, guildID, pkt.Souls,
+	)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to charge festa souls", err)
+	}
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfAcquireFesta(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfAcquireFesta(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfAcquireFesta)
-	// Mark festa as claimed
-	// Update guild table?
+	event, phase, err := currentFestaEvent(s.server.db)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to get festa", err)
+	} else if event == nil || event.ID != pkt.FestaID || phase != festaPhaseReward {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
+	guildID, err := guildIDOf(s, s.charID)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to get guild membership", err)
+	} else if guildID == 0 || guildID != pkt.GuildID {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
+	team, err := festaRegistration(s.server.db, event.ID, guildID)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to get festa registration", err)
+	} else if team == FestivalColourNone {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
+	res, err := s.server.db.Exec(`
+		INSERT INTO festa_rewards_claimed (festa_id, character_id) VALUES ($1, $2)
+		ON CONFLICT (festa_id, character_id) DO NOTHING`,
+		event.ID, s.charID,
+	)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to claim festa rewards", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
+}
+
+// festaPrizeSouls returns the souls counted towards the prizes of a type,
+// along with the running festa, nil when none is.
+func festaPrizeSouls(s *Session, prizeType string) (*FestaEvent, uint32, error) {
+	event, _, err := currentFestaEvent(s.server.db)
+	if err != nil || event == nil {
+		return nil, 0, err
+	}
+	if prizeType == FestaPrizePersonal {
+		souls, err := characterFestaSouls(s.server.db, event.ID, s.charID)
+		return event, souls, err
+	}
+	guildID, err := guildIDOf(s, s.charID)
+	if err != nil || guildID == 0 {
+		return event, 0, err
+	}
+	guild, err := festaGuildStanding(s.server.db, event.ID, guildID)
+	if err != nil || guild == nil {
+		return event, 0, err
+	}
+	return event, guild.Souls, nil
+}
+
+// acquireFestaPrize claims a prize of the running festa once enough souls were charged.
+func acquireFestaPrize(s *Session, ackHandle uint32, prizeID uint32, prizeType string) error {
+	event, souls, err := festaPrizeSouls(s, prizeType)
+	if err != nil {
+		return errSimpleFail(ackHandle, "Failed to get festa souls", err)
+	} else if event == nil {
+		doAckSimpleFail(s, ackHandle, make([]byte, 4))
+		return nil
+	}
+	var titleID *uint16
+	err = s.server.db.QueryRow(`
+		WITH claimed AS (
+			INSERT INTO festa_prizes_claimed (prize_id, character_id)
+			SELECT id, $2 FROM festa_prizes WHERE id=$1 AND festa_id=$3 AND prize_type=$4 AND souls_req<=$5
+			ON CONFLICT (prize_id, character_id) DO NOTHING
+			RETURNING prize_id
+		)
+		SELECT p.title_id FROM claimed JOIN festa_prizes p ON p.id = claimed.prize_id`,
+		prizeID, s.charID, event.ID, prizeType, souls,
+	).Scan(&titleID)
+	if err == sql.ErrNoRows {
+		doAckSimpleFail(s, ackHandle, make([]byte, 4))
+		return nil
+	} else if err != nil {
+		return errSimpleFail(ackHandle, "Failed to claim festa prize", err)
+	}
+	if titleID != nil {
+		if err = GrantTitles(s.server.db, s.charID, []uint16{*titleID}); err != nil {
+			return errSimpleFail(ackHandle, "Failed to grant festa prize title", err)
+		}
+	}
+	doAckSimpleSucceed(s, ackHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfAcquireFestaPersonalPrize(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfAcquireFestaPersonalPrize(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfAcquireFestaPersonalPrize)
-	// Set prize as claimed
-	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return acquireFestaPrize(s, pkt.AckHandle, pkt.PrizeID, FestaPrizePersonal)
 }
 
-func handleMsgMhfAcquireFestaIntermediatePrize(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfAcquireFestaIntermediatePrize(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfAcquireFestaIntermediatePrize)
-	// Set prize as claimed
-	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return acquireFestaPrize(s, pkt.AckHandle, pkt.PrizeID, FestaPrizeIntermediate)
+}
+
+// buildFestaPrizes builds the prizes of a type, flagged as claimed.
+func buildFestaPrizes(prizes []FestaPrize, prizeType string) []byte {
+	data := byteframe.NewByteFrame()
+	var count uint32
+	for _, prize := range prizes {
+		if prize.Type != prizeType {
+			continue
+		}
+		count++
+		data.WriteUint32(prize.ID)
+		data.WriteUint32(prize.Tier)
+		data.WriteUint32(prize.SoulsReq)
+		data.WriteUint32(7) // Unk
+		data.WriteUint32(prize.ItemID)
+		data.WriteUint32(prize.Amount)
+		data.WriteBool(prize.Claimed)
+	}
+	bf := byteframe.NewByteFrame()
+	bf.WriteUint32(count)
+	bf.WriteBytes(data.Data())
+	return bf.Data()
+}
+
+// enumerateFestaPrizes sends the prizes of a type of the running festa.
+func enumerateFestaPrizes(s *Session, ackHandle uint32, prizeType string) error {
+	event, _, err := currentFestaEvent(s.server.db)
+	if err != nil {
+		return errBufFail(ackHandle, "Failed to get festa", err)
+	} else if event == nil {
+		doAckBufSucceed(s, ackHandle, make([]byte, 4))
+		return nil
+	}
+	if err = loadFestaTables(s.server.db, event, s.charID); err != nil {
+		return errBufFail(ackHandle, "Failed to get festa prizes", err)
+	}
+	doAckBufSucceed(s, ackHandle, buildFestaPrizes(event.Prizes, prizeType))
+	return nil
 }
 
 // uint32 numPrizes
@@ -221,12 +854,12 @@ func handleMsgMhfAcquireFestaIntermediatePrize(s *Session, p mhfpacket.MHFPacket
 // uint32 numItem
 // bool claimed
 
-func handleMsgMhfEnumerateFestaPersonalPrize(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateFestaPersonalPrize(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateFestaPersonalPrize)
-	doAckBufSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return enumerateFestaPrizes(s, pkt.AckHandle, FestaPrizePersonal)
 }
 
-func handleMsgMhfEnumerateFestaIntermediatePrize(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateFestaIntermediatePrize(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateFestaIntermediatePrize)
-	doAckBufSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return enumerateFestaPrizes(s, pkt.AckHandle, FestaPrizeIntermediate)
 }
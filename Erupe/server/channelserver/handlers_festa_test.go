@@ -0,0 +1,49 @@
+package channelserver
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestFestaTeamFor(t *testing.T) {
+	if team := festaTeamFor(0, 0); team != FestivalColourBlue {
+		t.Errorf("first guild joined %s, expected blue", team)
+	}
+	if team := festaTeamFor(3, 2); team != FestivalColourRed {
+		t.Errorf("guild joined %s, expected red", team)
+	}
+	if team := festaTeamFor(2, 3); team != FestivalColourBlue {
+		t.Errorf("guild joined %s, expected blue", team)
+	}
+}
+
+func TestBuildFestaPrizes(t *testing.T) {
+	prizes := []FestaPrize{
+		{ID: 1, Type: FestaPrizePersonal, Tier: 1, SoulsReq: 100, ItemID: 7, Amount: 2, Claimed: true},
+		{ID: 2, Type: FestaPrizeIntermediate, Tier: 1, SoulsReq: 1000, ItemID: 8, Amount: 1},
+	}
+	data := buildFestaPrizes(prizes, FestaPrizePersonal)
+	if len(data) != 4+25 {
+		t.Fatalf("length = %d, expected 29", len(data))
+	}
+	if binary.BigEndian.Uint32(data) != 1 || binary.BigEndian.Uint32(data[4:]) != 1 || data[28] != 1 {
+		t.Errorf("unexpected prizes % x", data)
+	}
+}
+
+func TestFestaMonopoly(t *testing.T) {
+	tests := []struct {
+		blue, red int
+		expected  FestivalColour
+	}{
+		{3, 1, FestivalColourBlue},
+		{1, 3, FestivalColourRed},
+		{2, 2, FestivalColourNone},
+		{0, 0, FestivalColourNone},
+	}
+	for _, tt := range tests {
+		if got := festaMonopoly(tt.blue, tt.red); got != tt.expected {
+			t.Errorf("festaMonopoly(%d, %d) = %s, expected %s", tt.blue, tt.red, got, tt.expected)
+		}
+	}
+}
@@ -424,10 +424,6 @@ func CreateGuild(s *Session, guildName string) (int32, error) {
 		return 0, err
 	}
 
-	if err != nil {
-		panic(err)
-	}
-
 	guildResult, err := transaction.Query(
 		"INSERT INTO guilds (name, leader_id) VALUES ($1, $2) RETURNING id",
 		guildName, s.charID,
@@ -589,7 +585,7 @@ func buildGuildObjectFromDbResult(result *sqlx.Rows, err error, s *Session) (*Gu
 	return guild, nil
 }
 
-func handleMsgMhfCreateGuild(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfCreateGuild(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfCreateGuild)
 
 	guildId, err := CreateGuild(s, pkt.Name)
@@ -602,7 +598,7 @@ func handleMsgMhfCreateGuild(s *Session, p mhfpacket.MHFPacket) {
 		bf.WriteUint32(0x01010101)
 
 		doAckSimpleFail(s, pkt.AckHandle, bf.Data())
-		return
+		return nil
 	}
 
 	bf := byteframe.NewByteFrame()
@@ -610,22 +606,23 @@ func handleMsgMhfCreateGuild(s *Session, p mhfpacket.MHFPacket) {
 	bf.WriteUint32(uint32(guildId))
 
 	doAckSimpleSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfOperateGuild(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfOperateGuild(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfOperateGuild)
 
 	guild, err := GetGuildInfoByID(s, pkt.GuildID)
 
 	if err != nil {
-		return
+		return nil
 	}
 
 	characterGuildInfo, err := GetCharacterGuildData(s, s.charID)
 
 	if err != nil {
 		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	}
 
 	bf := byteframe.NewByteFrame()
@@ -634,7 +631,7 @@ func handleMsgMhfOperateGuild(s *Session, p mhfpacket.MHFPacket) {
 	case mhfpacket.OPERATE_GUILD_DISBAND:
 		if guild.LeaderCharID != s.charID {
 			s.logger.Warn(fmt.Sprintf("character '%d' is attempting to manage guild '%d' without permission", s.charID, guild.ID))
-			return
+			return nil
 		}
 
 		err = guild.Disband(s)
@@ -676,11 +673,11 @@ func handleMsgMhfOperateGuild(s *Session, p mhfpacket.MHFPacket) {
 	case mhfpacket.OPERATE_GUILD_SET_APPLICATION_DENY:
 		// TODO: close applications for guild
 		doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	case mhfpacket.OPERATE_GUILD_SET_APPLICATION_ALLOW:
 		// TODO: open applications for guild
 		doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	case mhfpacket.OPERATE_GUILD_SET_AVOID_LEADERSHIP_TRUE:
 		handleAvoidLeadershipUpdate(s, pkt, true)
 	case mhfpacket.OPERATE_GUILD_SET_AVOID_LEADERSHIP_FALSE:
@@ -690,7 +687,7 @@ func handleMsgMhfOperateGuild(s *Session, p mhfpacket.MHFPacket) {
 
 		if !characterGuildInfo.IsLeader && !characterGuildInfo.IsSubLeader() {
 			doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
-			return
+			return nil
 		}
 
 		_ = pbf.ReadUint8() // len
@@ -699,14 +696,14 @@ func handleMsgMhfOperateGuild(s *Session, p mhfpacket.MHFPacket) {
 		err = guild.Save(s)
 		if err != nil {
 			doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
-			return
+			return nil
 		}
 
 		bf.WriteUint32(0x00)
 	case mhfpacket.OPERATE_GUILD_UPDATE_MOTTO:
 		if !characterGuildInfo.IsLeader && !characterGuildInfo.IsSubLeader() {
 			doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
-			return
+			return nil
 		}
 
 		guild.SubMotto = pkt.UnkData[3]
@@ -716,36 +713,37 @@ func handleMsgMhfOperateGuild(s *Session, p mhfpacket.MHFPacket) {
 
 		if err != nil {
 			doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
-			return
+			return nil
 		}
 	case mhfpacket.OPERATE_GUILD_RENAME_PUGI_1:
 		handleRenamePugi(s, pkt.UnkData, guild, 1)
 		doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	case mhfpacket.OPERATE_GUILD_RENAME_PUGI_2:
 		handleRenamePugi(s, pkt.UnkData, guild, 2)
 		doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	case mhfpacket.OPERATE_GUILD_RENAME_PUGI_3:
 		handleRenamePugi(s, pkt.UnkData, guild, 3)
 		doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	case mhfpacket.OPERATE_GUILD_CHANGE_PUGI_1:
 		doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	case mhfpacket.OPERATE_GUILD_CHANGE_PUGI_2:
 		doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	case mhfpacket.OPERATE_GUILD_CHANGE_PUGI_3:
 		doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	case mhfpacket.OPERATE_GUILD_DONATE_EVENT:
 		handleDonateRP(s, pkt, bf, guild, true)
 	default:
-		panic(fmt.Sprintf("unhandled operate guild action '%d'", pkt.Action))
+		return errSimpleFail(pkt.AckHandle, fmt.Sprintf("unhandled operate guild action '%d'", pkt.Action), nil)
 	}
 
 	doAckSimpleSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
 func handleRenamePugi(s *Session, data []byte, guild *Guild, num int) {
@@ -813,21 +811,21 @@ func handleAvoidLeadershipUpdate(s *Session, pkt *mhfpacket.MsgMhfOperateGuild,
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
 }
 
-func handleMsgMhfOperateGuildMember(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfOperateGuildMember(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfOperateGuildMember)
 
 	guild, err := GetGuildInfoByCharacterId(s, pkt.CharID)
 
 	if err != nil || guild == nil {
 		doAckSimpleFail(s, pkt.AckHandle, nil)
-		return
+		return nil
 	}
 
 	actorCharacter, err := GetCharacterGuildData(s, s.charID)
 
 	if err != nil || (!actorCharacter.IsSubLeader() && guild.LeaderCharID != s.charID) {
 		doAckSimpleFail(s, pkt.AckHandle, nil)
-		return
+		return nil
 	}
 
 	if pkt.Action == mhfpacket.OPERATE_GUILD_MEMBER_ACTION_ACCEPT || pkt.Action == mhfpacket.OPERATE_GUILD_MEMBER_ACTION_REJECT {
@@ -843,33 +841,33 @@ func handleMsgMhfOperateGuildMember(s *Session, p mhfpacket.MHFPacket) {
 		}
 
 		doAckSimpleSucceed(s, pkt.AckHandle, nil)
-		return
+		return nil
 	}
 
 	character, err := GetCharacterGuildData(s, pkt.CharID)
 
 	if err != nil || character == nil {
 		doAckSimpleFail(s, pkt.AckHandle, nil)
-		return
+		return nil
 	}
 
 	switch pkt.Action {
 	case mhfpacket.OPERATE_GUILD_MEMBER_ACTION_KICK:
 		err = guild.RemoveCharacter(s, pkt.CharID)
 	default:
-		doAckSimpleFail(s, pkt.AckHandle, nil)
-		panic(fmt.Sprintf("unhandled operateGuildMember action '%d'", pkt.Action))
+		return errSimpleFail(pkt.AckHandle, fmt.Sprintf("unhandled operateGuildMember action '%d'", pkt.Action), nil)
 	}
 
 	if err != nil {
 		doAckSimpleFail(s, pkt.AckHandle, nil)
-		return
+		return nil
 	}
 
 	doAckSimpleSucceed(s, pkt.AckHandle, nil)
+	return nil
 }
 
-func handleMsgMhfInfoGuild(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfInfoGuild(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfInfoGuild)
 
 	var guild *Guild
@@ -899,7 +897,7 @@ func handleMsgMhfInfoGuild(s *Session, p mhfpacket.MHFPacket) {
 			resp.WriteUint8(0)  // Unk, read if count == 0.
 
 			doAckBufSucceed(s, pkt.AckHandle, resp.Data())
-			return
+			return nil
 		}
 
 		bf := byteframe.NewByteFrame()
@@ -969,7 +967,7 @@ func handleMsgMhfInfoGuild(s *Session, p mhfpacket.MHFPacket) {
 
 		if guild.AllianceID > 0 {
 			alliance, err := GetAllianceData(s, guild.AllianceID)
-			if err != nil {
+			if err != nil || alliance == nil {
 				bf.WriteUint32(0) // Error, no alliance
 			} else {
 				bf.WriteUint32(alliance.ID)
@@ -1048,21 +1046,27 @@ func handleMsgMhfInfoGuild(s *Session, p mhfpacket.MHFPacket) {
 			ps.Uint16(bf, applicant.Name, true)
 		}
 
-		bf.WriteUint16(0x0000)
-
-		/*
-		alliance application format
-		uint16 numapplicants (above)
+		var allianceApplicants []*Guild
+		if guild.AllianceID > 0 {
+			alliance, err := GetAllianceData(s, guild.AllianceID)
+			// Only the parent guild is shown the applications to its alliance.
+			if err == nil && alliance != nil && alliance.ParentGuildID == guild.ID {
+				allianceApplicants, err = GetAllianceApplicantGuilds(s, alliance.ID)
+				if err != nil {
+					s.logger.Error("Failed to get alliance applicants", zap.Error(err))
+				}
+			}
+		}
 
-		uint32 guild id
-		uint32 guild leader id (for mail)
-		uint32 unk (always null in pcap)
-		uint16 unk (always 0001 in pcap)
-		uint16 len guild name
-		string nullterm guild name
-		uint16 len guild leader name
-		string nullterm guild leader name
-		*/
+		bf.WriteUint16(uint16(len(allianceApplicants)))
+		for _, applicant := range allianceApplicants {
+			bf.WriteUint32(applicant.ID)
+			bf.WriteUint32(applicant.LeaderCharID)
+			bf.WriteUint32(0) // Unk, always null in pcap
+			bf.WriteUint16(1) // Unk, always 0001 in pcap
+			ps.Uint16(bf, applicant.Name, true)
+			ps.Uint16(bf, applicant.LeaderName, true)
+		}
 
 		if guild.Icon != nil {
 			bf.WriteUint8(uint8(len(guild.Icon.Parts)))
@@ -1092,9 +1096,10 @@ func handleMsgMhfInfoGuild(s *Session, p mhfpacket.MHFPacket) {
 
 		doAckBufSucceed(s, pkt.AckHandle, make([]byte, 8))
 	}
+	return nil
 }
 
-func handleMsgMhfEnumerateGuild(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateGuild(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateGuild)
 
 	var guilds []*Guild
@@ -1111,7 +1116,7 @@ func handleMsgMhfEnumerateGuild(s *Session, p mhfpacket.MHFPacket) {
 		var searchTermSafe string
 		searchTermSafe, err = s.clientContext.StrConv.Decode(bfutil.UpToNull(searchTerm))
 		if err != nil {
-			panic(err)
+			return errBufFail(pkt.AckHandle, "", err)
 		}
 		guilds, err = FindGuildsByName(s, searchTermSafe)
 	case mhfpacket.ENUMERATE_GUILD_TYPE_LEADER_NAME:
@@ -1122,7 +1127,7 @@ func handleMsgMhfEnumerateGuild(s *Session, p mhfpacket.MHFPacket) {
 		var searchTermSafe string
 		searchTermSafe, err = s.clientContext.StrConv.Decode(bfutil.UpToNull(searchTerm))
 		if err != nil {
-			panic(err)
+			return errBufFail(pkt.AckHandle, "", err)
 		}
 		rows, err = s.server.db.Queryx(fmt.Sprintf(`%s WHERE lc.name ILIKE $1`, guildInfoSelectQuery), searchTermSafe)
 		if err != nil {
@@ -1216,12 +1221,12 @@ func handleMsgMhfEnumerateGuild(s *Session, p mhfpacket.MHFPacket) {
 	case mhfpacket.ENUMERATE_ALLIANCE_TYPE_ORDER_REGISTRATION:
 		//
 	default:
-		panic(fmt.Sprintf("no handler for guild search type '%d'", pkt.Type))
+		return errBufFail(pkt.AckHandle, fmt.Sprintf("no handler for guild search type '%d'", pkt.Type), nil)
 	}
 
 	if err != nil || guilds == nil {
 		stubEnumerateNoResults(s, pkt.AckHandle)
-		return
+		return nil
 	}
 
 	bf = byteframe.NewByteFrame()
@@ -1245,9 +1250,10 @@ func handleMsgMhfEnumerateGuild(s *Session, p mhfpacket.MHFPacket) {
 	bf.WriteUint8(0x00) // Unk
 
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfArrangeGuildMember(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfArrangeGuildMember(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfArrangeGuildMember)
 
 	guild, err := GetGuildInfoByID(s, pkt.GuildID)
@@ -1257,7 +1263,7 @@ func handleMsgMhfArrangeGuildMember(s *Session, p mhfpacket.MHFPacket) {
 			"failed to respond to ArrangeGuildMember message",
 			zap.Uint32("charID", s.charID),
 		)
-		return
+		return nil
 	}
 
 	if guild.LeaderCharID != s.charID {
@@ -1265,7 +1271,7 @@ func handleMsgMhfArrangeGuildMember(s *Session, p mhfpacket.MHFPacket) {
 			zap.Uint32("charID", s.charID),
 			zap.Uint32("guildID", guild.ID),
 		)
-		return
+		return nil
 	}
 
 	err = guild.ArrangeCharacters(s, pkt.CharIDs)
@@ -1276,13 +1282,14 @@ func handleMsgMhfArrangeGuildMember(s *Session, p mhfpacket.MHFPacket) {
 			zap.Uint32("charID", s.charID),
 			zap.Uint32("guildID", guild.ID),
 		)
-		return
+		return nil
 	}
 
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfEnumerateGuildMember(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateGuildMember(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateGuildMember)
 
 	var guild *Guild
@@ -1297,23 +1304,23 @@ func handleMsgMhfEnumerateGuildMember(s *Session, p mhfpacket.MHFPacket) {
 	if err != nil {
 		s.logger.Warn("failed to retrieve guild sending no result message")
 		doAckBufSucceed(s, pkt.AckHandle, make([]byte, 2))
-		return
+		return nil
 	} else if guild == nil {
 		doAckBufSucceed(s, pkt.AckHandle, make([]byte, 2))
-		return
+		return nil
 	}
 
 	guildMembers, err := GetGuildMembers(s, guild.ID, false)
 
 	if err != nil {
 		s.logger.Error("failed to retrieve guild")
-		return
+		return nil
 	}
 
 	alliance, err := GetAllianceData(s, guild.AllianceID)
 	if err != nil {
 		s.logger.Error("Failed to get alliance data")
-		return
+		return nil
 	}
 
 	bf := byteframe.NewByteFrame()
@@ -1347,7 +1354,7 @@ func handleMsgMhfEnumerateGuildMember(s *Session, p mhfpacket.MHFPacket) {
 		if guild.ID != alliance.ParentGuildID {
 			mems, err := GetGuildMembers(s, alliance.ParentGuildID, false)
 			if err != nil {
-				panic(err)
+				return errBufFail(pkt.AckHandle, "", err)
 			}
 			for _, m := range mems {
 				bf.WriteUint32(m.CharID)
@@ -1356,7 +1363,7 @@ func handleMsgMhfEnumerateGuildMember(s *Session, p mhfpacket.MHFPacket) {
 		if guild.ID != alliance.SubGuild1ID {
 			mems, err := GetGuildMembers(s, alliance.SubGuild1ID, false)
 			if err != nil {
-				panic(err)
+				return errBufFail(pkt.AckHandle, "", err)
 			}
 			for _, m := range mems {
 				bf.WriteUint32(m.CharID)
@@ -1365,7 +1372,7 @@ func handleMsgMhfEnumerateGuildMember(s *Session, p mhfpacket.MHFPacket) {
 		if guild.ID != alliance.SubGuild2ID {
 			mems, err := GetGuildMembers(s, alliance.SubGuild2ID, false)
 			if err != nil {
-				panic(err)
+				return errBufFail(pkt.AckHandle, "", err)
 			}
 			for _, m := range mems {
 				bf.WriteUint32(m.CharID)
@@ -1380,23 +1387,24 @@ func handleMsgMhfEnumerateGuildMember(s *Session, p mhfpacket.MHFPacket) {
 	}
 
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfGetGuildManageRight(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetGuildManageRight(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetGuildManageRight)
 
 	guild, err := GetGuildInfoByCharacterId(s, s.charID)
 
 	if err != nil {
 		s.logger.Warn("failed to respond to manage rights message")
-		return
+		return nil
 	} else if guild == nil {
 		bf := byteframe.NewByteFrame()
 		bf.WriteUint16(0x00) // Unk
 		bf.WriteUint16(0x00) // Member count
 
 		doAckBufSucceed(s, pkt.AckHandle, bf.Data())
-		return
+		return nil
 	}
 
 	bf := byteframe.NewByteFrame()
@@ -1412,17 +1420,19 @@ func handleMsgMhfGetGuildManageRight(s *Session, p mhfpacket.MHFPacket) {
 	}
 
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfGetUdGuildMapInfo(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetUdGuildMapInfo(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetUdGuildMapInfo)
 
 	data, _ := hex.DecodeString("00050000013600000137013500000000E2DF000000000204000000640100000001019901350000E2DF0000000001000000044C000000000001FE01FF00000000000000000D0000001036000000000001FC01FD00000000000000000B0000000F0A000000000001FB01FC00000000000000000A0000000E740000000000019B013700000000000000000F00000011620000000000019601FB0000000000000000090000000DDE0000000000013700D400000000000000001000000011F80000000000013201960000000000000000080000000D48000000000000D40070000000000000000011000000128E000000000000CE01320000000000000000070000000CB200000000000070006F00000000000000001200000013240000000000006F006E00000000000000001300000013BA0000000000006E006D00000000000000001400000014500000000000006D0000000000000000000015020000157C0000000000006A00CE0000000000000000060000000C1C00000000000069006A0000000000000000050000000B860000000000006800690000000000000000040000000AF00000000000006700680000000000000000030000000A5A00000000000066006700000000000000000200000009C4000000000001FD01FE01990000000000000C0300000FA00000000000006500660000000000000000010100000000000000000001FF019B00000000000000000E00000010CC0000000000000000000000000000000000030000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000013700000138013200000000E2E0000000000204000000640100000002019701320000E2E00000000001000000044C00000000000193012E00000000000000000E000000319C0000000000013701360000000000000000060000001EDC00000000000136019900000000000000000700000021340000000000012E012D00000000000000000F00000033F40000000000019801FB01970000000000000903000025E4000000000001F9019400000000000000000C0000002CEC0000000000012D00C9000000000000000010000000364C000000000000D401370000000000000000050000001C84000000000000C9006600000000000000001100000036B00000000000007000D40000000000000000040000001A2C000000000001FA01F900000000000000000B0000002A940000000000006F007000000000000000000300000017D40000000000006E006F000000000000000002000000157C0000000000006D006E00000000000000000101000000000000000000006900000000000000000000150200004362000000000001FB01FA00000000000000000A000000283C0000000000006800690000000000000000140000003B6000000000000067006800000000000000001300000039D0000000000001990198000000000000000008000000238C000000000000660067000000000000000012000000390800000000000194019300000000000000000D0000002F44000000000000000000000000000000000003000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001380000013901FF00000000E2E10000000001040000044C0000000003019B013601FF0000000000000D0300003BC4000000000001FA000000000000000000001502000055F0000000000001F901FA00000000000000001400000051A400000000000199013400000000000000000F00000042040000000000019501F90000000000000000130000004E8400000000000138019B00000000000000000C00000038A400000000000136019900000000000000000E0000003EE4000000000001340133000000000000000010000000452400000000000133013200000000000000001100000048440000000000013201950000000000000000120000004B64000000000000D4013800000000000000000B0000003584000000000000D1006E0000000000000000070000002904000000000000CD006A0000000000000000030000001C840000000000007000D400000000000000000A00000032640000000000006F00700000000000000000090000002F440000000000006E006F0000000000000000080000002C240000000000006C00D100000000000000000600000025E40000000000006B006C00000000000000000500000022C40000000000006A006B0000000000000000040000001FA40000000000006800CD000000000000000002000000196400000000000067006800000000000000000101000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001390000013A006500000000E2E200000000020400000064010000000500C900650000E2E20000000001000000044C00000000000133019700000000000000000C040000445C0000000004013700D20000000000000000130000005FB4000000000000CA00CB00C9000000000000060300002CEC000000000001FF019B00000000000000001100000057E4000000000001FE01FF00000000000000001000000053FC000000000001FD01FE00000000000000000F0000005014000000000001FA01F90000000000000000010100000000000000000001F901940000000000000000020000001D4C0000000000019B01370000000000000000120000005BCC0000000000019801FD00000000000000000E0000004C2C00000000000197019800000000000000000D0000004844000000000001940193000000000000000003000000213400000000000193012E000000000000000004000000251C0000000000012E00CA0000000000000000050000002904000000000000D2006E000000000000000014000000639C000000000000CF013300000000000000000B0000004074000000000000CB006800000000000000000700000030D40000000000006E000000000000000000001502000075300000000000006A00CF00000000000000000A0000003C8C00000000000069006A00000000000000000900000038A400000000000068006900000000000000000800000034BC0000000000000000000000000000000000030000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000013A0000013701F800000000E2E30000000001040000044C000000000601FD01FC00000000000000000700000034BC000000000001FC01FB00000000000000000800000038A4000000000001FB01FA0000000000000000090000003C8C000000000001FA01F900000000000000000A00000040740000000000019B019A0000000000000000050000002CEC0000000000019A01FD00000000000000000600000030D400000000000195013200000000000000000C000000484400000000000138019B00000000000000000400000029040000000000013300CF00000000000000000E000000501400000000000132013300000000000000000D0000004C2C000000000000D40138000000000000000003000000251C000000000000D300D40000000000000000020000002134000000000000CF006A00000000000000000F00000053FC000000000000CD00CC0000000000000000110000005BCC000000000000CC00CB0000000000000000120000005FB4000000000000CB00CA000000000000000013000000639C000000000000CA00C90000000000000000140000006784000000000000C90000000000000000000015020000FDE80000000000006E00D30000000000000000010100000000000000000001F9019501F80000000000000B030000445C0000000000006A00CD00000000000000001000000057E400000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000009000000010738AD00010001000102000000011A000007D00002006302000000020738AD00020001000102000000021A000007D00002006302000000031A000003E80001006301000000041A000003E80001006301000000050738AD00020001000102000000051A000007D00002006302000000061A000003E800010063010100000136000117000000000000044C019901350000E2DF000000000100000000000000000064013500000000E2DF00000000020400000000000000000000650066000000000000000001010100000000000009C40066006700000000000000000200000000000000000A5A0067006800000000000000000300000000000000000AF00068006900000000000000000400000000000000000B860069006A00000000000000000500000000000000000C1C006A00CE00000000000000000600000000000000000CB200CE013200000000000000000700000000000000000D480132019600000000000000000800000000000000000DDE019601FB00000000000000000900000000000000000E7401FB01FC00000000000000000A00000000000000000F0A01FC01FD00000000000000000B00000000000000000FA001FD01FE01990000000000000C0300000000000000103601FE01FF00000000000000000D000000000000000010CC01FF019B00000000000000000E00000000000000001162019B013700000000000000000F000000000000000011F8013700D40000000000000000100000000000000000128E00D40070000000000000000011000000000000000013240070006F000000000000000012000000000000000013BA006F006E00000000000000001300000000000000001450006E006D0000000000000000140000000000000000157C006D000000000000000000001502000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000")
 
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
 }
 
-func handleMsgMhfGetGuildTargetMemberNum(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetGuildTargetMemberNum(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetGuildTargetMemberNum)
 
 	var guild *Guild
@@ -1437,10 +1447,10 @@ func handleMsgMhfGetGuildTargetMemberNum(s *Session, p mhfpacket.MHFPacket) {
 	if err != nil {
 		s.logger.Warn("failed to find guild", zap.Error(err), zap.Uint32("guildID", pkt.GuildID))
 		doAckBufSucceed(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	} else if guild == nil {
 		doAckBufSucceed(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	}
 
 	bf := byteframe.NewByteFrame()
@@ -1449,15 +1459,16 @@ func handleMsgMhfGetGuildTargetMemberNum(s *Session, p mhfpacket.MHFPacket) {
 	bf.WriteUint16(guild.MemberCount - 1)
 
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfEnumerateGuildItem(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateGuildItem(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateGuildItem)
 	var boxContents []byte
 	bf := byteframe.NewByteFrame()
 	err := s.server.db.QueryRow("SELECT item_box FROM guilds WHERE id = $1", int(pkt.GuildId)).Scan(&boxContents)
 	if err != nil {
-		s.logger.Fatal("Failed to get guild item box contents from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get guild item box contents from db", err)
 	} else {
 		if len(boxContents) == 0 {
 			bf.WriteUint32(0x00)
@@ -1475,6 +1486,7 @@ func handleMsgMhfEnumerateGuildItem(s *Session, p mhfpacket.MHFPacket) {
 		}
 	}
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
 type Item struct {
@@ -1482,7 +1494,7 @@ type Item struct {
 	Amount uint16
 }
 
-func handleMsgMhfUpdateGuildItem(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfUpdateGuildItem(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfUpdateGuildItem)
 
 	// Get item cache from DB
@@ -1490,7 +1502,7 @@ func handleMsgMhfUpdateGuildItem(s *Session, p mhfpacket.MHFPacket) {
 	var oldItems []Item
 	err := s.server.db.QueryRow("SELECT item_box FROM guilds WHERE id = $1", int(pkt.GuildId)).Scan(&boxContents)
 	if err != nil {
-		s.logger.Fatal("Failed to get guild item box contents from db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to get guild item box contents from db", err)
 	} else {
 		amount := len(boxContents) / 4
 		oldItems = make([]Item, amount)
@@ -1538,25 +1550,26 @@ func handleMsgMhfUpdateGuildItem(s *Session, p mhfpacket.MHFPacket) {
 	// Upload new item cache
 	_, err = s.server.db.Exec("UPDATE guilds SET item_box = $1 WHERE id = $2", bf.Data(), int(pkt.GuildId))
 	if err != nil {
-		s.logger.Fatal("Failed to update guild item box contents in db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to update guild item box contents in db", err)
 	}
 
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfUpdateGuildIcon(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfUpdateGuildIcon(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfUpdateGuildIcon)
 
 	guild, err := GetGuildInfoByID(s, pkt.GuildID)
 
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 
 	characterInfo, err := GetCharacterGuildData(s, s.charID)
 
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 
 	if !characterInfo.IsSubLeader() && !characterInfo.IsLeader {
@@ -1566,7 +1579,7 @@ func handleMsgMhfUpdateGuildIcon(s *Session, p mhfpacket.MHFPacket) {
 			zap.Uint32("charID", s.charID),
 		)
 		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	}
 
 	icon := &GuildIcon{}
@@ -1594,13 +1607,14 @@ func handleMsgMhfUpdateGuildIcon(s *Session, p mhfpacket.MHFPacket) {
 
 	if err != nil {
 		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	}
 
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfReadGuildcard(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfReadGuildcard(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfReadGuildcard)
 
 	resp := byteframe.NewByteFrame()
@@ -1614,40 +1628,7 @@ func handleMsgMhfReadGuildcard(s *Session, p mhfpacket.MHFPacket) {
 	resp.WriteUint32(0)
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
-}
-
-func handleMsgMhfGetGuildMissionList(s *Session, p mhfpacket.MHFPacket) {
-	pkt := p.(*mhfpacket.MsgMhfGetGuildMissionList)
-
-	decoded, err := hex.DecodeString("000694610000023E000112990023000100000200015DDD232100069462000002F30000005F000C000200000300025DDD232100069463000002EA0000005F0006000100000100015DDD23210006946400000245000000530010000200000400025DDD232100069465000002B60001129B0019000100000200015DDD232100069466000003DC0000001B0010000100000600015DDD232100069467000002DA000112A00019000100000400015DDD232100069468000002A800010DEF0032000200000200025DDD2321000694690000045500000022003C000200000600025DDD23210006946A00000080000122D90046000200000300025DDD23210006946B000001960000003B000A000100000100015DDD23210006946C0000049200000046005A000300000600035DDD23210006946D000000A4000000260018000200000600025DDD23210006946E0000017A00010DE40096000300000100035DDD23210006946F000001BE0000005E0014000200000400025DDD2355000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000")
-
-	if err != nil {
-		panic(err)
-	}
-
-	doAckBufSucceed(s, pkt.AckHandle, decoded)
-}
-
-func handleMsgMhfGetGuildMissionRecord(s *Session, p mhfpacket.MHFPacket) {
-	pkt := p.(*mhfpacket.MsgMhfGetGuildMissionRecord)
-
-	// No guild mission records = 0x190 empty bytes
-	doAckBufSucceed(s, pkt.AckHandle, make([]byte, 0x190))
-}
-
-func handleMsgMhfAddGuildMissionCount(s *Session, p mhfpacket.MHFPacket) {
-	pkt := p.(*mhfpacket.MsgMhfAddGuildMissionCount)
-	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
-}
-
-func handleMsgMhfSetGuildMissionTarget(s *Session, p mhfpacket.MHFPacket) {
-	pkt := p.(*mhfpacket.MsgMhfSetGuildMissionTarget)
-	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
-}
-
-func handleMsgMhfCancelGuildMissionTarget(s *Session, p mhfpacket.MHFPacket) {
-	pkt := p.(*mhfpacket.MsgMhfCancelGuildMissionTarget)
-	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
 type GuildMeal struct {
@@ -1657,13 +1638,13 @@ type GuildMeal struct {
 	Expires uint32 `db:"expires"`
 }
 
-func handleMsgMhfLoadGuildCooking(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfLoadGuildCooking(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfLoadGuildCooking)
 
 	guild, _ := GetGuildInfoByCharacterId(s, s.charID)
 	data, err := s.server.db.Queryx("SELECT id, meal_id, level, expires FROM guild_meals WHERE guild_id = $1", guild.ID)
 	if err != nil {
-		s.logger.Fatal("Failed to get guild meals from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get guild meals from db", err)
 	}
 	temp := byteframe.NewByteFrame()
 	count := 0
@@ -1671,7 +1652,7 @@ func handleMsgMhfLoadGuildCooking(s *Session, p mhfpacket.MHFPacket) {
 		mealData := &GuildMeal{}
 		err = data.StructScan(&mealData)
 		if err != nil {
-			s.logger.Fatal("Failed to scan meal data", zap.Error(err))
+			return errBufFail(pkt.AckHandle, "Failed to scan meal data", err)
 		}
 		if mealData.Expires > uint32(Time_Current_Adjusted().Add(-60 * time.Minute).Unix()) {
 			count++
@@ -1685,40 +1666,24 @@ func handleMsgMhfLoadGuildCooking(s *Session, p mhfpacket.MHFPacket) {
 	bf.WriteUint16(uint16(count))
 	bf.WriteBytes(temp.Data())
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfRegistGuildCooking(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfRegistGuildCooking(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfRegistGuildCooking)
 	guild, _ := GetGuildInfoByCharacterId(s, s.charID)
 	if pkt.OverwriteID != 0 {
 		_, err := s.server.db.Exec("DELETE FROM guild_meals WHERE id = $1", pkt.OverwriteID)
 		if err != nil {
-			s.logger.Fatal("Failed to delete meal in db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to delete meal in db", err)
 		}
 	}
 	_, err := s.server.db.Exec("INSERT INTO guild_meals (guild_id, meal_id, level, expires) VALUES ($1, $2, $3, $4)", guild.ID, pkt.MealID, pkt.Success, Time_Current_Adjusted().Add(30 * time.Minute).Unix())
 	if err != nil {
-		s.logger.Fatal("Failed to register meal in db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to register meal in db", err)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x01, 0x00})
-}
-
-func handleMsgMhfGetGuildWeeklyBonusMaster(s *Session, p mhfpacket.MHFPacket) {
-	pkt := p.(*mhfpacket.MsgMhfGetGuildWeeklyBonusMaster)
-
-	// Values taken from brand new guild capture
-	doAckBufSucceed(s, pkt.AckHandle, make([]byte, 0x28))
-}
-func handleMsgMhfGetGuildWeeklyBonusActiveCount(s *Session, p mhfpacket.MHFPacket) {
-	pkt := p.(*mhfpacket.MsgMhfGetGuildWeeklyBonusActiveCount)
-
-	// Values taken from brand new guild capture
-	doAckBufSucceed(s, pkt.AckHandle, make([]byte, 0x03))
-}
-
-func handleMsgMhfGuildHuntdata(s *Session, p mhfpacket.MHFPacket) {
-	pkt := p.(*mhfpacket.MsgMhfGuildHuntdata)
-	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
 type MessageBoardPost struct {
@@ -1731,13 +1696,13 @@ type MessageBoardPost struct {
 	LikedBy   string `db:"liked_by"`
 }
 
-func handleMsgMhfEnumerateGuildMessageBoard(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateGuildMessageBoard(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateGuildMessageBoard)
 	guild, _ := GetGuildInfoByCharacterId(s, s.charID)
 
 	msgs, err := s.server.db.Queryx("SELECT post_type, stamp_id, title, body, author_id, (EXTRACT(epoch FROM created_at)::int) as created_at, liked_by FROM guild_posts WHERE guild_id = $1 AND post_type = $2 ORDER BY created_at DESC", guild.ID, int(pkt.BoardType))
 	if err != nil {
-		s.logger.Fatal("Failed to get guild messages from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get guild messages from db", err)
 	}
 
 	bf := byteframe.NewByteFrame()
@@ -1749,7 +1714,7 @@ func handleMsgMhfEnumerateGuildMessageBoard(s *Session, p mhfpacket.MHFPacket) {
 		postData := &MessageBoardPost{}
 		err = msgs.StructScan(&postData)
 		if err != nil {
-			s.logger.Fatal("Failed to get guild messages from db", zap.Error(err))
+			return errBufFail(pkt.AckHandle, "Failed to get guild messages from db", err)
 		}
 
 		bf.WriteUint32(postData.Type)
@@ -1774,19 +1739,20 @@ func handleMsgMhfEnumerateGuildMessageBoard(s *Session, p mhfpacket.MHFPacket) {
 		data.WriteBytes(bf.Data())
 		doAckBufSucceed(s, pkt.AckHandle, data.Data())
 	}
+	return nil
 }
 
-func handleMsgMhfUpdateGuildMessageBoard(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfUpdateGuildMessageBoard(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfUpdateGuildMessageBoard)
 	bf := byteframe.NewByteFrameFromBytes(pkt.Request)
 	guild, _ := GetGuildInfoByCharacterId(s, s.charID)
 	if guild == nil {
 		if pkt.MessageOp == 5 {
 			doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
-			return
+			return nil
 		}
 		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	}
 	var titleConv, bodyConv string
 	switch pkt.MessageOp {
@@ -1801,19 +1767,19 @@ func handleMsgMhfUpdateGuildMessageBoard(s *Session, p mhfpacket.MHFPacket) {
 		bodyConv = stringsupport.SJISToUTF8(body)
 		_, err := s.server.db.Exec("INSERT INTO guild_posts (guild_id, author_id, stamp_id, post_type, title, body) VALUES ($1, $2, $3, $4, $5, $6)", guild.ID, s.charID, int(stampId), int(postType), titleConv, bodyConv)
 		if err != nil {
-			s.logger.Fatal("Failed to add new guild message to db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to add new guild message to db", err)
 		}
 		// TODO: if there are too many messages, purge excess
 		_, err = s.server.db.Exec("")
 		if err != nil {
-			s.logger.Fatal("Failed to remove excess guild messages from db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to remove excess guild messages from db", err)
 		}
 	case 1: // Delete message
 		postType := bf.ReadUint32()
 		timestamp := bf.ReadUint64()
 		_, err := s.server.db.Exec("DELETE FROM guild_posts WHERE post_type = $1 AND (EXTRACT(epoch FROM created_at)::int) = $2 AND guild_id = $3", int(postType), int(timestamp), guild.ID)
 		if err != nil {
-			s.logger.Fatal("Failed to delete guild message from db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to delete guild message from db", err)
 		}
 	case 2: // Update message
 		postType := bf.ReadUint32()
@@ -1826,7 +1792,7 @@ func handleMsgMhfUpdateGuildMessageBoard(s *Session, p mhfpacket.MHFPacket) {
 		bodyConv = stringsupport.SJISToUTF8(body)
 		_, err := s.server.db.Exec("UPDATE guild_posts SET title = $1, body = $2 WHERE post_type = $3 AND (EXTRACT(epoch FROM created_at)::int) = $4 AND guild_id = $5", titleConv, bodyConv, int(postType), int(timestamp), guild.ID)
 		if err != nil {
-			s.logger.Fatal("Failed to update guild message in db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to update guild message in db", err)
 		}
 	case 3: // Update stamp
 		postType := bf.ReadUint32()
@@ -1834,7 +1800,7 @@ func handleMsgMhfUpdateGuildMessageBoard(s *Session, p mhfpacket.MHFPacket) {
 		stampId := bf.ReadUint32()
 		_, err := s.server.db.Exec("UPDATE guild_posts SET stamp_id = $1 WHERE post_type = $2 AND (EXTRACT(epoch FROM created_at)::int) = $3 AND guild_id = $4", int(stampId), int(postType), int(timestamp), guild.ID)
 		if err != nil {
-			s.logger.Fatal("Failed to update guild message stamp in db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to update guild message stamp in db", err)
 		}
 	case 4: // Like message
 		postType := bf.ReadUint32()
@@ -1843,19 +1809,19 @@ func handleMsgMhfUpdateGuildMessageBoard(s *Session, p mhfpacket.MHFPacket) {
 		var likedBy string
 		err := s.server.db.QueryRow("SELECT liked_by FROM guild_posts WHERE post_type = $1 AND (EXTRACT(epoch FROM created_at)::int) = $2 AND guild_id = $3", int(postType), int(timestamp), guild.ID).Scan(&likedBy)
 		if err != nil {
-			s.logger.Fatal("Failed to get guild message like data from db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to get guild message like data from db", err)
 		} else {
 			if likeState {
 				likedBy = stringsupport.CSVAdd(likedBy, int(s.charID))
 				_, err := s.server.db.Exec("UPDATE guild_posts SET liked_by = $1 WHERE post_type = $2 AND (EXTRACT(epoch FROM created_at)::int) = $3 AND guild_id = $4", likedBy, int(postType), int(timestamp), guild.ID)
 				if err != nil {
-					s.logger.Fatal("Failed to like guild message in db", zap.Error(err))
+					return errSimpleFail(pkt.AckHandle, "Failed to like guild message in db", err)
 				}
 			} else {
 				likedBy = stringsupport.CSVRemove(likedBy, int(s.charID))
 				_, err := s.server.db.Exec("UPDATE guild_posts SET liked_by = $1 WHERE post_type = $2 AND (EXTRACT(epoch FROM created_at)::int) = $3 AND guild_id = $4", likedBy, int(postType), int(timestamp), guild.ID)
 				if err != nil {
-					s.logger.Fatal("Failed to unlike guild message in db", zap.Error(err))
+					return errSimpleFail(pkt.AckHandle, "Failed to unlike guild message in db", err)
 				}
 			}
 		}
@@ -1864,46 +1830,40 @@ func handleMsgMhfUpdateGuildMessageBoard(s *Session, p mhfpacket.MHFPacket) {
 		var newPosts int
 		err := s.server.db.QueryRow("SELECT (EXTRACT(epoch FROM guild_post_checked)::int) FROM characters WHERE id = $1", s.charID).Scan(&timeChecked)
 		if err != nil {
-			s.logger.Fatal("Failed to get last guild post check timestamp from db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to get last guild post check timestamp from db", err)
 		} else {
 			_, err = s.server.db.Exec("UPDATE characters SET guild_post_checked = $1 WHERE id = $2", time.Now(), s.charID)
 			if err != nil {
-				s.logger.Fatal("Failed to update guild post check timestamp in db", zap.Error(err))
+				return errSimpleFail(pkt.AckHandle, "Failed to update guild post check timestamp in db", err)
 			} else {
 				err = s.server.db.QueryRow("SELECT COUNT(*) FROM guild_posts WHERE guild_id = $1 AND (EXTRACT(epoch FROM created_at)::int) > $2 AND author_id != $3", guild.ID, timeChecked, s.charID).Scan(&newPosts)
 				if err != nil {
-					s.logger.Fatal("Failed to check for new guild posts in db", zap.Error(err))
+					return errSimpleFail(pkt.AckHandle, "Failed to check for new guild posts in db", err)
 				} else {
 					if newPosts > 0 {
 						doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x01})
-						return
+						return nil
 					}
 				}
 			}
 		}
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfEntryRookieGuild(s *Session, p mhfpacket.MHFPacket) {}
-
-func handleMsgMhfUpdateForceGuildRank(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfEntryRookieGuild(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfAddGuildWeeklyBonusExceptionalUser(s *Session, p mhfpacket.MHFPacket) {
-	pkt := p.(*mhfpacket.MsgMhfAddGuildWeeklyBonusExceptionalUser)
-	// TODO: record pkt.NumUsers to DB
-	// must use addition
-	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
-}
+func handleMsgMhfUpdateForceGuildRank(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGenerateUdGuildMap(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGenerateUdGuildMap(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfUpdateGuild(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfUpdateGuild(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfSetGuildManageRight(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfSetGuildManageRight(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfEnumerateInvGuild(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfEnumerateInvGuild(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfOperationInvGuild(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfOperationInvGuild(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfUpdateGuildcard(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfUpdateGuildcard(s *Session, p mhfpacket.MHFPacket) error { return nil }
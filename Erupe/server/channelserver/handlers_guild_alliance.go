@@ -1,9 +1,12 @@
 package channelserver
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
+	"erupe-ce/common/byteframe"
+	ps "erupe-ce/common/pascalstring"
 	"erupe-ce/network/mhfpacket"
 	"github.com/jmoiron/sqlx"
   "go.uber.org/zap"
@@ -71,7 +74,8 @@ func buildAllianceObjectFromDbResult(result *sqlx.Rows, err error, s *Session) (
 
 	parentGuild, err := GetGuildInfoByID(s, alliance.ParentGuildID)
 	if err != nil {
-		s.logger.Fatal("Failed to get parent guild info", zap.Error(err))
+		s.logger.Error("Failed to get parent guild info", zap.Error(err))
+		return nil, err
 	} else {
 		alliance.ParentGuild = *parentGuild
 		alliance.TotalMembers += parentGuild.MemberCount
@@ -80,7 +84,8 @@ func buildAllianceObjectFromDbResult(result *sqlx.Rows, err error, s *Session) (
 	if alliance.SubGuild1ID > 0 {
 		subGuild1, err := GetGuildInfoByID(s, alliance.SubGuild1ID)
 		if err != nil {
-			s.logger.Fatal("Failed to get sub guild 1 info", zap.Error(err))
+			s.logger.Error("Failed to get sub guild 1 info", zap.Error(err))
+			return nil, err
 		} else {
 			alliance.SubGuild1 = *subGuild1
 			alliance.TotalMembers += subGuild1.MemberCount
@@ -90,7 +95,8 @@ func buildAllianceObjectFromDbResult(result *sqlx.Rows, err error, s *Session) (
 	if alliance.SubGuild2ID > 0 {
 		subGuild2, err := GetGuildInfoByID(s, alliance.SubGuild2ID)
 		if err != nil {
-			s.logger.Fatal("Failed to get sub guild 2 info", zap.Error(err))
+			s.logger.Error("Failed to get sub guild 2 info", zap.Error(err))
+			return nil, err
 		} else {
 			alliance.SubGuild2 = *subGuild2
 			alliance.TotalMembers += subGuild2.MemberCount
@@ -100,58 +106,386 @@ func buildAllianceObjectFromDbResult(result *sqlx.Rows, err error, s *Session) (
 	return alliance, nil
 }
 
-func handleMsgMhfCreateJoint(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfCreateJoint(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfCreateJoint)
 	_, err := s.server.db.Exec("INSERT INTO guild_alliances (name, parent_id) VALUES ($1, $2)", pkt.Name, pkt.GuildID)
 	if err != nil {
-		s.logger.Fatal("Failed to create guild alliance in db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to create guild alliance in db", err)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x01, 0x01, 0x01, 0x01})
+	return nil
+}
+
+// hasSubGuild returns whether a guild is a sub guild of the alliance.
+func (alliance *GuildAlliance) hasSubGuild(guildID uint32) bool {
+	return guildID > 0 && (alliance.SubGuild1ID == guildID || alliance.SubGuild2ID == guildID)
+}
+
+// full returns whether the alliance has no room left for another guild,
+// sub guilds being kept in order so the second one is only set with the first.
+func (alliance *GuildAlliance) full() bool {
+	return alliance.SubGuild2ID > 0
+}
+
+// guilds returns the guilds of the alliance, starting with its parent.
+func (alliance *GuildAlliance) guilds() []Guild {
+	guilds := []Guild{alliance.ParentGuild}
+	if alliance.SubGuild1ID > 0 {
+		guilds = append(guilds, alliance.SubGuild1)
+	}
+	if alliance.SubGuild2ID > 0 {
+		guilds = append(guilds, alliance.SubGuild2)
+	}
+	return guilds
+}
+
+// ErrAllianceNotJoinable is returned when an alliance is full or missing, or
+// the guild is missing or already belongs to an alliance.
+var ErrAllianceNotJoinable = errors.New("guild cannot join the alliance")
+
+// ErrAllianceApplicationNotFound is returned when no application or invitation
+// is pending between an alliance and a guild.
+var ErrAllianceApplicationNotFound = errors.New("alliance application not found")
+
+// ErrNotAllianceSubGuild is returned when a guild is not a sub guild of the alliance.
+var ErrNotAllianceSubGuild = errors.New("guild is not a sub guild of the alliance")
+
+// AllianceApplication is a guild applying to an alliance, or invited by one.
+type AllianceApplication struct {
+	AllianceID      uint32               `json:"alliance_id" db:"alliance_id"`
+	GuildID         uint32               `json:"guild_id" db:"guild_id"`
+	ApplicationType GuildApplicationType `json:"type" db:"application_type"`
+	CreatedAt       time.Time            `json:"created_at" db:"created_at"`
+}
+
+// GetAllianceApplications returns the applications of an alliance of a type.
+func GetAllianceApplications(db *sqlx.DB, allianceID uint32, applicationType GuildApplicationType) ([]AllianceApplication, error) {
+	applications := make([]AllianceApplication, 0)
+	err := db.Select(&applications, `
+		SELECT alliance_id, guild_id, application_type, created_at FROM guild_alliance_applications
+		WHERE alliance_id=$1 AND application_type=$2 ORDER BY created_at`,
+		allianceID, applicationType,
+	)
+	return applications, err
+}
+
+// ListAllianceApplications returns the applications and invitations of an alliance.
+func ListAllianceApplications(db *sqlx.DB, allianceID uint32) ([]AllianceApplication, error) {
+	applications := make([]AllianceApplication, 0)
+	err := db.Select(&applications, `
+		SELECT alliance_id, guild_id, application_type, created_at FROM guild_alliance_applications
+		WHERE alliance_id=$1 ORDER BY created_at`, allianceID,
+	)
+	return applications, err
+}
+
+// GetAllianceApplicantGuilds returns the guilds applying to an alliance.
+func GetAllianceApplicantGuilds(s *Session, allianceID uint32) ([]*Guild, error) {
+	applications, err := GetAllianceApplications(s.server.db, allianceID, GuildApplicationTypeApplied)
+	if err != nil {
+		return nil, err
+	}
+	guilds := make([]*Guild, 0, len(applications))
+	for _, application := range applications {
+		guild, err := GetGuildInfoByID(s, application.GuildID)
+		if err != nil {
+			return nil, err
+		} else if guild != nil {
+			guilds = append(guilds, guild)
+		}
+	}
+	return guilds, nil
+}
+
+// CreateAllianceApplication records an application or invitation, replacing
+// any previous one between the alliance and the guild.
+func CreateAllianceApplication(db *sqlx.DB, allianceID uint32, guildID uint32, applicationType GuildApplicationType) error {
+	res, err := db.Exec(`
+		INSERT INTO guild_alliance_applications (alliance_id, guild_id, application_type)
+		SELECT $1, $2, $3::guild_application_type FROM guild_alliances
+		WHERE id=$1 AND sub2_id IS NULL
+			AND EXISTS (SELECT 1 FROM guilds WHERE id=$2)
+			AND NOT EXISTS (SELECT 1 FROM guild_alliances WHERE $2 IN (parent_id, sub1_id, sub2_id))
+		ON CONFLICT (alliance_id, guild_id) DO UPDATE SET application_type=$3, created_at=now()`,
+		allianceID, guildID, applicationType,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrAllianceNotJoinable
+	}
+	return nil
+}
+
+// DeleteAllianceApplication rejects the application or invitation pending
+// between an alliance and a guild.
+func DeleteAllianceApplication(db *sqlx.DB, allianceID uint32, guildID uint32) error {
+	res, err := db.Exec(
+		"DELETE FROM guild_alliance_applications WHERE alliance_id=$1 AND guild_id=$2",
+		allianceID, guildID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrAllianceApplicationNotFound
+	}
+	return nil
+}
+
+// JoinAlliance accepts the application or invitation pending between an
+// alliance and a guild, adding the guild to the alliance.
+func JoinAlliance(db *sqlx.DB, allianceID uint32, guildID uint32) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(
+		"DELETE FROM guild_alliance_applications WHERE alliance_id=$1 AND guild_id=$2",
+		allianceID, guildID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrAllianceApplicationNotFound
+	}
+	res, err = tx.Exec(`
+		UPDATE guild_alliances SET
+			sub1_id = COALESCE(sub1_id, $2),
+			sub2_id = CASE WHEN sub1_id IS NULL THEN NULL ELSE $2 END
+		WHERE id=$1 AND sub2_id IS NULL AND NOT EXISTS (
+			SELECT 1 FROM guild_alliances WHERE $2 IN (parent_id, sub1_id, sub2_id)
+		)`, allianceID, guildID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrAllianceNotJoinable
+	}
+	// A guild belongs to a single alliance.
+	if _, err = tx.Exec("DELETE FROM guild_alliance_applications WHERE guild_id=$1", guildID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// leaveAlliance removes a sub guild from an alliance, moving the second sub
+// guild up when the first one leaves.
+func leaveAlliance(db *sqlx.DB, allianceID uint32, guildID uint32) (bool, error) {
+	res, err := db.Exec(`
+		UPDATE guild_alliances SET
+			sub1_id = CASE WHEN sub1_id=$2 THEN sub2_id ELSE sub1_id END,
+			sub2_id = NULL
+		WHERE id=$1 AND $2 IN (sub1_id, sub2_id)`, allianceID, guildID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// TransferAlliance makes a sub guild the parent of an alliance, the former
+// parent taking its place.
+func TransferAlliance(db *sqlx.DB, allianceID uint32, guildID uint32) error {
+	res, err := db.Exec(`
+		UPDATE guild_alliances SET
+			parent_id = $2,
+			sub1_id = CASE WHEN sub1_id=$2 THEN parent_id ELSE sub1_id END,
+			sub2_id = CASE WHEN sub2_id=$2 THEN parent_id ELSE sub2_id END
+		WHERE id=$1 AND $2 IN (sub1_id, sub2_id)`, allianceID, guildID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotAllianceSubGuild
+	}
+	return nil
+}
+
+// pendingAllianceApplication reports whether an application or invitation of
+// the type is pending between an alliance and a guild.
+func pendingAllianceApplication(db *sqlx.DB, allianceID uint32, guildID uint32, applicationType GuildApplicationType) (bool, error) {
+	var pending bool
+	err := db.Get(&pending, `
+		SELECT EXISTS (SELECT 1 FROM guild_alliance_applications
+		WHERE alliance_id=$1 AND guild_id=$2 AND application_type=$3)`,
+		allianceID, guildID, applicationType,
+	)
+	return pending, err
+}
+
+// allianceOperationResult tells the alliance errors refusing an operation
+// apart from the failures.
+func allianceOperationResult(err error) (bool, error) {
+	switch err {
+	case nil:
+		return true, nil
+	case ErrAllianceNotJoinable, ErrAllianceApplicationNotFound, ErrNotAllianceSubGuild:
+		return false, nil
+	}
+	return false, err
+}
+
+// notifyAllianceLeader mails the leader of a guild about an alliance request.
+func notifyAllianceLeader(s *Session, guild *Guild, subject string, body string) {
+	_, err := SendSystemMail(s.server.db, s.server.bus, MailTarget{CharID: guild.LeaderCharID}, subject, body, nil, nil)
+	if err != nil {
+		s.logger.Error("Failed to mail alliance request", zap.Error(err), zap.Uint32("guildID", guild.ID))
+	}
+}
+
+// answerAllianceApplication accepts or rejects, on behalf of a guild, the
+// application or invitation pending on its side of an alliance.
+func answerAllianceApplication(s *Session, alliance *GuildAlliance, guildID uint32, applicationType GuildApplicationType, accept bool) (bool, error) {
+	pending, err := pendingAllianceApplication(s.server.db, alliance.ID, guildID, applicationType)
+	if err != nil || !pending {
+		return false, err
+	}
+	if accept {
+		return allianceOperationResult(JoinAlliance(s.server.db, alliance.ID, guildID))
+	}
+	return allianceOperationResult(DeleteAllianceApplication(s.server.db, alliance.ID, guildID))
 }
 
-func handleMsgMhfOperateJoint(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfOperateJoint(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfOperateJoint)
 
 	guild, err := GetGuildInfoByID(s, pkt.GuildID)
 	if err != nil {
-		s.logger.Fatal("Failed to get guild info", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to get guild info", err)
 	}
 	alliance, err := GetAllianceData(s, pkt.AllianceID)
 	if err != nil {
-		s.logger.Fatal("Failed to get alliance info", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to get alliance info", err)
+	}
+	if guild == nil || alliance == nil || guild.LeaderCharID != s.charID {
+		s.logger.Warn(
+			"Non-owner of guild attempted alliance operation",
+			zap.Uint32("CharID", s.charID),
+			zap.Uint32("GuildID", pkt.GuildID),
+			zap.Uint32("AllyID", pkt.AllianceID),
+		)
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
 	}
 
+	var targetID uint32
+	if len(pkt.Data) >= 4 {
+		targetID = byteframe.NewByteFrameFromBytes(pkt.Data).ReadUint32()
+	}
+	isParent := alliance.ParentGuildID == guild.ID
+
+	var ok bool
 	switch pkt.Action {
 	case mhfpacket.OPERATE_JOINT_DISBAND:
-		if guild.LeaderCharID == s.charID && alliance.ParentGuildID == guild.ID {
+		if isParent {
 			_, err = s.server.db.Exec("DELETE FROM guild_alliances WHERE id=$1", alliance.ID)
-			if err != nil {
-				s.logger.Fatal("Failed to disband alliance", zap.Error(err))
-			}
-			doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
-		} else {
-			s.logger.Warn(
-				"Non-owner of alliance attempted disband",
-				zap.Uint32("CharID", s.charID),
-				zap.Uint32("AllyID", alliance.ID),
-			)
-			doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+			ok = true
 		}
 	case mhfpacket.OPERATE_JOINT_LEAVE:
-		if guild.LeaderCharID == s.charID {
-			// delete alliance application
-			// or leave alliance
+		if alliance.hasSubGuild(guild.ID) {
+			ok, err = leaveAlliance(s.server.db, alliance.ID, guild.ID)
+		} else if !isParent {
+			// Withdraws a pending application.
+			ok, err = allianceOperationResult(DeleteAllianceApplication(s.server.db, alliance.ID, guild.ID))
+		}
+	case mhfpacket.OPERATE_JOINT_KICK:
+		if isParent && alliance.hasSubGuild(targetID) {
+			ok, err = leaveAlliance(s.server.db, alliance.ID, targetID)
+		}
+	case mhfpacket.OPERATE_JOINT_APPLY:
+		if guild.AllianceID == 0 && !alliance.full() {
+			ok, err = allianceOperationResult(CreateAllianceApplication(s.server.db, alliance.ID, guild.ID, GuildApplicationTypeApplied))
+			if ok {
+				notifyAllianceLeader(s, &alliance.ParentGuild, "Alliance Application",
+					fmt.Sprintf("%s applied to join %s.", guild.Name, alliance.Name))
+			}
+		}
+	case mhfpacket.OPERATE_JOINT_INVITE:
+		if !isParent || alliance.full() {
+			break
+		}
+		var target *Guild
+		target, err = GetGuildInfoByID(s, targetID)
+		if err == nil && target != nil && target.AllianceID == 0 {
+			ok, err = allianceOperationResult(CreateAllianceApplication(s.server.db, alliance.ID, target.ID, GuildApplicationTypeInvited))
+			if ok {
+				notifyAllianceLeader(s, target, "Alliance Invitation",
+					fmt.Sprintf("%s invited %s to join %s.", guild.Name, target.Name, alliance.Name))
+			}
+		}
+	case mhfpacket.OPERATE_JOINT_ACCEPT, mhfpacket.OPERATE_JOINT_REJECT:
+		accept := pkt.Action == mhfpacket.OPERATE_JOINT_ACCEPT
+		if isParent {
+			ok, err = answerAllianceApplication(s, alliance, targetID, GuildApplicationTypeApplied, accept)
 		} else {
-			s.logger.Warn(
-				"Non-owner of guild attempted alliance leave",
-				zap.Uint32("CharID", s.charID),
-			)
-			doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+			ok, err = answerAllianceApplication(s, alliance, guild.ID, GuildApplicationTypeInvited, accept)
+		}
+	case mhfpacket.OPERATE_JOINT_TRANSFER:
+		if isParent && alliance.hasSubGuild(targetID) {
+			ok, err = allianceOperationResult(TransferAlliance(s.server.db, alliance.ID, targetID))
 		}
 	default:
-		panic(fmt.Sprintf("Unhandled operate joint action '%d'", pkt.Action))
-		doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+		s.logger.Warn("Unhandled operate joint action", zap.Uint8("action", uint8(pkt.Action)))
+	}
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, fmt.Sprintf("Failed to operate alliance with action '%d'", pkt.Action), err)
+	}
+	if !ok {
+		s.logger.Warn(
+			"Rejected alliance operation",
+			zap.Uint8("action", uint8(pkt.Action)),
+			zap.Uint32("CharID", s.charID),
+			zap.Uint32("AllyID", alliance.ID),
+		)
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
 	}
+	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfInfoJoint(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfInfoJoint(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfInfoJoint)
+	alliance, err := GetAllianceData(s, pkt.AllianceID)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get alliance info", err)
+	} else if alliance == nil {
+		doAckBufSucceed(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
+	guilds := alliance.guilds()
+	bf := byteframe.NewByteFrame()
+	bf.WriteUint32(alliance.ID)
+	bf.WriteUint32(uint32(alliance.CreatedAt.Unix()))
+	bf.WriteUint16(alliance.TotalMembers)
+	bf.WriteUint16(0) // Unk
+	ps.Uint16(bf, alliance.Name, true)
+	bf.WriteUint8(uint8(len(guilds)))
+	for _, guild := range guilds {
+		bf.WriteUint32(guild.ID)
+		bf.WriteUint32(guild.LeaderCharID)
+		bf.WriteUint16(guild.Rank)
+		bf.WriteUint16(guild.MemberCount)
+		ps.Uint16(bf, guild.Name, true)
+		ps.Uint16(bf, guild.LeaderName, true)
+	}
+	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
+}
@@ -0,0 +1,26 @@
+package channelserver
+
+import "testing"
+
+func TestGuildAllianceMembership(t *testing.T) {
+	alliance := &GuildAlliance{
+		ParentGuildID: 1,
+		ParentGuild:   Guild{ID: 1},
+	}
+	if alliance.full() || alliance.hasSubGuild(1) || alliance.hasSubGuild(0) {
+		t.Errorf("alliance without sub guilds: full=%v hasSubGuild(1)=%v", alliance.full(), alliance.hasSubGuild(1))
+	}
+	if guilds := alliance.guilds(); len(guilds) != 1 || guilds[0].ID != 1 {
+		t.Errorf("guilds() = %+v, expected the parent only", guilds)
+	}
+
+	alliance.SubGuild1ID, alliance.SubGuild1 = 2, Guild{ID: 2}
+	alliance.SubGuild2ID, alliance.SubGuild2 = 3, Guild{ID: 3}
+	if !alliance.full() || !alliance.hasSubGuild(2) || !alliance.hasSubGuild(3) {
+		t.Errorf("alliance with two sub guilds: full=%v", alliance.full())
+	}
+	guilds := alliance.guilds()
+	if len(guilds) != 3 || guilds[0].ID != 1 || guilds[1].ID != 2 || guilds[2].ID != 3 {
+		t.Errorf("guilds() = %+v, expected the parent first", guilds)
+	}
+}
@@ -122,6 +122,16 @@ func GetCharacterGuildData(s *Session, charID uint32) (*GuildMember, error) {
 	return buildGuildMemberObjectFromDBResult(rows, err, s)
 }
 
+// GetCharacterGuildMember returns the guild membership of a character, nil
+// when it isn't a member of a guild or only applied to one.
+func GetCharacterGuildMember(s *Session, charID uint32) (*GuildMember, error) {
+	member, err := GetCharacterGuildData(s, charID)
+	if err != nil || member == nil || member.IsApplicant {
+		return nil, err
+	}
+	return member, nil
+}
+
 func buildGuildMemberObjectFromDBResult(rows *sqlx.Rows, err error, s *Session) (*GuildMember, error) {
 	memberData := &GuildMember{}
 
@@ -0,0 +1,333 @@
+package channelserver
+
+import (
+	"database/sql"
+	"time"
+
+	"erupe-ce/common/byteframe"
+	"erupe-ce/network/mhfpacket"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	// guildMissionSlots is the number of missions a guild is assigned per rotation.
+	guildMissionSlots = 15
+	// guildMissionListSize is the size of the mission list the client expects,
+	// 16 entries followed by a 20 byte trailer.
+	guildMissionListSize = 420
+	// guildMissionRecordSize is the size of the mission record, 16 entries.
+	guildMissionRecordSize = 0x190
+	// guildMissionEntrySize is the size of one mission entry.
+	guildMissionEntrySize = 25
+	// guildMissionMaxCount is the most progress a single report can add to a
+	// mission, above what a quest can achieve.
+	guildMissionMaxCount = 50
+)
+
+// GuildMission is a mission assigned to a guild, along with its catalogue entry.
+type GuildMission struct {
+	ID           uint32     `db:"id"`
+	MissionID    uint32     `db:"mission_id"`
+	Target       uint32     `db:"target"`
+	Goal         uint16     `db:"goal"`
+	Rank         uint16     `db:"rank"`
+	Category     uint8      `db:"category"`
+	Progress     uint16     `db:"progress"`
+	AssignedAt   time.Time  `db:"assigned_at"`
+	CompletedAt  *time.Time `db:"completed_at"`
+	RewardRP     uint32     `db:"reward_rp"`
+	RewardItem   uint16     `db:"reward_item"`
+	RewardAmount uint16     `db:"reward_amount"`
+}
+
+const guildMissionColumns = `m.id, c.mission_id, c.target, c.goal, c.rank, c.category, m.progress,
+       m.assigned_at, m.completed_at, c.reward_rp, c.reward_item, c.reward_amount`
+
+const guildMissionSelectSQL = `
+SELECT ` + guildMissionColumns + `
+FROM guild_missions m
+JOIN guild_mission_catalogue c ON c.id = m.catalogue_id
+`
+
+// missionRotationStart returns the midnight the mission rotation containing
+// now started at, rotations lasting days days since the unix epoch.
+func missionRotationStart(now time.Time, days int) time.Time {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if days < 1 {
+		days = 1
+	}
+	elapsed := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400 % int64(days)
+	return midnight.AddDate(0, 0, -int(elapsed))
+}
+
+// ensureGuildMissions replaces the unfinished missions of a previous rotation
+// by a new set rolled from the catalogue, once per rotation.
+func ensureGuildMissions(db *sqlx.DB, guildID uint32, now time.Time, rotationStart time.Time) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	// Serialises the rotation between the members of the guild.
+	if _, err = tx.Exec("SELECT id FROM guilds WHERE id=$1 FOR UPDATE", guildID); err != nil {
+		return err
+	}
+	var current int
+	err = tx.Get(&current, "SELECT COUNT(*) FROM guild_missions WHERE guild_id=$1 AND assigned_at>=$2", guildID, rotationStart)
+	if err != nil || current > 0 {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM guild_missions WHERE guild_id=$1 AND completed_at IS NULL", guildID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO guild_missions (guild_id, catalogue_id, assigned_at)
+		SELECT $1, id, $2 FROM guild_mission_catalogue WHERE enabled ORDER BY random() LIMIT $3`,
+		guildID, now, guildMissionSlots,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// activeGuildMissions returns the unfinished missions of the current rotation.
+func activeGuildMissions(db *sqlx.DB, guildID uint32, rotationStart time.Time) ([]GuildMission, error) {
+	missions := make([]GuildMission, 0)
+	err := db.Select(&missions, guildMissionSelectSQL+`
+		WHERE m.guild_id=$1 AND m.assigned_at>=$2 AND m.completed_at IS NULL ORDER BY m.id`,
+		guildID, rotationStart,
+	)
+	return missions, err
+}
+
+// completedGuildMissions returns the last missions completed by a guild.
+func completedGuildMissions(db *sqlx.DB, guildID uint32) ([]GuildMission, error) {
+	missions := make([]GuildMission, 0)
+	err := db.Select(&missions, guildMissionSelectSQL+`
+		WHERE m.guild_id=$1 AND m.completed_at IS NOT NULL ORDER BY m.completed_at DESC LIMIT $2`,
+		guildID, guildMissionRecordSize/guildMissionEntrySize,
+	)
+	return missions, err
+}
+
+// writeGuildMission encodes a mission, timestamp being a real time.
+func writeGuildMission(bf *byteframe.ByteFrame, mission GuildMission, timestamp time.Time) {
+	bf.WriteUint32(mission.ID)
+	bf.WriteUint32(mission.MissionID)
+	bf.WriteUint32(mission.Target)
+	bf.WriteUint16(mission.Goal)
+	bf.WriteUint16(mission.Rank)
+	bf.WriteUint16(mission.Progress)
+	bf.WriteUint8(mission.Category)
+	bf.WriteUint8(0)                   // Unk
+	bf.WriteUint8(uint8(mission.Rank)) // Always matched the rank in captures
+	bf.WriteUint32(uint32(Time_Adjusted(timestamp).Unix()))
+}
+
+// buildGuildMissionList encodes the active missions of a guild, targetID being
+// the mission picked by the leader or 0.
+func buildGuildMissionList(missions []GuildMission, targetID uint32) []byte {
+	bf := byteframe.NewByteFrame()
+	for i, mission := range missions {
+		if i == guildMissionSlots {
+			break
+		}
+		writeGuildMission(bf, mission, mission.AssignedAt)
+	}
+	// A zeroed entry ends the list, the trailer presumably starts with the target.
+	bf.WriteBytes(make([]byte, guildMissionListSize-20-len(bf.Data())))
+	bf.WriteUint32(targetID)
+	bf.WriteBytes(make([]byte, 16))
+	return bf.Data()
+}
+
+// buildGuildMissionRecord encodes the last missions completed by a guild.
+func buildGuildMissionRecord(missions []GuildMission) []byte {
+	bf := byteframe.NewByteFrame()
+	for i, mission := range missions {
+		if i == guildMissionRecordSize/guildMissionEntrySize {
+			break
+		}
+		writeGuildMission(bf, mission, *mission.CompletedAt)
+	}
+	bf.WriteBytes(make([]byte, guildMissionRecordSize-len(bf.Data())))
+	return bf.Data()
+}
+
+// addGuildMissionCount adds count to the progress of an active mission of a
+// guild, returning the mission if this completed it. The RP reward of the
+// mission is granted in the same transaction as its completion.
+func addGuildMissionCount(db *sqlx.DB, guildID uint32, missionID uint32, count uint32, now time.Time, rotationStart time.Time) (*GuildMission, error) {
+	if count > guildMissionMaxCount {
+		count = guildMissionMaxCount
+	}
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var mission GuildMission
+	err = tx.Get(&mission, `
+		WITH m AS (
+			UPDATE guild_missions m SET
+				progress = LEAST(m.progress + $3, c.goal),
+				completed_at = CASE WHEN m.progress + $3 >= c.goal THEN $4::timestamptz END
+			FROM guild_mission_catalogue c
+			WHERE c.id = m.catalogue_id AND m.id = $1 AND m.guild_id = $2
+			  AND m.completed_at IS NULL AND m.assigned_at >= $5
+			RETURNING m.*
+		)
+		SELECT `+guildMissionColumns+`
+		FROM m JOIN guild_mission_catalogue c ON c.id = m.catalogue_id`,
+		missionID, guildID, count, now, rotationStart,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if mission.CompletedAt != nil && mission.RewardRP > 0 {
+		_, err = tx.Exec("UPDATE guilds SET rank_rp = rank_rp + $1 WHERE id = $2", mission.RewardRP, guildID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	if mission.CompletedAt == nil {
+		return nil, nil
+	}
+	return &mission, nil
+}
+
+// mailGuildMissionReward mails the item reward of a completed mission to the
+// members of the guild.
+func mailGuildMissionReward(s *Session, guildID uint32, mission *GuildMission) error {
+	if mission.RewardItem > 0 && mission.RewardAmount > 0 {
+		items := []MailItem{{ItemID: mission.RewardItem, Amount: mission.RewardAmount}}
+		_, err := SendSystemMail(s.server.db, s.server.bus, MailTarget{GuildID: guildID}, "Guild Mission", "Reward for completing a guild mission.", items, nil)
+		if err != nil && err != ErrNoMailRecipients {
+			return err
+		}
+	}
+	return nil
+}
+
+// guildMissionRotationStart returns the real time the current rotation of the
+// client calendar started at.
+func (s *Session) guildMissionRotationStart() time.Time {
+	return Time_Unadjusted(missionRotationStart(Time_Current_Adjusted(), s.server.erupeConfig.Guilds.MissionRotationDays))
+}
+
+func handleMsgMhfGetGuildMissionList(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfGetGuildMissionList)
+	member, err := GetCharacterGuildMember(s, s.charID)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get guild membership", err)
+	} else if member == nil {
+		doAckBufSucceed(s, pkt.AckHandle, buildGuildMissionList(nil, 0))
+		return nil
+	}
+	rotationStart := s.guildMissionRotationStart()
+	if err = ensureGuildMissions(s.server.db, member.GuildID, time.Now(), rotationStart); err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to rotate guild missions", err)
+	}
+	missions, err := activeGuildMissions(s.server.db, member.GuildID, rotationStart)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get guild missions", err)
+	}
+	var target sql.NullInt64
+	err = s.server.db.QueryRow("SELECT mission_target FROM guilds WHERE id=$1", member.GuildID).Scan(&target)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get guild mission target", err)
+	}
+	doAckBufSucceed(s, pkt.AckHandle, buildGuildMissionList(missions, uint32(target.Int64)))
+	return nil
+}
+
+func handleMsgMhfGetGuildMissionRecord(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfGetGuildMissionRecord)
+	member, err := GetCharacterGuildMember(s, s.charID)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get guild membership", err)
+	} else if member == nil {
+		doAckBufSucceed(s, pkt.AckHandle, buildGuildMissionRecord(nil))
+		return nil
+	}
+	missions, err := completedGuildMissions(s.server.db, member.GuildID)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get guild mission records", err)
+	}
+	doAckBufSucceed(s, pkt.AckHandle, buildGuildMissionRecord(missions))
+	return nil
+}
+
+func handleMsgMhfAddGuildMissionCount(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfAddGuildMissionCount)
+	member, err := GetCharacterGuildMember(s, s.charID)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to get guild membership", err)
+	} else if member == nil {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
+	mission, err := addGuildMissionCount(s.server.db, member.GuildID, pkt.MissionID, pkt.Count, time.Now(), s.guildMissionRotationStart())
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to add guild mission count", err)
+	}
+	if mission != nil {
+		if err = mailGuildMissionReward(s, member.GuildID, mission); err != nil {
+			return errSimpleFail(pkt.AckHandle, "Failed to mail guild mission reward", err)
+		}
+		s.logger.Info("Guild mission completed", zap.Uint32("guildID", member.GuildID), zap.Uint32("missionID", mission.ID))
+	}
+	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
+}
+
+func handleMsgMhfSetGuildMissionTarget(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfSetGuildMissionTarget)
+	member, err := GetCharacterGuildMember(s, s.charID)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to get guild membership", err)
+	} else if member == nil || !member.IsLeader {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
+	res, err := s.server.db.Exec(`
+		UPDATE guilds SET mission_target=$1 WHERE id=$2 AND EXISTS (
+			SELECT 1 FROM guild_missions WHERE id=$1 AND guild_id=$2 AND completed_at IS NULL AND assigned_at>=$3
+		)`, pkt.MissionID, member.GuildID, s.guildMissionRotationStart())
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to set guild mission target", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
+	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
+}
+
+func handleMsgMhfCancelGuildMissionTarget(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfCancelGuildMissionTarget)
+	member, err := GetCharacterGuildMember(s, s.charID)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to get guild membership", err)
+	} else if member == nil || !member.IsLeader {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
+	_, err = s.server.db.Exec("UPDATE guilds SET mission_target=NULL WHERE id=$1 AND mission_target=$2", member.GuildID, pkt.MissionID)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to cancel guild mission target", err)
+	}
+	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
+}
@@ -0,0 +1,63 @@
+package channelserver
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func TestMissionRotationStart(t *testing.T) {
+	zone := time.FixedZone("UTC+9", 9*60*60)
+	// 1970-01-01 is day 0, so 7 day rotations start on Thursdays.
+	now := time.Date(2015, 3, 10, 15, 30, 0, 0, zone)
+	if got, expected := missionRotationStart(now, 7), time.Date(2015, 3, 5, 0, 0, 0, 0, zone); !got.Equal(expected) {
+		t.Errorf("missionRotationStart(7) = %v, expected %v", got, expected)
+	}
+	if got, expected := missionRotationStart(now, 1), time.Date(2015, 3, 10, 0, 0, 0, 0, zone); !got.Equal(expected) {
+		t.Errorf("missionRotationStart(1) = %v, expected %v", got, expected)
+	}
+	start := missionRotationStart(now, 7)
+	if got := missionRotationStart(start.AddDate(0, 0, 6), 7); !got.Equal(start) {
+		t.Errorf("rotation changed within its days: %v", got)
+	}
+	if got := missionRotationStart(start.AddDate(0, 0, 7), 7); !got.Equal(start.AddDate(0, 0, 7)) {
+		t.Errorf("rotation did not change after its days: %v", got)
+	}
+}
+
+func TestBuildGuildMissionList(t *testing.T) {
+	mission := GuildMission{
+		ID:         431201,
+		MissionID:  574,
+		Target:     70297,
+		Goal:       35,
+		Rank:       1,
+		Category:   2,
+		AssignedAt: Time_Unadjusted(time.Unix(0x5DDD2321, 0)),
+	}
+	data := buildGuildMissionList([]GuildMission{mission}, 431201)
+	if len(data) != guildMissionListSize {
+		t.Fatalf("list is %d bytes, expected %d", len(data), guildMissionListSize)
+	}
+	expected, _ := hex.DecodeString("000694610000023E000112990023000100000200015DDD2321")
+	if !bytes.Equal(data[:guildMissionEntrySize], expected) {
+		t.Errorf("entry = %X, expected %X", data[:guildMissionEntrySize], expected)
+	}
+	if !bytes.Equal(data[guildMissionEntrySize:400], make([]byte, 400-guildMissionEntrySize)) {
+		t.Error("list is not terminated by zeroed entries")
+	}
+	if !bytes.Equal(data[400:404], []byte{0x00, 0x06, 0x94, 0x61}) {
+		t.Errorf("target = %X, expected the mission ID", data[400:404])
+	}
+
+	completed := Time_Unadjusted(time.Unix(0x5DDD2355, 0))
+	mission.CompletedAt = &completed
+	record := buildGuildMissionRecord([]GuildMission{mission})
+	if len(record) != guildMissionRecordSize {
+		t.Fatalf("record is %d bytes, expected %d", len(record), guildMissionRecordSize)
+	}
+	if !bytes.Equal(record[21:25], []byte{0x5D, 0xDD, 0x23, 0x55}) {
+		t.Errorf("record timestamp = %X, expected the completion time", record[21:25])
+	}
+}
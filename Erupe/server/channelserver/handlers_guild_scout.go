@@ -11,58 +11,54 @@ import (
 	"go.uber.org/zap"
 )
 
-func handleMsgMhfPostGuildScout(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfPostGuildScout(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfPostGuildScout)
 
 	actorCharGuildData, err := GetCharacterGuildData(s, s.charID)
 
 	if err != nil {
-		doAckBufFail(s, pkt.AckHandle, make([]byte, 4))
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 
 	if actorCharGuildData == nil || !actorCharGuildData.IsRecruiter() {
 		doAckBufFail(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	}
 
 	guildInfo, err := GetGuildInfoByID(s, actorCharGuildData.GuildID)
 
 	if err != nil {
-		doAckBufFail(s, pkt.AckHandle, make([]byte, 4))
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 
 	hasApplication, err := guildInfo.HasApplicationForCharID(s, pkt.CharID)
 
 	if err != nil {
-		doAckBufFail(s, pkt.AckHandle, make([]byte, 4))
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 
 	if hasApplication {
 		doAckBufSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x04})
-		return
+		return nil
 	}
 
 	transaction, err := s.server.db.Begin()
 
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 
 	err = guildInfo.CreateApplication(s, pkt.CharID, GuildApplicationTypeInvited, transaction)
 
 	if err != nil {
 		rollbackTransaction(s, transaction)
-		doAckBufFail(s, pkt.AckHandle, nil)
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 
 	senderName, err := getCharacterName(s, s.charID)
 
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 
 	mail := &Mail{
@@ -81,58 +77,58 @@ func handleMsgMhfPostGuildScout(s *Session, p mhfpacket.MHFPacket) {
 
 	if err != nil {
 		rollbackTransaction(s, transaction)
-		doAckBufFail(s, pkt.AckHandle, nil)
-		return
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 
 	err = transaction.Commit()
 
 	if err != nil {
-		doAckBufFail(s, pkt.AckHandle, nil)
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 
 	doAckBufSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfCancelGuildScout(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfCancelGuildScout(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfCancelGuildScout)
 
 	guildCharData, err := GetCharacterGuildData(s, s.charID)
 
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 
 	if guildCharData == nil || !guildCharData.IsRecruiter() {
 		doAckBufFail(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	}
 
 	guild, err := GetGuildInfoByID(s, guildCharData.GuildID)
 
 	if err != nil {
 		doAckBufFail(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	}
 
 	err = guild.CancelInvitation(s, pkt.InvitationID)
 
 	if err != nil {
 		doAckBufFail(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	}
 
 	doAckBufSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfAnswerGuildScout(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfAnswerGuildScout(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfAnswerGuildScout)
 
 	guild, err := GetGuildInfoByCharacterId(s, pkt.LeaderID)
 
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 
 	_, err = guild.GetApplicationForCharID(s, s.charID, GuildApplicationTypeInvited)
@@ -145,7 +141,7 @@ func handleMsgMhfAnswerGuildScout(s *Session, p mhfpacket.MHFPacket) {
 			zap.Uint32("charID", s.charID),
 		)
 		doAckBufFail(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	}
 
 	if pkt.Answer {
@@ -156,14 +152,13 @@ func handleMsgMhfAnswerGuildScout(s *Session, p mhfpacket.MHFPacket) {
 
 	if err != nil {
 		doAckBufFail(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	}
 
 	senderName, err := getCharacterName(s, pkt.LeaderID)
 
 	if err != nil {
-		doAckSimpleFail(s, pkt.AckHandle, nil)
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 
 	successMail := Mail{
@@ -178,25 +173,25 @@ func handleMsgMhfAnswerGuildScout(s *Session, p mhfpacket.MHFPacket) {
 	err = successMail.Send(s, nil)
 
 	if err != nil {
-		doAckSimpleFail(s, pkt.AckHandle, nil)
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 
 	doAckBufSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00, 0x0f, 0x42, 0x81, 0x7e})
+	return nil
 }
 
-func handleMsgMhfGetGuildScoutList(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetGuildScoutList(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetGuildScoutList)
 
 	guildInfo, err := GetGuildInfoByCharacterId(s, s.charID)
 
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 
 	if guildInfo == nil {
 		doAckSimpleFail(s, pkt.AckHandle, nil)
-		return
+		return nil
 	}
 
 	rows, err := s.server.db.Queryx(`
@@ -209,7 +204,7 @@ func handleMsgMhfGetGuildScoutList(s *Session, p mhfpacket.MHFPacket) {
 	if err != nil {
 		s.logger.Error("failed to retrieve scouted characters", zap.Error(err))
 		doAckSimpleFail(s, pkt.AckHandle, nil)
-		return
+		return nil
 	}
 
 	defer rows.Close()
@@ -231,8 +226,7 @@ func handleMsgMhfGetGuildScoutList(s *Session, p mhfpacket.MHFPacket) {
 		err = rows.Scan(&charID, &charName, &actorID)
 
 		if err != nil {
-			doAckSimpleFail(s, pkt.AckHandle, nil)
-			panic(err)
+			return errSimpleFail(pkt.AckHandle, "", err)
 		}
 
 		// This seems to be used as a unique ID for the invitation sent
@@ -255,15 +249,16 @@ func handleMsgMhfGetGuildScoutList(s *Session, p mhfpacket.MHFPacket) {
 	_, err = bf.Seek(0, io.SeekStart)
 
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 
 	bf.WriteUint32(count)
 
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfGetRejectGuildScout(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetRejectGuildScout(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetRejectGuildScout)
 
 	row := s.server.db.QueryRow("SELECT restrict_guild_scout FROM characters WHERE id=$1", s.charID)
@@ -279,7 +274,7 @@ func handleMsgMhfGetRejectGuildScout(s *Session, p mhfpacket.MHFPacket) {
 			zap.Uint32("charID", s.charID),
 		)
 		doAckSimpleFail(s, pkt.AckHandle, nil)
-		return
+		return nil
 	}
 
 	response := uint8(0x00)
@@ -289,9 +284,10 @@ func handleMsgMhfGetRejectGuildScout(s *Session, p mhfpacket.MHFPacket) {
 	}
 
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, response})
+	return nil
 }
 
-func handleMsgMhfSetRejectGuildScout(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSetRejectGuildScout(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSetRejectGuildScout)
 
 	_, err := s.server.db.Exec("UPDATE characters SET restrict_guild_scout=$1 WHERE id=$2", pkt.Reject, s.charID)
@@ -303,8 +299,9 @@ func handleMsgMhfSetRejectGuildScout(s *Session, p mhfpacket.MHFPacket) {
 			zap.Uint32("charID", s.charID),
 		)
 		doAckSimpleFail(s, pkt.AckHandle, nil)
-		return
+		return nil
 	}
 
 	doAckSimpleSucceed(s, pkt.AckHandle, nil)
+	return nil
 }
@@ -19,11 +19,11 @@ type TreasureHunt struct {
 	HuntData    []byte `db:"hunt_data"`
 }
 
-func handleMsgMhfEnumerateGuildTresure(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateGuildTresure(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateGuildTresure)
 	guild, err := GetGuildInfoByCharacterId(s, s.charID)
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	bf := byteframe.NewByteFrame()
 	hunts := 0
@@ -34,7 +34,7 @@ func handleMsgMhfEnumerateGuildTresure(s *Session, p mhfpacket.MHFPacket) {
 		// Remove self from other hunter count
 		hunt.Hunters = stringsupport.CSVRemove(hunt.Hunters, int(s.charID))
 		if err != nil {
-			panic(err)
+			return errBufFail(pkt.AckHandle, "", err)
 		}
 		if pkt.MaxHunts == 1 {
 			if hunt.HostID != s.charID || hunt.Acquired {
@@ -67,15 +67,16 @@ func handleMsgMhfEnumerateGuildTresure(s *Session, p mhfpacket.MHFPacket) {
 	resp.WriteUint16(uint16(hunts))
 	resp.WriteBytes(bf.Data())
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfRegistGuildTresure(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfRegistGuildTresure(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfRegistGuildTresure)
 	bf := byteframe.NewByteFrameFromBytes(pkt.Data)
 	huntData := byteframe.NewByteFrame()
 	guild, err := GetGuildInfoByCharacterId(s, s.charID)
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 	guildCats := getGuildAirouList(s)
 	destination := bf.ReadUint32()
@@ -100,18 +101,20 @@ func handleMsgMhfRegistGuildTresure(s *Session, p mhfpacket.MHFPacket) {
 	_, err = s.server.db.Exec("INSERT INTO guild_hunts (guild_id, host_id, destination, level, return, hunt_data, cats_used) VALUES ($1, $2, $3, $4, $5, $6, $7)",
 		guild.ID, s.charID, destination, level, Time_Current_Adjusted().Unix(), huntData.Data(), catsUsed)
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfAcquireGuildTresure(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfAcquireGuildTresure(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfAcquireGuildTresure)
 	_, err := s.server.db.Exec("UPDATE guild_hunts SET acquired=true WHERE id=$1", pkt.HuntID)
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
 func treasureHuntUnregister(s *Session) {
@@ -129,7 +132,7 @@ func treasureHuntUnregister(s *Session) {
 	}
 }
 
-func handleMsgMhfOperateGuildTresureReport(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfOperateGuildTresureReport(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfOperateGuildTresureReport)
 	var csv string
 	if pkt.State == 0 { // Report registration
@@ -139,12 +142,12 @@ func handleMsgMhfOperateGuildTresureReport(s *Session, p mhfpacket.MHFPacket) {
 			// Register to selected hunt
 			err := s.server.db.QueryRow("SELECT hunters FROM guild_hunts WHERE id=$1", pkt.HuntID).Scan(&csv)
 			if err != nil {
-				panic(err)
+				return errSimpleFail(pkt.AckHandle, "", err)
 			}
 			csv = stringsupport.CSVAdd(csv, int(s.charID))
 			_, err = s.server.db.Exec("UPDATE guild_hunts SET hunters=$1 WHERE id=$2", csv, pkt.HuntID)
 			if err != nil {
-				panic(err)
+				return errSimpleFail(pkt.AckHandle, "", err)
 			}
 		}
 	} else if pkt.State == 1 { // Collected by hunter
@@ -152,24 +155,27 @@ func handleMsgMhfOperateGuildTresureReport(s *Session, p mhfpacket.MHFPacket) {
 	} else if pkt.State == 2 { // Claim treasure
 		err := s.server.db.QueryRow("SELECT treasure FROM guild_hunts WHERE id=$1", pkt.HuntID).Scan(&csv)
 		if err != nil {
-			panic(err)
+			return errSimpleFail(pkt.AckHandle, "", err)
 		}
 		csv = stringsupport.CSVAdd(csv, int(s.charID))
 		_, err = s.server.db.Exec("UPDATE guild_hunts SET treasure=$1 WHERE id=$2", csv, pkt.HuntID)
 		if err != nil {
-			panic(err)
+			return errSimpleFail(pkt.AckHandle, "", err)
 		}
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfGetGuildTresureSouvenir(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetGuildTresureSouvenir(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetGuildTresureSouvenir)
 
 	doAckBufSucceed(s, pkt.AckHandle, make([]byte, 6))
+	return nil
 }
 
-func handleMsgMhfAcquireGuildTresureSouvenir(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfAcquireGuildTresureSouvenir(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfAcquireGuildTresureSouvenir)
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
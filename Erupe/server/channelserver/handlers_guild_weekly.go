@@ -0,0 +1,230 @@
+package channelserver
+
+import (
+	"time"
+
+	"erupe-ce/common/byteframe"
+	"erupe-ce/common/mhfmon"
+	"erupe-ce/network/mhfpacket"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const (
+	// weeklyBonusTiers is the number of tiers in the weekly bonus master.
+	weeklyBonusTiers = 10
+	// recordLogMonsters is the number of monster kill counters in a quest record log.
+	recordLogMonsters = 176
+)
+
+// GuildKill is a monster hunted by a guild member.
+type GuildKill struct {
+	ID       uint32 `db:"id"`
+	Monster  uint32 `db:"monster"`
+	Quantity uint32 `db:"quantity"`
+}
+
+// GuildWeeklyActivity is the activity of a guild since the start of the week.
+type GuildWeeklyActivity struct {
+	Hunts            int `db:"hunts"`
+	Hunters          int `db:"hunters"`
+	ExceptionalUsers int `db:"exceptional_users"`
+}
+
+// ActiveMembers returns the members counted as active this week.
+func (a GuildWeeklyActivity) ActiveMembers() int {
+	return a.Hunters + a.ExceptionalUsers
+}
+
+// guildWeekStart returns the Monday midnight the week containing now started at.
+func guildWeekStart(now time.Time) time.Time {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return midnight.AddDate(0, 0, -(int(now.Weekday())+6)%7)
+}
+
+// currentGuildWeekStart returns the real time the current week of the client
+// calendar started at, the guild timestamps being stored in real time.
+func currentGuildWeekStart() time.Time {
+	return Time_Unadjusted(guildWeekStart(Time_Current_Adjusted()))
+}
+
+// recordLogKills returns the large monsters killed according to a quest record
+// log, indexed by monster ID. Small monsters do not count towards the hunts.
+func recordLogKills(data []byte) map[uint32]uint8 {
+	kills := make(map[uint32]uint8)
+	if len(data) < 32+recordLogMonsters {
+		return kills
+	}
+	bf := byteframe.NewByteFrameFromBytes(data)
+	bf.ReadBytes(32) // Unk
+	for i := uint32(0); i < recordLogMonsters; i++ {
+		if n := bf.ReadUint8(); n > 0 && mhfmon.IsLarge(i) {
+			kills[i] = n
+		}
+	}
+	return kills
+}
+
+// recordGuildHunts adds the monsters killed by a member to the hunts of its guild.
+func recordGuildHunts(db *sqlx.DB, guildID uint32, charID uint32, kills map[uint32]uint8, now time.Time) error {
+	if len(kills) == 0 {
+		return nil
+	}
+	monsters := make([]int64, 0, len(kills))
+	quantities := make([]int64, 0, len(kills))
+	for monster, n := range kills {
+		monsters = append(monsters, int64(monster))
+		quantities = append(quantities, int64(n))
+	}
+	_, err := db.Exec(`
+		INSERT INTO guild_kill_logs (guild_id, character_id, monster, quantity, hunted_at)
+		SELECT $1, $2, k.monster, k.quantity, $5 FROM unnest($3::int[], $4::int[]) AS k(monster, quantity)`,
+		guildID, charID, pq.Int64Array(monsters), pq.Int64Array(quantities), now,
+	)
+	return err
+}
+
+// loadGuildWeeklyActivity returns the activity of a guild since weekStart.
+func loadGuildWeeklyActivity(db *sqlx.DB, guildID uint32, weekStart time.Time) (GuildWeeklyActivity, error) {
+	var activity GuildWeeklyActivity
+	err := db.Get(&activity, `
+		SELECT COALESCE(SUM(quantity), 0) AS hunts, COUNT(DISTINCT character_id) AS hunters,
+		       COALESCE((SELECT exceptional_users FROM guild_weekly_bonus WHERE guild_id=$1 AND week_start=$2), 0) AS exceptional_users
+		FROM guild_kill_logs WHERE guild_id=$1 AND hunted_at>=$2`,
+		guildID, weekStart,
+	)
+	return activity, err
+}
+
+// weeklyBonusTier returns the weekly bonus tier reached by a guild, 0 for none.
+func weeklyBonusTier(activity GuildWeeklyActivity, hunts []int, minActive int) uint8 {
+	if activity.ActiveMembers() < minActive {
+		return 0
+	}
+	var tier uint8
+	for i, required := range hunts {
+		if i == weeklyBonusTiers || activity.Hunts < required {
+			break
+		}
+		tier = uint8(i + 1)
+	}
+	return tier
+}
+
+// guildKillsSince returns the hunts of a guild since the member last claimed them,
+// within the current week.
+func guildKillsSince(db *sqlx.DB, guildID uint32, charID uint32, weekStart time.Time) ([]GuildKill, error) {
+	hunts := make([]GuildKill, 0)
+	err := db.Select(&hunts, `
+		SELECT id, monster, quantity FROM guild_kill_logs
+		WHERE guild_id=$1 AND hunted_at >= GREATEST($3, COALESCE(
+			(SELECT hunts_claimed_at FROM guild_characters WHERE character_id=$2), $3))
+		ORDER BY id LIMIT 255`,
+		guildID, charID, weekStart,
+	)
+	return hunts, err
+}
+
+func handleMsgMhfGetGuildWeeklyBonusMaster(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfGetGuildWeeklyBonusMaster)
+	bf := byteframe.NewByteFrame()
+	hunts := s.server.erupeConfig.Guilds.WeeklyBonusHunts
+	for i := 0; i < weeklyBonusTiers; i++ {
+		// A brand new guild capture had every tier zeroed, unused tiers are left that way.
+		if i < len(hunts) {
+			bf.WriteUint32(uint32(hunts[i]))
+		} else {
+			bf.WriteUint32(0)
+		}
+	}
+	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
+}
+
+func handleMsgMhfGetGuildWeeklyBonusActiveCount(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfGetGuildWeeklyBonusActiveCount)
+	member, err := GetCharacterGuildMember(s, s.charID)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get guild membership", err)
+	} else if member == nil {
+		doAckBufSucceed(s, pkt.AckHandle, make([]byte, 3))
+		return nil
+	}
+	activity, err := loadGuildWeeklyActivity(s.server.db, member.GuildID, currentGuildWeekStart())
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get guild weekly activity", err)
+	}
+	config := s.server.erupeConfig.Guilds
+	active := activity.ActiveMembers()
+	if active > 255 {
+		active = 255
+	}
+	bf := byteframe.NewByteFrame()
+	bf.WriteUint8(uint8(active))
+	bf.WriteUint8(weeklyBonusTier(activity, config.WeeklyBonusHunts, config.WeeklyBonusActive))
+	bf.WriteUint8(0) // Unk
+	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
+}
+
+func handleMsgMhfAddGuildWeeklyBonusExceptionalUser(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfAddGuildWeeklyBonusExceptionalUser)
+	member, err := GetCharacterGuildMember(s, s.charID)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to get guild membership", err)
+	} else if member == nil {
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	}
+	_, err = s.server.db.Exec(`
+		INSERT INTO guild_weekly_bonus (guild_id, week_start, exceptional_users) VALUES ($1, $2, $3)
+		ON CONFLICT (guild_id, week_start) DO UPDATE SET exceptional_users = guild_weekly_bonus.exceptional_users + $3`,
+		member.GuildID, currentGuildWeekStart(), pkt.NumUsers,
+	)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to add guild weekly bonus exceptional users", err)
+	}
+	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
+}
+
+func handleMsgMhfGuildHuntdata(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfGuildHuntdata)
+	member, err := GetCharacterGuildMember(s, s.charID)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to get guild membership", err)
+	}
+	bf := byteframe.NewByteFrame()
+	switch pkt.Operation {
+	case 0: // Claim
+		if member != nil {
+			_, err = s.server.db.Exec("UPDATE guild_characters SET hunts_claimed_at=$1 WHERE character_id=$2", time.Now(), s.charID)
+			if err != nil {
+				return errBufFail(pkt.AckHandle, "Failed to claim guild hunts", err)
+			}
+		}
+	case 1, 2: // List, check
+		hunts := make([]GuildKill, 0)
+		if member != nil {
+			hunts, err = guildKillsSince(s.server.db, member.GuildID, s.charID, currentGuildWeekStart())
+			if err != nil {
+				return errBufFail(pkt.AckHandle, "Failed to get guild hunts", err)
+			}
+		}
+		if pkt.Operation == 2 {
+			bf.WriteBool(len(hunts) > 0)
+			break
+		}
+		bf.WriteUint8(uint8(len(hunts)))
+		for _, hunt := range hunts {
+			bf.WriteUint32(hunt.ID)
+			bf.WriteUint32(hunt.Monster)
+		}
+	default:
+		s.logger.Warn("Unknown guild hunt data operation", zap.Uint8("operation", pkt.Operation))
+	}
+	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
+}
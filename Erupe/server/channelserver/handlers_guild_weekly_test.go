@@ -0,0 +1,56 @@
+package channelserver
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGuildWeekStart(t *testing.T) {
+	zone := time.FixedZone("UTC+9", 9*60*60)
+	monday := time.Date(2015, 3, 9, 0, 0, 0, 0, zone)
+	for _, now := range []time.Time{
+		monday,
+		time.Date(2015, 3, 11, 12, 0, 0, 0, zone),
+		time.Date(2015, 3, 15, 23, 59, 59, 0, zone),
+	} {
+		if got := guildWeekStart(now); !got.Equal(monday) {
+			t.Errorf("guildWeekStart(%v) = %v, expected %v", now, got, monday)
+		}
+	}
+	if got := guildWeekStart(time.Date(2015, 3, 16, 0, 0, 1, 0, zone)); !got.Equal(monday.AddDate(0, 0, 7)) {
+		t.Errorf("week did not reset on Monday: %v", got)
+	}
+}
+
+func TestWeeklyBonusTier(t *testing.T) {
+	hunts := []int{10, 30, 60}
+	tests := []struct {
+		activity GuildWeeklyActivity
+		expected uint8
+	}{
+		{GuildWeeklyActivity{Hunts: 100, Hunters: 1}, 0},
+		{GuildWeeklyActivity{Hunts: 9, Hunters: 3}, 0},
+		{GuildWeeklyActivity{Hunts: 10, Hunters: 3}, 1},
+		{GuildWeeklyActivity{Hunts: 59, Hunters: 1, ExceptionalUsers: 2}, 2},
+		{GuildWeeklyActivity{Hunts: 500, Hunters: 3}, 3},
+	}
+	for _, tt := range tests {
+		if got := weeklyBonusTier(tt.activity, hunts, 3); got != tt.expected {
+			t.Errorf("weeklyBonusTier(%+v) = %d, expected %d", tt.activity, got, tt.expected)
+		}
+	}
+}
+
+func TestRecordLogKills(t *testing.T) {
+	data := make([]byte, 0x4AC)
+	data[32+3] = 5 // Kelbi
+	data[32+7] = 2
+	data[32+146] = 1
+	kills := recordLogKills(data)
+	if len(kills) != 2 || kills[7] != 2 || kills[146] != 1 {
+		t.Errorf("recordLogKills = %v, expected monsters 7 and 146", kills)
+	}
+	if kills := recordLogKills(data[:100]); len(kills) != 0 {
+		t.Errorf("recordLogKills(truncated) = %v, expected none", kills)
+	}
+}
@@ -1,76 +1,258 @@
 package channelserver
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"erupe-ce/common/byteframe"
+	ps "erupe-ce/common/pascalstring"
+	"erupe-ce/common/stringsupport"
 	"erupe-ce/network/mhfpacket"
+	"erupe-ce/server/channelserver/compression/nullcomp"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
-func handleMsgMhfUpdateInterior(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfUpdateInterior(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfUpdateInterior)
 	_, err := s.server.db.Exec("UPDATE characters SET house=$1 WHERE id=$2", pkt.InteriorData, s.charID)
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
+}
+
+// House states set by the owner.
+const (
+	houseClosed       = 1
+	houseOpen         = 2
+	houseFriends      = 3
+	houseGuild        = 4
+	houseFriendsGuild = 5
+)
+
+const (
+	// housePasswordFlag is shown by the client as a lock on the house list.
+	housePasswordFlag = 3
+	houseSearchLimit  = 100
+
+	// houseExteriorSize is the tier and layout sent before another character's interior.
+	houseExteriorSize   = 219
+	houseTierSize       = 5
+	houseDataSize       = 195
+	saveOffsetHouseTier = 0x1FB6C
+	saveOffsetHouseData = 0x1FE01
+)
+
+// Ways to list houses in MsgMhfEnumerateHouse.
+const (
+	houseListFriends = 1
+	houseListGuild   = 2
+	houseListName    = 3
+	houseListCharID  = 4
+	houseListRecent  = 5
+)
+
+type houseEntry struct {
+	CharID   uint32 `db:"id"`
+	HRP      uint16 `db:"hrp"`
+	GR       uint16 `db:"gr"`
+	Name     string `db:"name"`
+	State    uint8  `db:"house_state"`
+	Password string `db:"house_password"`
 }
 
-func handleMsgMhfEnumerateHouse(s *Session, p mhfpacket.MHFPacket) {
+const houseSelectQuery = `SELECT id, COALESCE(hrp, 0) AS hrp, COALESCE(gr, 0) AS gr, COALESCE(name, '') AS name, house_state, house_password FROM characters`
+
+func handleMsgMhfEnumerateHouse(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateHouse)
-	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	houses := []houseEntry{}
+	var err error
+	switch pkt.Method {
+	case houseListFriends:
+		var friends string
+		err = s.server.db.QueryRow("SELECT COALESCE(friends, '') FROM characters WHERE id=$1", s.charID).Scan(&friends)
+		if err == nil {
+			var ids pq.Int64Array
+			for _, id := range stringsupport.CSVElems(friends) {
+				ids = append(ids, int64(id))
+			}
+			err = s.server.db.Select(&houses, houseSelectQuery+" WHERE id = ANY($1) LIMIT $2", ids, houseSearchLimit)
+		}
+	case houseListGuild:
+		err = s.server.db.Select(&houses, houseSelectQuery+`
+			WHERE id IN (
+				SELECT character_id FROM guild_characters WHERE guild_id = (
+					SELECT guild_id FROM guild_characters WHERE character_id=$1
+				)
+			) AND id <> $1 LIMIT $2`, s.charID, houseSearchLimit)
+	case houseListName:
+		name := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(stringsupport.SJISToUTF8(pkt.Name))
+		err = s.server.db.Select(&houses, houseSelectQuery+" WHERE name ILIKE $1 LIMIT $2", "%"+name+"%", houseSearchLimit)
+	case houseListCharID:
+		err = s.server.db.Select(&houses, houseSelectQuery+" WHERE id=$1", pkt.CharID)
+	case houseListRecent:
+		err = s.server.db.Select(&houses, houseSelectQuery+`
+			JOIN house_visits v ON v.owner_id = id
+			WHERE v.visitor_id=$1 ORDER BY v.visited_at DESC LIMIT $2`, s.charID, houseSearchLimit)
+	}
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to enumerate houses", err)
+	}
+
+	bf := byteframe.NewByteFrame()
+	bf.WriteUint16(uint16(len(houses)))
+	for _, house := range houses {
+		bf.WriteUint32(house.CharID)
+		bf.WriteUint8(house.State)
+		if house.Password != "" {
+			bf.WriteUint8(housePasswordFlag)
+		} else {
+			bf.WriteUint8(0)
+		}
+		bf.WriteUint16(house.HRP)
+		bf.WriteUint16(house.GR)
+		ps.Uint8(bf, house.Name, true)
+	}
+	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfUpdateHouse(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfUpdateHouse(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfUpdateHouse)
+	if pkt.State < houseClosed || pkt.State > houseFriendsGuild {
+		return errSimpleFail(pkt.AckHandle, fmt.Sprintf("Unknown house state %d", pkt.State), nil)
+	}
+	_, err := s.server.db.Exec("UPDATE characters SET house_state=$1, house_password=$2 WHERE id=$3", pkt.State, pkt.Password, s.charID)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to update house state", err)
+	}
+	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
+}
+
+// houseVisitAllowed applies the state the owner set on their house to a visitor.
+func houseVisitAllowed(state uint8, isFriend bool, isGuildmate bool) bool {
+	switch state {
+	case houseOpen:
+		return true
+	case houseFriends:
+		return isFriend
+	case houseGuild:
+		return isGuildmate
+	case houseFriendsGuild:
+		return isFriend || isGuildmate
+	default:
+		return false
+	}
+}
+
+// canVisitHouse checks whether the session's character may enter the house of charID.
+func (s *Session) canVisitHouse(charID uint32, password string) (bool, error) {
+	var state uint8
+	var housePassword, friends string
+	var isGuildmate bool
+	err := s.server.db.QueryRow(`
+		SELECT house_state, house_password, COALESCE(friends, ''), EXISTS(
+			SELECT 1 FROM guild_characters gc1 JOIN guild_characters gc2 ON gc1.guild_id = gc2.guild_id
+			WHERE gc1.character_id = c.id AND gc2.character_id = $2
+		)
+		FROM characters c WHERE id=$1`, charID, s.charID,
+	).Scan(&state, &housePassword, &friends, &isGuildmate)
+	if err != nil {
+		return false, err
+	}
+	if housePassword != "" && password != housePassword {
+		return false, nil
+	}
+	return houseVisitAllowed(state, stringsupport.CSVContains(friends, int(s.charID)), isGuildmate), nil
+}
 
-func handleMsgMhfLoadHouse(s *Session, p mhfpacket.MHFPacket) {
+// houseExterior extracts the house tier and layout the client shows around
+// another character's interior from their compressed savedata.
+func houseExterior(savedata []byte) []byte {
+	exterior := make([]byte, houseExteriorSize)
+	data, err := nullcomp.Decompress(savedata)
+	if err != nil || len(data) < saveOffsetHouseData+houseDataSize {
+		return exterior
+	}
+	copy(exterior, data[saveOffsetHouseTier:saveOffsetHouseTier+houseTierSize])
+	copy(exterior[houseTierSize:], data[saveOffsetHouseData:saveOffsetHouseData+houseDataSize])
+	return exterior
+}
+
+func handleMsgMhfLoadHouse(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfLoadHouse)
+	if pkt.CharID != s.charID {
+		allowed, err := s.canVisitHouse(pkt.CharID, string(pkt.Password))
+		if err != nil {
+			return errBufFail(pkt.AckHandle, "Failed to check house access", err)
+		}
+		if !allowed {
+			doAckBufFail(s, pkt.AckHandle, make([]byte, 4))
+			return nil
+		}
+	}
+
 	bf := byteframe.NewByteFrame()
-	var data []byte
-	err := s.server.db.QueryRow("SELECT house FROM characters WHERE id=$1", s.charID).Scan(&data)
+	var data, savedata []byte
+	err := s.server.db.QueryRow("SELECT house, savedata FROM characters WHERE id=$1", pkt.CharID).Scan(&data, &savedata)
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	if data == nil {
 		data = make([]byte, 20)
 	}
 	if pkt.CharID != s.charID {
-		bf.WriteBytes(make([]byte, 219))
+		bf.WriteBytes(houseExterior(savedata))
+		_, err = s.server.db.Exec(`
+			INSERT INTO house_visits (visitor_id, owner_id) VALUES ($1, $2)
+			ON CONFLICT (visitor_id, owner_id) DO UPDATE SET visited_at = now()`, s.charID, pkt.CharID,
+		)
+		if err != nil {
+			s.logger.Warn("Failed to record house visit", zap.Uint32("charID", pkt.CharID), zap.Error(err))
+		}
 	}
 	bf.WriteBytes(data)
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfGetMyhouseInfo(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetMyhouseInfo(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetMyhouseInfo)
 
 	var data []byte
 	err := s.server.db.QueryRow("SELECT trophy FROM characters WHERE id = $1", s.charID).Scan(&data)
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	if len(data) > 0 {
 		doAckBufSucceed(s, pkt.AckHandle, data)
 	} else {
 		doAckBufSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
 	}
+	return nil
 }
 
-func handleMsgMhfUpdateMyhouseInfo(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfUpdateMyhouseInfo(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfUpdateMyhouseInfo)
 
 	_, err := s.server.db.Exec("UPDATE characters SET trophy=$1 WHERE id=$2", pkt.Unk0, s.charID)
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfLoadDecoMyset(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfLoadDecoMyset(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfLoadDecoMyset)
 	var data []byte
 	err := s.server.db.QueryRow("SELECT decomyset FROM characters WHERE id = $1", s.charID).Scan(&data)
 	if err != nil {
-		s.logger.Fatal("Failed to get preset decorations savedata from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get preset decorations savedata from db", err)
 	}
 
 	if len(data) > 0 {
@@ -82,16 +264,17 @@ func handleMsgMhfLoadDecoMyset(s *Session, p mhfpacket.MHFPacket) {
 		body[0] = 1
 		doAckBufSucceed(s, pkt.AckHandle, body)
 	}
+	return nil
 }
 
-func handleMsgMhfSaveDecoMyset(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSaveDecoMyset(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSaveDecoMyset)
 	// https://gist.github.com/Andoryuuta/9c524da7285e4b5ca7e52e0fc1ca1daf
 	var loadData []byte
 	bf := byteframe.NewByteFrameFromBytes(pkt.RawDataPayload[1:]) // skip first unk byte
 	err := s.server.db.QueryRow("SELECT decomyset FROM characters WHERE id = $1", s.charID).Scan(&loadData)
 	if err != nil {
-		s.logger.Fatal("Failed to get preset decorations savedata from db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to get preset decorations savedata from db", err)
 	} else {
 		numSets := bf.ReadUint8() // sets being written
 		// empty save
@@ -136,29 +319,92 @@ func handleMsgMhfSaveDecoMyset(s *Session, p mhfpacket.MHFPacket) {
 		}
 		_, err := s.server.db.Exec("UPDATE characters SET decomyset=$1 WHERE id=$2", loadData, s.charID)
 		if err != nil {
-			s.logger.Fatal("Failed to update decomyset savedata in db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to update decomyset savedata in db", err)
 		}
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfEnumerateTitle(s *Session, p mhfpacket.MHFPacket) {
-	pkt := p.(*mhfpacket.MsgMhfEnumerateTitle)
+func handleMsgMhfOperateWarehouse(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfOperateWarehouse)
 	bf := byteframe.NewByteFrame()
-	titleCount := 114                  // all titles unlocked
-	bf.WriteUint16(uint16(titleCount)) // title count
-	bf.WriteUint16(0)                  // unk
-	for i := 0; i < titleCount; i++ {
-		bf.WriteUint16(uint16(i))
-		bf.WriteUint16(0) // unk
-		bf.WriteUint32(0) // timestamp acquired
-		bf.WriteUint32(0) // timestamp updated
+	bf.WriteUint8(pkt.Operation)
+	switch pkt.Operation {
+	case mhfpacket.OperateWarehouseGet:
+		usages, renewal, err := warehouseUsages(s.server.db, s.charID, false)
+		if err != nil {
+			return errBufFail(pkt.AckHandle, "Failed to get warehouse usages", err)
+		}
+		boxes, err := loadWarehouseBoxNames(s.server.db, s.charID)
+		if err != nil {
+			return errBufFail(pkt.AckHandle, "Failed to get warehouse page names", err)
+		}
+		writeWarehouseUsages(bf, usages, renewal)
+		bf.WriteUint8(uint8(len(boxes)))
+		for _, box := range boxes {
+			bf.WriteUint8(box.BoxType)
+			bf.WriteUint8(box.BoxIndex)
+			ps.Uint8(bf, box.Name, true)
+		}
+	case mhfpacket.OperateWarehouseUnk1:
+		bf.WriteUint8(0)
+	case mhfpacket.OperateWarehouseRename:
+		if !validWarehouseBox(pkt.BoxType, pkt.BoxIndex) {
+			return errBufFail(pkt.AckHandle, fmt.Sprintf("Invalid warehouse page %d:%d", pkt.BoxType, pkt.BoxIndex), nil)
+		}
+		if err := renameWarehouseBox(s.server.db, s.charID, pkt.BoxType, pkt.BoxIndex, pkt.Name); err != nil {
+			return errBufFail(pkt.AckHandle, "Failed to rename warehouse page", err)
+		}
+	case mhfpacket.OperateWarehouseGetUsages, mhfpacket.OperateWarehouseUse:
+		usages, renewal, err := warehouseUsages(s.server.db, s.charID, pkt.Operation == mhfpacket.OperateWarehouseUse)
+		if err != nil {
+			return errBufFail(pkt.AckHandle, "Failed to update warehouse usages", err)
+		}
+		writeWarehouseUsages(bf, usages, renewal)
+		if pkt.Operation == mhfpacket.OperateWarehouseUse {
+			bf.WriteUint8(0)
+		}
+	default:
+		return errBufFail(pkt.AckHandle, fmt.Sprintf("Unknown warehouse operation %d", pkt.Operation), nil)
 	}
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfOperateWarehouse(s *Session, p mhfpacket.MHFPacket) {}
+// writeWarehouseUsages writes the usages left, the client refuses to open the
+// warehouse while the renewal time is set.
+func writeWarehouseUsages(bf *byteframe.ByteFrame, usages uint16, renewal time.Time) {
+	if usages > 0 {
+		bf.WriteUint32(0)
+	} else {
+		bf.WriteUint32(uint32(renewal.Unix()))
+	}
+	bf.WriteUint16(usages)
+}
 
-func handleMsgMhfEnumerateWarehouse(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfEnumerateWarehouse(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfEnumerateWarehouse)
+	if !validWarehouseBox(pkt.BoxType, pkt.BoxIndex) {
+		return errBufFail(pkt.AckHandle, fmt.Sprintf("Invalid warehouse page %d:%d", pkt.BoxType, pkt.BoxIndex), nil)
+	}
+	stacks, err := loadWarehouseBox(s.server.db, s.charID, pkt.BoxType, pkt.BoxIndex)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to load warehouse page", err)
+	}
+	doAckBufSucceed(s, pkt.AckHandle, encodeWarehouseBox(pkt.BoxType, stacks))
+	return nil
+}
 
-func handleMsgMhfUpdateWarehouse(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfUpdateWarehouse(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfUpdateWarehouse)
+	if !validWarehouseBox(pkt.BoxType, pkt.BoxIndex) {
+		return errSimpleFail(pkt.AckHandle, fmt.Sprintf("Invalid warehouse page %d:%d", pkt.BoxType, pkt.BoxIndex), nil)
+	}
+	err := updateWarehouseBox(s.server.db, s.charID, pkt.BoxType, pkt.BoxIndex, pkt.Stacks)
+	if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to update warehouse page", err)
+	}
+	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
+}
@@ -0,0 +1,34 @@
+package channelserver
+
+import "testing"
+
+func TestHouseVisitAllowed(t *testing.T) {
+	tests := []struct {
+		state       uint8
+		isFriend    bool
+		isGuildmate bool
+		allowed     bool
+	}{
+		{houseClosed, true, true, false},
+		{houseOpen, false, false, true},
+		{houseFriends, true, false, true},
+		{houseFriends, false, true, false},
+		{houseGuild, false, true, true},
+		{houseGuild, true, false, false},
+		{houseFriendsGuild, true, false, true},
+		{houseFriendsGuild, false, true, true},
+		{houseFriendsGuild, false, false, false},
+		{0, true, true, false},
+	}
+	for _, tt := range tests {
+		if got := houseVisitAllowed(tt.state, tt.isFriend, tt.isGuildmate); got != tt.allowed {
+			t.Errorf("houseVisitAllowed(%d, %t, %t) = %t, expected %t", tt.state, tt.isFriend, tt.isGuildmate, got, tt.allowed)
+		}
+	}
+}
+
+func TestHouseExterior(t *testing.T) {
+	if exterior := houseExterior(nil); len(exterior) != houseExteriorSize {
+		t.Errorf("expected %d bytes without savedata, got %d", houseExteriorSize, len(exterior))
+	}
+}
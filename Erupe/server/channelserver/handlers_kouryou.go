@@ -3,43 +3,45 @@ package channelserver
 import (
 	"erupe-ce/network/mhfpacket"
 	"erupe-ce/common/byteframe"
-	"go.uber.org/zap"
 )
 
-func handleMsgMhfAddKouryouPoint(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfAddKouryouPoint(s *Session, p mhfpacket.MHFPacket) error {
 	// hunting with both ranks maxed gets you these
 	pkt := p.(*mhfpacket.MsgMhfAddKouryouPoint)
 	var points int
 	err := s.server.db.QueryRow("UPDATE characters SET kouryou_point=COALESCE(kouryou_point + $1, $1) WHERE id=$2 RETURNING kouryou_point", pkt.KouryouPoints, s.charID).Scan(&points)
 	if err != nil {
-		s.logger.Fatal("Failed to update KouryouPoint in db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to update KouryouPoint in db", err)
 	}
 	resp := byteframe.NewByteFrame()
 	resp.WriteUint32(uint32(points))
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfGetKouryouPoint(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetKouryouPoint(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetKouryouPoint)
 	var points int
 	err := s.server.db.QueryRow("SELECT COALESCE(kouryou_point, 0) FROM characters WHERE id = $1", s.charID).Scan(&points)
 	if err != nil {
-		s.logger.Fatal("Failed to get kouryou_point savedata from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get kouryou_point savedata from db", err)
 	}
 	resp := byteframe.NewByteFrame()
 	resp.WriteUint32(uint32(points))
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfExchangeKouryouPoint(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfExchangeKouryouPoint(s *Session, p mhfpacket.MHFPacket) error {
 	// spent at the guildmaster, 10000 a roll
 	var points int
 	pkt := p.(*mhfpacket.MsgMhfExchangeKouryouPoint)
 	err := s.server.db.QueryRow("UPDATE characters SET kouryou_point=kouryou_point - $1 WHERE id=$2 RETURNING kouryou_point", pkt.KouryouPoints, s.charID).Scan(&points)
 	if err != nil {
-		s.logger.Fatal("Failed to update platemyset savedata in db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to update platemyset savedata in db", err)
 	}
 	resp := byteframe.NewByteFrame()
 	resp.WriteUint32(uint32(points))
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
@@ -24,6 +24,7 @@ type Mail struct {
 	AttachedItemAmount   uint16    `db:"attached_item_amount"`
 	CreatedAt            time.Time `db:"created_at"`
 	IsGuildInvite        bool      `db:"is_guild_invite"`
+	IsSystemMessage      bool      `db:"is_sys_message"`
 	SenderName           string    `db:"sender_name"`
 }
 
@@ -93,23 +94,6 @@ func (m *Mail) MarkDeleted(s *Session) error {
 	return nil
 }
 
-func (m *Mail) MarkAcquired(s *Session) error {
-	_, err := s.server.db.Exec(`
-		UPDATE mail SET attached_item_received = true WHERE id = $1
-	`, m.ID)
-
-	if err != nil {
-		s.logger.Error(
-			"failed to mark mail item as claimed",
-			zap.Error(err),
-			zap.Int("mailID", m.ID),
-		)
-		return err
-	}
-
-	return nil
-}
-
 func (m *Mail) MarkLocked(s *Session, locked bool) error {
 	_, err := s.server.db.Exec(`
 		UPDATE mail SET locked = $1 WHERE id = $2
@@ -131,21 +115,22 @@ func GetMailListForCharacter(s *Session, charID uint32) ([]Mail, error) {
 	rows, err := s.server.db.Queryx(`
 		SELECT
 			m.id,
-			m.sender_id,
+			COALESCE(m.sender_id, 0) AS sender_id,
 			m.recipient_id,
 			m.subject,
 			m.read,
 			m.attached_item_received,
-			m.attached_item,
+			COALESCE(m.attached_item, 0) AS attached_item,
 			m.attached_item_amount,
 			m.created_at,
 			m.is_guild_invite,
+			m.is_sys_message,
 			m.deleted,
 			m.locked,
-			c.name as sender_name
+			COALESCE(c.name, '') as sender_name
 		FROM mail m
-			JOIN characters c ON c.id = m.sender_id
-		WHERE recipient_id = $1 AND m.deleted = false
+			LEFT JOIN characters c ON c.id = m.sender_id
+		WHERE recipient_id = $1 AND m.deleted = false AND (m.expires_at IS NULL OR m.expires_at > now())
 		ORDER BY m.created_at DESC, id DESC
 		LIMIT 32
 	`, charID)
@@ -171,6 +156,12 @@ func GetMailListForCharacter(s *Session, charID uint32) ([]Mail, error) {
 		allMail = append(allMail, mail)
 	}
 
+	err = loadMailAttachments(s.server.db, allMail)
+	if err != nil {
+		s.logger.Error("failed to get mail attachments", zap.Error(err), zap.Uint32("charID", charID))
+		return nil, err
+	}
+
 	return allMail, nil
 }
 
@@ -178,21 +169,22 @@ func GetMailByID(s *Session, ID int) (*Mail, error) {
 	row := s.server.db.QueryRowx(`
 		SELECT
 			m.id,
-			m.sender_id,
+			COALESCE(m.sender_id, 0) AS sender_id,
 			m.recipient_id,
 			m.subject,
 			m.read,
 			m.body,
 			m.attached_item_received,
-			m.attached_item,
+			COALESCE(m.attached_item, 0) AS attached_item,
 			m.attached_item_amount,
 			m.created_at,
 			m.is_guild_invite,
+			m.is_sys_message,
 			m.deleted,
 			m.locked,
-			c.name as sender_name
+			COALESCE(c.name, '') as sender_name
 		FROM mail m
-			JOIN characters c ON c.id = m.sender_id
+			LEFT JOIN characters c ON c.id = m.sender_id
 		WHERE m.id = $1
 		LIMIT 1
 	`, ID)
@@ -201,6 +193,12 @@ func GetMailByID(s *Session, ID int) (*Mail, error) {
 
 	err := row.StructScan(mail)
 
+	if err == nil {
+		mails := []Mail{*mail}
+		err = loadMailAttachments(s.server.db, mails)
+		*mail = mails[0]
+	}
+
 	if err != nil {
 		s.logger.Error(
 			"failed to retrieve mail",
@@ -217,7 +215,8 @@ func SendMailNotification(s *Session, m *Mail, recipient *Session) {
 	senderName, err := getCharacterName(s, m.SenderID)
 
 	if err != nil {
-		panic(err)
+		s.logger.Error("Failed to get mail sender name", zap.Error(err))
+		return
 	}
 
 	bf := byteframe.NewByteFrame()
@@ -254,21 +253,19 @@ func getCharacterName(s *Session, charID uint32) (string, error) {
 	return charName, nil
 }
 
-func handleMsgMhfReadMail(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfReadMail(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfReadMail)
 
 	mailId := s.mailList[pkt.AccIndex]
 
 	if mailId == 0 {
-		doAckBufFail(s, pkt.AckHandle, make([]byte, 4))
-		panic("attempting to read mail that doesn't exist in session map")
+		return errBufFail(pkt.AckHandle, "attempting to read mail that doesn't exist in session map", nil)
 	}
 
 	mail, err := GetMailByID(s, mailId)
 
 	if err != nil {
-		doAckBufFail(s, pkt.AckHandle, make([]byte, 4))
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 
 	_ = mail.MarkRead(s)
@@ -279,16 +276,16 @@ func handleMsgMhfReadMail(s *Session, p mhfpacket.MHFPacket) {
 	bf.WriteNullTerminatedBytes(body)
 
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfListMail(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfListMail(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfListMail)
 
 	mail, err := GetMailListForCharacter(s, s.charID)
 
 	if err != nil {
-		doAckBufFail(s, pkt.AckHandle, make([]byte, 4))
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 
 	if s.mailList == nil {
@@ -328,7 +325,9 @@ func handleMsgMhfListMail(s *Session, p mhfpacket.MHFPacket) {
 		}
 
 		// System message, hides ID
-		// flags |= 0x04
+		if m.IsSystemMessage {
+			flags |= 0x04
+		}
 
 		if m.AttachedItemReceived {
 			flags |= 0x08
@@ -352,47 +351,49 @@ func handleMsgMhfListMail(s *Session, p mhfpacket.MHFPacket) {
 	}
 
 	doAckBufSucceed(s, pkt.AckHandle, msg.Data())
+	return nil
 }
 
-func handleMsgMhfOprtMail(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfOprtMail(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfOprtMail)
 
 	mail, err := GetMailByID(s, s.mailList[pkt.AccIndex])
 	if err != nil {
-		doAckSimpleFail(s, pkt.AckHandle, nil)
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 	switch mhfpacket.OperateMailOperation(pkt.Operation) {
 	case mhfpacket.OPERATE_MAIL_DELETE:
 		err = mail.MarkDeleted(s)
 		if err != nil {
-			doAckSimpleFail(s, pkt.AckHandle, nil)
-			panic(err)
+			return errSimpleFail(pkt.AckHandle, "", err)
 		}
 	case mhfpacket.OPERATE_MAIL_LOCK:
 		err = mail.MarkLocked(s, true)
 		if err != nil {
-			doAckSimpleFail(s, pkt.AckHandle, nil)
-			panic(err)
+			return errSimpleFail(pkt.AckHandle, "", err)
 		}
 	case mhfpacket.OPERATE_MAIL_UNLOCK:
 		err = mail.MarkLocked(s, false)
 		if err != nil {
-			doAckSimpleFail(s, pkt.AckHandle, nil)
-			panic(err)
+			return errSimpleFail(pkt.AckHandle, "", err)
 		}
 	case mhfpacket.OPERATE_MAIL_ACQUIRE_ITEM:
-		err = mail.MarkAcquired(s)
+		// Only the first claim succeeds, the client adds the item on success.
+		claimed, err := claimMailItem(s.server.db, mail.ID, s.charID, pkt.ItemID, pkt.Amount)
 		if err != nil {
-			doAckSimpleFail(s, pkt.AckHandle, nil)
-			panic(err)
+			return errSimpleFail(pkt.AckHandle, "", err)
+		}
+		if !claimed {
+			doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+			return nil
 		}
 	}
 
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfSendMail(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSendMail(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSendMail)
 	query := `
 		INSERT INTO mail (sender_id, recipient_id, subject, body, attached_item, attached_item_amount, is_guild_invite)
@@ -402,24 +403,26 @@ func handleMsgMhfSendMail(s *Session, p mhfpacket.MHFPacket) {
 	if pkt.RecipientID == 0 { // Guild mail
 		g, err := GetGuildInfoByCharacterId(s, s.charID)
 		if err != nil {
-			s.logger.Fatal("Failed to get guild info for mail")
+			return errSimpleFail(pkt.AckHandle, "Failed to get guild info for mail", nil)
 		}
 		gm, err := GetGuildMembers(s, g.ID, false)
 		if err != nil {
-			s.logger.Fatal("Failed to get guild members for mail")
+			return errSimpleFail(pkt.AckHandle, "Failed to get guild members for mail", nil)
 		}
 		for i := 0; i < len(gm); i++ {
 			_, err := s.server.db.Exec(query, s.charID, gm[i].CharID, pkt.Subject, pkt.Body, 0, 0, false)
 			if err != nil {
-				s.logger.Fatal("Failed to send mail")
+				return errSimpleFail(pkt.AckHandle, "Failed to send mail", nil)
 			}
 		}
 	} else {
 		_, err := s.server.db.Exec(query, s.charID, pkt.RecipientID, pkt.Subject, pkt.Body, pkt.ItemID, pkt.Quantity, false)
 		if err != nil {
-			s.logger.Fatal("Failed to send mail")
+			return errSimpleFail(pkt.AckHandle, "Failed to send mail", nil)
 		}
+		notifyMail(s.server.bus, s.Name, []uint32{pkt.RecipientID})
 	}
 
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
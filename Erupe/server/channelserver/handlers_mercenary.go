@@ -16,17 +16,20 @@ import (
 
 // THERE ARE [PARTENER] [MERCENARY] [OTOMO AIRU]
 
+// hunterNaviLength is the size of the hunter navi savedata.
+const hunterNaviLength = 0x226
+
 ///////////////////////////////////////////
 ///				 PARTENER				 //
 ///////////////////////////////////////////
 
-func handleMsgMhfLoadPartner(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfLoadPartner(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfLoadPartner)
 	// load partner from database
 	var data []byte
 	err := s.server.db.QueryRow("SELECT partner FROM characters WHERE id = $1", s.charID).Scan(&data)
 	if err != nil {
-		s.logger.Fatal("Failed to get partner savedata from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get partner savedata from db", err)
 	}
 	if len(data) > 0 {
 		doAckBufSucceed(s, pkt.AckHandle, data)
@@ -35,45 +38,49 @@ func handleMsgMhfLoadPartner(s *Session, p mhfpacket.MHFPacket) {
 	}
 	// TODO(Andoryuuta): Figure out unusual double ack. One sized, one not.
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfSavePartner(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSavePartner(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSavePartner)
 
 	dumpSaveData(s, pkt.RawDataPayload, "_partner")
 
 	_, err := s.server.db.Exec("UPDATE characters SET partner=$1 WHERE id=$2", pkt.RawDataPayload, s.charID)
 	if err != nil {
-		s.logger.Fatal("Failed to update partner savedata in db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to update partner savedata in db", err)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfLoadLegendDispatch(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfLoadLegendDispatch(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfLoadLegendDispatch)
 	data := []byte{0x03, 0x00, 0x00, 0x00, 0x00, 0x5e, 0x01, 0x8d, 0x40, 0x00, 0x00, 0x00, 0x00, 0x5e, 0x02, 0xde, 0xc0, 0x00, 0x00, 0x00, 0x00, 0x5e, 0x04, 0x30, 0x40}
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
 }
 
-func handleMsgMhfLoadHunterNavi(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfLoadHunterNavi(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfLoadHunterNavi)
 	var data []byte
 	err := s.server.db.QueryRow("SELECT hunternavi FROM characters WHERE id = $1", s.charID).Scan(&data)
 	if err != nil {
-		s.logger.Fatal("Failed to get hunter navigation savedata from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get hunter navigation savedata from db", err)
 	}
 
 	if len(data) > 0 {
 		doAckBufSucceed(s, pkt.AckHandle, data)
 	} else {
 		// set first byte to 1 to avoid pop up every time without save
-		body := make([]byte, 0x226)
+		body := make([]byte, hunterNaviLength)
 		body[0] = 1
 		doAckBufSucceed(s, pkt.AckHandle, body)
 	}
+	return nil
 }
 
-func handleMsgMhfSaveHunterNavi(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSaveHunterNavi(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSaveHunterNavi)
 
 	dumpSaveData(s, pkt.RawDataPayload, "_hunternavi")
@@ -84,23 +91,26 @@ func handleMsgMhfSaveHunterNavi(s *Session, p mhfpacket.MHFPacket) {
 		// Load existing save
 		err := s.server.db.QueryRow("SELECT hunternavi FROM characters WHERE id = $1", s.charID).Scan(&data)
 		if err != nil {
-			s.logger.Fatal("Failed to get hunternavi savedata from db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to get hunternavi savedata from db", err)
 		}
 
 		// Check if we actually had any hunternavi data, using a blank buffer if not.
 		// This is requried as the client will try to send a diff after character creation without a prior MsgMhfSaveHunterNavi packet.
 		if len(data) == 0 {
-			data = make([]byte, 0x226)
+			data = make([]byte, hunterNaviLength)
 			data[0] = 1 // set first byte to 1 to avoid pop up every time without save
 		}
 
 		// Perform diff and compress it to write back to db
 		s.logger.Info("Diffing...")
-		saveOutput := deltacomp.ApplyDataDiff(pkt.RawDataPayload, data)
+		saveOutput, err := deltacomp.ApplyDataDiffChecked(pkt.RawDataPayload, data, hunterNaviLength)
+		if err != nil {
+			return errSimpleFail(pkt.AckHandle, "Failed to diff hunternavi savedata", err)
+		}
 
 		_, err = s.server.db.Exec("UPDATE characters SET hunternavi=$1 WHERE id=$2", saveOutput, s.charID)
 		if err != nil {
-			s.logger.Fatal("Failed to update hunternavi savedata in db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to update hunternavi savedata in db", err)
 		}
 
 		s.logger.Info("Wrote recompressed hunternavi back to DB.")
@@ -108,10 +118,11 @@ func handleMsgMhfSaveHunterNavi(s *Session, p mhfpacket.MHFPacket) {
 		// simply update database, no extra processing
 		_, err := s.server.db.Exec("UPDATE characters SET hunternavi=$1 WHERE id=$2", pkt.RawDataPayload, s.charID)
 		if err != nil {
-			s.logger.Fatal("Failed to update hunternavi savedata in db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to update hunternavi savedata in db", err)
 		}
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
 ///////////////////////////////////////////
@@ -120,14 +131,15 @@ func handleMsgMhfSaveHunterNavi(s *Session, p mhfpacket.MHFPacket) {
 ///				 MERCENARY				 //
 ///////////////////////////////////////////
 
-func handleMsgMhfMercenaryHuntdata(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfMercenaryHuntdata(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfMercenaryHuntdata)
 	doAckBufSucceed(s, pkt.AckHandle, make([]byte, 0x0A))
+	return nil
 }
 
-func handleMsgMhfEnumerateMercenaryLog(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfEnumerateMercenaryLog(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfCreateMercenary(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfCreateMercenary(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfCreateMercenary)
 
 	bf := byteframe.NewByteFrame()
@@ -136,9 +148,10 @@ func handleMsgMhfCreateMercenary(s *Session, p mhfpacket.MHFPacket) {
 	bf.WriteUint32(rand.Uint32()) // Partner ID?
 
 	doAckSimpleSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
 
-func handleMsgMhfSaveMercenary(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSaveMercenary(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSaveMercenary)
 	bf := byteframe.NewByteFrameFromBytes(pkt.RawDataPayload)
 	GCPValue := bf.ReadUint32()
@@ -151,30 +164,31 @@ func handleMsgMhfSaveMercenary(s *Session, p mhfpacket.MHFPacket) {
 		// the save packet has an extra null byte after its size
 		_, err := s.server.db.Exec("UPDATE characters SET savemercenary=$1 WHERE id=$2", MercData[:MercDataSize], s.charID)
 		if err != nil {
-			s.logger.Fatal("Failed to update savemercenary and gcp in db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to update savemercenary and gcp in db", err)
 		}
 	}
 	// gcp value is always present regardless
 	_, err := s.server.db.Exec("UPDATE characters SET gcp=$1 WHERE id=$2", GCPValue, s.charID)
 	if err != nil {
-		s.logger.Fatal("Failed to update savemercenary and gcp in db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to update savemercenary and gcp in db", err)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfReadMercenaryW(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfReadMercenaryW(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfReadMercenaryW)
 	var data []byte
 	var gcp uint32
 	// still has issues
 	err := s.server.db.QueryRow("SELECT savemercenary FROM characters WHERE id = $1", s.charID).Scan(&data)
 	if err != nil {
-		s.logger.Fatal("Failed to get savemercenary data from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get savemercenary data from db", err)
 	}
 
 	err = s.server.db.QueryRow("SELECT COALESCE(gcp, 0) FROM characters WHERE id = $1", s.charID).Scan(&gcp)
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	if len(data) == 0 {
 		data = []byte{0x00}
@@ -185,15 +199,17 @@ func handleMsgMhfReadMercenaryW(s *Session, p mhfpacket.MHFPacket) {
 	resp.WriteUint16(0)
 	resp.WriteUint32(gcp)
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfReadMercenaryM(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfReadMercenaryM(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfReadMercenaryM)
 	// accessing actual rasta data of someone else still unsure of the formatting of this
 	doAckBufSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfContractMercenary(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfContractMercenary(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
 ///////////////////////////////////////////
 
@@ -201,27 +217,28 @@ func handleMsgMhfContractMercenary(s *Session, p mhfpacket.MHFPacket) {}
 ///				OTOMO AIRU				 //
 ///////////////////////////////////////////
 
-func handleMsgMhfLoadOtomoAirou(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfLoadOtomoAirou(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfLoadOtomoAirou)
 	var data []byte
 	err := s.server.db.QueryRow("SELECT otomoairou FROM characters WHERE id = $1", s.charID).Scan(&data)
 	if err != nil {
-		s.logger.Fatal("Failed to get partnyaa savedata from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get partnyaa savedata from db", err)
 	}
 	if len(data) > 0 {
 		doAckBufSucceed(s, pkt.AckHandle, data)
 	} else {
 		doAckBufSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
 	}
+	return nil
 }
 
-func handleMsgMhfSaveOtomoAirou(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSaveOtomoAirou(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSaveOtomoAirou)
 	decomp, err := nullcomp.Decompress(pkt.RawDataPayload[1:])
 	if err != nil {
 		s.logger.Error("Failed to decompress airou", zap.Error(err))
 		doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
-		return
+		return nil
 	}
 	bf := byteframe.NewByteFrameFromBytes(decomp)
 	cats := bf.ReadUint8()
@@ -244,16 +261,17 @@ func handleMsgMhfSaveOtomoAirou(s *Session, p mhfpacket.MHFPacket) {
 		s.server.db.Exec("UPDATE characters SET otomoairou=$1 WHERE id=$2", comp, s.charID)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfEnumerateAiroulist(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateAiroulist(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateAiroulist)
 	resp := byteframe.NewByteFrame()
 	if _, err := os.Stat(filepath.Join(s.server.erupeConfig.BinPath, "airoulist.bin")); err == nil {
 		data, _ := ioutil.ReadFile(filepath.Join(s.server.erupeConfig.BinPath, "airoulist.bin"))
 		resp.WriteBytes(data)
 		doAckBufSucceed(s, pkt.AckHandle, resp.Data())
-		return
+		return nil
 	}
 	airouList := getGuildAirouList(s)
 	resp.WriteUint16(uint16(len(airouList)))
@@ -269,6 +287,7 @@ func handleMsgMhfEnumerateAiroulist(s *Session, p mhfpacket.MHFPacket) {
 		resp.WriteUint32(0) // 32 bit unix timestamp, either time at which the cat stops being fatigued or the time at which it started
 	}
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
 // CatDefinition holds values needed to populate the guild cat list
@@ -2,12 +2,12 @@ package channelserver
 
 import "erupe-ce/network/mhfpacket"
 
-func handleMsgSysCreateMutex(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysCreateMutex(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysCreateOpenMutex(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysCreateOpenMutex(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysDeleteMutex(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysDeleteMutex(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysOpenMutex(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysOpenMutex(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysCloseMutex(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysCloseMutex(s *Session, p mhfpacket.MHFPacket) error { return nil }
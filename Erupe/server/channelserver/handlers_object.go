@@ -7,7 +7,7 @@ import (
 	"erupe-ce/network/mhfpacket"
 )
 
-func handleMsgSysCreateObject(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysCreateObject(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysCreateObject)
 
 	// Lock the stage.
@@ -49,11 +49,12 @@ func handleMsgSysCreateObject(s *Session, p mhfpacket.MHFPacket) {
 
 	s.logger.Info("Duplicate a new characters to others clients")
 	s.stage.BroadcastMHF(dupObjUpdate, s)
+	return nil
 }
 
-func handleMsgSysDeleteObject(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysDeleteObject(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysPositionObject(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysPositionObject(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysPositionObject)
 	if s.server.erupeConfig.DevMode && s.server.erupeConfig.DevModeOptions.LogInboundMessages {
 		fmt.Printf("[%s] with objectID [%d] move to (%f,%f,%f)\n\n", s.Name, pkt.ObjID, pkt.X, pkt.Y, pkt.Z)
@@ -68,26 +69,27 @@ func handleMsgSysPositionObject(s *Session, p mhfpacket.MHFPacket) {
 	s.stage.Unlock()
 	// One of the few packets we can just re-broadcast directly.
 	s.stage.BroadcastMHF(pkt, s)
+	return nil
 }
 
-func handleMsgSysRotateObject(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysRotateObject(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysDuplicateObject(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysDuplicateObject(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysSetObjectBinary(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysSetObjectBinary(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysGetObjectBinary(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysGetObjectBinary(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysGetObjectOwner(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysGetObjectOwner(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysUpdateObjectBinary(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysUpdateObjectBinary(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysCleanupObject(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysCleanupObject(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysAddObject(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysAddObject(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysDelObject(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysDelObject(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysDispObject(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysDispObject(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysHideObject(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysHideObject(s *Session, p mhfpacket.MHFPacket) error { return nil }
@@ -4,15 +4,21 @@ import (
 	"erupe-ce/network/mhfpacket"
 	"erupe-ce/server/channelserver/compression/deltacomp"
 	"erupe-ce/server/channelserver/compression/nullcomp"
-	"go.uber.org/zap"
 )
 
-func handleMsgMhfLoadPlateData(s *Session, p mhfpacket.MHFPacket) {
+const (
+	// plateDataLength is the size of the decompressed plate data.
+	plateDataLength = 0x1AF20
+	// plateBoxLength is the size of the decompressed sigil box.
+	plateBoxLength = 0x820
+)
+
+func handleMsgMhfLoadPlateData(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfLoadPlateData)
 	var data []byte
 	err := s.server.db.QueryRow("SELECT platedata FROM characters WHERE id = $1", s.charID).Scan(&data)
 	if err != nil {
-		s.logger.Fatal("Failed to get plate data savedata from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get plate data savedata from db", err)
 	}
 
 	if len(data) > 0 {
@@ -20,9 +26,10 @@ func handleMsgMhfLoadPlateData(s *Session, p mhfpacket.MHFPacket) {
 	} else {
 		doAckBufSucceed(s, pkt.AckHandle, []byte{})
 	}
+	return nil
 }
 
-func handleMsgMhfSavePlateData(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSavePlateData(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSavePlateData)
 
 	dumpSaveData(s, pkt.RawDataPayload, "_platedata")
@@ -33,7 +40,7 @@ func handleMsgMhfSavePlateData(s *Session, p mhfpacket.MHFPacket) {
 		// Load existing save
 		err := s.server.db.QueryRow("SELECT platedata FROM characters WHERE id = $1", s.charID).Scan(&data)
 		if err != nil {
-			s.logger.Fatal("Failed to get platedata savedata from db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to get platedata savedata from db", err)
 		}
 
 		if len(data) > 0 {
@@ -41,23 +48,27 @@ func handleMsgMhfSavePlateData(s *Session, p mhfpacket.MHFPacket) {
 			s.logger.Info("Decompressing...")
 			data, err = nullcomp.Decompress(data)
 			if err != nil {
-				s.logger.Fatal("Failed to decompress savedata from db", zap.Error(err))
+				return errSimpleFail(pkt.AckHandle, "Failed to decompress savedata from db", err)
 			}
 		} else {
 			// create empty save if absent
-			data = make([]byte, 0x1AF20)
+			data = make([]byte, plateDataLength)
 		}
 
 		// Perform diff and compress it to write back to db
 		s.logger.Info("Diffing...")
-		saveOutput, err := nullcomp.Compress(deltacomp.ApplyDataDiff(pkt.RawDataPayload, data))
+		data, err = deltacomp.ApplyDataDiffChecked(pkt.RawDataPayload, data, plateDataLength)
+		if err != nil {
+			return errSimpleFail(pkt.AckHandle, "Failed to diff platedata savedata", err)
+		}
+		saveOutput, err := nullcomp.Compress(data)
 		if err != nil {
-			s.logger.Fatal("Failed to diff and compress platedata savedata", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to diff and compress platedata savedata", err)
 		}
 
 		_, err = s.server.db.Exec("UPDATE characters SET platedata=$1 WHERE id=$2", saveOutput, s.charID)
 		if err != nil {
-			s.logger.Fatal("Failed to update platedata savedata in db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to update platedata savedata in db", err)
 		}
 
 		s.logger.Info("Wrote recompressed platedata back to DB.")
@@ -65,19 +76,20 @@ func handleMsgMhfSavePlateData(s *Session, p mhfpacket.MHFPacket) {
 		// simply update database, no extra processing
 		_, err := s.server.db.Exec("UPDATE characters SET platedata=$1 WHERE id=$2", pkt.RawDataPayload, s.charID)
 		if err != nil {
-			s.logger.Fatal("Failed to update platedata savedata in db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to update platedata savedata in db", err)
 		}
 	}
 
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfLoadPlateBox(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfLoadPlateBox(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfLoadPlateBox)
 	var data []byte
 	err := s.server.db.QueryRow("SELECT platebox FROM characters WHERE id = $1", s.charID).Scan(&data)
 	if err != nil {
-		s.logger.Fatal("Failed to get sigil box savedata from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get sigil box savedata from db", err)
 	}
 
 	if len(data) > 0 {
@@ -85,9 +97,10 @@ func handleMsgMhfLoadPlateBox(s *Session, p mhfpacket.MHFPacket) {
 	} else {
 		doAckBufSucceed(s, pkt.AckHandle, []byte{})
 	}
+	return nil
 }
 
-func handleMsgMhfSavePlateBox(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSavePlateBox(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSavePlateBox)
 
 	dumpSaveData(s, pkt.RawDataPayload, "_platebox")
@@ -98,7 +111,7 @@ func handleMsgMhfSavePlateBox(s *Session, p mhfpacket.MHFPacket) {
 		// Load existing save
 		err := s.server.db.QueryRow("SELECT platebox FROM characters WHERE id = $1", s.charID).Scan(&data)
 		if err != nil {
-			s.logger.Fatal("Failed to get sigil box savedata from db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to get sigil box savedata from db", err)
 		}
 
 		// Decompress
@@ -107,23 +120,27 @@ func handleMsgMhfSavePlateBox(s *Session, p mhfpacket.MHFPacket) {
 			s.logger.Info("Decompressing...")
 			data, err = nullcomp.Decompress(data)
 			if err != nil {
-				s.logger.Fatal("Failed to decompress savedata from db", zap.Error(err))
+				return errSimpleFail(pkt.AckHandle, "Failed to decompress savedata from db", err)
 			}
 		} else {
 			// create empty save if absent
-			data = make([]byte, 0x820)
+			data = make([]byte, plateBoxLength)
 		}
 
 		// Perform diff and compress it to write back to db
 		s.logger.Info("Diffing...")
-		saveOutput, err := nullcomp.Compress(deltacomp.ApplyDataDiff(pkt.RawDataPayload, data))
+		data, err = deltacomp.ApplyDataDiffChecked(pkt.RawDataPayload, data, plateBoxLength)
+		if err != nil {
+			return errSimpleFail(pkt.AckHandle, "Failed to diff sigil box savedata", err)
+		}
+		saveOutput, err := nullcomp.Compress(data)
 		if err != nil {
-			s.logger.Fatal("Failed to diff and compress savedata", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to diff and compress savedata", err)
 		}
 
 		_, err = s.server.db.Exec("UPDATE characters SET platebox=$1 WHERE id=$2", saveOutput, s.charID)
 		if err != nil {
-			s.logger.Fatal("Failed to update platebox savedata in db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to update platebox savedata in db", err)
 		}
 
 		s.logger.Info("Wrote recompressed platebox back to DB.")
@@ -131,18 +148,19 @@ func handleMsgMhfSavePlateBox(s *Session, p mhfpacket.MHFPacket) {
 		// simply update database, no extra processing
 		_, err := s.server.db.Exec("UPDATE characters SET platebox=$1 WHERE id=$2", pkt.RawDataPayload, s.charID)
 		if err != nil {
-			s.logger.Fatal("Failed to update platedata savedata in db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to update platedata savedata in db", err)
 		}
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfLoadPlateMyset(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfLoadPlateMyset(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfLoadPlateMyset)
 	var data []byte
 	err := s.server.db.QueryRow("SELECT platemyset FROM characters WHERE id = $1", s.charID).Scan(&data)
 	if err != nil {
-		s.logger.Fatal("Failed to get presets sigil savedata from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get presets sigil savedata from db", err)
 	}
 
 	if len(data) > 0 {
@@ -151,15 +169,17 @@ func handleMsgMhfLoadPlateMyset(s *Session, p mhfpacket.MHFPacket) {
 		blankData := make([]byte, 0x780)
 		doAckBufSucceed(s, pkt.AckHandle, blankData)
 	}
+	return nil
 }
 
-func handleMsgMhfSavePlateMyset(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSavePlateMyset(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSavePlateMyset)
 	// looks to always return the full thing, simply update database, no extra processing
 
 	_, err := s.server.db.Exec("UPDATE characters SET platemyset=$1 WHERE id=$2", pkt.RawDataPayload, s.charID)
 	if err != nil {
-		s.logger.Fatal("Failed to update platemyset savedata in db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to update platemyset savedata in db", err)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
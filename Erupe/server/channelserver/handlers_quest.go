@@ -3,45 +3,39 @@ package channelserver
 import (
 	"fmt"
 	"io/ioutil"
-	"os"
 	"path/filepath"
+	"time"
 
 	"erupe-ce/common/byteframe"
 	"erupe-ce/network/mhfpacket"
+	"go.uber.org/zap"
 )
 
-func handleMsgSysGetFile(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysGetFile(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysGetFile)
 
-	// Debug print the request.
 	if pkt.IsScenario {
-		fmt.Printf("%+v\n", pkt.ScenarioIdentifer)
 		filename := fmt.Sprintf("%d_0_0_0_S%d_T%d_C%d", pkt.ScenarioIdentifer.CategoryID, pkt.ScenarioIdentifer.MainID, pkt.ScenarioIdentifer.Flags, pkt.ScenarioIdentifer.ChapterID)
-		// Read the scenario file.
-		data, err := ioutil.ReadFile(filepath.Join(s.server.erupeConfig.BinPath, fmt.Sprintf("scenarios/%s.bin", filename)))
+		data, err := s.server.questFiles.Scenario(filename)
 		if err != nil {
-			panic(err)
+			s.logger.Warn("Failed to load scenario file", zap.String("filename", filename), zap.Error(err))
+			doAckBufFail(s, pkt.AckHandle, make([]byte, 4))
+			return nil
 		}
 		doAckBufSucceed(s, pkt.AckHandle, data)
 	} else {
-		if _, err := os.Stat(filepath.Join(s.server.erupeConfig.BinPath, "quest_override.bin")); err == nil {
-			data, err := ioutil.ReadFile(filepath.Join(s.server.erupeConfig.BinPath, "quest_override.bin"))
-			if err != nil {
-				panic(err)
-			}
-			doAckBufSucceed(s, pkt.AckHandle, data)
-		} else {
-			// Get quest file.
-			data, err := ioutil.ReadFile(filepath.Join(s.server.erupeConfig.BinPath, fmt.Sprintf("quests/%s.bin", pkt.Filename)))
-			if err != nil {
-				panic(err)
-			}
-			doAckBufSucceed(s, pkt.AckHandle, data)
+		data, err := s.server.questFiles.Quest(pkt.Filename, s.server.questOverridePath)
+		if err != nil {
+			s.logger.Warn("Failed to load quest file", zap.String("filename", pkt.Filename), zap.Error(err))
+			doAckBufFail(s, pkt.AckHandle, make([]byte, 4))
+			return nil
 		}
+		doAckBufSucceed(s, pkt.AckHandle, data)
 	}
+	return nil
 }
 
-func handleMsgMhfLoadFavoriteQuest(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfLoadFavoriteQuest(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfLoadFavoriteQuest)
 	var data []byte
 	err := s.server.db.QueryRow("SELECT savefavoritequest FROM characters WHERE id = $1", s.charID).Scan(&data)
@@ -50,31 +44,47 @@ func handleMsgMhfLoadFavoriteQuest(s *Session, p mhfpacket.MHFPacket) {
 	} else {
 		doAckBufSucceed(s, pkt.AckHandle, []byte{0x01, 0x00, 0x01, 0x00, 0x01, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
 	}
+	return nil
 }
 
-func handleMsgMhfSaveFavoriteQuest(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSaveFavoriteQuest(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfSaveFavoriteQuest)
 	s.server.db.Exec("UPDATE characters SET savefavoritequest=$1 WHERE id=$2", pkt.Data, s.charID)
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfEnumerateQuest(s *Session, p mhfpacket.MHFPacket) {
-	// local files are easier for now, probably best would be to generate dynamically
+func handleMsgMhfEnumerateQuest(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateQuest)
-	data, err := ioutil.ReadFile(filepath.Join(s.server.erupeConfig.BinPath, fmt.Sprintf("questlists/list_%d.bin", pkt.QuestList)))
+	_, week := Time_Current_Adjusted().ISOWeek()
+	quests, catalogued, err := loadQuestCatalogue(s.server.db, time.Now(), week)
 	if err != nil {
-		fmt.Printf("questlists/list_%d.bin", pkt.QuestList)
-		stubEnumerateNoResults(s, pkt.AckHandle)
+		s.logger.Warn("Failed to load quest catalogue, using quest list files", zap.Error(err))
+	}
+	if catalogued {
+		tuneValues, err := loadQuestTuneValues(s.server.db, pkt.QuestList)
+		if err != nil {
+			return errBufFail(pkt.AckHandle, "Failed to load quest tune values", err)
+		}
+		doAckBufSucceed(s, pkt.AckHandle, BuildQuestList(quests, pkt.QuestList, tuneValues))
 	} else {
-		doAckBufSucceed(s, pkt.AckHandle, data)
+		// Fall back to the pre-built lists while the catalogue is empty.
+		data, err := ioutil.ReadFile(filepath.Join(s.server.erupeConfig.BinPath, fmt.Sprintf("questlists/list_%d.bin", pkt.QuestList)))
+		if err != nil {
+			s.logger.Warn("Missing quest list file", zap.Uint16("offset", pkt.QuestList), zap.Error(err))
+			stubEnumerateNoResults(s, pkt.AckHandle)
+		} else {
+			doAckBufSucceed(s, pkt.AckHandle, data)
+		}
 	}
 	// Update the client's rights as well:
 	updateRights(s)
+	return nil
 }
 
-func handleMsgMhfEnterTournamentQuest(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfEnterTournamentQuest(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetUdBonusQuestInfo(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetUdBonusQuestInfo(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetUdBonusQuestInfo)
 
 	udBonusQuestInfos := []struct {
@@ -100,4 +110,5 @@ func handleMsgMhfGetUdBonusQuestInfo(s *Session, p mhfpacket.MHFPacket) {
 	}
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
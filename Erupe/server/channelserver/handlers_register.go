@@ -4,15 +4,40 @@ import (
 	"encoding/hex"
 	"erupe-ce/common/byteframe"
 	"erupe-ce/network/mhfpacket"
+	"erupe-ce/server/bus"
+	"go.uber.org/zap"
 )
 
-func handleMsgSysOperateRegister(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysOperateRegister(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysOperateRegister)
-	bf := byteframe.NewByteFrameFromBytes(pkt.RawDataPayload)
-	s.server.raviente.Lock()
-	switch pkt.RegisterID {
+	var resp *byteframe.ByteFrame
+	var damage, support uint32
+	d, err := s.server.raviente.update(Time_Current_Adjusted(), func(d *ravienteData) bool {
+		if d.hostID == 0 {
+			d.hostID = s.server.ID
+		}
+		resp, damage, support = operateRegister(d, pkt.RegisterID, pkt.RawDataPayload)
+		return true
+	})
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to update Raviente registers", err)
+	}
+	if resp != nil {
+		doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	}
+	s.server.raviente.logContribution(d.siege, s.charID, damage, support)
+	s.notifyall()
+	return nil
+}
+
+// operateRegister applies the operations of a register update to d, returning
+// the answer to send, nil for an unknown register, and the damage and support
+// contributed.
+func operateRegister(d *ravienteData, registerID uint32, payload []byte) (resp *byteframe.ByteFrame, damage uint32, support uint32) {
+	bf := byteframe.NewByteFrameFromBytes(payload)
+	switch registerID {
 	case 786461:
-		resp := byteframe.NewByteFrame()
+		resp = byteframe.NewByteFrame()
 		size := 6
 		for i := 0; i < len(bf.Data())-1; i += size {
 			op := bf.ReadUint8()
@@ -24,21 +49,21 @@ func handleMsgSysOperateRegister(s *Session, p mhfpacket.MHFPacket) {
 				case 0:
 					resp.WriteUint32(0)
 					resp.WriteUint32(data)
-					s.server.raviente.register.nextTime = data
+					d.register.nextTime = data
 				case 1:
 					resp.WriteUint32(0)
 					resp.WriteUint32(data)
-					s.server.raviente.register.startTime = data
+					d.register.startTime = data
 				case 2:
 					resp.WriteUint32(0)
 					resp.WriteUint32(data)
-					s.server.raviente.register.killedTime = data
+					d.register.killedTime = data
 				case 3:
 					resp.WriteUint32(0)
 					resp.WriteUint32(data)
-					s.server.raviente.register.postTime = data
+					d.register.postTime = data
 				case 4:
-					ref := &s.server.raviente.register.register[0]
+					ref := &d.register.register[0]
 					switch op {
 						case 2:
 							resp.WriteUint32(*ref)
@@ -55,9 +80,9 @@ func handleMsgSysOperateRegister(s *Session, p mhfpacket.MHFPacket) {
 				case 5:
 					resp.WriteUint32(0)
 					resp.WriteUint32(data)
-					s.server.raviente.register.carveQuest = data
+					d.register.carveQuest = data
 				case 6:
-					ref := &s.server.raviente.register.register[1]
+					ref := &d.register.register[1]
 					switch op {
 						case 2:
 							resp.WriteUint32(*ref)
@@ -72,7 +97,7 @@ func handleMsgSysOperateRegister(s *Session, p mhfpacket.MHFPacket) {
 							resp.WriteUint32(data)
 					}
 				case 7:
-					ref := &s.server.raviente.register.register[2]
+					ref := &d.register.register[2]
 					switch op {
 						case 2:
 							resp.WriteUint32(*ref)
@@ -87,7 +112,7 @@ func handleMsgSysOperateRegister(s *Session, p mhfpacket.MHFPacket) {
 							resp.WriteUint32(data)
 					}
 				case 8:
-					ref := &s.server.raviente.register.register[3]
+					ref := &d.register.register[3]
 					switch op {
 						case 2:
 							resp.WriteUint32(*ref)
@@ -104,13 +129,13 @@ func handleMsgSysOperateRegister(s *Session, p mhfpacket.MHFPacket) {
 				case 9:
 					resp.WriteUint32(0)
 					resp.WriteUint32(data)
-					s.server.raviente.register.maxPlayers = data
+					d.register.maxPlayers = data
 				case 10:
 					resp.WriteUint32(0)
 					resp.WriteUint32(data)
-					s.server.raviente.register.ravienteType = data
+					d.register.ravienteType = data
 				case 11:
-					ref := &s.server.raviente.register.register[4]
+					ref := &d.register.register[4]
 					switch op {
 						case 2:
 							resp.WriteUint32(*ref)
@@ -130,10 +155,9 @@ func handleMsgSysOperateRegister(s *Session, p mhfpacket.MHFPacket) {
 			}
 		}
 		resp.WriteUint8(0)
-		doAckBufSucceed(s, pkt.AckHandle, resp.Data())
 
 	case 917533:
-		resp := byteframe.NewByteFrame()
+		resp = byteframe.NewByteFrame()
 		size := 6
 		for i := 0; i < len(bf.Data())-1; i += size {
 			op := bf.ReadUint8()
@@ -141,8 +165,13 @@ func handleMsgSysOperateRegister(s *Session, p mhfpacket.MHFPacket) {
 			data := bf.ReadUint32()
 			resp.WriteUint8(1)
 			resp.WriteUint8(dest)
-			ref := &s.server.raviente.state.stateData[dest]
-			damageMultiplier := s.server.raviente.state.damageMultiplier
+			if int(dest) >= len(d.state.stateData) {
+				resp.WriteUint32(0)
+				resp.WriteUint32(0)
+				continue
+			}
+			ref := &d.state.stateData[dest]
+			damageMultiplier := d.state.damageMultiplier
 			switch op {
 			case 2:
 				resp.WriteUint32(*ref)
@@ -159,6 +188,7 @@ func handleMsgSysOperateRegister(s *Session, p mhfpacket.MHFPacket) {
 				} else {
 					resp.WriteUint32(*ref + data * damageMultiplier)
 					*ref += data * damageMultiplier
+					damage += data * damageMultiplier
 				}
 			case 13:
 				fallthrough
@@ -169,10 +199,9 @@ func handleMsgSysOperateRegister(s *Session, p mhfpacket.MHFPacket) {
 			}
 		}
 		resp.WriteUint8(0)
-		doAckBufSucceed(s, pkt.AckHandle, resp.Data())
 
 	case 851997:
-		resp := byteframe.NewByteFrame()
+		resp = byteframe.NewByteFrame()
 		size := 6
 		for i := 0; i < len(bf.Data())-1; i += size {
 			op := bf.ReadUint8()
@@ -180,12 +209,18 @@ func handleMsgSysOperateRegister(s *Session, p mhfpacket.MHFPacket) {
 			data := bf.ReadUint32()
 			resp.WriteUint8(1)
 			resp.WriteUint8(dest)
-			ref := &s.server.raviente.support.supportData[dest]
+			if int(dest) >= len(d.support.supportData) {
+				resp.WriteUint32(0)
+				resp.WriteUint32(0)
+				continue
+			}
+			ref := &d.support.supportData[dest]
 			switch op {
 			case 2:
 				resp.WriteUint32(*ref)
 				resp.WriteUint32(*ref + data)
 				*ref += data
+				support += data
 			case 13:
 				fallthrough
 			case 14:
@@ -195,14 +230,16 @@ func handleMsgSysOperateRegister(s *Session, p mhfpacket.MHFPacket) {
 			}
 		}
 		resp.WriteUint8(0)
-		doAckBufSucceed(s, pkt.AckHandle, resp.Data())
 	}
-	s.notifyall()
-	s.server.raviente.Unlock()
+	return resp, damage, support
 }
 
-func handleMsgSysLoadRegister(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysLoadRegister(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysLoadRegister)
+	d, err := s.server.raviente.view(Time_Current_Adjusted())
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to load Raviente registers", err)
+	}
 	r := pkt.Unk1
 	switch r {
 		case 12:
@@ -216,24 +253,24 @@ func handleMsgSysLoadRegister(s *Session, p mhfpacket.MHFPacket) {
 			resp := byteframe.NewByteFrame()
 			resp.WriteUint8(0)
 			resp.WriteUint8(12)
-			resp.WriteUint32(s.server.raviente.register.nextTime)
-			resp.WriteUint32(s.server.raviente.register.startTime)
-			resp.WriteUint32(s.server.raviente.register.killedTime)
-			resp.WriteUint32(s.server.raviente.register.postTime)
-			resp.WriteUint32(s.server.raviente.register.register[0])
-			resp.WriteUint32(s.server.raviente.register.carveQuest)
-			resp.WriteUint32(s.server.raviente.register.register[1])
-			resp.WriteUint32(s.server.raviente.register.register[2])
-			resp.WriteUint32(s.server.raviente.register.register[3])
-			resp.WriteUint32(s.server.raviente.register.maxPlayers)
-			resp.WriteUint32(s.server.raviente.register.ravienteType)
-			resp.WriteUint32(s.server.raviente.register.register[4])
+			resp.WriteUint32(d.register.nextTime)
+			resp.WriteUint32(d.register.startTime)
+			resp.WriteUint32(d.register.killedTime)
+			resp.WriteUint32(d.register.postTime)
+			resp.WriteUint32(d.register.register[0])
+			resp.WriteUint32(d.register.carveQuest)
+			resp.WriteUint32(d.register.register[1])
+			resp.WriteUint32(d.register.register[2])
+			resp.WriteUint32(d.register.register[3])
+			resp.WriteUint32(d.register.maxPlayers)
+			resp.WriteUint32(d.register.ravienteType)
+			resp.WriteUint32(d.register.register[4])
 			doAckBufSucceed(s, pkt.AckHandle, resp.Data())
 		case 29:
 			resp := byteframe.NewByteFrame()
 			resp.WriteUint8(0)
 			resp.WriteUint8(29)
-			for _, v := range s.server.raviente.state.stateData {
+			for _, v := range d.state.stateData {
 				resp.WriteUint32(v)
 			}
 			doAckBufSucceed(s, pkt.AckHandle, resp.Data())
@@ -241,11 +278,12 @@ func handleMsgSysLoadRegister(s *Session, p mhfpacket.MHFPacket) {
 			resp := byteframe.NewByteFrame()
 			resp.WriteUint8(0)
 			resp.WriteUint8(25)
-			for _, v := range s.server.raviente.support.supportData {
+			for _, v := range d.support.supportData {
 				resp.WriteUint32(v)
 			}
 			doAckBufSucceed(s, pkt.AckHandle, resp.Data())
 	}
+	return nil
 }
 
 // Unused
@@ -255,71 +293,84 @@ func (s *Session) notifyplayer() {
 	s.QueueSendNonBlocking([]byte{0x00, 0x3F, 0x00, 0x0C, 0x00, 0x1D})
 }
 
-func (s *Session) notifyall() {
-	if _, exists := s.server.semaphore["hs_l0u3B51J9k3"]; exists {
-		for session := range s.server.semaphore["hs_l0u3B51J9k3"].clients {
-			session.QueueSendNonBlocking([]byte{0x00, 0x3F, 0x00, 0x0C, 0x00, 0x1D})
-			session.QueueSendNonBlocking([]byte{0x00, 0x3F, 0x00, 0x0D, 0x00, 0x1D})
-			session.QueueSendNonBlocking([]byte{0x00, 0x3F, 0x00, 0x0E, 0x00, 0x1D})
-		}
-	} else if _, exists := s.server.semaphore["hs_l0u3B5129k3"]; exists {
-		for session := range s.server.semaphore["hs_l0u3B5129k3"].clients {
-			session.QueueSendNonBlocking([]byte{0x00, 0x3F, 0x00, 0x0C, 0x00, 0x1D})
-			session.QueueSendNonBlocking([]byte{0x00, 0x3F, 0x00, 0x0D, 0x00, 0x1D})
-			session.QueueSendNonBlocking([]byte{0x00, 0x3F, 0x00, 0x0E, 0x00, 0x1D})
-		}
-	} else if _, exists := s.server.semaphore["hs_l0u3B512Ak3"]; exists {
-		for session := range s.server.semaphore["hs_l0u3B512Ak3"].clients {
-			session.QueueSendNonBlocking([]byte{0x00, 0x3F, 0x00, 0x0C, 0x00, 0x1D})
-			session.QueueSendNonBlocking([]byte{0x00, 0x3F, 0x00, 0x0D, 0x00, 0x1D})
-			session.QueueSendNonBlocking([]byte{0x00, 0x3F, 0x00, 0x0E, 0x00, 0x1D})
+// ravienteSemaphores are the semaphores of the Great Slaying participants.
+var ravienteSemaphores = []string{"hs_l0u3B51J9k3", "hs_l0u3B5129k3", "hs_l0u3B512Ak3"}
+
+// worldChannels returns the channels of this process sharing the Great Slaying of the session's channel.
+func (s *Session) worldChannels() []*Server {
+	var channels []*Server
+	for _, c := range s.server.Channels {
+		if WorldIndex(c.ID) == WorldIndex(s.server.ID) {
+			channels = append(channels, c)
 		}
 	}
+	if len(channels) == 0 {
+		channels = append(channels, s.server)
+	}
+	return channels
 }
 
-func checkRaviSemaphore(s *Session) bool {
-	if _, exists := s.server.semaphore["hs_l0u3B51J9k3"]; exists {
-		return true
-	} else if _, exists := s.server.semaphore["hs_l0u3B5129k3"]; exists {
-		return true
-	} else if _, exists := s.server.semaphore["hs_l0u3B512Ak3"]; exists {
-		return true
-	}
-	return false
+// notifyall tells every participant of the world to reload the registers,
+// through the bus as the world's channels may run in other processes.
+func (s *Session) notifyall() {
+	s.server.publish(bus.Message{Kind: bus.KindRaviente, ServerID: s.server.ID})
 }
 
-func releaseRaviSemaphore(s *Session) {
-	s.server.raviente.Lock()
-	if _, exists := s.server.semaphore["hs_l0u3B51J9k3"]; exists {
-		if len(s.server.semaphore["hs_l0u3B51J9k3"].reservedClientSlots) == 0 {
-			resetRavi(s)
+// notifyRaviente tells the Great Slaying participants of the channel to reload the registers.
+func (s *Server) notifyRaviente() {
+	s.semaphoreLock.RLock()
+	defer s.semaphoreLock.RUnlock()
+	for _, id := range ravienteSemaphores {
+		if semaphore, exists := s.semaphore[id]; exists {
+			for session := range semaphore.clients {
+				session.QueueSendNonBlocking([]byte{0x00, 0x3F, 0x00, 0x0C, 0x00, 0x1D})
+				session.QueueSendNonBlocking([]byte{0x00, 0x3F, 0x00, 0x0D, 0x00, 0x1D})
+				session.QueueSendNonBlocking([]byte{0x00, 0x3F, 0x00, 0x0E, 0x00, 0x1D})
+			}
+			break
 		}
 	}
-	if _, exists := s.server.semaphore["hs_l0u3B5129k3"]; exists {
-		if len(s.server.semaphore["hs_l0u3B5129k3"].reservedClientSlots) == 0 {
-			resetRavi(s)
+}
+
+// checkRaviSemaphore tells whether anyone joined the Great Slaying in the
+// world, on a channel of this process or on the channel hosting it.
+func checkRaviSemaphore(s *Session) bool {
+	for _, c := range s.worldChannels() {
+		c.semaphoreLock.RLock()
+		for _, id := range ravienteSemaphores {
+			if _, exists := c.semaphore[id]; exists {
+				c.semaphoreLock.RUnlock()
+				return true
+			}
 		}
+		c.semaphoreLock.RUnlock()
 	}
-	if _, exists := s.server.semaphore["hs_l0u3B512Ak3"]; exists {
-		if len(s.server.semaphore["hs_l0u3B512Ak3"].reservedClientSlots) == 0 {
-			resetRavi(s)
-		}
+	d, err := s.server.raviente.view(Time_Current_Adjusted())
+	if err != nil {
+		s.logger.Error("Failed to load Raviente state", zap.Error(err))
+		return false
 	}
-	s.server.raviente.Unlock()
+	return d.hostID != 0
 }
 
-func resetRavi(s *Session) {
-	s.server.raviente.register.nextTime = 0
-	s.server.raviente.register.startTime = 0
-	s.server.raviente.register.killedTime = 0
-	s.server.raviente.register.postTime = 0
-	s.server.raviente.register.ravienteType = 0
-	s.server.raviente.register.maxPlayers = 0
-	s.server.raviente.register.carveQuest = 0
-	s.server.raviente.state.damageMultiplier = 1
-	s.server.raviente.register.register = []uint32{0, 0, 0, 0, 0}
-	s.server.raviente.state.stateData = []uint32{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
-	s.server.raviente.support.supportData = []uint32{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+// releaseRaviSemaphore resets the Great Slaying once everyone left it on
+// the channel hosting it, the channel's semaphores must be locked.
+func releaseRaviSemaphore(s *Session) {
+	_, err := s.server.raviente.update(Time_Current_Adjusted(), func(d *ravienteData) bool {
+		if d.hostID != 0 && d.hostID != s.server.ID {
+			return false
+		}
+		for _, id := range ravienteSemaphores {
+			if semaphore, exists := s.server.semaphore[id]; exists && len(semaphore.reservedClientSlots) == 0 {
+				d.reset()
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		s.logger.Error("Failed to reset Raviente", zap.Error(err))
+	}
 }
 
 // Unused
@@ -334,4 +385,4 @@ func (s *Session) notifyticker() {
 	}
 }
 
-func handleMsgSysNotifyRegister(s *Session, p mhfpacket.MHFPacket) {}
\ No newline at end of file
+func handleMsgSysNotifyRegister(s *Session, p mhfpacket.MHFPacket) error { return nil }
\ No newline at end of file
@@ -6,27 +6,27 @@ import (
 
 	"erupe-ce/network/mhfpacket"
 	"erupe-ce/common/byteframe"
-	"go.uber.org/zap"
 )
 
-func handleMsgMhfSaveRengokuData(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfSaveRengokuData(s *Session, p mhfpacket.MHFPacket) error {
 	// saved every floor on road, holds values such as floors progressed, points etc.
 	// can be safely handled by the client
 	pkt := p.(*mhfpacket.MsgMhfSaveRengokuData)
 	_, err := s.server.db.Exec("UPDATE characters SET rengokudata=$1 WHERE id=$2", pkt.RawDataPayload, s.charID)
 	if err != nil {
-		s.logger.Fatal("Failed to update rengokudata savedata in db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to update rengokudata savedata in db", err)
 	}
 
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfLoadRengokuData(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfLoadRengokuData(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfLoadRengokuData)
 	var data []byte
 	err := s.server.db.QueryRow("SELECT rengokudata FROM characters WHERE id = $1", s.charID).Scan(&data)
 	if err != nil {
-		s.logger.Fatal("Failed to get rengokudata savedata from db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to get rengokudata savedata from db", err)
 	}
 	if len(data) > 0 {
 		doAckBufSucceed(s, pkt.AckHandle, data)
@@ -69,28 +69,32 @@ func handleMsgMhfLoadRengokuData(s *Session, p mhfpacket.MHFPacket) {
 
 		doAckBufSucceed(s, pkt.AckHandle, resp.Data())
 	}
+	return nil
 }
 
-func handleMsgMhfGetRengokuBinary(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetRengokuBinary(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetRengokuBinary)
 	// a (massively out of date) version resides in the game's /dat/ folder or up to date can be pulled from packets
 	data, err := ioutil.ReadFile(filepath.Join(s.server.erupeConfig.BinPath, "rengoku_data.bin"))
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
 }
 
-func handleMsgMhfEnumerateRengokuRanking(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateRengokuRanking(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateRengokuRanking)
 	doAckBufSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfGetRengokuRankingRank(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetRengokuRankingRank(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetRengokuRankingRank)
 
 	resp := byteframe.NewByteFrame()
 	resp.WriteBytes([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
@@ -7,43 +7,47 @@ import (
 	"erupe-ce/common/byteframe"
 )
 
-func handleMsgMhfGetAdditionalBeatReward(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetAdditionalBeatReward(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetAdditionalBeatReward)
 	// Actual response in packet captures are all just giant batches of null bytes
 	// I'm assuming this is because it used to be tied to an actual event and
 	// they never bothered killing off the packet when they made it static
 	doAckBufSucceed(s, pkt.AckHandle, make([]byte, 0x104))
+	return nil
 }
 
-func handleMsgMhfGetUdRankingRewardList(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetUdRankingRewardList(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetUdRankingRewardList)
 	// Temporary canned response
 	data, _ := hex.DecodeString("0100001600000A5397DF00000000000000000000000000000000")
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
 }
 
-func handleMsgMhfGetRewardSong(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetRewardSong(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetRewardSong)
 	// Temporary canned response
 	data, _ := hex.DecodeString("0100001600000A5397DF00000000000000000000000000000000")
 	doAckBufSucceed(s, pkt.AckHandle, data)
+	return nil
 }
 
-func handleMsgMhfUseRewardSong(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfUseRewardSong(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfAddRewardSongCount(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfAddRewardSongCount(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfAcquireMonthlyReward(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfAcquireMonthlyReward(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfAcquireMonthlyReward)
 
 	resp := byteframe.NewByteFrame()
 	resp.WriteUint32(0)
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfAcceptReadReward(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfAcceptReadReward(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetBreakSeibatuLevelReward(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGetBreakSeibatuLevelReward(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetWeeklySeibatuRankingReward(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGetWeeklySeibatuRankingReward(s *Session, p mhfpacket.MHFPacket) error { return nil }
@@ -18,12 +18,13 @@ func removeSessionFromSemaphore(s *Session) {
 	s.server.semaphoreLock.Unlock()
 }
 
-func handleMsgSysCreateSemaphore(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysCreateSemaphore(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysCreateSemaphore)
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x03, 0x00, 0x0d})
+	return nil
 }
 
-func handleMsgSysDeleteSemaphore(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysDeleteSemaphore(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysDeleteSemaphore)
 	sem := pkt.AckHandle
 	if s.server.semaphore != nil {
@@ -75,9 +76,10 @@ func handleMsgSysDeleteSemaphore(s *Session, p mhfpacket.MHFPacket) {
 		}
 		s.server.semaphoreLock.Unlock()
 	}
+	return nil
 }
 
-func handleMsgSysCreateAcquireSemaphore(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysCreateAcquireSemaphore(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysCreateAcquireSemaphore)
 	SemaphoreID := pkt.SemaphoreID
 
@@ -130,18 +132,23 @@ func handleMsgSysCreateAcquireSemaphore(s *Session, p mhfpacket.MHFPacket) {
 	} else {
 		doAckSimpleFail(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
 	}
+	return nil
 }
 
-func handleMsgSysAcquireSemaphore(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysAcquireSemaphore(s *Session, p mhfpacket.MHFPacket) error {
 	//pkt := p.(*mhfpacket.MsgSysAcquireSemaphore)
+	return nil
 }
 
-func handleMsgSysReleaseSemaphore(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysReleaseSemaphore(s *Session, p mhfpacket.MHFPacket) error {
 	//pkt := p.(*mhfpacket.MsgSysReleaseSemaphore)
+	s.server.semaphoreLock.Lock()
 	releaseRaviSemaphore(s)
+	s.server.semaphoreLock.Unlock()
+	return nil
 }
 
-func handleMsgSysCheckSemaphore(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysCheckSemaphore(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysCheckSemaphore)
 	resp := []byte{0x00, 0x00, 0x00, 0x00}
 	s.server.semaphoreLock.Lock()
@@ -150,4 +157,5 @@ func handleMsgSysCheckSemaphore(s *Session, p mhfpacket.MHFPacket) {
 	}
 	s.server.semaphoreLock.Unlock()
 	doAckSimpleSucceed(s, pkt.AckHandle, resp)
+	return nil
 }
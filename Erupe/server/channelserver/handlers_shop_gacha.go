@@ -11,7 +11,6 @@ import (
 	"erupe-ce/network/mhfpacket"
 	"github.com/lib/pq"
 	"github.com/sachaos/lottery"
-	"go.uber.org/zap"
 )
 
 func contains(s []string, str string) bool {
@@ -24,7 +23,7 @@ func contains(s []string, str string) bool {
 	return false
 }
 
-func handleMsgMhfEnumerateShop(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfEnumerateShop(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfEnumerateShop)
 	// SHOP TYPES:
 	// 01 = Running Gachas, 02 = actual gacha, 04 = N Points, 05 = GCP, 07 = Item to GCP, 08 = Diva Defense, 10 = Hunter's Road
@@ -54,7 +53,7 @@ func handleMsgMhfEnumerateShop(s *Session, p mhfpacket.MHFPacket) {
 	if pkt.ShopType == 2 {
 		shopEntries, err := s.server.db.Query("SELECT entryType, itemhash, currType, currNumber, currQuant, percentage, rarityIcon, rollsCount, itemCount, dailyLimit, itemType, itemId, quantity FROM gacha_shop_items WHERE shophash=$1", pkt.ShopID)
 		if err != nil {
-			panic(err)
+			return errBufFail(pkt.AckHandle, "", err)
 		}
 		var entryType, currType, rarityIcon, rollsCount, itemCount, dailyLimit byte
 		var currQuant, currNumber, percentage uint16
@@ -67,7 +66,7 @@ func handleMsgMhfEnumerateShop(s *Session, p mhfpacket.MHFPacket) {
 		for shopEntries.Next() {
 			err = shopEntries.Scan(&entryType, &itemhash, &currType, &currNumber, &currQuant, &percentage, &rarityIcon, &rollsCount, &itemCount, &dailyLimit, (*pq.Int64Array)(&itemType), (*pq.Int64Array)(&itemId), (*pq.Int64Array)(&quantity))
 			if err != nil {
-				panic(err)
+				return errBufFail(pkt.AckHandle, "", err)
 			}
 			resp.WriteUint8(entryType)
 			resp.WriteUint32(itemhash)
@@ -91,7 +90,7 @@ func handleMsgMhfEnumerateShop(s *Session, p mhfpacket.MHFPacket) {
 		}
 		if entryCount == 0 {
 			doAckBufSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
-			return
+			return nil
 		}
 		resp.Seek(4, 0)
 		resp.WriteUint16(uint16(entryCount))
@@ -100,7 +99,7 @@ func handleMsgMhfEnumerateShop(s *Session, p mhfpacket.MHFPacket) {
 		gachaCount := 0
 		shopEntries, err := s.server.db.Query("SELECT hash, reqGR, reqHR, gachaName, gachaLink0, gachaLink1, COALESCE(gachaLink2, ''), extraIcon, gachaType, hideFlag FROM gacha_shop")
 		if err != nil {
-			panic(err)
+			return errBufFail(pkt.AckHandle, "", err)
 		}
 		resp := byteframe.NewByteFrame()
 		resp.WriteUint32(0)
@@ -110,7 +109,7 @@ func handleMsgMhfEnumerateShop(s *Session, p mhfpacket.MHFPacket) {
 		for shopEntries.Next() {
 			err = shopEntries.Scan(&hash, &reqGR, &reqHR, &gachaName, &gachaLink0, &gachaLink1, &gachaLink2, &extraIcon, &gachaType, &hideFlag)
 			if err != nil {
-				panic(err)
+				return errBufFail(pkt.AckHandle, "", err)
 			}
 			resp.WriteUint32(uint32(hash))
 			resp.WriteUint32(0) // only 0 in known packets
@@ -166,7 +165,7 @@ func handleMsgMhfEnumerateShop(s *Session, p mhfpacket.MHFPacket) {
 		season := fmt.Sprintf("%d", week%4)
 		shopEntries, err := s.server.db.Query("SELECT itemhash,itemID,Points,TradeQuantity,rankReqLow,rankReqHigh,rankReqG,storeLevelReq,maximumQuantity,boughtQuantity,roadFloorsRequired,weeklyFatalisKills, COALESCE(enable_weeks, '') FROM normal_shop_items WHERE shoptype=$1 AND shopid=$2", pkt.ShopType, pkt.ShopID)
 		if err != nil {
-			panic(err)
+			return errBufFail(pkt.AckHandle, "", err)
 		}
 		var ItemHash, entryCount int
 		var itemID, Points, TradeQuantity, rankReqLow, rankReqHigh, rankReqG, storeLevelReq, maximumQuantity, boughtQuantity, roadFloorsRequired, weeklyFatalisKills, charQuantity uint16
@@ -176,7 +175,7 @@ func handleMsgMhfEnumerateShop(s *Session, p mhfpacket.MHFPacket) {
 		for shopEntries.Next() {
 			err = shopEntries.Scan(&ItemHash, &itemID, &Points, &TradeQuantity, &rankReqLow, &rankReqHigh, &rankReqG, &storeLevelReq, &maximumQuantity, &boughtQuantity, &roadFloorsRequired, &weeklyFatalisKills, &itemWeeks)
 			if err != nil {
-				panic(err)
+				return errBufFail(pkt.AckHandle, "", err)
 			}
 
 			if len(itemWeeks) > 0 && !contains(strings.Split(itemWeeks, ","), season) {
@@ -200,7 +199,9 @@ func handleMsgMhfEnumerateShop(s *Session, p mhfpacket.MHFPacket) {
 				if err != nil {
 					resp.WriteUint16(0)
 				} else if pkt.ShopID == 7 && itemWeek >= 0 && itemWeek != week {
-					clearShopItemState(s, s.charID, uint32(ItemHash))
+					if err = clearShopItemState(s, s.charID, uint32(ItemHash)); err != nil {
+						return errBufFail(pkt.AckHandle, "Failed to delete shop_item_state in db", err)
+					}
 					resp.WriteUint16(0)
 				} else {
 					resp.WriteUint16(charQuantity)
@@ -214,16 +215,17 @@ func handleMsgMhfEnumerateShop(s *Session, p mhfpacket.MHFPacket) {
 		}
 		if entryCount == 0 {
 			doAckBufSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
-			return
+			return nil
 		}
 		resp.Seek(0, 0)
 		resp.WriteUint16(uint16(entryCount))
 		resp.WriteUint16(uint16(entryCount))
 		doAckBufSucceed(s, pkt.AckHandle, resp.Data())
 	}
+	return nil
 }
 
-func handleMsgMhfAcquireExchangeShop(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfAcquireExchangeShop(s *Session, p mhfpacket.MHFPacket) error {
 	_, week := time.Now().ISOWeek()
 	// writing out to an editable shop enumeration
 	pkt := p.(*mhfpacket.MsgMhfAcquireExchangeShop)
@@ -237,26 +239,26 @@ func handleMsgMhfAcquireExchangeShop(s *Session, p mhfpacket.MHFPacket) {
   														 DO UPDATE SET usedquantity = shop_item_state.usedquantity + $3
   														 WHERE EXCLUDED.char_id=$1 AND EXCLUDED.itemhash=$2`, s.charID, itemHash, buyCount, week)
 		if err != nil {
-			s.logger.Fatal("Failed to update shop_item_state in db", zap.Error(err))
+			return errSimpleFail(pkt.AckHandle, "Failed to update shop_item_state in db", err)
 		}
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func clearShopItemState(s *Session, charId uint32, itemHash uint32) {
+func clearShopItemState(s *Session, charId uint32, itemHash uint32) error {
 	_, err := s.server.db.Exec(`DELETE FROM shop_item_state WHERE char_id=$1 AND itemhash=$2`, charId, itemHash)
-	if err != nil {
-		s.logger.Fatal("Failed to delete shop_item_state in db", zap.Error(err))
-	}
+	return err
 }
 
-func handleMsgMhfGetGachaPlayHistory(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetGachaPlayHistory(s *Session, p mhfpacket.MHFPacket) error {
 	// returns number of times the gacha was played, will need persistent db stuff
 	pkt := p.(*mhfpacket.MsgMhfGetGachaPlayHistory)
 	doAckBufSucceed(s, pkt.AckHandle, []byte{0x0A})
+	return nil
 }
 
-func handleMsgMhfGetGachaPoint(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetGachaPoint(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetGachaPoint)
 	var fp, gp, gt uint32
 	_ = s.server.db.QueryRow("SELECT COALESCE(frontier_points, 0), COALESCE(gacha_prem, 0), COALESCE(gacha_trial,0) FROM characters WHERE id=$1", s.charID).Scan(&fp, &gp, &gt)
@@ -266,6 +268,7 @@ func handleMsgMhfGetGachaPoint(s *Session, p mhfpacket.MHFPacket) {
 	resp.WriteUint32(fp) // Frontier Points?
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
 type gachaItem struct {
@@ -282,7 +285,7 @@ func (i gachaItem) Weight() int {
 	return int(i.percentage)
 }
 
-func handleMsgMhfPlayNormalGacha(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfPlayNormalGacha(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfPlayNormalGacha)
 	// needs to query db for input gacha and return a result or number of results
 	// uint8 number of results
@@ -298,7 +301,7 @@ func handleMsgMhfPlayNormalGacha(s *Session, p mhfpacket.MHFPacket) {
 	// get info for updating data and calculating costs
 	err := s.server.db.QueryRow("SELECT currType, currNumber, currQuant, rollsCount FROM gacha_shop_items WHERE shophash=$1 AND entryType=$2", pkt.GachaHash, pkt.RollType).Scan(&currType, &currNumber, &currQuant, &rollsCount)
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	// get existing items in storage if any
 	var data []byte
@@ -309,12 +312,12 @@ func handleMsgMhfPlayNormalGacha(s *Session, p mhfpacket.MHFPacket) {
 	// get gacha items and iterate through them for gacha roll
 	shopEntries, err := s.server.db.Query("SELECT itemhash, percentage, rarityIcon, itemCount, itemType, itemId, quantity FROM gacha_shop_items WHERE shophash=$1 AND entryType=100", pkt.GachaHash)
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	for shopEntries.Next() {
 		err = shopEntries.Scan(&itemhash, &percentage, &rarityIcon, &itemCount, (*pq.Int64Array)(&itemType), (*pq.Int64Array)(&itemId), (*pq.Int64Array)(&quantity))
 		if err != nil {
-			panic(err)
+			return errBufFail(pkt.AckHandle, "", err)
 		}
 		items = append(items, &gachaItem{itemhash: itemhash, percentage: percentage, rarityIcon: rarityIcon, itemCount: itemCount, itemType: itemType, itemId: itemId, quantity: quantity})
 	}
@@ -347,24 +350,26 @@ func handleMsgMhfPlayNormalGacha(s *Session, p mhfpacket.MHFPacket) {
 	data[0] = data[0] + results
 	_, err = s.server.db.Exec("UPDATE characters SET gacha_items = $1 WHERE id = $2", data, s.charID)
 	if err != nil {
-		s.logger.Fatal("Failed to update minidata in db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to update minidata in db", err)
 	}
 	// deduct gacha coins if relevant, items are handled fine by the standard savedata packet immediately afterwards
 	if currType == 19 {
 		_, err = s.server.db.Exec("UPDATE characters SET gacha_trial = CASE WHEN (gacha_trial > $1) then gacha_trial - $1 else gacha_trial end, gacha_prem = CASE WHEN NOT (gacha_trial > $1) then gacha_prem - $1 else gacha_prem end WHERE id=$2", currNumber, s.charID)
 	}
 	if err != nil {
-		s.logger.Fatal("Failed to update gacha_items in db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to update gacha_items in db", err)
 	}
+	return nil
 }
 
-func handleMsgMhfUseGachaPoint(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfUseGachaPoint(s *Session, p mhfpacket.MHFPacket) error {
 	// should write to database when that's set up
 	pkt := p.(*mhfpacket.MsgMhfUseGachaPoint)
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfExchangeFpoint2Item(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfExchangeFpoint2Item(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfExchangeFpoint2Item)
 
 	var itemValue, quant int
@@ -374,12 +379,13 @@ func handleMsgMhfExchangeFpoint2Item(s *Session, p mhfpacket.MHFPacket) {
 	// also update frontierpoints entry in database
 	_, err := s.server.db.Exec("UPDATE characters SET frontier_points=frontier_points::int - $1 WHERE id=$2", itemCost, s.charID)
 	if err != nil {
-		s.logger.Fatal("Failed to update minidata in db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to update minidata in db", err)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfExchangeItem2Fpoint(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfExchangeItem2Fpoint(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfExchangeItem2Fpoint)
 
 	var itemValue, quant int
@@ -388,12 +394,13 @@ func handleMsgMhfExchangeItem2Fpoint(s *Session, p mhfpacket.MHFPacket) {
 	// also update frontierpoints entry in database
 	_, err := s.server.db.Exec("UPDATE characters SET frontier_points=COALESCE(frontier_points::int + $1, $1) WHERE id=$2", itemCost, s.charID)
 	if err != nil {
-		s.logger.Fatal("Failed to update minidata in db", zap.Error(err))
+		return errSimpleFail(pkt.AckHandle, "Failed to update minidata in db", err)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgMhfGetFpointExchangeList(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetFpointExchangeList(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetFpointExchangeList)
 	//absurd, probably lists every single item to trade to FP?
 
@@ -402,7 +409,7 @@ func handleMsgMhfGetFpointExchangeList(s *Session, p mhfpacket.MHFPacket) {
 
 	buyRows, err := s.server.db.Query("SELECT hash,itemType,itemID,quant,itemValue FROM fpoint_items WHERE tradeType=0")
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	resp := byteframe.NewByteFrame()
 	resp.WriteUint32(0)
@@ -410,7 +417,7 @@ func handleMsgMhfGetFpointExchangeList(s *Session, p mhfpacket.MHFPacket) {
 	for buyRows.Next() {
 		err = buyRows.Scan(&hash, &itemType, &itemID, &quant, &itemValue)
 		if err != nil {
-			panic("Error in fpoint_items")
+			return errBufFail(pkt.AckHandle, "Error in fpoint_items", nil)
 		}
 		resp.WriteUint32(uint32(hash))
 		resp.WriteUint32(0) // this and following only 0 in known packets
@@ -424,12 +431,12 @@ func handleMsgMhfGetFpointExchangeList(s *Session, p mhfpacket.MHFPacket) {
 
 	sellRows, err := s.server.db.Query("SELECT hash,itemType,itemID,quant,itemValue FROM fpoint_items WHERE tradeType=1")
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	for sellRows.Next() {
 		err = sellRows.Scan(&hash, &itemType, &itemID, &quant, &itemValue)
 		if err != nil {
-			panic("Error in fpoint_items")
+			return errBufFail(pkt.AckHandle, "Error in fpoint_items", nil)
 		}
 		resp.WriteUint32(uint32(hash))
 		resp.WriteUint32(0) // this and following only 0 in known packets
@@ -445,9 +452,10 @@ func handleMsgMhfGetFpointExchangeList(s *Session, p mhfpacket.MHFPacket) {
 	resp.WriteUint16(uint16(buyables))
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfPlayStepupGacha(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfPlayStepupGacha(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfPlayStepupGacha)
 	results := byte(0)
 	stepResults := byte(0)
@@ -463,7 +471,7 @@ func handleMsgMhfPlayStepupGacha(s *Session, p mhfpacket.MHFPacket) {
 	// get info for updating data and calculating costs
 	err := s.server.db.QueryRow("SELECT currType, currNumber, currQuant, rollsCount, itemCount, itemType, itemId, quantity FROM gacha_shop_items WHERE shophash=$1 AND entryType=$2", pkt.GachaHash, pkt.RollType).Scan(&currType, &currNumber, &currQuant, &rollsCount, &itemCount, (*pq.Int64Array)(&itemType), (*pq.Int64Array)(&itemId), (*pq.Int64Array)(&quantity))
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	// get existing items in storage if any
 	var data []byte
@@ -483,12 +491,12 @@ func handleMsgMhfPlayStepupGacha(s *Session, p mhfpacket.MHFPacket) {
 	// get gacha items and iterate through them for gacha roll
 	shopEntries, err := s.server.db.Query("SELECT itemhash, percentage, rarityIcon, itemCount, itemType, itemId, quantity FROM gacha_shop_items WHERE shophash=$1 AND entryType=100", pkt.GachaHash)
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	for shopEntries.Next() {
 		err = shopEntries.Scan(&itemhash, &percentage, &rarityIcon, &itemCount, (*pq.Int64Array)(&itemType), (*pq.Int64Array)(&itemId), (*pq.Int64Array)(&quantity))
 		if err != nil {
-			panic(err)
+			return errBufFail(pkt.AckHandle, "", err)
 		}
 		items = append(items, &gachaItem{itemhash: itemhash, percentage: percentage, rarityIcon: rarityIcon, itemCount: itemCount, itemType: itemType, itemId: itemId, quantity: quantity})
 	}
@@ -521,7 +529,7 @@ func handleMsgMhfPlayStepupGacha(s *Session, p mhfpacket.MHFPacket) {
 	data[0] = data[0] + results + stepResults
 	_, err = s.server.db.Exec("UPDATE characters SET gacha_items = $1 WHERE id = $2", data, s.charID)
 	if err != nil {
-		s.logger.Fatal("Failed to update gacha_items in db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to update gacha_items in db", err)
 	}
 	// deduct gacha coins if relevant, items are handled fine by the standard savedata packet immediately afterwards
 	// reduce real if trial don't cover cost
@@ -533,23 +541,24 @@ func handleMsgMhfPlayStepupGacha(s *Session, p mhfpacket.MHFPacket) {
 
 	}
 	if err != nil {
-		s.logger.Fatal("Failed to update gacha_items in db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to update gacha_items in db", err)
 	}
 	// update step progression
 	_, err = s.server.db.Exec("UPDATE stepup_state SET step_progression = $1 WHERE char_id = $2", pkt.RollType+1, s.charID)
 	if err != nil {
-		s.logger.Fatal("Failed to update step_progression in db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to update step_progression in db", err)
 	}
 
+	return nil
 }
 
-func handleMsgMhfReceiveGachaItem(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfReceiveGachaItem(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfReceiveGachaItem)
 	// persistent for claimable items on cat
 	var data []byte
 	err := s.server.db.QueryRow("SELECT COALESCE(gacha_items, $2) FROM characters WHERE id = $1", s.charID, []byte{0x00}).Scan(&data)
 	if err != nil {
-		panic("Failed to get gacha_items")
+		return errBufFail(pkt.AckHandle, "Failed to get gacha_items", nil)
 	}
 	// limit of 36 items are returned
 	if data[0] > 36 {
@@ -562,7 +571,7 @@ func handleMsgMhfReceiveGachaItem(s *Session, p mhfpacket.MHFPacket) {
 		if pkt.Unk0 != 0x2401 {
 			_, err := s.server.db.Exec("UPDATE characters SET gacha_items = $2 WHERE id = $1", s.charID, saveData)
 			if err != nil {
-				s.logger.Fatal("Failed to update gacha_items in db", zap.Error(err))
+				return errBufFail(pkt.AckHandle, "Failed to update gacha_items in db", err)
 			}
 		}
 	} else {
@@ -570,20 +579,21 @@ func handleMsgMhfReceiveGachaItem(s *Session, p mhfpacket.MHFPacket) {
 		if pkt.Unk0 != 0x2401 {
 			_, err := s.server.db.Exec("UPDATE characters SET gacha_items = null WHERE id = $1", s.charID)
 			if err != nil {
-				s.logger.Fatal("Failed to update gacha_items in db", zap.Error(err))
+				return errBufFail(pkt.AckHandle, "Failed to update gacha_items in db", err)
 			}
 		}
 	}
+	return nil
 }
 
-func handleMsgMhfGetStepupStatus(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetStepupStatus(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetStepupStatus)
 	// get the reset time from db
 	var step_progression int
 	var step_time time.Time
 	err := s.server.db.QueryRow(`SELECT COALESCE(step_progression, 0), COALESCE(step_time, $1) FROM stepup_state WHERE char_id = $2 AND shophash = $3`, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), s.charID, pkt.GachaHash).Scan(&step_progression, &step_time)
 	if err != nil {
-		s.logger.Fatal("Failed to Select coalesce in db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to Select coalesce in db", err)
 	}
 
 	// calculate next midday
@@ -602,19 +612,21 @@ func handleMsgMhfGetStepupStatus(s *Session, p mhfpacket.MHFPacket) {
 														 DO UPDATE SET step_progression=$2, step_time=$3
 														 WHERE EXCLUDED.char_id=$4 AND EXCLUDED.shophash=$1`, pkt.GachaHash, step_progression, midday, s.charID)
 	if err != nil {
-		s.logger.Fatal("Failed to update platedata savedata in db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to update platedata savedata in db", err)
 	}
 	resp := byteframe.NewByteFrame()
 	resp.WriteUint8(uint8(step_progression))
 	resp.WriteUint32(uint32(time.Now().In(time.FixedZone("UTC+9", 9*60*60)).Unix()))
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfPlayFreeGacha(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfPlayFreeGacha(s *Session, p mhfpacket.MHFPacket) error {
 	// not sure this is used anywhere, free gachas use the MSG_MHF_PLAY_NORMAL_GACHA method in captures
+	return nil
 }
 
-func handleMsgMhfGetBoxGachaInfo(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfGetBoxGachaInfo(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfGetBoxGachaInfo)
 	count := 0
 	var used_itemhash pq.Int64Array
@@ -631,9 +643,10 @@ func handleMsgMhfGetBoxGachaInfo(s *Session, p mhfpacket.MHFPacket) {
 	resp.Seek(0, 0)
 	resp.WriteUint8(uint8(count))
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgMhfPlayBoxGacha(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfPlayBoxGacha(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfPlayBoxGacha)
 	// needs to query db for input gacha and return a result or number of results
 	// uint8 number of results
@@ -649,7 +662,7 @@ func handleMsgMhfPlayBoxGacha(s *Session, p mhfpacket.MHFPacket) {
 	// get info for updating data and calculating costs
 	err := s.server.db.QueryRow("SELECT currType, currNumber, currQuant, rollsCount FROM gacha_shop_items WHERE shophash=$1 AND entryType=$2", pkt.GachaHash, pkt.RollType).Scan(&currType, &currNumber, &currQuant, &rollsCount)
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	// get existing items in storage if any
 	var data []byte
@@ -665,12 +678,12 @@ func handleMsgMhfPlayBoxGacha(s *Session, p mhfpacket.MHFPacket) {
 																				 FROM gacha_shop_items gsi JOIN lucky_box_state lbs ON gsi.itemhash = ANY(lbs.used_itemhash)
 																				 WHERE lbs.char_id=$2`, pkt.GachaHash, s.charID)
 	if err != nil {
-		panic(err)
+		return errBufFail(pkt.AckHandle, "", err)
 	}
 	for shopEntries.Next() {
 		err = shopEntries.Scan(&itemhash, &percentage, &rarityIcon, &itemCount, (*pq.Int64Array)(&itemType), (*pq.Int64Array)(&itemId), (*pq.Int64Array)(&quantity))
 		if err != nil {
-			panic(err)
+			return errBufFail(pkt.AckHandle, "", err)
 		}
 		items = append(items, &gachaItem{itemhash: itemhash, percentage: percentage, rarityIcon: rarityIcon, itemCount: itemCount, itemType: itemType, itemId: itemId, quantity: quantity})
 	}
@@ -707,7 +720,7 @@ func handleMsgMhfPlayBoxGacha(s *Session, p mhfpacket.MHFPacket) {
 	data[0] = data[0] + results
 	_, err = s.server.db.Exec("UPDATE characters SET gacha_items = $1 WHERE id = $2", data, s.charID)
 	if err != nil {
-		s.logger.Fatal("Failed to update gacha_items in db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to update gacha_items in db", err)
 	}
 	// update lucky_box_state
 	_, err = s.server.db.Exec(`	INSERT INTO lucky_box_state (char_id, shophash, used_itemhash)
@@ -715,7 +728,7 @@ func handleMsgMhfPlayBoxGacha(s *Session, p mhfpacket.MHFPacket) {
 								DO UPDATE SET used_itemhash = COALESCE(lucky_box_state.used_itemhash::int[] || $3::int[], $3::int[])
 								WHERE EXCLUDED.char_id=$1 AND EXCLUDED.shophash=$2`, s.charID, pkt.GachaHash, usedItemHash)
 	if err != nil {
-		s.logger.Fatal("Failed to update lucky box state in db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to update lucky box state in db", err)
 	}
 	// deduct gacha coins if relevant, items are handled fine by the standard savedata packet immediately afterwards
 	if currType == 19 {
@@ -724,15 +737,17 @@ func handleMsgMhfPlayBoxGacha(s *Session, p mhfpacket.MHFPacket) {
 									WHERE id=$2`, currNumber, s.charID)
 	}
 	if err != nil {
-		s.logger.Fatal("Failed to update gacha_trial in db", zap.Error(err))
+		return errBufFail(pkt.AckHandle, "Failed to update gacha_trial in db", err)
 	}
+	return nil
 }
 
-func handleMsgMhfResetBoxGachaInfo(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfResetBoxGachaInfo(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfResetBoxGachaInfo)
 	_, err := s.server.db.Exec("DELETE FROM lucky_box_state WHERE shophash=$1 AND char_id=$2", pkt.GachaHash, s.charID)
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
\ No newline at end of file
@@ -9,7 +9,7 @@ import (
 	"go.uber.org/zap"
 )
 
-func handleMsgSysCreateStage(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysCreateStage(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysCreateStage)
 	s.server.Lock()
 	defer s.server.Unlock()
@@ -21,9 +21,10 @@ func handleMsgSysCreateStage(s *Session, p mhfpacket.MHFPacket) {
 		s.server.stages[stage.id] = stage
 		doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
 	}
+	return nil
 }
 
-func handleMsgSysStageDestruct(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysStageDestruct(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
 func doStageTransfer(s *Session, ackHandle uint32, stageID string) {
 	s.server.Lock()
@@ -54,6 +55,7 @@ func doStageTransfer(s *Session, ackHandle uint32, stageID string) {
 	s.stageID = string(stageID)
 	s.stage = s.server.stages[stageID]
 	s.Unlock()
+	s.server.publishPresence(s, true)
 
 	// Tell the client to cleanup its current stage objects.
 	s.QueueSendMHF(&mhfpacket.MsgSysCleanupObject{})
@@ -137,7 +139,7 @@ func removeSessionFromStage(s *Session) {
 	removeEmptyStages(s)
 }
 
-func handleMsgSysEnterStage(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysEnterStage(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysEnterStage)
 	// Push our current stage ID to the movement stack before entering another one.
 	s.Lock()
@@ -178,9 +180,10 @@ func handleMsgSysEnterStage(s *Session, p mhfpacket.MHFPacket) {
 		}
 	}
 	doStageTransfer(s, pkt.AckHandle, pkt.StageID)
+	return nil
 }
 
-func handleMsgSysBackStage(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysBackStage(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysBackStage)
 
 	// Transfer back to the saved stage ID before the previous move or enter.
@@ -189,13 +192,14 @@ func handleMsgSysBackStage(s *Session, p mhfpacket.MHFPacket) {
 	s.Unlock()
 
 	if err != nil {
-		panic(err)
+		return errSimpleFail(pkt.AckHandle, "", err)
 	}
 
 	doStageTransfer(s, pkt.AckHandle, backStage)
+	return nil
 }
 
-func handleMsgSysMoveStage(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysMoveStage(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysMoveStage)
 
 	// Push our current stage ID to the movement stack before entering another one.
@@ -204,17 +208,19 @@ func handleMsgSysMoveStage(s *Session, p mhfpacket.MHFPacket) {
 	s.Unlock()
 
 	doStageTransfer(s, pkt.AckHandle, pkt.StageID)
+	return nil
 }
 
-func handleMsgSysLeaveStage(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysLeaveStage(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysLockStage(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysLockStage(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysLockStage)
 	// TODO(Andoryuuta): What does this packet _actually_ do?
 	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	return nil
 }
 
-func handleMsgSysUnlockStage(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysUnlockStage(s *Session, p mhfpacket.MHFPacket) error {
 	s.reservationStage.RLock()
 	defer s.reservationStage.RUnlock()
 
@@ -222,16 +228,19 @@ func handleMsgSysUnlockStage(s *Session, p mhfpacket.MHFPacket) {
 
 	for charID := range s.reservationStage.reservedClientSlots {
 		session := s.server.FindSessionByCharID(charID)
-		session.QueueSendMHF(destructMessage)
+		if session != nil {
+			session.QueueSendMHF(destructMessage)
+		}
 	}
 
 	s.server.Lock()
 	defer s.server.Unlock()
 
 	delete(s.server.stages, s.reservationStage.id)
+	return nil
 }
 
-func handleMsgSysReserveStage(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysReserveStage(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysReserveStage)
 	if stage, exists := s.server.stages[pkt.StageID]; exists {
 		stage.Lock()
@@ -248,7 +257,7 @@ func handleMsgSysReserveStage(s *Session, p mhfpacket.MHFPacket) {
 			if len(stage.password) > 0 {
 				if stage.password != s.stagePass {
 					doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
-					return
+					return nil
 				}
 			}
 			stage.reservedClientSlots[s.charID] = false
@@ -264,9 +273,10 @@ func handleMsgSysReserveStage(s *Session, p mhfpacket.MHFPacket) {
 		s.logger.Error("Failed to get stage", zap.String("StageID", pkt.StageID))
 		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
 	}
+	return nil
 }
 
-func handleMsgSysUnreserveStage(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysUnreserveStage(s *Session, p mhfpacket.MHFPacket) error {
 	s.Lock()
 	stage := s.reservationStage
 	s.reservationStage = nil
@@ -278,9 +288,10 @@ func handleMsgSysUnreserveStage(s *Session, p mhfpacket.MHFPacket) {
 		}
 		stage.Unlock()
 	}
+	return nil
 }
 
-func handleMsgSysSetStagePass(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysSetStagePass(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysSetStagePass)
 	s.Lock()
 	stage := s.reservationStage
@@ -298,9 +309,10 @@ func handleMsgSysSetStagePass(s *Session, p mhfpacket.MHFPacket) {
 		s.stagePass = pkt.Password
 		s.Unlock()
 	}
+	return nil
 }
 
-func handleMsgSysSetStageBinary(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysSetStageBinary(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysSetStageBinary)
 	if stage, exists := s.server.stages[pkt.StageID]; exists {
 		stage.Lock()
@@ -309,9 +321,10 @@ func handleMsgSysSetStageBinary(s *Session, p mhfpacket.MHFPacket) {
 	} else {
 		s.logger.Warn("Failed to get stage", zap.String("StageID", pkt.StageID))
 	}
+	return nil
 }
 
-func handleMsgSysGetStageBinary(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysGetStageBinary(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysGetStageBinary)
 	if stage, exists := s.server.stages[pkt.StageID]; exists {
 		stage.Lock()
@@ -331,15 +344,16 @@ func handleMsgSysGetStageBinary(s *Session, p mhfpacket.MHFPacket) {
 		s.logger.Warn("Failed to get stage", zap.String("StageID", pkt.StageID))
 	}
 	s.logger.Debug("MsgSysGetStageBinary Done!")
+	return nil
 }
 
-func handleMsgSysWaitStageBinary(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysWaitStageBinary(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysWaitStageBinary)
 	if stage, exists := s.server.stages[pkt.StageID]; exists {
 		if pkt.BinaryType0 == 1 && pkt.BinaryType1 == 12 {
 			// This might contain the hunter count, or max player count?
 			doAckBufSucceed(s, pkt.AckHandle, []byte{0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
-			return
+			return nil
 		}
 		for {
 			s.logger.Debug("MsgSysWaitStageBinary before lock and get stage")
@@ -360,9 +374,10 @@ func handleMsgSysWaitStageBinary(s *Session, p mhfpacket.MHFPacket) {
 		s.logger.Warn("Failed to get stage", zap.String("StageID", pkt.StageID))
 	}
 	s.logger.Debug("MsgSysWaitStageBinary Done!")
+	return nil
 }
 
-func handleMsgSysEnumerateStage(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysEnumerateStage(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysEnumerateStage)
 
 	// Read-lock the server stage map.
@@ -406,4 +421,5 @@ func handleMsgSysEnumerateStage(s *Session, p mhfpacket.MHFPacket) {
 	bf.WriteBytes(resp.Data())
 
 	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
 }
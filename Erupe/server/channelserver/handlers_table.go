@@ -5,7 +5,7 @@ import (
 	"erupe-ce/network/mhfpacket"
 )
 
-type handlerFunc func(s *Session, p mhfpacket.MHFPacket)
+type handlerFunc func(s *Session, p mhfpacket.MHFPacket) error
 
 var handlerTable map[network.PacketID]handlerFunc
 
@@ -0,0 +1,149 @@
+package channelserver
+
+import (
+	"errors"
+	"time"
+
+	"erupe-ce/common/byteframe"
+	"erupe-ce/config"
+	"erupe-ce/network/mhfpacket"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// titleCount is the number of titles known by the client.
+const titleCount = 114
+
+// ErrUnknownTitle is returned when a title ID is past the titles known by the client.
+var ErrUnknownTitle = errors.New("unknown title")
+
+// Title is a guild card title unlocked by a character.
+type Title struct {
+	ID         uint16    `db:"title_id" json:"id"`
+	UnlockedAt time.Time `db:"unlocked_at" json:"unlocked_at"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ListTitles returns the titles unlocked by a character.
+func ListTitles(db *sqlx.DB, charID uint32) ([]Title, error) {
+	titles := make([]Title, 0)
+	err := db.Select(&titles, "SELECT title_id, unlocked_at, updated_at FROM titles WHERE char_id=$1 ORDER BY title_id", charID)
+	return titles, err
+}
+
+// GrantTitles unlocks titles for a character, bumping the update time of the
+// ones it already has.
+func GrantTitles(db *sqlx.DB, charID uint32, titleIDs []uint16) error {
+	for _, id := range titleIDs {
+		if id >= titleCount {
+			return ErrUnknownTitle
+		}
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, id := range titleIDs {
+		_, err = tx.Exec(`
+			INSERT INTO titles (char_id, title_id) VALUES ($1, $2)
+			ON CONFLICT (char_id, title_id) DO UPDATE SET updated_at = now()`,
+			charID, id,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RevokeTitles locks titles of a character again, all of them when titleIDs is empty.
+func RevokeTitles(db *sqlx.DB, charID uint32, titleIDs []uint16) error {
+	if len(titleIDs) == 0 {
+		_, err := db.Exec("DELETE FROM titles WHERE char_id=$1", charID)
+		return err
+	}
+	ids := make([]int64, len(titleIDs))
+	for i, id := range titleIDs {
+		ids[i] = int64(id)
+	}
+	_, err := db.Exec("DELETE FROM titles WHERE char_id=$1 AND title_id = ANY($2)", charID, pq.Int64Array(ids))
+	return err
+}
+
+// enumerateTitles lists the titles of a character as sent to the client,
+// every known title when unlockAll is set.
+func enumerateTitles(titles []Title, unlockAll bool) []Title {
+	if !unlockAll {
+		return titles
+	}
+	all := make([]Title, titleCount)
+	for i := range all {
+		all[i].ID = uint16(i)
+	}
+	for _, title := range titles {
+		if int(title.ID) < titleCount {
+			all[title.ID] = title
+		} else {
+			all = append(all, title)
+		}
+	}
+	return all
+}
+
+// achievementTitles returns the titles granted when an achievement reaches a rank.
+func achievementTitles(rewards []config.AchievementTitle, id uint8, rank uint8) []uint16 {
+	var titles []uint16
+	for _, reward := range rewards {
+		if reward.Achievement == id && reward.Rank == rank {
+			titles = append(titles, reward.Title)
+		}
+	}
+	return titles
+}
+
+func titleTimestamp(t time.Time) uint32 {
+	if t.IsZero() {
+		return 0
+	}
+	return uint32(t.Unix())
+}
+
+func handleMsgMhfEnumerateTitle(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfEnumerateTitle)
+	titles, err := ListTitles(s.server.db, s.charID)
+	if err != nil {
+		return errBufFail(pkt.AckHandle, "Failed to load titles", err)
+	}
+	titles = enumerateTitles(titles, s.server.erupeConfig.Titles.UnlockAll)
+	bf := byteframe.NewByteFrame()
+	bf.WriteUint16(uint16(len(titles)))
+	bf.WriteUint16(0) // Unk
+	for _, title := range titles {
+		bf.WriteUint16(title.ID)
+		bf.WriteUint16(0) // Unk
+		bf.WriteUint32(titleTimestamp(title.UnlockedAt))
+		bf.WriteUint32(titleTimestamp(title.UpdatedAt))
+	}
+	doAckBufSucceed(s, pkt.AckHandle, bf.Data())
+	return nil
+}
+
+func handleMsgMhfAcquireTitle(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfAcquireTitle)
+	err := GrantTitles(s.server.db, s.charID, pkt.TitleIDs)
+	if err == ErrUnknownTitle {
+		s.logger.Warn("Rejected unknown titles", zap.Uint32("charID", s.charID), zap.Any("titles", pkt.TitleIDs))
+		doAckSimpleFail(s, pkt.AckHandle, make([]byte, 4))
+		return nil
+	} else if err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to acquire titles", err)
+	}
+	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
+}
+
+// The layout of MsgMhfResetTitle is unknown, titles are revoked through the admin API instead.
+func handleMsgMhfResetTitle(s *Session, p mhfpacket.MHFPacket) error { return nil }
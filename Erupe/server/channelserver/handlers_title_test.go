@@ -0,0 +1,54 @@
+package channelserver
+
+import (
+	"testing"
+	"time"
+
+	"erupe-ce/config"
+)
+
+func TestEnumerateTitles(t *testing.T) {
+	unlocked := time.Unix(1600000000, 0)
+	titles := []Title{{ID: 3, UnlockedAt: unlocked, UpdatedAt: unlocked}}
+
+	if got := enumerateTitles(titles, false); len(got) != 1 || got[0].ID != 3 {
+		t.Errorf("enumerateTitles(locked) = %+v, expected only title 3", got)
+	}
+
+	got := enumerateTitles(titles, true)
+	if len(got) != titleCount {
+		t.Fatalf("enumerateTitles(unlockAll) returned %d titles, expected %d", len(got), titleCount)
+	}
+	for i, title := range got {
+		if int(title.ID) != i {
+			t.Errorf("title %d has ID %d", i, title.ID)
+		}
+	}
+	if !got[3].UnlockedAt.Equal(unlocked) || titleTimestamp(got[3].UnlockedAt) != 1600000000 {
+		t.Errorf("title 3 lost its unlock time: %+v", got[3])
+	}
+	if titleTimestamp(got[4].UnlockedAt) != 0 {
+		t.Errorf("title 4 has an unlock time: %+v", got[4])
+	}
+}
+
+func TestAchievementTitles(t *testing.T) {
+	rewards := []config.AchievementTitle{
+		{Achievement: 3, Rank: 8, Title: 10},
+		{Achievement: 3, Rank: 4, Title: 11},
+		{Achievement: 5, Rank: 8, Title: 12},
+	}
+	if got := achievementTitles(rewards, 3, 8); len(got) != 1 || got[0] != 10 {
+		t.Errorf("achievementTitles(3, 8) = %v, expected [10]", got)
+	}
+	if got := achievementTitles(rewards, 3, 7); len(got) != 0 {
+		t.Errorf("achievementTitles(3, 7) = %v, expected none", got)
+	}
+}
+
+func TestGrantUnknownTitles(t *testing.T) {
+	// Unknown titles are rejected before reaching the database.
+	if err := GrantTitles(nil, 1, []uint16{1, titleCount}); err != ErrUnknownTitle {
+		t.Errorf("GrantTitles(%d) = %v, expected ErrUnknownTitle", titleCount, err)
+	}
+}
@@ -2,8 +2,8 @@ package channelserver
 
 import "erupe-ce/network/mhfpacket"
 
-func handleMsgMhfInfoTournament(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfInfoTournament(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfEntryTournament(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfEntryTournament(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfAcquireTournament(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfAcquireTournament(s *Session, p mhfpacket.MHFPacket) error { return nil }
@@ -1,63 +1,317 @@
 package channelserver
 
 import (
+	"fmt"
+	"time"
+
+	"erupe-ce/common/byteframe"
+	"erupe-ce/common/stringsupport"
 	"erupe-ce/network/mhfpacket"
-    "encoding/hex"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
 )
 
-func handleMsgMhfGetTowerInfo(s *Session, p mhfpacket.MHFPacket) {
-	pkt := p.(*mhfpacket.MsgMhfGetTowerInfo)
-	var data []byte
-	var err error
-	/*
-		type:
-		1 == TOWER_RANK_POINT,
-		2 == GET_OWN_TOWER_SKILL
-		3 == ?
-		4 == TOWER_TOUHA_HISTORY
-		5 = ?
+const (
+	towerSkillCount   = 64
+	towerHistoryCount = 10
+	// towerSkillMaxLevel is above the level of any tower skill, it only stops
+	// a client from levelling a skill forever.
+	towerSkillMaxLevel = 40
+	// Gems come in gemCategories categories of gemsPerCategory gems.
+	gemCategories   = 6
+	gemsPerCategory = 5
+	gemHistoryLimit = 100
+)
+
+// towerData is the tower progress of a character.
+type towerData struct {
+	TR      int32         `db:"tr"`
+	TRP     int32         `db:"trp"`
+	TSP     int32         `db:"tsp"`
+	Block1  int32         `db:"block1"`
+	Block2  int32         `db:"block2"`
+	Skills  pq.Int64Array `db:"skills"`
+	History pq.Int64Array `db:"history"`
+	Gems    pq.Int64Array `db:"gems"`
+}
+
+// loadTower returns the tower progress of a character, creating it on first use.
+func loadTower(s *Session, charID uint32) (*towerData, error) {
+	_, err := s.server.db.Exec("INSERT INTO tower (char_id) VALUES ($1) ON CONFLICT DO NOTHING", charID)
+	if err != nil {
+		return nil, err
+	}
+	tower := &towerData{}
+	err = s.server.db.Get(tower, "SELECT tr, trp, tsp, block1, block2, skills, history, gems FROM tower WHERE char_id=$1", charID)
+	if err != nil {
+		return nil, err
+	}
+	return tower, nil
+}
 
-		[] = type
-		req
-		resp
+// arrayValue returns the value at i of a stored array, 0 past its end.
+func arrayValue(values pq.Int64Array, i int) int64 {
+	if i < len(values) {
+		return values[i]
+	}
+	return 0
+}
 
-		01 1d 01 fc 00 09 [00 00 00 01] 00 00 00 02 00 00 00 00
-		00 12 01 fc 00 09 01 00 00 18 0a 21 8e ad 00 00 00 00 00 00 00 00 00 00 00 01 00 00 00 00 00 00 00 00
+// recordClear adds the floors of a cleared block to the second block counter
+// and pushes them onto the history, keeping the latest towerHistoryCount clears.
+func (t *towerData) recordClear(floors int32) {
+	t.Block2 += floors
+	history := append(pq.Int64Array{int64(floors)}, t.History...)
+	if len(history) > towerHistoryCount {
+		history = history[:towerHistoryCount]
+	}
+	t.History = history
+}
+
+// validTowerProgress reports whether the points and floors of a progress or
+// clear update can only be earned.
+func validTowerProgress(pkt *mhfpacket.MsgMhfPostTowerInfo) bool {
+	return pkt.TRP >= 0 && pkt.Cost >= 0 && pkt.Block1 >= 0
+}
 
-		01 1d 01 fc 00 0a [00 00 00 02] 00 00 00 00 00 00 00 00
-		00 12 01 fc 00 0a 01 00 00 94 0a 21 8e ad 00 00 00 00 00 00 00 00 00 00 00 01 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00
+// recordTowerClear records a cleared block in a single transaction, so
+// concurrent clears can't drop each other's floors.
+func recordTowerClear(db *sqlx.DB, charID uint32, pkt *mhfpacket.MsgMhfPostTowerInfo) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-		01 1d 01 ff 00 0f [00 00 00 04] 00 00 00 00 00 00 00 00
-		00 12 01 ff 00 0f 01 00 00 24 0a 21 8e ad 00 00 00 00 00 00 00 00 00 00 00 01 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00
+	tower := &towerData{}
+	err = tx.Get(tower, "SELECT block2, history FROM tower WHERE char_id=$1 FOR UPDATE", charID)
+	if err != nil {
+		return err
+	}
+	tower.recordClear(pkt.Block1)
+	_, err = tx.Exec(`
+		UPDATE tower SET tr=$1, trp=trp+$2, tsp=tsp+$3, block2=$4, history=$5
+		WHERE char_id=$6`,
+		pkt.TR, pkt.TRP, pkt.Cost, tower.Block2, tower.History, charID,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
-		01 1d 01 fc 00 0b [00 00 00 05] 00 00 00 00 00 00 00 00
-		00 12 01 fc 00 0b 01 00 00 10 0a 21 8e ad 00 00 00 00 00 00 00 00 00 00 00 00
-	*/
-		switch pkt.InfoType {
-		case mhfpacket.TowerInfoTypeTowerRankPoint:
-			data, err = hex.DecodeString("0A218EAD0000000000000000000000010000000000000000")
-		case mhfpacket.TowerInfoTypeGetOwnTowerSkill:
-			//data, err = hex.DecodeString("0A218EAD000000000000000000000001000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000")
-			data, err = hex.DecodeString("0A218EAD0000000000000000000000010000001C0000000500050000000000020000000000000000000000000000000000030003000000000003000500050000000300030003000300030003000200030001000300020002000300010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000")
-		case mhfpacket.TowerInfoTypeUnk3:
-			panic("No known response values for TowerInfoTypeUnk3")
-		case mhfpacket.TowerInfoTypeTowerTouhaHistory:
-			data, err = hex.DecodeString("0A218EAD0000000000000000000000010000000000000000000000000000000000000000")
-		case mhfpacket.TowerInfoTypeUnk5:
-			data, err = hex.DecodeString("0A218EAD000000000000000000000000")
+// towerInfo builds the entries answering a tower info request.
+func towerInfo(tower *towerData, infoType uint32) []*byteframe.ByteFrame {
+	var entries []*byteframe.ByteFrame
+	switch infoType {
+	case mhfpacket.TowerInfoTypeTowerRankPoint:
+		bf := byteframe.NewByteFrame()
+		bf.WriteInt32(tower.TR)
+		bf.WriteInt32(tower.TRP)
+		entries = append(entries, bf)
+	case mhfpacket.TowerInfoTypeGetOwnTowerSkill:
+		bf := byteframe.NewByteFrame()
+		bf.WriteInt32(tower.TSP)
+		for i := 0; i < towerSkillCount; i++ {
+			bf.WriteInt16(int16(arrayValue(tower.Skills, i)))
+		}
+		entries = append(entries, bf)
+	case mhfpacket.TowerInfoTypeTowerTouhaHistory:
+		bf := byteframe.NewByteFrame()
+		for i := 0; i < towerHistoryCount; i++ {
+			bf.WriteInt16(int16(arrayValue(tower.History, i)))
 		}
+		entries = append(entries, bf)
+	case mhfpacket.TowerInfoTypeUnk3, mhfpacket.TowerInfoTypeUnk5:
+		for _, floors := range []int32{tower.Block1, tower.Block2} {
+			bf := byteframe.NewByteFrame()
+			bf.WriteInt32(floors)
+			bf.WriteInt32(0) // Unk
+			bf.WriteInt32(0) // Unk
+			bf.WriteInt32(0) // Unk
+			entries = append(entries, bf)
+		}
+	}
+	return entries
+}
 
+func handleMsgMhfGetTowerInfo(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfGetTowerInfo)
+	tower, err := loadTower(s, s.charID)
 	if err != nil {
-		stubGetNoResults(s, pkt.AckHandle)
+		return errBufFail(pkt.AckHandle, "Failed to load tower data", err)
 	}
-	doAckBufSucceed(s, pkt.AckHandle, data)
+	doAckEarthSucceed(s, pkt.AckHandle, towerInfo(tower, pkt.InfoType))
+	return nil
 }
 
-func handleMsgMhfPostTowerInfo(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgMhfPostTowerInfo(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgMhfPostTowerInfo)
-	doAckSimpleSucceed(s, pkt.AckHandle, []byte{0x00, 0x00, 0x00, 0x00})
+	if _, err := loadTower(s, s.charID); err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to load tower data", err)
+	}
+
+	switch pkt.InfoType {
+	case mhfpacket.PostTowerInfoTypeSkill:
+		if pkt.Skill < 0 || pkt.Skill >= towerSkillCount || pkt.Cost <= 0 {
+			return errSimpleFail(pkt.AckHandle, fmt.Sprintf("Invalid tower skill %d for %d TSP", pkt.Skill, pkt.Cost), nil)
+		}
+		// Arrays are 1-indexed, and the skill is only bought with enough TSP.
+		res, err := s.server.db.Exec(`
+			UPDATE tower SET skills[$1] = skills[$1] + 1, tsp = tsp - $2
+			WHERE char_id=$3 AND tsp >= $2 AND skills[$1] < $4`,
+			pkt.Skill+1, pkt.Cost, s.charID, towerSkillMaxLevel,
+		)
+		if err != nil {
+			return errSimpleFail(pkt.AckHandle, "Failed to level up tower skill", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return errSimpleFail(pkt.AckHandle, fmt.Sprintf("Not enough TSP or max level for tower skill %d", pkt.Skill), nil)
+		}
+	case mhfpacket.PostTowerInfoTypeProgress:
+		if !validTowerProgress(pkt) {
+			return errSimpleFail(pkt.AckHandle, fmt.Sprintf("Invalid tower progress %+v", pkt), nil)
+		}
+		_, err := s.server.db.Exec(`
+			UPDATE tower SET tr=$1, trp=trp+$2, tsp=tsp+$3, block1=block1+$4
+			WHERE char_id=$5`,
+			pkt.TR, pkt.TRP, pkt.Cost, pkt.Block1, s.charID,
+		)
+		if err != nil {
+			return errSimpleFail(pkt.AckHandle, "Failed to update tower progress", err)
+		}
+	case mhfpacket.PostTowerInfoTypeClear:
+		if !validTowerProgress(pkt) {
+			return errSimpleFail(pkt.AckHandle, fmt.Sprintf("Invalid tower clear %+v", pkt), nil)
+		}
+		if err := recordTowerClear(s.server.db, s.charID, pkt); err != nil {
+			return errSimpleFail(pkt.AckHandle, "Failed to record tower clear", err)
+		}
+	default:
+		s.logger.Debug("Unknown tower update", zap.Uint32("type", pkt.InfoType), zap.Any("pkt", pkt))
+	}
+	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
+}
+
+// gemID returns the gem stored at index i of the gems array.
+func gemID(i int) uint16 {
+	return uint16(i/gemsPerCategory)<<8 | uint16(i%gemsPerCategory+1)
+}
+
+// gemIndex returns where a gem is stored in the gems array.
+func gemIndex(gem int32) (int, bool) {
+	category, n := int(gem>>8), int(gem&0xFF)
+	if gem < 0 || category >= gemCategories || n < 1 || n > gemsPerCategory {
+		return 0, false
+	}
+	return category*gemsPerCategory + n - 1, true
+}
+
+type gemHistory struct {
+	Gem       uint16    `db:"gem"`
+	Message   uint16    `db:"message"`
+	CreatedAt time.Time `db:"created_at"`
+	Sender    string    `db:"sender"`
+}
+
+func handleMsgMhfGetGemInfo(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfGetGemInfo)
+	var entries []*byteframe.ByteFrame
+	switch pkt.InfoType {
+	case mhfpacket.GemInfoTypeGems:
+		tower, err := loadTower(s, s.charID)
+		if err != nil {
+			return errBufFail(pkt.AckHandle, "Failed to load gems", err)
+		}
+		for i := 0; i < gemCategories*gemsPerCategory; i++ {
+			bf := byteframe.NewByteFrame()
+			bf.WriteUint16(gemID(i))
+			bf.WriteUint16(uint16(arrayValue(tower.Gems, i)))
+			entries = append(entries, bf)
+		}
+	case mhfpacket.GemInfoTypeHistory:
+		var history []gemHistory
+		err := s.server.db.Select(&history, `
+			SELECT gh.gem, gh.message, gh.created_at, COALESCE(c.name, '') AS sender
+			FROM gem_history gh LEFT JOIN characters c ON c.id = gh.sender_id
+			WHERE gh.char_id=$1 ORDER BY gh.created_at DESC LIMIT $2`,
+			s.charID, gemHistoryLimit,
+		)
+		if err != nil {
+			return errBufFail(pkt.AckHandle, "Failed to load gem history", err)
+		}
+		for _, h := range history {
+			bf := byteframe.NewByteFrame()
+			bf.WriteUint16(h.Gem)
+			bf.WriteUint16(h.Message)
+			bf.WriteUint32(uint32(h.CreatedAt.Unix()))
+			bf.WriteBytes(stringsupport.PaddedString(h.Sender, 14, true))
+			entries = append(entries, bf)
+		}
+	}
+	doAckEarthSucceed(s, pkt.AckHandle, entries)
+	return nil
+}
+
+func handleMsgMhfPostGemInfo(s *Session, p mhfpacket.MHFPacket) error {
+	pkt := p.(*mhfpacket.MsgMhfPostGemInfo)
+	i, ok := gemIndex(pkt.Gem)
+	if !ok || pkt.Quantity <= 0 {
+		return errSimpleFail(pkt.AckHandle, fmt.Sprintf("Invalid gem %d x%d", pkt.Gem, pkt.Quantity), nil)
+	}
+	if _, err := loadTower(s, s.charID); err != nil {
+		return errSimpleFail(pkt.AckHandle, "Failed to load gems", err)
+	}
+
+	switch pkt.Op {
+	case mhfpacket.PostGemInfoAdd:
+		_, err := s.server.db.Exec("UPDATE tower SET gems[$1] = gems[$1] + $2 WHERE char_id=$3", i+1, pkt.Quantity, s.charID)
+		if err != nil {
+			return errSimpleFail(pkt.AckHandle, "Failed to add gems", err)
+		}
+	case mhfpacket.PostGemInfoTransfer:
+		if err := transferGems(s, uint32(pkt.CID), i, pkt); err != nil {
+			return errSimpleFail(pkt.AckHandle, "Failed to transfer gems", err)
+		}
+	default:
+		return errSimpleFail(pkt.AckHandle, fmt.Sprintf("Unknown gem operation %d", pkt.Op), nil)
+	}
+	doAckSimpleSucceed(s, pkt.AckHandle, make([]byte, 4))
+	return nil
 }
 
-func handleMsgMhfGetGemInfo(s *Session, p mhfpacket.MHFPacket) {}
+// transferGems moves gems to another character and records it in their history.
+func transferGems(s *Session, recipient uint32, i int, pkt *mhfpacket.MsgMhfPostGemInfo) error {
+	if recipient == s.charID {
+		return fmt.Errorf("transfer to self")
+	}
+	tx, err := s.server.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-func handleMsgMhfPostGemInfo(s *Session, p mhfpacket.MHFPacket) {}
\ No newline at end of file
+	res, err := tx.Exec("UPDATE tower SET gems[$1] = gems[$1] - $2 WHERE char_id=$3 AND gems[$1] >= $2", i+1, pkt.Quantity, s.charID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("not enough gems")
+	}
+	if _, err = tx.Exec("INSERT INTO tower (char_id) VALUES ($1) ON CONFLICT DO NOTHING", recipient); err != nil {
+		return err
+	}
+	if _, err = tx.Exec("UPDATE tower SET gems[$1] = gems[$1] + $2 WHERE char_id=$3", i+1, pkt.Quantity, recipient); err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO gem_history (char_id, sender_id, gem, quantity, message) VALUES ($1, $2, $3, $4, $5)",
+		recipient, s.charID, pkt.Gem, pkt.Quantity, pkt.Message,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,72 @@
+package channelserver
+
+import (
+	"testing"
+
+	"erupe-ce/common/byteframe"
+	"erupe-ce/network/mhfpacket"
+	"github.com/lib/pq"
+)
+
+func TestGemIndex(t *testing.T) {
+	for i := 0; i < gemCategories*gemsPerCategory; i++ {
+		if got, ok := gemIndex(int32(gemID(i))); !ok || got != i {
+			t.Errorf("gemIndex(gemID(%d)) = %d, %t", i, got, ok)
+		}
+	}
+	if gemID(7) != 0x0103 {
+		t.Errorf("gemID(7) = %#x, expected 0x0103", gemID(7))
+	}
+	for _, gem := range []int32{-1, 0x0000, 0x0006, 0x0601, 0x10001} {
+		if _, ok := gemIndex(gem); ok {
+			t.Errorf("gemIndex(%#x) accepted", gem)
+		}
+	}
+}
+
+func TestTowerClearHistory(t *testing.T) {
+	tower := &towerData{History: make(pq.Int64Array, towerHistoryCount)}
+	for floors := int32(1); floors <= towerHistoryCount+2; floors++ {
+		tower.recordClear(floors)
+	}
+	if tower.Block2 != 78 {
+		t.Errorf("expected 78 floors in the second block, got %d", tower.Block2)
+	}
+
+	entries := towerInfo(tower, mhfpacket.TowerInfoTypeTowerTouhaHistory)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 history entry, got %d", len(entries))
+	}
+	bf := byteframe.NewByteFrameFromBytes(entries[0].Data())
+	for i := 0; i < towerHistoryCount; i++ {
+		if floors := bf.ReadInt16(); floors != int16(towerHistoryCount+2-i) {
+			t.Errorf("history[%d] = %d, expected %d", i, floors, towerHistoryCount+2-i)
+		}
+	}
+
+	entries = towerInfo(tower, mhfpacket.TowerInfoTypeUnk5)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 block entries, got %d", len(entries))
+	}
+	if floors := byteframe.NewByteFrameFromBytes(entries[1].Data()).ReadInt32(); floors != tower.Block2 {
+		t.Errorf("second block = %d, expected %d", floors, tower.Block2)
+	}
+}
+
+func TestValidTowerProgress(t *testing.T) {
+	tests := []struct {
+		pkt  mhfpacket.MsgMhfPostTowerInfo
+		want bool
+	}{
+		{mhfpacket.MsgMhfPostTowerInfo{TRP: 10, Cost: 5, Block1: 2}, true},
+		{mhfpacket.MsgMhfPostTowerInfo{}, true},
+		{mhfpacket.MsgMhfPostTowerInfo{TRP: -1}, false},
+		{mhfpacket.MsgMhfPostTowerInfo{Cost: -5}, false},
+		{mhfpacket.MsgMhfPostTowerInfo{Block1: -2}, false},
+	}
+	for _, tt := range tests {
+		if got := validTowerProgress(&tt.pkt); got != tt.want {
+			t.Errorf("validTowerProgress(%+v) = %t, expected %t", tt.pkt, got, tt.want)
+		}
+	}
+}
@@ -8,11 +8,11 @@ import (
 	"erupe-ce/network/mhfpacket"
 )
 
-func handleMsgSysInsertUser(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysInsertUser(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysDeleteUser(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysDeleteUser(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgSysSetUserBinary(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysSetUserBinary(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysSetUserBinary)
 	s.server.userBinaryPartsLock.Lock()
 	s.server.userBinaryParts[userBinaryPartID{charID: s.charID, index: pkt.BinaryType}] = pkt.RawDataPayload
@@ -23,14 +23,14 @@ func handleMsgSysSetUserBinary(s *Session, p mhfpacket.MHFPacket) {
 		for i := 0; i < 3; i++ {
 			_, exists := s.server.userBinaryParts[userBinaryPartID{charID: s.charID, index: uint8(i + 1)}]
 			if !exists {
-				return
+				return nil
 			}
 		}
 		s.binariesDone = true
 		s.server.BroadcastMHF(&mhfpacket.MsgSysInsertUser{
 			CharID: s.charID,
 		}, s)
-		return
+		return nil
 	}
 
 	msg := &mhfpacket.MsgSysNotifyUserBinary{
@@ -39,9 +39,10 @@ func handleMsgSysSetUserBinary(s *Session, p mhfpacket.MHFPacket) {
 	}
 
 	s.server.BroadcastMHF(msg, s)
+	return nil
 }
 
-func handleMsgSysGetUserBinary(s *Session, p mhfpacket.MHFPacket) {
+func handleMsgSysGetUserBinary(s *Session, p mhfpacket.MHFPacket) error {
 	pkt := p.(*mhfpacket.MsgSysGetUserBinary)
 
 	// Try to get the data.
@@ -59,13 +60,13 @@ func handleMsgSysGetUserBinary(s *Session, p mhfpacket.MHFPacket) {
 		} else if pkt.BinaryType == 2 {
 			data, err := base64.StdEncoding.DecodeString("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABBn8AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAgAAAAAAAAAAAAAAAwAAAAAAAAAAAAAABAAAAAAAAAAAAAAABQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA==")
 			if err != nil {
-				panic(err)
+				return errBufFail(pkt.AckHandle, "", err)
 			}
 			resp.WriteBytes(data)
 		} else if pkt.BinaryType == 3 {
 			data, err := base64.StdEncoding.DecodeString("AQAAA2ea5P8ATgEA/wEAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABBn8AAAAAAAAAAAABAKAMAAAAAAAAAAAAACgAAAAAAAAAAAABAsQOAAAAAAAAAAABA6UMAAAAAAAAAAABBKAMAAAAAAAAAAABBToNAAAAAAAAAAAAAAMAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAAAgACAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAD/////AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA")
 			if err != nil {
-				panic(err)
+				return errBufFail(pkt.AckHandle, "", err)
 			}
 			resp.WriteBytes(data)
 		}
@@ -74,8 +75,9 @@ func handleMsgSysGetUserBinary(s *Session, p mhfpacket.MHFPacket) {
 	}
 
 	doAckBufSucceed(s, pkt.AckHandle, resp.Data())
+	return nil
 }
 
-func handleMsgSysNotifyUserBinary(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgSysNotifyUserBinary(s *Session, p mhfpacket.MHFPacket) error { return nil }
 
-func handleMsgMhfGetBbsUserStatus(s *Session, p mhfpacket.MHFPacket) {}
+func handleMsgMhfGetBbsUserStatus(s *Session, p mhfpacket.MHFPacket) error { return nil }
@@ -0,0 +1,153 @@
+package channelserver
+
+import (
+	"time"
+
+	"erupe-ce/common/byteframe"
+	"erupe-ce/common/stringsupport"
+	"erupe-ce/network/clientctx"
+	"erupe-ce/network/mhfpacket"
+	"erupe-ce/server/bus"
+
+	"go.uber.org/zap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// heartbeatInterval is the time between two heartbeats of a channel.
+const heartbeatInterval = 10 * time.Second
+
+// busClientContext builds the packets sent through the bus, every client
+// using the same encoding.
+var busClientContext = &clientctx.ClientContext{
+	StrConv: &stringsupport.StringConverter{
+		Encoding: japanese.ShiftJIS,
+	},
+}
+
+// buildBusPacket builds a packet with its opcode, ready to be queued to any session.
+func buildBusPacket(pkt mhfpacket.MHFPacket) []byte {
+	bf := byteframe.NewByteFrame()
+	bf.WriteUint16(uint16(pkt.Opcode()))
+	pkt.Build(bf, busClientContext)
+	return bf.Data()
+}
+
+func (s *Server) publish(msg bus.Message) {
+	if err := s.bus.Publish(msg); err != nil {
+		s.logger.Error("Failed to publish bus message", zap.Uint8("kind", uint8(msg.Kind)), zap.Error(err))
+	}
+}
+
+// publishPresence reports the character of the session online on its current stage, or offline.
+func (s *Server) publishPresence(session *Session, online bool) {
+	session.Lock()
+	msg := bus.Message{
+		Kind:     bus.KindPresence,
+		ServerID: s.ID,
+		CharID:   session.charID,
+		Name:     session.Name,
+		StageID:  session.stageID,
+		Online:   online,
+	}
+	session.Unlock()
+	if msg.CharID == 0 {
+		return
+	}
+	s.publish(msg)
+}
+
+// status returns the current status of the channel as a message of the given kind.
+func (s *Server) status(kind bus.Kind) bus.Message {
+	s.Lock()
+	defer s.Unlock()
+	players := 0
+	for _, session := range s.sessions {
+		if session.charID != 0 {
+			players++
+		}
+	}
+	return bus.Message{
+		Kind:        kind,
+		ServerID:    s.ID,
+		Players:     uint16(players),
+		MaxPlayers:  s.maxPlayers,
+		Season:      s.season,
+		Maintenance: s.maintenance,
+	}
+}
+
+// publishHeartbeat reports the channel status to the registries.
+func (s *Server) publishHeartbeat() {
+	s.publish(s.status(bus.KindHeartbeat))
+}
+
+func (s *Server) heartbeat() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.publishHeartbeat()
+			s.endFestas()
+		case <-s.heartbeatStop:
+			return
+		}
+	}
+}
+
+// handleBusMessage delivers the messages published by any server to the sessions of this channel.
+func (s *Server) handleBusMessage(msg bus.Message) {
+	switch msg.Kind {
+	case bus.KindWorldcast:
+		s.Lock()
+		for _, session := range s.sessions {
+			if msg.Exclude != 0 && session.charID == msg.Exclude {
+				continue
+			}
+			session.QueueSendNonBlocking(msg.Packet)
+		}
+		s.Unlock()
+	case bus.KindTargeted:
+		s.Lock()
+		for _, session := range s.sessions {
+			for _, charID := range msg.CharIDs {
+				if session.charID == charID {
+					session.QueueSendNonBlocking(msg.Packet)
+					break
+				}
+			}
+		}
+		s.Unlock()
+	case bus.KindChat:
+		s.BroadcastChatMessage(msg.Text)
+	case bus.KindKick:
+		for _, charID := range msg.CharIDs {
+			s.KickCharacter(charID)
+		}
+	case bus.KindHeartbeatSync:
+		s.publishHeartbeat()
+	case bus.KindMaintenance:
+		if msg.ServerID != s.ID {
+			return
+		}
+		s.Lock()
+		s.maintenance = msg.Maintenance
+		s.Unlock()
+		s.logger.Info("Changed maintenance mode", zap.Bool("maintenance", msg.Maintenance))
+		s.publishHeartbeat()
+	case bus.KindRaviente:
+		if WorldIndex(msg.ServerID) == WorldIndex(s.ID) {
+			s.notifyRaviente()
+		}
+	case bus.KindPresenceSync:
+		s.Lock()
+		sessions := make([]*Session, 0, len(s.sessions))
+		for _, session := range s.sessions {
+			sessions = append(sessions, session)
+		}
+		s.Unlock()
+		for _, session := range sessions {
+			s.publishPresence(session, true)
+		}
+	}
+}
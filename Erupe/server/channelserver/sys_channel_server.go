@@ -10,6 +10,7 @@ import (
 	"erupe-ce/config"
 	"erupe-ce/network/binpacket"
 	"erupe-ce/network/mhfpacket"
+	"erupe-ce/server/bus"
 	"erupe-ce/server/discordbot"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
@@ -41,6 +42,16 @@ type Config struct {
 	ErupeConfig *config.Config
 	Name        string
 	Enable      bool
+	QuestFiles  *QuestFileProvider
+	// Great Slaying shared with the other channels of the world.
+	Raviente *Raviente
+	// Directory of quest files taking precedence on this channel.
+	QuestOverridePath string
+	// Bus reaching the other servers, a local one is created if nil.
+	Bus bus.Bus
+	// Capacity and season reported in the heartbeats of the channel.
+	MaxPlayers uint16
+	Season     uint8
 }
 
 // Map key type for a user binary part.
@@ -52,7 +63,7 @@ type userBinaryPartID struct {
 // Server is a MHF channel server.
 type Server struct {
 	sync.Mutex
-	Channels       []*Server
+	Channels       []*Server // Channels of this process, the others are only reachable through the bus.
 	ID             uint16
 	logger         *zap.Logger
 	db             *sqlx.DB
@@ -81,61 +92,32 @@ type Server struct {
 	enable bool
 
 	raviente *Raviente
-}
 
-type Raviente struct {
-	sync.Mutex
+	questFiles        *QuestFileProvider
+	questOverridePath string
 
-	register *RavienteRegister
-	state    *RavienteState
-	support  *RavienteSupport
-}
-
-type RavienteRegister struct {
-	nextTime uint32
-	startTime uint32
-	postTime uint32
-	killedTime uint32
-	ravienteType uint32
-	maxPlayers uint32
-	carveQuest uint32
-	register []uint32
-}
+	handlerFailures handlerFailures
 
-type RavienteState struct {
-	damageMultiplier uint32
-	stateData []uint32
+	bus            bus.Bus
+	busUnsubscribe func()
+	heartbeatStop  chan struct{}
+	maxPlayers     uint16
+	season         uint8
+	maintenance    bool
 }
 
-type RavienteSupport struct {
-	supportData []uint32
+// ServerID returns the ID of a channel from its index and the index of its world.
+func ServerID(worldIdx int, channelIdx int) uint16 {
+	return uint16((4096 + worldIdx*256) + (16 + channelIdx))
 }
 
-// Set up the Raviente variables for the server
-func NewRaviente() *Raviente {
-	ravienteRegister := &RavienteRegister {
-		nextTime: 0,
-		startTime: 0,
-		killedTime: 0,
-		postTime: 0,
-		ravienteType: 0,
-		maxPlayers: 0,
-		carveQuest: 0,
+// WorldIndex returns the index of the world of a channel, which keys the state
+// shared by the channels of a world.
+func WorldIndex(serverID uint16) uint16 {
+	if serverID < 4096 {
+		return 0
 	}
-	ravienteState := &RavienteState {
-		damageMultiplier: 1,
-	}
-	ravienteSupport := &RavienteSupport { }
-	ravienteRegister.register = []uint32{0, 0, 0, 0, 0}
-	ravienteState.stateData = []uint32{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
-	ravienteSupport.supportData = []uint32{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
-
-	raviente := &Raviente {
-		register: ravienteRegister,
-		state: ravienteState,
-		support: ravienteSupport,
-	}
-	return raviente
+	return (serverID - 4096) / 256
 }
 
 // NewServer creates a new Server type.
@@ -154,7 +136,23 @@ func NewServer(config *Config) *Server {
 		discordBot:      config.DiscordBot,
 		name:            config.Name,
 		enable:          config.Enable,
-		raviente:        NewRaviente(),
+		raviente:        config.Raviente,
+		questFiles:        config.QuestFiles,
+		questOverridePath: config.QuestOverridePath,
+		bus:               config.Bus,
+		heartbeatStop:     make(chan struct{}),
+		maxPlayers:        config.MaxPlayers,
+		season:            config.Season,
+	}
+
+	if s.questFiles == nil {
+		s.questFiles = NewQuestFileProvider(s.logger, s.erupeConfig.BinPath, s.erupeConfig.Quests.CacheEntries)
+	}
+	if s.bus == nil {
+		s.bus = bus.NewLocal(s.logger)
+	}
+	if s.raviente == nil {
+		s.raviente = NewRaviente(s.db, s.logger, s.ID, s.erupeConfig.Raviente)
 	}
 
 	// Mezeporta
@@ -204,6 +202,10 @@ func (s *Server) Start(port int) error {
 	go s.acceptClients()
 	go s.manageSessions()
 
+	s.busUnsubscribe = s.bus.Subscribe(s.handleBusMessage)
+	s.publish(s.status(bus.KindRegister))
+	go s.heartbeat()
+
 	// Start the discord bot for chat integration.
 	if s.erupeConfig.Discord.Enabled && s.discordBot != nil {
 		s.discordBot.Session.AddHandler(s.onDiscordMessage)
@@ -221,6 +223,10 @@ func (s *Server) Shutdown() {
 	s.listener.Close()
 
 	close(s.acceptConns)
+
+	close(s.heartbeatStop)
+	s.publish(bus.Message{Kind: bus.KindUnregister, ServerID: s.ID})
+	s.busUnsubscribe()
 }
 
 func (s *Server) acceptClients() {
@@ -261,6 +267,7 @@ func (s *Server) manageSessions() {
 
 			s.Lock()
 			s.sessions[newConn] = session
+			s.updateSessionsMetric()
 			s.Unlock()
 
 			session.Start()
@@ -268,6 +275,7 @@ func (s *Server) manageSessions() {
 		case delConn := <-s.deleteConns:
 			s.Lock()
 			delete(s.sessions, delConn)
+			s.updateSessionsMetric()
 			s.Unlock()
 		}
 	}
@@ -293,18 +301,18 @@ func (s *Server) BroadcastMHF(pkt mhfpacket.MHFPacket, ignoredSession *Session)
 	}
 }
 
+// WorldcastMHF queues a MHFPacket to be sent to the sessions of every channel.
 func (s *Server) WorldcastMHF(pkt mhfpacket.MHFPacket, ignoredSession *Session) {
-	for _, c := range s.Channels {
-		for _, s := range c.sessions {
-			if s == ignoredSession {
-				continue
-			}
-			bf := byteframe.NewByteFrame()
-			bf.WriteUint16(uint16(pkt.Opcode()))
-			pkt.Build(bf, s.clientContext)
-			s.QueueSendNonBlocking(bf.Data())
-		}
+	msg := bus.Message{Kind: bus.KindWorldcast, ServerID: s.ID, Packet: buildBusPacket(pkt)}
+	if ignoredSession != nil {
+		msg.Exclude = ignoredSession.charID
 	}
+	s.publish(msg)
+}
+
+// SendToCharacters queues a MHFPacket to be sent to the characters, on whichever channel they are.
+func (s *Server) SendToCharacters(pkt mhfpacket.MHFPacket, charIDs ...uint32) {
+	s.publish(bus.Message{Kind: bus.KindTargeted, ServerID: s.ID, CharIDs: charIDs, Packet: buildBusPacket(pkt)})
 }
 
 // BroadcastChatMessage broadcasts a simple chat message to all the sessions.
@@ -366,20 +374,19 @@ func (s *Server) DiscordChannelSend(charName string, content string) {
 	}
 }
 
+// FindSessionByCharID returns the session playing the character on this channel, if any.
 func (s *Server) FindSessionByCharID(charID uint32) *Session {
-	for _, c := range s.Channels {
-		c.stagesLock.RLock()
-		for _, stage := range c.stages {
-			stage.RLock()
-			for client := range stage.clients {
-				if client.charID == charID {
-					stage.RUnlock()
-					return client
-				}
+	s.stagesLock.RLock()
+	defer s.stagesLock.RUnlock()
+	for _, stage := range s.stages {
+		stage.RLock()
+		for client := range stage.clients {
+			if client.charID == charID {
+				stage.RUnlock()
+				return client
 			}
-			stage.RUnlock()
 		}
-		c.stagesLock.RUnlock()
+		stage.RUnlock()
 	}
 	return nil
 }
@@ -401,3 +408,36 @@ func (s *Server) FindStageObjectByChar(charID uint32) *StageObject {
 
 	return nil
 }
+
+// IsCharacterOnline reports whether the character has a session on this server.
+func (s *Server) IsCharacterOnline(charID uint32) bool {
+	s.Lock()
+	defer s.Unlock()
+	for _, session := range s.sessions {
+		if session.charID == charID {
+			return true
+		}
+	}
+	return false
+}
+
+// KickCharacter disconnects the session playing the given character, if it is on this server.
+func (s *Server) KickCharacter(charID uint32) bool {
+	s.Lock()
+	var target *Session
+	for _, session := range s.sessions {
+		if session.charID == charID {
+			target = session
+			break
+		}
+	}
+	s.Unlock()
+
+	if target == nil {
+		return false
+	}
+	s.logger.Info("Kicking character", zap.Uint32("charID", charID))
+	// Closing the connection makes the recv loop log the player out.
+	target.rawConn.Close()
+	return true
+}
@@ -0,0 +1,87 @@
+package channelserver
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// eventResultsPeriod is how long an ended event stays shown for its results.
+const eventResultsPeriod = 7 * 24 * time.Hour
+
+// eventWindow is the schedule of an event going through phases in order, phase
+// i running from the i-1th time until the ith one. Once ended, the event goes
+// through a last results phase for eventResultsPeriod. Phase 0 stands for none.
+type eventWindow []time.Time
+
+// phase returns the phase the event is in at t, 0 outside of it.
+func (w eventWindow) phase(t time.Time) int {
+	if len(w) < 2 || t.Before(w[0]) {
+		return 0
+	}
+	end := w[len(w)-1]
+	if !t.Before(end.Add(eventResultsPeriod)) {
+		return 0
+	} else if !t.Before(end) {
+		return len(w)
+	}
+	phase := 1
+	for i := 1; i < len(w)-1 && !t.Before(w[i]); i++ {
+		phase = i + 1
+	}
+	return phase
+}
+
+// scheduledEvent is an event kept in a table with start_time and end_time columns.
+type scheduledEvent interface {
+	window() eventWindow
+}
+
+// listEvents loads the columns of every event of a table into dest, ordered by start time.
+func listEvents(db *sqlx.DB, dest interface{}, table string, columns string) error {
+	return db.Select(dest, fmt.Sprintf("SELECT %s FROM %s ORDER BY start_time", columns, table))
+}
+
+// deleteEvent deletes an event of a table, returning notFound when there is none with this ID.
+func deleteEvent(db *sqlx.DB, table string, id interface{}, notFound error) error {
+	res, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id=$1", table), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+// eventAt loads the event of a table running or showing its results at t, or
+// else the next one, into dest and returns false when none is scheduled.
+func eventAt(db *sqlx.DB, dest scheduledEvent, table string, columns string, t time.Time) (bool, error) {
+	err := db.Get(dest, fmt.Sprintf(
+		"SELECT %s FROM %s WHERE start_time <= $1 AND end_time > $2 ORDER BY start_time DESC LIMIT 1", columns, table,
+	), t, t.Add(-eventResultsPeriod))
+	if err == sql.ErrNoRows {
+		err = db.Get(dest, fmt.Sprintf("SELECT %s FROM %s WHERE start_time > $1 ORDER BY start_time LIMIT 1", columns, table), t)
+	}
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// currentEvent loads the event of a table running now into dest and returns
+// its phase, 0 when none is running, results aside.
+func currentEvent(db *sqlx.DB, dest scheduledEvent, table string, columns string) (int, error) {
+	now := time.Now()
+	found, err := eventAt(db, dest, table, columns, now)
+	if err != nil || !found {
+		return 0, err
+	}
+	w := dest.window()
+	if phase := w.phase(now); phase != len(w) {
+		return phase, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,47 @@
+package channelserver
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventWindowPhase(t *testing.T) {
+	start := time.Date(2022, 6, 6, 0, 0, 0, 0, time.UTC)
+	diva := &DivaEvent{
+		StartTime:        start,
+		InterceptionTime: start.AddDate(0, 0, 7),
+		SongTime:         start.AddDate(0, 0, 14),
+		EndTime:          start.AddDate(0, 0, 21),
+	}
+	// A festa may be tallied and rewarded at once.
+	festa := &FestaEvent{
+		StartTime:  start,
+		TrialTime:  start.AddDate(0, 0, 7),
+		TallyTime:  start.AddDate(0, 0, 14),
+		RewardTime: start.AddDate(0, 0, 14),
+		EndTime:    start.AddDate(0, 0, 28),
+	}
+	tests := []struct {
+		event    scheduledEvent
+		t        time.Time
+		expected int
+	}{
+		{diva, start.Add(-time.Second), divaPhaseNone},
+		{diva, start, divaPhasePrayer},
+		{diva, diva.InterceptionTime.Add(-time.Second), divaPhasePrayer},
+		{diva, diva.InterceptionTime, divaPhaseInterception},
+		{diva, diva.SongTime, divaPhaseSong},
+		{diva, diva.EndTime, divaPhaseResults},
+		{diva, diva.EndTime.Add(eventResultsPeriod), divaPhaseNone},
+		{festa, start, festaPhaseRegistration},
+		{festa, festa.TrialTime, festaPhaseTrial},
+		{festa, festa.TallyTime, festaPhaseReward},
+		{festa, festa.EndTime.Add(-time.Second), festaPhaseReward},
+		{festa, festa.EndTime, festaPhaseResults},
+	}
+	for _, tt := range tests {
+		if got := tt.event.window().phase(tt.t); got != tt.expected {
+			t.Errorf("phase(%v) = %d, expected %d", tt.t, got, tt.expected)
+		}
+	}
+}
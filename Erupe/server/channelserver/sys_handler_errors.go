@@ -0,0 +1,128 @@
+package channelserver
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"erupe-ce/network"
+	"erupe-ce/network/mhfpacket"
+	"go.uber.org/zap"
+)
+
+// ackKind is the kind of ack the client waits for after a request.
+type ackKind int
+
+const (
+	ackNone ackKind = iota
+	ackBuf
+	ackSimple
+)
+
+// handlerError is returned by packet handlers that failed to serve a request.
+// The dispatcher logs it and answers the request with a failure ack.
+type handlerError struct {
+	msg       string
+	err       error
+	kind      ackKind
+	ackHandle uint32
+}
+
+func (e *handlerError) Error() string {
+	if e.err == nil {
+		return e.msg
+	} else if e.msg == "" {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.msg, e.err)
+}
+
+func (e *handlerError) Unwrap() error {
+	return e.err
+}
+
+// errBufFail fails a request answered by doAckBufSucceed.
+func errBufFail(ackHandle uint32, msg string, err error) error {
+	return &handlerError{msg: msg, err: err, kind: ackBuf, ackHandle: ackHandle}
+}
+
+// errSimpleFail fails a request answered by doAckSimpleSucceed.
+func errSimpleFail(ackHandle uint32, msg string, err error) error {
+	return &handlerError{msg: msg, err: err, kind: ackSimple, ackHandle: ackHandle}
+}
+
+// errNoAck fails a packet the client doesn't wait an answer for.
+func errNoAck(msg string, err error) error {
+	return &handlerError{msg: msg, err: err, kind: ackNone}
+}
+
+// handlerFailures counts the failed requests per opcode.
+type handlerFailures struct {
+	sync.Mutex
+	counts map[network.PacketID]uint64
+}
+
+func (f *handlerFailures) inc(opcode network.PacketID) {
+	f.Lock()
+	defer f.Unlock()
+	if f.counts == nil {
+		f.counts = make(map[network.PacketID]uint64)
+	}
+	f.counts[opcode]++
+	metricHandlerFailures.Inc(opcode.String())
+}
+
+// HandlerFailures returns the number of failed requests per opcode since the server started.
+func (s *Server) HandlerFailures() map[network.PacketID]uint64 {
+	s.handlerFailures.Lock()
+	defer s.handlerFailures.Unlock()
+	counts := make(map[network.PacketID]uint64, len(s.handlerFailures.counts))
+	for opcode, n := range s.handlerFailures.counts {
+		counts[opcode] = n
+	}
+	return counts
+}
+
+// handlerFailed records a failed request and answers it with a failure ack.
+func (s *Session) handlerFailed(opcode network.PacketID, err error) {
+	s.server.handlerFailures.inc(opcode)
+	s.logger.Error("Packet handler failed", zap.Stringer("opcode", opcode), zap.Uint32("charID", s.charID), zap.Error(err))
+
+	var handlerErr *handlerError
+	if !errors.As(err, &handlerErr) {
+		return
+	}
+	switch handlerErr.kind {
+	case ackBuf:
+		doAckBufFail(s, handlerErr.ackHandle, make([]byte, 4))
+	case ackSimple:
+		doAckSimpleFail(s, handlerErr.ackHandle, make([]byte, 4))
+	}
+}
+
+// handlerPanicked records a request whose handler panicked and answers it with
+// a failure ack. The kind of ack the request waits for is unknown at this
+// point, a buffer one is sent.
+func (s *Session) handlerPanicked(opcode network.PacketID, pkt mhfpacket.MHFPacket, r interface{}) {
+	s.server.handlerFailures.inc(opcode)
+	s.logger.Error("Recovered from panic in packet handler", zap.Stringer("opcode", opcode), zap.Uint32("charID", s.charID), zap.Any("panic", r))
+
+	if ackHandle, ok := packetAckHandle(pkt); ok {
+		doAckBufFail(s, ackHandle, make([]byte, 4))
+	}
+}
+
+// packetAckHandle returns the ack handle of a request, packets the client
+// doesn't wait an answer for have none.
+func packetAckHandle(pkt mhfpacket.MHFPacket) (uint32, bool) {
+	v := reflect.ValueOf(pkt)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return 0, false
+	}
+	field := v.Elem().FieldByName("AckHandle")
+	if !field.IsValid() || field.Kind() != reflect.Uint32 {
+		return 0, false
+	}
+	return uint32(field.Uint()), true
+}
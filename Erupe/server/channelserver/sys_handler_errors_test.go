@@ -0,0 +1,101 @@
+package channelserver
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"erupe-ce/config"
+	"erupe-ce/network"
+	"erupe-ce/network/mhfpacket"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+func TestHandlerError(t *testing.T) {
+	err := errBufFail(1, "Failed to get savedata from db", sql.ErrNoRows)
+	if err.Error() != "Failed to get savedata from db: sql: no rows in result set" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Error("expected the handler error to wrap its cause")
+	}
+	var handlerErr *handlerError
+	if !errors.As(err, &handlerErr) || handlerErr.kind != ackBuf || handlerErr.ackHandle != 1 {
+		t.Errorf("unexpected handler error %+v", handlerErr)
+	}
+	if err = errSimpleFail(2, "", sql.ErrNoRows); err.Error() != sql.ErrNoRows.Error() {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestHandlerFailures(t *testing.T) {
+	s := &Server{}
+	s.handlerFailures.inc(network.MSG_MHF_SAVEDATA)
+	s.handlerFailures.inc(network.MSG_MHF_SAVEDATA)
+	s.handlerFailures.inc(network.MSG_MHF_LOADDATA)
+	failures := s.HandlerFailures()
+	if failures[network.MSG_MHF_SAVEDATA] != 2 || failures[network.MSG_MHF_LOADDATA] != 1 {
+		t.Errorf("unexpected failure counts %v", failures)
+	}
+}
+
+func TestHandlerFailureAcksOnce(t *testing.T) {
+	db, err := sqlx.Open("postgres", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A closed pool fails every query without a database to talk to.
+	db.Close()
+	server := &Server{logger: zap.NewNop(), db: db, erupeConfig: &config.Config{}}
+
+	tests := []struct {
+		name   string
+		opcode network.PacketID
+		pkt    mhfpacket.MHFPacket
+		mailID int
+	}{
+		{"read unknown mail", network.MSG_MHF_READ_MAIL, &mhfpacket.MsgMhfReadMail{AckHandle: 1}, 0},
+		{"read mail", network.MSG_MHF_READ_MAIL, &mhfpacket.MsgMhfReadMail{AckHandle: 2}, 1},
+		{"list mail", network.MSG_MHF_LIST_MAIL, &mhfpacket.MsgMhfListMail{AckHandle: 3}, 0},
+		{"operate mail", network.MSG_MHF_OPRT_MAIL, &mhfpacket.MsgMhfOprtMail{AckHandle: 4}, 1},
+		{"post guild scout", network.MSG_MHF_POST_GUILD_SCOUT, &mhfpacket.MsgMhfPostGuildScout{AckHandle: 5}, 0},
+	}
+	for _, tt := range tests {
+		s := &Session{logger: zap.NewNop(), server: server, sendPackets: make(chan []byte, 20), mailList: make([]int, 256)}
+		s.mailList[0] = tt.mailID
+		err := handlerTable[tt.opcode](s, tt.pkt)
+		if err == nil {
+			t.Errorf("%s: expected the handler to fail", tt.name)
+			continue
+		}
+		s.handlerFailed(tt.opcode, err)
+		if n := len(s.sendPackets); n != 1 {
+			t.Errorf("%s: expected exactly one ack, got %d", tt.name, n)
+		}
+	}
+}
+
+func TestHandlerPanicAcks(t *testing.T) {
+	server := &Server{logger: zap.NewNop(), erupeConfig: &config.Config{}}
+	tests := []struct {
+		name string
+		pkt  mhfpacket.MHFPacket
+		acks int
+	}{
+		{"request", &mhfpacket.MsgMhfReadMail{AckHandle: 1}, 1},
+		{"packet without ack", &mhfpacket.MsgSysEnd{}, 0},
+		{"unparsed packet", nil, 0},
+	}
+	for _, tt := range tests {
+		s := &Session{logger: zap.NewNop(), server: server, sendPackets: make(chan []byte, 20)}
+		s.handlerPanicked(network.MSG_MHF_READ_MAIL, tt.pkt, "panic")
+		if n := len(s.sendPackets); n != tt.acks {
+			t.Errorf("%s: expected %d acks, got %d", tt.name, tt.acks, n)
+		}
+	}
+	if server.HandlerFailures()[network.MSG_MHF_READ_MAIL] != 3 {
+		t.Error("expected every panic to count as a failure")
+	}
+}
@@ -0,0 +1,225 @@
+package channelserver
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"erupe-ce/common/byteframe"
+	"erupe-ce/network/binpacket"
+	"erupe-ce/network/mhfpacket"
+	"erupe-ce/server/bus"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ErrNoMailRecipients is returned when a system mail target matches no character.
+var ErrNoMailRecipients = errors.New("no character matches the mail target")
+
+// mailNotifyBatch is the number of recipients notified per bus message.
+const mailNotifyBatch = 500
+
+// MailItem is an item attached to a system mail.
+type MailItem struct {
+	ItemID uint16 `json:"item_id"`
+	Amount uint16 `json:"amount"`
+}
+
+// MailTarget selects the recipients of a system mail: a character, the
+// members of a guild, or every character whose HR and GR are in range.
+type MailTarget struct {
+	CharID  uint32 `json:"char_id"`
+	GuildID uint32 `json:"guild_id"`
+	MinHR   uint16 `json:"min_hr"`
+	MaxHR   uint16 `json:"max_hr"` // 0 for no upper bound.
+	MinGR   uint16 `json:"min_gr"`
+	MaxGR   uint16 `json:"max_gr"` // 0 for no upper bound.
+}
+
+// recipientQuery returns the query selecting the character IDs of the target.
+func (t MailTarget) recipientQuery() (string, []interface{}) {
+	if t.CharID != 0 {
+		return "SELECT id FROM characters WHERE id=$1", []interface{}{t.CharID}
+	}
+	if t.GuildID != 0 {
+		return "SELECT character_id FROM guild_characters WHERE guild_id=$1", []interface{}{t.GuildID}
+	}
+	return `
+		SELECT id FROM characters
+		WHERE COALESCE(hrp, 0) >= $1 AND ($2 = 0 OR COALESCE(hrp, 0) <= $2)
+		AND COALESCE(gr, 0) >= $3 AND ($4 = 0 OR COALESCE(gr, 0) <= $4)`,
+		[]interface{}{t.MinHR, t.MaxHR, t.MinGR, t.MaxGR}
+}
+
+// SendSystemMail sends a mail without sending character to every character
+// of the target, with the items attached. It expires at expires unless nil.
+// Recipients online on a channel reachable through b are notified.
+func SendSystemMail(db *sqlx.DB, b bus.Bus, target MailTarget, subject string, body string, items []MailItem, expires *time.Time) (int, error) {
+	itemIDs := make([]int64, len(items))
+	amounts := make([]int64, len(items))
+	for i, item := range items {
+		itemIDs[i] = int64(item.ItemID)
+		amounts[i] = int64(item.Amount)
+	}
+
+	// The mails and their attachments are inserted by a single statement,
+	// the parameters following the ones of the recipient query.
+	query, args := target.recipientQuery()
+	n := len(args)
+	var recipients []uint32
+	err := db.Select(&recipients, fmt.Sprintf(`
+		WITH recipients (id) AS (%s),
+		sent AS (
+			INSERT INTO mail (sender_id, recipient_id, subject, body, is_sys_message, expires_at)
+			SELECT NULL::int, id, $%d::text, $%d::text, true, $%d::timestamptz FROM recipients
+			RETURNING id, recipient_id
+		),
+		attached AS (
+			INSERT INTO mail_attachments (mail_id, item_id, amount)
+			SELECT sent.id, a.item_id, a.amount FROM sent, unnest($%d::int[], $%d::int[]) AS a(item_id, amount)
+		)
+		SELECT recipient_id FROM sent`, query, n+1, n+2, n+3, n+4, n+5),
+		append(args, subject, body, expires, pq.Int64Array(itemIDs), pq.Int64Array(amounts))...,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if len(recipients) == 0 {
+		return 0, ErrNoMailRecipients
+	}
+
+	notifyMail(b, "", recipients)
+	return len(recipients), nil
+}
+
+// notifyMail shows the new mail notification to the recipients online.
+func notifyMail(b bus.Bus, senderName string, recipients []uint32) {
+	bf := byteframe.NewByteFrame()
+	binpacket.MsgBinMailNotify{SenderName: senderName}.Build(bf)
+	packet := buildBusPacket(&mhfpacket.MsgSysCastedBinary{
+		MessageType:    BinaryMessageTypeMailNotify,
+		RawDataPayload: bf.Data(),
+	})
+	for start := 0; start < len(recipients); start += mailNotifyBatch {
+		end := start + mailNotifyBatch
+		if end > len(recipients) {
+			end = len(recipients)
+		}
+		b.Publish(bus.Message{Kind: bus.KindTargeted, CharIDs: recipients[start:end], Packet: packet})
+	}
+}
+
+type mailAttachment struct {
+	MailID  int    `db:"mail_id"`
+	ItemID  uint16 `db:"item_id"`
+	Amount  uint16 `db:"amount"`
+	Claimed bool   `db:"claimed"`
+}
+
+// loadMailAttachments shows the first unclaimed attachment of each system
+// mail as its attached item, the next one being shown once it is claimed.
+func loadMailAttachments(db *sqlx.DB, mail []Mail) error {
+	var ids []int64
+	for _, m := range mail {
+		if m.IsSystemMessage {
+			ids = append(ids, int64(m.ID))
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	var attachments []mailAttachment
+	err := db.Select(&attachments, `
+		SELECT mail_id, item_id, amount, claimed FROM mail_attachments
+		WHERE mail_id = ANY($1) ORDER BY id`,
+		pq.Int64Array(ids),
+	)
+	if err != nil {
+		return err
+	}
+	byMail := make(map[int][]mailAttachment)
+	for _, a := range attachments {
+		byMail[a.MailID] = append(byMail[a.MailID], a)
+	}
+	for i := range mail {
+		if a, ok := byMail[mail[i].ID]; ok {
+			applyMailAttachments(&mail[i], a)
+		}
+	}
+	return nil
+}
+
+func applyMailAttachments(m *Mail, attachments []mailAttachment) {
+	shown := attachments[len(attachments)-1]
+	m.AttachedItemReceived = true
+	for _, a := range attachments {
+		if !a.Claimed {
+			shown = a
+			m.AttachedItemReceived = false
+			break
+		}
+	}
+	m.AttachedItemID = shown.ItemID
+	m.AttachedItemAmount = shown.Amount
+}
+
+// claimMailItem marks the attached item as received by the recipient,
+// returning false if it was already claimed or the mail expired.
+func claimMailItem(db *sqlx.DB, mailID int, recipientID uint32, itemID uint16, amount uint16) (bool, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var isSystemMessage bool
+	err = tx.QueryRow(`
+		SELECT is_sys_message FROM mail
+		WHERE id=$1 AND recipient_id=$2 AND deleted=false AND (expires_at IS NULL OR expires_at > now())
+		FOR UPDATE`,
+		mailID, recipientID,
+	).Scan(&isSystemMessage)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if !isSystemMessage {
+		res, err := tx.Exec("UPDATE mail SET attached_item_received=true WHERE id=$1 AND attached_item_received=false", mailID)
+		if err != nil {
+			return false, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return false, nil
+		}
+		return true, tx.Commit()
+	}
+
+	res, err := tx.Exec(`
+		UPDATE mail_attachments SET claimed=true WHERE id = (
+			SELECT id FROM mail_attachments
+			WHERE mail_id=$1 AND item_id=$2 AND amount=$3 AND claimed=false
+			ORDER BY id LIMIT 1
+		)`,
+		mailID, itemID, amount,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	_, err = tx.Exec(`
+		UPDATE mail SET attached_item_received = NOT EXISTS (
+			SELECT 1 FROM mail_attachments WHERE mail_id=$1 AND claimed=false
+		) WHERE id=$1`,
+		mailID,
+	)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,25 @@
+package channelserver
+
+import "testing"
+
+func TestApplyMailAttachments(t *testing.T) {
+	attachments := []mailAttachment{
+		{ItemID: 1, Amount: 5, Claimed: true},
+		{ItemID: 2, Amount: 3},
+		{ItemID: 3, Amount: 1},
+	}
+
+	var m Mail
+	applyMailAttachments(&m, attachments)
+	if m.AttachedItemID != 2 || m.AttachedItemAmount != 3 || m.AttachedItemReceived {
+		t.Errorf("expected the first unclaimed item to be shown, got %+v", m)
+	}
+
+	for i := range attachments {
+		attachments[i].Claimed = true
+	}
+	applyMailAttachments(&m, attachments)
+	if m.AttachedItemID != 3 || !m.AttachedItemReceived {
+		t.Errorf("expected the last item to be shown as received, got %+v", m)
+	}
+}
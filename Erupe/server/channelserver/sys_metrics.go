@@ -0,0 +1,32 @@
+package channelserver
+
+import (
+	"strconv"
+
+	"erupe-ce/common/metrics"
+)
+
+var (
+	metricSessions = metrics.NewGaugeVec(
+		"erupe_channel_sessions", "Connected sessions per channel server.", "server")
+	metricPacketsReceived = metrics.NewCounterVec(
+		"erupe_channel_packets_received_total", "Packets received from clients per opcode.", "opcode")
+	metricPacketsSent = metrics.NewCounterVec(
+		"erupe_channel_packets_sent_total", "Packets queued for clients per opcode.", "opcode")
+	metricHandlerDuration = metrics.NewHistogramVec(
+		"erupe_channel_handler_duration_seconds", "Packet handler latency per opcode.", metrics.DefaultBuckets, "opcode")
+	metricHandlerFailures = metrics.NewCounterVec(
+		"erupe_channel_handler_failures_total", "Failed packet handlers per opcode.", "opcode")
+	metricSendQueueDrops = metrics.NewCounterVec(
+		"erupe_channel_send_queue_drops_total", "Packets dropped because a session send queue was full.", "server")
+)
+
+// metricLabel is the server label of the channel server metrics.
+func (s *Server) metricLabel() string {
+	return strconv.Itoa(int(s.ID))
+}
+
+// updateSessionsMetric must be called with the server locked.
+func (s *Server) updateSessionsMetric() {
+	metricSessions.Set(float64(len(s.sessions)), s.metricLabel())
+}
@@ -0,0 +1,199 @@
+package channelserver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"erupe-ce/server/bus"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// isModerator checks whether the session's account holds the configured moderation rights.
+func (s *Session) isModerator() bool {
+	mask := s.server.erupeConfig.Moderation.Rights
+	return mask != 0 && s.rights&mask == mask
+}
+
+// userIDForChar returns the account ID owning the given character.
+func (s *Server) userIDForChar(charID uint32) (uint32, error) {
+	var userID uint32
+	err := s.db.QueryRow("SELECT user_id FROM characters WHERE id=$1", charID).Scan(&userID)
+	return userID, err
+}
+
+func recordAccountHistory(db *sqlx.DB, userID uint32, adminID uint32, title string, reason string) error {
+	_, err := db.Exec("INSERT INTO account_history (user_id, admin_id, title, reason, date) VALUES ($1, $2, $3, $4, now())", userID, adminID, title, reason)
+	return err
+}
+
+// disconnectUser kicks every character of the account from all channels.
+func disconnectUser(db *sqlx.DB, b bus.Bus, userID uint32) error {
+	var charIDs []uint32
+	err := db.Select(&charIDs, "SELECT id FROM characters WHERE user_id=$1", userID)
+	if err != nil {
+		return err
+	}
+	return b.Publish(bus.Message{Kind: bus.KindKick, CharIDs: charIDs})
+}
+
+// BanUser bans an account until expires, or permanently if expires is nil.
+// The ban is recorded in the account history and any online character of the
+// account is disconnected from the channels reachable through b.
+func BanUser(db *sqlx.DB, b bus.Bus, userID uint32, adminID uint32, expires *time.Time, reason string) error {
+	_, err := db.Exec(`
+		INSERT INTO account_ban (user_id, title, reason, date, expires, admin_id)
+		VALUES ($1, 'Ban', $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET title='Ban', reason=$2, date=$3, expires=$4, admin_id=$5`,
+		userID, reason, Time_Current().Format(time.RFC3339), expires, adminID,
+	)
+	if err != nil {
+		return err
+	}
+
+	title := "Ban (permanent)"
+	if expires != nil {
+		title = fmt.Sprintf("Ban (until %s)", expires.Format(time.RFC3339))
+	}
+	err = recordAccountHistory(db, userID, adminID, title, reason)
+	if err != nil {
+		return err
+	}
+	return disconnectUser(db, b, userID)
+}
+
+// UnbanUser lifts the ban on an account, returning false if it wasn't banned.
+func UnbanUser(db *sqlx.DB, userID uint32, adminID uint32) (bool, error) {
+	res, err := db.Exec("DELETE FROM account_ban WHERE user_id=$1", userID)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	return true, recordAccountHistory(db, userID, adminID, "Unban", "")
+}
+
+// BanUser bans an account from this channel, see BanUser.
+func (s *Server) BanUser(userID uint32, adminID uint32, expires *time.Time, reason string) error {
+	err := BanUser(s.db, s.bus, userID, adminID, expires, reason)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Banned user", zap.Uint32("userID", userID), zap.Uint32("adminID", adminID), zap.String("reason", reason))
+	return nil
+}
+
+// UnbanUser lifts a ban from this channel, see UnbanUser.
+func (s *Server) UnbanUser(userID uint32, adminID uint32) (bool, error) {
+	unbanned, err := UnbanUser(s.db, userID, adminID)
+	if err != nil || !unbanned {
+		return unbanned, err
+	}
+	s.logger.Info("Unbanned user", zap.Uint32("userID", userID), zap.Uint32("adminID", adminID))
+	return true, nil
+}
+
+// parseBanDuration parses durations such as "30m", "12h" or "7d". "perm" means a permanent ban.
+func parseBanDuration(str string) (time.Duration, bool, error) {
+	if str == "perm" {
+		return 0, true, nil
+	}
+	if strings.HasSuffix(str, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(str, "d"))
+		if err != nil || days <= 0 {
+			return 0, false, fmt.Errorf("invalid duration %q", str)
+		}
+		return time.Duration(days) * 24 * time.Hour, false, nil
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil || d <= 0 {
+		return 0, false, fmt.Errorf("invalid duration %q", str)
+	}
+	return d, false, nil
+}
+
+// handleBanCommand handles `!ban <char id> <duration|perm> [reason]`.
+func handleBanCommand(s *Session, message string) {
+	if !s.isModerator() {
+		sendServerChatMessage(s, "You don't have the rights to use this command")
+		return
+	}
+	args := strings.Fields(message)
+	if len(args) < 3 {
+		sendServerChatMessage(s, "Error in command. Format: !ban <char id> <30m|12h|7d|perm> [reason]")
+		return
+	}
+	charID, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		sendServerChatMessage(s, "Error in command. Format: !ban <char id> <30m|12h|7d|perm> [reason]")
+		return
+	}
+	duration, permanent, err := parseBanDuration(args[2])
+	if err != nil {
+		sendServerChatMessage(s, "Invalid ban duration, use e.g. 30m, 12h, 7d or perm")
+		return
+	}
+	reason := strings.Join(args[3:], " ")
+
+	userID, err := s.server.userIDForChar(uint32(charID))
+	if err != nil {
+		sendServerChatMessage(s, fmt.Sprintf("Character %d not found", charID))
+		return
+	}
+	adminID, _ := s.server.userIDForChar(s.charID)
+	if userID == adminID {
+		sendServerChatMessage(s, "You can't ban yourself")
+		return
+	}
+
+	var expires *time.Time
+	if !permanent {
+		t := Time_Current().Add(duration)
+		expires = &t
+	}
+	err = s.server.BanUser(userID, adminID, expires, reason)
+	if err != nil {
+		s.logger.Error("Failed to ban user", zap.Error(err))
+		sendServerChatMessage(s, "Failed to ban the account")
+		return
+	}
+	if permanent {
+		sendServerChatMessage(s, fmt.Sprintf("Banned the account of character %d permanently", charID))
+	} else {
+		sendServerChatMessage(s, fmt.Sprintf("Banned the account of character %d until %s", charID, expires.Format("2006-01-02 15:04")))
+	}
+}
+
+// handleUnbanCommand handles `!unban <char id>`.
+func handleUnbanCommand(s *Session, message string) {
+	if !s.isModerator() {
+		sendServerChatMessage(s, "You don't have the rights to use this command")
+		return
+	}
+	var charID uint32
+	n, err := fmt.Sscanf(message, "!unban %d", &charID)
+	if err != nil || n != 1 {
+		sendServerChatMessage(s, "Error in command. Format: !unban <char id>")
+		return
+	}
+	userID, err := s.server.userIDForChar(charID)
+	if err != nil {
+		sendServerChatMessage(s, fmt.Sprintf("Character %d not found", charID))
+		return
+	}
+	adminID, _ := s.server.userIDForChar(s.charID)
+	unbanned, err := s.server.UnbanUser(userID, adminID)
+	if err != nil {
+		s.logger.Error("Failed to unban user", zap.Error(err))
+		sendServerChatMessage(s, "Failed to unban the account")
+		return
+	}
+	if !unbanned {
+		sendServerChatMessage(s, fmt.Sprintf("The account of character %d is not banned", charID))
+		return
+	}
+	sendServerChatMessage(s, fmt.Sprintf("Unbanned the account of character %d", charID))
+}
@@ -0,0 +1,174 @@
+package channelserver
+
+import (
+	"container/list"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// questOverrideFile replaces every quest on a channel when present in its override directory.
+const questOverrideFile = "quest_override.bin"
+
+type questFileEntry struct {
+	path string
+	data []byte
+	err  error // Set when the file doesn't exist, so that misses are cached too.
+}
+
+// QuestFileProvider serves quest and scenario binaries from the bin path,
+// keeping the most recently used files in memory. It is shared by all channels,
+// each of which may have its own directory of overriding quest files.
+type QuestFileProvider struct {
+	sync.Mutex
+	logger     *zap.Logger
+	binPath    string
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List
+	watcher    *fsnotify.Watcher
+	// generation is bumped on every invalidation, files read across one aren't cached.
+	generation uint64
+	readFile   func(string) ([]byte, error)
+}
+
+// NewQuestFileProvider creates a QuestFileProvider caching up to maxEntries files.
+func NewQuestFileProvider(logger *zap.Logger, binPath string, maxEntries int) *QuestFileProvider {
+	if maxEntries <= 0 {
+		maxEntries = 1
+	}
+	return &QuestFileProvider{
+		logger:     logger,
+		binPath:    binPath,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		readFile:   ioutil.ReadFile,
+	}
+}
+
+// Watch drops cached files as soon as they change on disk. Besides the quest
+// and scenario directories, any extra (override) directories are watched too.
+func (p *QuestFileProvider) Watch(extraDirs ...string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	dirs := append([]string{
+		filepath.Join(p.binPath, "quests"),
+		filepath.Join(p.binPath, "scenarios"),
+	}, extraDirs...)
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			p.logger.Warn("Unable to watch quest directory", zap.String("dir", dir), zap.Error(err))
+		}
+	}
+	p.watcher = watcher
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				p.invalidate(event.Name)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				p.logger.Warn("Quest directory watcher error", zap.Error(err))
+			}
+		}
+	}()
+	return nil
+}
+
+// Close stops watching for file changes.
+func (p *QuestFileProvider) Close() {
+	if p.watcher != nil {
+		p.watcher.Close()
+	}
+}
+
+func (p *QuestFileProvider) invalidate(path string) {
+	path = filepath.Clean(path)
+	p.Lock()
+	defer p.Unlock()
+	p.generation++
+	if elem, ok := p.entries[path]; ok {
+		p.lru.Remove(elem)
+		delete(p.entries, path)
+		p.logger.Debug("Reloading changed quest file", zap.String("path", path))
+	}
+}
+
+// read returns the file contents, from memory when possible.
+func (p *QuestFileProvider) read(path string) ([]byte, error) {
+	path = filepath.Clean(path)
+	p.Lock()
+	if elem, ok := p.entries[path]; ok {
+		p.lru.MoveToFront(elem)
+		entry := elem.Value.(*questFileEntry)
+		p.Unlock()
+		return entry.data, entry.err
+	}
+	generation := p.generation
+	p.Unlock()
+
+	data, err := p.readFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	if p.generation != generation {
+		// The file changed while it was read, leave it to the next read.
+		return data, err
+	}
+	if elem, ok := p.entries[path]; ok {
+		// Another session loaded it in the meantime.
+		elem.Value.(*questFileEntry).data = data
+		elem.Value.(*questFileEntry).err = err
+		p.lru.MoveToFront(elem)
+		return data, err
+	}
+	p.entries[path] = p.lru.PushFront(&questFileEntry{path: path, data: data, err: err})
+	for p.lru.Len() > p.maxEntries {
+		oldest := p.lru.Back()
+		p.lru.Remove(oldest)
+		delete(p.entries, oldest.Value.(*questFileEntry).path)
+	}
+	return data, err
+}
+
+// Quest returns the quest binary with the given name. Files in overridePath,
+// if set, take precedence over the ones in the quests directory.
+func (p *QuestFileProvider) Quest(name string, overridePath string) ([]byte, error) {
+	// The name comes from the client, never let it leave the quest directories.
+	name = filepath.Base(name) + ".bin"
+	if overridePath != "" {
+		for _, candidate := range []string{questOverrideFile, name} {
+			data, err := p.read(filepath.Join(overridePath, candidate))
+			if err == nil {
+				return data, nil
+			} else if !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+	}
+	return p.read(filepath.Join(p.binPath, "quests", name))
+}
+
+// Scenario returns the scenario binary with the given name.
+func (p *QuestFileProvider) Scenario(name string) ([]byte, error) {
+	return p.read(filepath.Join(p.binPath, "scenarios", filepath.Base(name)+".bin"))
+}
@@ -0,0 +1,118 @@
+package channelserver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func writeQuestFile(t *testing.T, path string, data string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQuestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	binPath := filepath.Join(dir, "bin")
+	overridePath := filepath.Join(dir, "override")
+	writeQuestFile(t, filepath.Join(binPath, "quests", "a.bin"), "a")
+	writeQuestFile(t, filepath.Join(binPath, "quests", "b.bin"), "b")
+	writeQuestFile(t, filepath.Join(overridePath, "b.bin"), "override b")
+
+	p := NewQuestFileProvider(zap.NewNop(), binPath, 1)
+
+	data, err := p.Quest("a", overridePath)
+	if err != nil || string(data) != "a" {
+		t.Fatalf("Quest(a) = %q, %v", data, err)
+	}
+	data, err = p.Quest("b", overridePath)
+	if err != nil || string(data) != "override b" {
+		t.Fatalf("Quest(b) with override = %q, %v", data, err)
+	}
+	data, err = p.Quest("b", "")
+	if err != nil || string(data) != "b" {
+		t.Fatalf("Quest(b) without override = %q, %v", data, err)
+	}
+	if p.lru.Len() != 1 {
+		t.Errorf("cache holds %d entries, expected 1", p.lru.Len())
+	}
+
+	// Client supplied names must not escape the quest directory.
+	writeQuestFile(t, filepath.Join(binPath, "secret.bin"), "secret")
+	if _, err = p.Quest("../secret", ""); err == nil {
+		t.Error("expected an error for a path outside the quest directory")
+	}
+
+	if _, err = p.Quest("missing", overridePath); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error for a missing quest, got %v", err)
+	}
+}
+
+func TestQuestFileProviderInvalidate(t *testing.T) {
+	binPath := t.TempDir()
+	path := filepath.Join(binPath, "scenarios", "s.bin")
+	writeQuestFile(t, path, "old")
+
+	p := NewQuestFileProvider(zap.NewNop(), binPath, 8)
+	if data, _ := p.Scenario("s"); string(data) != "old" {
+		t.Fatalf("Scenario(s) = %q", data)
+	}
+	writeQuestFile(t, path, "new")
+	if data, _ := p.Scenario("s"); string(data) != "old" {
+		t.Fatalf("expected the cached scenario, got %q", data)
+	}
+	p.invalidate(path)
+	if data, _ := p.Scenario("s"); string(data) != "new" {
+		t.Fatalf("expected the reloaded scenario, got %q", data)
+	}
+}
+
+func TestQuestFileProviderMisses(t *testing.T) {
+	binPath := t.TempDir()
+	overridePath := t.TempDir()
+	writeQuestFile(t, filepath.Join(binPath, "quests", "q.bin"), "q")
+
+	p := NewQuestFileProvider(zap.NewNop(), binPath, 8)
+	if data, _ := p.Quest("q", overridePath); string(data) != "q" {
+		t.Fatalf("Quest(q) = %q", data)
+	}
+	// The missing override files are cached as misses until they show up.
+	path := filepath.Join(overridePath, "q.bin")
+	writeQuestFile(t, path, "override q")
+	if data, _ := p.Quest("q", overridePath); string(data) != "q" {
+		t.Fatalf("expected the cached miss to serve the quest directory, got %q", data)
+	}
+	p.invalidate(path)
+	if data, _ := p.Quest("q", overridePath); string(data) != "override q" {
+		t.Fatalf("expected the new override, got %q", data)
+	}
+}
+
+func TestQuestFileProviderStaleRead(t *testing.T) {
+	binPath := t.TempDir()
+	path := filepath.Join(binPath, "scenarios", "s.bin")
+	writeQuestFile(t, path, "old")
+
+	p := NewQuestFileProvider(zap.NewNop(), binPath, 8)
+	// The file changes on disk while it's being read.
+	p.readFile = func(name string) ([]byte, error) {
+		data, err := ioutil.ReadFile(name)
+		writeQuestFile(t, path, "new")
+		p.invalidate(path)
+		return data, err
+	}
+	if data, _ := p.Scenario("s"); string(data) != "old" {
+		t.Fatalf("Scenario(s) = %q", data)
+	}
+	p.readFile = ioutil.ReadFile
+	if data, _ := p.Scenario("s"); string(data) != "new" {
+		t.Fatalf("expected the stale read to stay out of the cache, got %q", data)
+	}
+}
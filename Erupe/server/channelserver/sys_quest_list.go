@@ -0,0 +1,271 @@
+package channelserver
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"erupe-ce/common/byteframe"
+	"github.com/jmoiron/sqlx"
+)
+
+// questListPageSize is the number of quests sent per MSG_MHF_ENUMERATE_QUEST
+// page, matching the offsets (0, 42, 84...) the client requests.
+const questListPageSize = 42
+
+// questListEntryHeaderSize is the size of an entry before its length prefixed quest data.
+const questListEntryHeaderSize = 22
+
+// questListTrailerSize is the size of the tune value count, total count and offset ending a page.
+const questListTrailerSize = 6
+
+var errTruncatedQuestList = errors.New("truncated quest list")
+
+// QuestListEntry is a single quest as it appears in a quest list page. The
+// unknown fields are kept as read so that lists are rebuilt byte for byte.
+type QuestListEntry struct {
+	EventID    uint32 `db:"event_id"`
+	Unk0       uint32 `db:"unk0"`
+	Unk1       uint8  `db:"unk1"`
+	MaxPlayers uint8  `db:"max_players"`
+	QuestType  uint8  `db:"quest_type"`
+	Unk2       uint8  `db:"unk2"`
+	Unk3       uint16 `db:"unk3"`
+	Mark       uint32 `db:"mark"`
+	Unk4       uint16 `db:"unk4"`
+	// Quest info body (header, strings...) copied into the list.
+	Data []byte `db:"quest_data"`
+}
+
+// QuestCatalogueEntry is a row of the quests catalogue table.
+type QuestCatalogueEntry struct {
+	QuestListEntry
+	Enabled     bool       `db:"enabled"`
+	EnableWeeks string     `db:"enable_weeks"`
+	StartTime   *time.Time `db:"start_time"`
+	EndTime     *time.Time `db:"end_time"`
+}
+
+// QuestListPage is a page of the quest list as sent to the client.
+type QuestListPage struct {
+	Entries []QuestListEntry
+	// TuneValues holds the tune value count and the values, kept verbatim as
+	// their layout is unknown. Empty stands for no tune values.
+	TuneValues []byte
+	Total      uint16
+	Offset     uint16
+}
+
+func (e *QuestListEntry) build(bf *byteframe.ByteFrame) {
+	bf.WriteUint32(e.EventID)
+	bf.WriteUint32(e.Unk0)
+	bf.WriteUint8(e.Unk1)
+	bf.WriteUint8(e.MaxPlayers)
+	bf.WriteUint8(e.QuestType)
+	bf.WriteUint8(e.Unk2)
+	bf.WriteUint16(e.Unk3)
+	bf.WriteUint32(e.Mark)
+	bf.WriteUint16(e.Unk4)
+	bf.WriteUint16(uint16(len(e.Data)))
+	bf.WriteBytes(e.Data)
+}
+
+func parseQuestListEntry(bf *byteframe.ByteFrame) (QuestListEntry, error) {
+	var e QuestListEntry
+	if len(bf.DataFromCurrent()) < questListEntryHeaderSize {
+		return e, errTruncatedQuestList
+	}
+	e.EventID = bf.ReadUint32()
+	e.Unk0 = bf.ReadUint32()
+	e.Unk1 = bf.ReadUint8()
+	e.MaxPlayers = bf.ReadUint8()
+	e.QuestType = bf.ReadUint8()
+	e.Unk2 = bf.ReadUint8()
+	e.Unk3 = bf.ReadUint16()
+	e.Mark = bf.ReadUint32()
+	e.Unk4 = bf.ReadUint16()
+	dataLen := bf.ReadUint16()
+	if len(bf.DataFromCurrent()) < int(dataLen) {
+		return e, errTruncatedQuestList
+	}
+	e.Data = append([]byte{}, bf.ReadBytes(uint(dataLen))...)
+	return e, nil
+}
+
+// Build builds the page as sent to the client.
+func (p *QuestListPage) Build() []byte {
+	bf := byteframe.NewByteFrame()
+	bf.WriteUint16(uint16(len(p.Entries)))
+	for i := range p.Entries {
+		p.Entries[i].build(bf)
+	}
+	if len(p.TuneValues) > 0 {
+		bf.WriteBytes(p.TuneValues)
+	} else {
+		bf.WriteUint16(0) // Tune value count
+	}
+	bf.WriteUint16(p.Total)
+	bf.WriteUint16(p.Offset)
+	return bf.Data()
+}
+
+// BuildQuestList builds the quest list page starting at offset.
+func BuildQuestList(entries []QuestListEntry, offset uint16, tuneValues []byte) []byte {
+	var page []QuestListEntry
+	if int(offset) < len(entries) {
+		page = entries[offset:]
+	}
+	if len(page) > questListPageSize {
+		page = page[:questListPageSize]
+	}
+	return (&QuestListPage{
+		Entries:    page,
+		TuneValues: tuneValues,
+		Total:      uint16(len(entries)),
+		Offset:     offset,
+	}).Build()
+}
+
+// ParseQuestList reads a quest list page, as built by BuildQuestList or
+// captured from the original servers.
+func ParseQuestList(data []byte) (*QuestListPage, error) {
+	bf := byteframe.NewByteFrameFromBytes(data)
+	if len(data) < 2 {
+		return nil, errTruncatedQuestList
+	}
+	count := bf.ReadUint16()
+	page := &QuestListPage{Entries: make([]QuestListEntry, 0, count)}
+	for i := uint16(0); i < count; i++ {
+		e, err := parseQuestListEntry(bf)
+		if err != nil {
+			return nil, fmt.Errorf("quest %d: %w", i, err)
+		}
+		page.Entries = append(page.Entries, e)
+	}
+	rest := bf.DataFromCurrent()
+	if len(rest) < questListTrailerSize {
+		return nil, errTruncatedQuestList
+	}
+	page.TuneValues = append([]byte{}, rest[:len(rest)-4]...)
+	trailer := byteframe.NewByteFrameFromBytes(rest[len(rest)-4:])
+	page.Total = trailer.ReadUint16()
+	page.Offset = trailer.ReadUint16()
+	return page, nil
+}
+
+// questEnabledThisWeek follows the enable_weeks convention of the road shop,
+// a comma separated list of the weeks (ISO week modulo 4) the quest is listed in.
+func questEnabledThisWeek(enableWeeks string, week int) bool {
+	if enableWeeks == "" {
+		return true
+	}
+	return contains(strings.Split(enableWeeks, ","), strconv.Itoa(week%4))
+}
+
+// listed reports whether a catalogue quest is listed at now, week being the ISO
+// week of the game time.
+func (q *QuestCatalogueEntry) listed(now time.Time, week int) bool {
+	return q.Enabled && (q.StartTime == nil || !now.Before(*q.StartTime)) &&
+		(q.EndTime == nil || now.Before(*q.EndTime)) && questEnabledThisWeek(q.EnableWeeks, week)
+}
+
+// loadQuestCatalogue returns the quests listed by the catalogue at now, in list
+// order, and whether the catalogue has any quest at all.
+func loadQuestCatalogue(db *sqlx.DB, now time.Time, week int) ([]QuestListEntry, bool, error) {
+	var rows []QuestCatalogueEntry
+	err := db.Select(&rows, `
+		SELECT event_id, unk0, unk1, max_players, quest_type, unk2, unk3, mark, unk4, quest_data,
+			enabled, COALESCE(enable_weeks, '') AS enable_weeks, start_time, end_time
+		FROM quests ORDER BY sort_order, id`)
+	if err != nil {
+		return nil, false, err
+	}
+	entries := make([]QuestListEntry, 0, len(rows))
+	for _, row := range rows {
+		if row.listed(now, week) {
+			entries = append(entries, row.QuestListEntry)
+		}
+	}
+	return entries, len(rows) > 0, nil
+}
+
+// loadQuestTuneValues returns the tune values sent with the quest list page at offset.
+func loadQuestTuneValues(db *sqlx.DB, offset uint16) ([]byte, error) {
+	var tuneValues []byte
+	err := db.QueryRow("SELECT tune_values FROM quest_tune_values WHERE list_offset=$1", offset).Scan(&tuneValues)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return tuneValues, err
+}
+
+var questListFileRegex = regexp.MustCompile(`^list_(\d+)\.bin$`)
+
+// ImportQuestLists fills the quests catalogue from the list_<offset>.bin files
+// in dir, keeping their order. It returns the number of imported quests.
+func ImportQuestLists(db *sqlx.DB, dir string) (int, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	type listFile struct {
+		offset int
+		name   string
+	}
+	var lists []listFile
+	for _, f := range files {
+		match := questListFileRegex.FindStringSubmatch(f.Name())
+		if match == nil {
+			continue
+		}
+		offset, _ := strconv.Atoi(match[1])
+		lists = append(lists, listFile{offset, f.Name()})
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		return lists[i].offset < lists[j].offset
+	})
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	imported := 0
+	for _, list := range lists {
+		data, err := ioutil.ReadFile(filepath.Join(dir, list.name))
+		if err != nil {
+			return 0, err
+		}
+		page, err := ParseQuestList(data)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", list.name, err)
+		}
+		for _, e := range page.Entries {
+			_, err = tx.Exec(`
+				INSERT INTO quests (event_id, unk0, unk1, max_players, quest_type, unk2, unk3, mark, unk4, quest_data, sort_order)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+				e.EventID, e.Unk0, e.Unk1, e.MaxPlayers, e.QuestType, e.Unk2, e.Unk3, e.Mark, e.Unk4, e.Data, imported,
+			)
+			if err != nil {
+				return 0, err
+			}
+			imported++
+		}
+		_, err = tx.Exec(`
+			INSERT INTO quest_tune_values (list_offset, tune_values) VALUES ($1, $2)
+			ON CONFLICT (list_offset) DO UPDATE SET tune_values=$2`, list.offset, page.TuneValues,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return imported, tx.Commit()
+}
@@ -0,0 +1,140 @@
+package channelserver
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"erupe-ce/common/byteframe"
+)
+
+func TestQuestListRoundTrip(t *testing.T) {
+	var entries []QuestListEntry
+	for i := 0; i < questListPageSize+3; i++ {
+		entries = append(entries, QuestListEntry{
+			EventID:    uint32(i + 1),
+			MaxPlayers: 4,
+			QuestType:  uint8(i % 10),
+			Mark:       uint32(i),
+			Data:       []byte{byte(i), 0xAA, 0xBB},
+		})
+	}
+
+	first, err := ParseQuestList(BuildQuestList(entries, 0, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Entries) != questListPageSize {
+		t.Fatalf("first page has %d quests, expected %d", len(first.Entries), questListPageSize)
+	}
+
+	second := BuildQuestList(entries, questListPageSize, nil)
+	parsed, err := ParseQuestList(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Entries) != 3 {
+		t.Fatalf("second page has %d quests, expected 3", len(parsed.Entries))
+	}
+	for i, e := range parsed.Entries {
+		want := entries[questListPageSize+i]
+		if e.EventID != want.EventID || e.QuestType != want.QuestType || e.Mark != want.Mark || !bytes.Equal(e.Data, want.Data) {
+			t.Errorf("quest %d = %+v, expected %+v", i, e, want)
+		}
+	}
+
+	// The page ends with the tune value count, total count and offset.
+	trailer := byteframe.NewByteFrameFromBytes(second[len(second)-6:])
+	if trailer.ReadUint16() != 0 || trailer.ReadUint16() != uint16(len(entries)) || trailer.ReadUint16() != questListPageSize {
+		t.Error("unexpected quest list trailer")
+	}
+}
+
+// questListCapture is a page laid out like the lists captured from the
+// original servers, with every unknown field and the tune values set.
+var questListCapture = []byte{
+	0x00, 0x02, // Quest count
+	// Quest 1
+	0x00, 0x00, 0xEA, 0x61, // Event ID
+	0x12, 0x34, 0x56, 0x78, // Unk0
+	0x01,       // Unk1
+	0x04,       // Max players
+	0x12,       // Quest type
+	0x01,       // Unk2
+	0xAB, 0xCD, // Unk3
+	0x00, 0x00, 0x00, 0x02, // Mark
+	0x00, 0x07, // Unk4
+	0x00, 0x03, 0x11, 0x22, 0x33, // Quest data
+	// Quest 2
+	0x00, 0x00, 0xEA, 0x62,
+	0x00, 0x00, 0x00, 0x00,
+	0x00,
+	0x02,
+	0x09,
+	0x00,
+	0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00,
+	0x00, 0x01, 0x44,
+	// Tune values
+	0x00, 0x02, 0x00, 0x10, 0x00, 0x64, 0x00, 0x11, 0x00, 0x32,
+	0x00, 0x02, // Total
+	0x00, 0x00, // Offset
+}
+
+func TestQuestListCaptureRoundTrip(t *testing.T) {
+	page, err := ParseQuestList(questListCapture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := page.Entries[0]; e.Unk0 != 0x12345678 || e.Unk1 != 1 || e.Unk2 != 1 || e.Unk3 != 0xABCD || e.Unk4 != 7 {
+		t.Errorf("unexpected unknown fields %+v", e)
+	}
+	if got := page.Build(); !bytes.Equal(got, questListCapture) {
+		t.Errorf("rebuilt page\n%X\ndiffers from capture\n%X", got, questListCapture)
+	}
+	if got := BuildQuestList(page.Entries, page.Offset, page.TuneValues); !bytes.Equal(got, questListCapture) {
+		t.Errorf("built list\n%X\ndiffers from capture\n%X", got, questListCapture)
+	}
+}
+
+func TestQuestCatalogueListed(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+	tests := []struct {
+		name  string
+		entry QuestCatalogueEntry
+		want  bool
+	}{
+		{"enabled", QuestCatalogueEntry{Enabled: true}, true},
+		{"disabled", QuestCatalogueEntry{}, false},
+		{"started", QuestCatalogueEntry{Enabled: true, StartTime: &before}, true},
+		{"not started", QuestCatalogueEntry{Enabled: true, StartTime: &after}, false},
+		{"ended", QuestCatalogueEntry{Enabled: true, EndTime: &before}, false},
+		{"other week", QuestCatalogueEntry{Enabled: true, EnableWeeks: "2"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.entry.listed(now, 5); got != tt.want {
+			t.Errorf("%s: listed = %v, expected %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseQuestListTruncated(t *testing.T) {
+	data := BuildQuestList([]QuestListEntry{{EventID: 1, Data: []byte{1, 2, 3, 4}}}, 0, nil)
+	if _, err := ParseQuestList(data[:20]); err == nil {
+		t.Error("expected an error for a truncated quest list")
+	}
+}
+
+func TestQuestEnabledThisWeek(t *testing.T) {
+	if !questEnabledThisWeek("", 5) {
+		t.Error("quests without enable_weeks should always be listed")
+	}
+	if !questEnabledThisWeek("0,1", 5) {
+		t.Error("week 5 is season 1")
+	}
+	if questEnabledThisWeek("2", 5) {
+		t.Error("week 5 is not season 2")
+	}
+}
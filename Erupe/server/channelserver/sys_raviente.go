@@ -0,0 +1,250 @@
+package channelserver
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"erupe-ce/config"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const (
+	ravienteRegisterSize = 5
+	ravienteStateSize    = 29
+	ravienteSupportSize  = 25
+)
+
+// Raviente is the Great Slaying of a world, shared by all of its channels.
+// With a database, the state is kept in the raviente_state row of the world
+// and every change is made under a lock of that row, so that the channels of
+// the world see each other's changes whichever process runs them.
+type Raviente struct {
+	// Mutex guards data, the state used without a database.
+	sync.Mutex
+
+	db      *sqlx.DB
+	logger  *zap.Logger
+	worldID uint16
+	config  config.Raviente
+
+	data ravienteData
+}
+
+// ravienteData is the state of the Great Slaying of a world.
+type ravienteData struct {
+	// siege counts the Great Slayings of the world, contributions are logged per siege.
+	siege int
+	// hostID is the channel the current Great Slaying is fought on.
+	hostID uint16
+
+	register RavienteRegister
+	state    RavienteState
+	support  RavienteSupport
+}
+
+type RavienteRegister struct {
+	nextTime     uint32
+	startTime    uint32
+	postTime     uint32
+	killedTime   uint32
+	ravienteType uint32
+	maxPlayers   uint32
+	carveQuest   uint32
+	register     []uint32
+}
+
+type RavienteState struct {
+	damageMultiplier uint32
+	stateData        []uint32
+}
+
+type RavienteSupport struct {
+	supportData []uint32
+}
+
+// NewRaviente sets up the Great Slaying of the world of a channel, kept in
+// the database when db is set.
+func NewRaviente(db *sqlx.DB, logger *zap.Logger, serverID uint16, cfg config.Raviente) *Raviente {
+	r := &Raviente{
+		db:      db,
+		logger:  logger,
+		worldID: WorldIndex(serverID),
+		config:  cfg,
+	}
+	r.data.clear()
+	return r
+}
+
+// clear empties the registers without touching the siege count.
+func (d *ravienteData) clear() {
+	d.register = RavienteRegister{register: make([]uint32, ravienteRegisterSize)}
+	d.state = RavienteState{damageMultiplier: 1, stateData: make([]uint32, ravienteStateSize)}
+	d.support = RavienteSupport{supportData: make([]uint32, ravienteSupportSize)}
+	d.hostID = 0
+}
+
+// reset ends the current Great Slaying.
+func (d *ravienteData) reset() {
+	d.clear()
+	d.siege++
+}
+
+// copy returns a copy of d sharing none of its registers.
+func (d *ravienteData) copy() *ravienteData {
+	c := *d
+	c.register.register = append([]uint32{}, d.register.register...)
+	c.state.stateData = append([]uint32{}, d.state.stateData...)
+	c.support.supportData = append([]uint32{}, d.support.supportData...)
+	return &c
+}
+
+func toUint32s(values pq.Int64Array, size int) []uint32 {
+	out := make([]uint32, size)
+	for i := 0; i < size && i < len(values); i++ {
+		out[i] = uint32(values[i])
+	}
+	return out
+}
+
+func toInt64s(values []uint32) pq.Int64Array {
+	out := make(pq.Int64Array, len(values))
+	for i, v := range values {
+		out[i] = int64(v)
+	}
+	return out
+}
+
+// update applies the schedule then fn, which reports whether it changed the
+// state, and returns the resulting state. With a database, the state is read
+// and written back in a transaction holding the lock of the world's row.
+func (r *Raviente) update(now time.Time, fn func(d *ravienteData) bool) (*ravienteData, error) {
+	if r.db == nil {
+		r.Lock()
+		defer r.Unlock()
+		r.tick(&r.data, now)
+		if fn != nil {
+			fn(&r.data)
+		}
+		return r.data.copy(), nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Makes sure the row exists so it can be locked.
+	_, err = tx.Exec(`
+		INSERT INTO raviente_state (world_id, register, state, support) VALUES ($1, '{}', '{}', '{}')
+		ON CONFLICT DO NOTHING`, r.worldID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	d := &ravienteData{}
+	var register, state, support pq.Int64Array
+	err = tx.QueryRow(`
+		SELECT siege, host_id, next_time, start_time, killed_time, post_time, raviente_type, max_players, carve_quest,
+			register, state, support, damage_multiplier
+		FROM raviente_state WHERE world_id=$1 FOR UPDATE`, r.worldID,
+	).Scan(
+		&d.siege, &d.hostID, &d.register.nextTime, &d.register.startTime, &d.register.killedTime, &d.register.postTime,
+		&d.register.ravienteType, &d.register.maxPlayers, &d.register.carveQuest,
+		&register, &state, &support, &d.state.damageMultiplier,
+	)
+	if err != nil {
+		return nil, err
+	}
+	d.register.register = toUint32s(register, ravienteRegisterSize)
+	d.state.stateData = toUint32s(state, ravienteStateSize)
+	d.support.supportData = toUint32s(support, ravienteSupportSize)
+
+	changed := r.tick(d, now)
+	if fn != nil && fn(d) {
+		changed = true
+	}
+	if !changed {
+		return d, tx.Commit()
+	}
+	_, err = tx.Exec(`
+		UPDATE raviente_state SET siege=$2, host_id=$3, next_time=$4, start_time=$5, killed_time=$6, post_time=$7,
+			raviente_type=$8, max_players=$9, carve_quest=$10, register=$11, state=$12, support=$13,
+			damage_multiplier=$14, updated_at=now()
+		WHERE world_id=$1`,
+		r.worldID, d.siege, d.hostID, d.register.nextTime, d.register.startTime, d.register.killedTime, d.register.postTime,
+		d.register.ravienteType, d.register.maxPlayers, d.register.carveQuest,
+		toInt64s(d.register.register), toInt64s(d.state.stateData), toInt64s(d.support.supportData),
+		d.state.damageMultiplier,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return d, tx.Commit()
+}
+
+// view returns the current state.
+func (r *Raviente) view(now time.Time) (*ravienteData, error) {
+	return r.update(now, nil)
+}
+
+// nextRavienteTime returns the first scheduled hour strictly after now.
+func nextRavienteTime(now time.Time, hours []int) time.Time {
+	sorted := append([]int{}, hours...)
+	sort.Ints(sorted)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for day := 0; day < 2; day++ {
+		for _, hour := range sorted {
+			t := midnight.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour)
+			if t.After(now) {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// tick resets a Great Slaying past its duration and follows the schedule,
+// reporting whether it changed d.
+func (r *Raviente) tick(d *ravienteData, now time.Time) bool {
+	changed := false
+	duration := int64(r.config.Duration) * 60
+	if d.register.startTime != 0 && duration > 0 && now.Unix() >= int64(d.register.startTime)+duration {
+		r.logger.Info("Resetting Raviente after its duration", zap.Uint16("world", r.worldID), zap.Int("siege", d.siege))
+		d.reset()
+		changed = true
+	}
+	if len(r.config.Hours) > 0 && d.register.startTime == 0 {
+		if d.register.nextTime == 0 {
+			if next := nextRavienteTime(now, r.config.Hours); !next.IsZero() {
+				d.register.nextTime = uint32(next.Unix())
+				changed = true
+			}
+		} else if now.Unix() >= int64(d.register.nextTime) {
+			d.register.startTime = d.register.nextTime
+			changed = true
+		}
+	}
+	return changed
+}
+
+// logContribution adds what a character did in a siege to the contribution log.
+func (r *Raviente) logContribution(siege int, charID uint32, damage uint32, support uint32) {
+	if r.db == nil || (damage == 0 && support == 0) {
+		return
+	}
+	_, err := r.db.Exec(`
+		INSERT INTO raviente_contributions (world_id, siege, char_id, damage, support) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (world_id, siege, char_id) DO UPDATE SET
+			damage = raviente_contributions.damage + excluded.damage,
+			support = raviente_contributions.support + excluded.support,
+			updated_at = now()`,
+		r.worldID, siege, charID, damage, support,
+	)
+	if err != nil {
+		r.logger.Error("Failed to log Raviente contribution", zap.Uint32("charID", charID), zap.Error(err))
+	}
+}
@@ -0,0 +1,71 @@
+package channelserver
+
+import (
+	"testing"
+	"time"
+
+	"erupe-ce/config"
+	"go.uber.org/zap"
+)
+
+func TestNextRavienteTime(t *testing.T) {
+	loc := time.FixedZone("UTC+9", 9*60*60)
+	now := time.Date(2022, 5, 10, 19, 30, 0, 0, loc)
+	tests := []struct {
+		hours    []int
+		expected time.Time
+	}{
+		{[]int{20}, time.Date(2022, 5, 10, 20, 0, 0, 0, loc)},
+		{[]int{22, 12}, time.Date(2022, 5, 10, 22, 0, 0, 0, loc)},
+		{[]int{12}, time.Date(2022, 5, 11, 12, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		if got := nextRavienteTime(now, tt.hours); !got.Equal(tt.expected) {
+			t.Errorf("nextRavienteTime(%v) = %v, expected %v", tt.hours, got, tt.expected)
+		}
+	}
+}
+
+func TestRavienteTick(t *testing.T) {
+	r := NewRaviente(nil, zap.NewNop(), 0, config.Raviente{Hours: []int{20}, Duration: 60})
+	start := time.Date(2022, 5, 10, 19, 30, 0, 0, time.UTC)
+
+	d, _ := r.view(start)
+	if d.register.nextTime != uint32(start.Add(30*time.Minute).Unix()) || d.register.startTime != 0 {
+		t.Fatalf("expected the next siege to be scheduled, got %+v", d.register)
+	}
+	d, _ = r.view(start.Add(31 * time.Minute))
+	if d.register.startTime != d.register.nextTime {
+		t.Fatalf("expected the siege to start, got %+v", d.register)
+	}
+
+	r.update(start.Add(32*time.Minute), func(d *ravienteData) bool {
+		d.state.stateData[0] = 1000
+		return true
+	})
+	// Returned states are copies.
+	d.state.stateData[1] = 1000
+	d, _ = r.view(start.Add(33 * time.Minute))
+	if d.state.stateData[0] != 1000 || d.state.stateData[1] != 0 {
+		t.Fatalf("unexpected state %v", d.state.stateData)
+	}
+
+	d, _ = r.view(start.Add(91 * time.Minute))
+	if d.siege != 1 || d.state.stateData[0] != 0 {
+		t.Fatalf("expected the siege to be reset after its duration, got siege %d", d.siege)
+	}
+	if d.register.nextTime != uint32(start.Add(24*time.Hour+30*time.Minute).Unix()) {
+		t.Errorf("expected the next day to be scheduled, got %d", d.register.nextTime)
+	}
+}
+
+func TestRavienteWorldKey(t *testing.T) {
+	for world := 0; world < 3; world++ {
+		for channel := 0; channel < 4; channel++ {
+			r := NewRaviente(nil, zap.NewNop(), ServerID(world, channel), config.Raviente{})
+			if r.worldID != uint16(world) {
+				t.Errorf("channel %d of world %d keyed Raviente with world %d", channel, world, r.worldID)
+			}
+		}
+	}
+}
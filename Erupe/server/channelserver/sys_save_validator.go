@@ -0,0 +1,129 @@
+package channelserver
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"erupe-ce/common/bfutil"
+	"erupe-ce/common/stringsupport"
+	"erupe-ce/server/channelserver/compression/deltacomp"
+	"erupe-ce/server/channelserver/compression/nullcomp"
+)
+
+// Offsets of the savedata fields mirrored into the characters table.
+const (
+	saveOffsetGender     = 80     // 0x50
+	saveOffsetName       = 88     // 0x58, 12 bytes
+	saveOffsetWeaponID   = 128522 // 0x1F60A
+	saveOffsetWeaponType = 128789 // 0x1F715
+	saveOffsetHRP        = 130550 // 0x1FDF6
+	saveOffsetGRP        = 130556 // 0x1FDFC
+)
+
+const (
+	// saveDataMinLength is the smallest savedata holding every field above.
+	saveDataMinLength = saveOffsetGRP + 4
+	// saveDataMaxLength is well above the savedata of any client.
+	saveDataMaxLength = 1 << 20
+
+	saveMaxWeaponType = 13
+	saveMaxRank       = 999
+	// saveMaxGRP is the GRP of GR 1000, see grpToGR.
+	saveMaxGRP = 11345900 + 100*23950
+)
+
+// saveDiffType is the save type of diff based saves, any other type is a full blob.
+const saveDiffType = 1
+
+// errInvalidSave wraps every reason a save gets rejected for.
+var errInvalidSave = errors.New("invalid savedata")
+
+func invalidSave(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", errInvalidSave, fmt.Sprintf(format, args...))
+}
+
+// saveFields holds the savedata fields mirrored into the characters table.
+type saveFields struct {
+	WeaponType uint8
+	IsFemale   bool
+	WeaponID   uint16
+	HRP        uint16
+	GR         uint16
+	Name       string
+}
+
+// validateSave applies a savedata payload sent by the client onto the current
+// savedata and sanity checks the result. It never trusts the payload: any
+// decompression, patching or field error is returned instead of panicking.
+func validateSave(saveType uint8, payload []byte, base []byte, strConv *stringsupport.StringConverter) ([]byte, *saveFields, error) {
+	decompressed, err := nullcomp.Decompress(payload)
+	if err != nil {
+		return nil, nil, invalidSave("decompression failed: %v", err)
+	}
+
+	var data []byte
+	if saveType == saveDiffType {
+		if len(base) == 0 {
+			return nil, nil, invalidSave("diff without any savedata to apply it to")
+		}
+		// A diff can grow the savedata, as newer clients append to it.
+		data, err = deltacomp.ApplyDataDiffChecked(decompressed, base, saveDataMaxLength)
+		if err != nil {
+			return nil, nil, invalidSave("diff failed: %v", err)
+		}
+	} else {
+		data = decompressed
+	}
+
+	if len(data) < saveDataMinLength || len(data) > saveDataMaxLength {
+		return nil, nil, invalidSave("unexpected length %d", len(data))
+	}
+
+	fields, err := parseSaveFields(data, strConv)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, fields, nil
+}
+
+// parseSaveFields extracts and sanity checks the mirrored fields, data must
+// be at least saveDataMinLength long.
+func parseSaveFields(data []byte, strConv *stringsupport.StringConverter) (*saveFields, error) {
+	fields := &saveFields{
+		WeaponType: data[saveOffsetWeaponType],
+		WeaponID:   binary.LittleEndian.Uint16(data[saveOffsetWeaponID:]),
+		HRP:        binary.LittleEndian.Uint16(data[saveOffsetHRP:]),
+	}
+
+	switch data[saveOffsetGender] {
+	case 0:
+	case 1:
+		fields.IsFemale = true
+	default:
+		return nil, invalidSave("unexpected gender %d", data[saveOffsetGender])
+	}
+
+	if fields.WeaponType > saveMaxWeaponType {
+		return nil, invalidSave("unexpected weapon type %d", fields.WeaponType)
+	}
+	if fields.HRP > saveMaxRank {
+		return nil, invalidSave("unexpected HR %d", fields.HRP)
+	}
+
+	grp := binary.LittleEndian.Uint32(data[saveOffsetGRP:])
+	if grp >= saveMaxGRP {
+		return nil, invalidSave("unexpected GRP %d", grp)
+	} else if grp > 0 {
+		fields.GR = grpToGR(grp)
+	}
+
+	name, err := strConv.Decode(bfutil.UpToNull(data[saveOffsetName : saveOffsetName+12]))
+	if err != nil || name == "" || strings.ContainsRune(name, utf8.RuneError) {
+		return nil, invalidSave("unexpected name %q", name)
+	}
+	fields.Name = name
+	return fields, nil
+}
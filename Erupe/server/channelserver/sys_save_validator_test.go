@@ -0,0 +1,89 @@
+package channelserver
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"erupe-ce/common/stringsupport"
+	"erupe-ce/server/channelserver/compression/nullcomp"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func testSaveData() []byte {
+	data := make([]byte, saveDataMinLength+16)
+	data[saveOffsetGender] = 1
+	copy(data[saveOffsetName:], "Hunter")
+	binary.LittleEndian.PutUint16(data[saveOffsetWeaponID:], 0x1234)
+	data[saveOffsetWeaponType] = 7
+	binary.LittleEndian.PutUint16(data[saveOffsetHRP:], 100)
+	binary.LittleEndian.PutUint32(data[saveOffsetGRP:], 0)
+	return data
+}
+
+// appendSaveDiff builds a diff skipping the whole base and appending extra to it.
+func appendSaveDiff(baseLen int, extra []byte) []byte {
+	var diff []byte
+	// The first match count is one more than the bytes skipped, and each
+	// empty run of different bytes steps back one byte.
+	skip := baseLen + 1
+	for skip > 0xFFFF {
+		diff = append(diff, 0, 0xFF, 0xFF, 1)
+		skip -= 0xFFFE
+	}
+	diff = append(diff, 0, byte(skip>>8), byte(skip), 0, byte((len(extra)+1)>>8), byte(len(extra)+1))
+	return append(diff, extra...)
+}
+
+func TestValidateSave(t *testing.T) {
+	strConv := &stringsupport.StringConverter{Encoding: japanese.ShiftJIS}
+	base := testSaveData()
+
+	blob, _ := nullcomp.Compress(base)
+	data, fields, err := validateSave(2, blob, nil, strConv)
+	if err != nil {
+		t.Fatalf("valid blob rejected: %v", err)
+	}
+	if len(data) != len(base) || !fields.IsFemale || fields.Name != "Hunter" || fields.WeaponType != 7 || fields.WeaponID != 0x1234 || fields.HRP != 100 {
+		t.Errorf("unexpected fields %+v", fields)
+	}
+
+	// Skip to the gender byte and set it to 0.
+	diff, _ := nullcomp.Compress([]byte{saveOffsetGender + 1, 2, 0})
+	_, fields, err = validateSave(saveDiffType, diff, base, strConv)
+	if err != nil || fields.IsFemale {
+		t.Errorf("valid diff rejected or not applied: %+v, %v", fields, err)
+	}
+
+	growing, _ := nullcomp.Compress(appendSaveDiff(len(base), []byte{1, 2, 3, 4}))
+	data, _, err = validateSave(saveDiffType, growing, base, strConv)
+	if err != nil {
+		t.Errorf("diff extending the savedata rejected: %v", err)
+	} else if len(data) != len(base)+4 || data[len(base)] != 1 || data[len(data)-1] != 4 {
+		t.Errorf("diff extending the savedata not applied, got %d bytes", len(data))
+	}
+
+	bad := testSaveData()
+	bad[saveOffsetGender] = 2
+	invalidBlob, _ := nullcomp.Compress(bad)
+	truncated, _ := nullcomp.Compress(base[:saveOffsetHRP])
+	truncatedDiff, _ := nullcomp.Compress([]byte{saveOffsetGender + 1, 3, 0})
+	oversizedDiff, _ := nullcomp.Compress(appendSaveDiff(saveDataMaxLength, []byte{1}))
+	tests := map[string]struct {
+		saveType uint8
+		payload  []byte
+		base     []byte
+	}{
+		"invalid field":   {2, invalidBlob, nil},
+		"truncated":       {2, truncated, nil},
+		"bad compression": {2, []byte{1, 2, 3}, nil},
+		"truncated diff":  {saveDiffType, truncatedDiff, base},
+		"diff on nothing": {saveDiffType, diff, nil},
+		"oversized diff":  {saveDiffType, oversizedDiff, base},
+	}
+	for name, tt := range tests {
+		if _, _, err := validateSave(tt.saveType, tt.payload, tt.base, strConv); !errors.Is(err, errInvalidSave) {
+			t.Errorf("%s: expected errInvalidSave, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package channelserver
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrSnapshotNotFound is returned when restoring a snapshot the character doesn't have.
+var ErrSnapshotNotFound = errors.New("savedata snapshot not found")
+
+// SaveSnapshot describes a stored copy of a character's compressed savedata.
+type SaveSnapshot struct {
+	ID        int       `db:"id" json:"id"`
+	CharID    uint32    `db:"char_id" json:"char_id"`
+	SaveType  uint8     `db:"save_type" json:"save_type"` // 1 for a diff, otherwise a full blob.
+	Size      int       `db:"size" json:"size"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// snapshotSaveData copies the savedata just written for the character into
+// its snapshot history, keeping only the newest keep snapshots. On failure the
+// transaction is rolled back to before the snapshot and remains usable.
+func snapshotSaveData(tx *sql.Tx, charID uint32, saveType uint8, keep int) (err error) {
+	if keep <= 0 {
+		return nil
+	}
+	if _, err = tx.Exec("SAVEPOINT snapshot"); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Exec("ROLLBACK TO SAVEPOINT snapshot")
+		}
+	}()
+	_, err = tx.Exec(`
+		INSERT INTO savedata_snapshots (char_id, savedata, save_type)
+		SELECT id, savedata, $2 FROM characters WHERE id=$1 AND savedata IS NOT NULL`,
+		charID, saveType,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		DELETE FROM savedata_snapshots WHERE char_id=$1 AND id NOT IN (
+			SELECT id FROM savedata_snapshots WHERE char_id=$1 ORDER BY id DESC LIMIT $2
+		)`,
+		charID, keep,
+	)
+	return err
+}
+
+// ListSaveSnapshots returns the snapshots of a character, newest first.
+func ListSaveSnapshots(db *sqlx.DB, charID uint32) ([]SaveSnapshot, error) {
+	snapshots := make([]SaveSnapshot, 0)
+	err := db.Select(&snapshots, `
+		SELECT id, char_id, save_type, length(savedata) AS size, created_at
+		FROM savedata_snapshots WHERE char_id=$1 ORDER BY id DESC`,
+		charID,
+	)
+	return snapshots, err
+}
+
+// RestoreSaveSnapshot overwrites the savedata of a character with one of its
+// snapshots. The character must be offline, or its client will save over it.
+func RestoreSaveSnapshot(db *sqlx.DB, charID uint32, snapshotID int) error {
+	res, err := db.Exec(`
+		UPDATE characters SET savedata=s.savedata
+		FROM savedata_snapshots s
+		WHERE characters.id=$1 AND s.id=$2 AND s.char_id=characters.id`,
+		charID, snapshotID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSnapshotNotFound
+	}
+	return nil
+}
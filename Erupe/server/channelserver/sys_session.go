@@ -1,11 +1,13 @@
 package channelserver
 
 import (
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"net"
 	"sync"
+	"time"
 
 	"erupe-ce/common/byteframe"
 	"erupe-ce/common/stringstack"
@@ -99,6 +101,7 @@ func (s *Session) QueueSendNonBlocking(data []byte) {
 		// Enqueued properly.
 	default:
 		// Couldn't enqueue, likely something wrong with the connection.
+		metricSendQueueDrops.Inc(s.server.metricLabel())
 		s.logger.Warn("Dropped packet for session because of full send buffer, something is probably wrong")
 	}
 }
@@ -135,6 +138,10 @@ func (s *Session) sendLoop() {
 			return
 		}
 
+		if len(rawPacket) >= 2 {
+			metricPacketsSent.Inc(network.PacketID(binary.BigEndian.Uint16(rawPacket)).String())
+		}
+
 		// Make a copy of the data.
 		terminatedPacket := make([]byte, len(rawPacket))
 		copy(terminatedPacket, rawPacket)
@@ -169,21 +176,22 @@ func (s *Session) handlePacketGroup(pktGroup []byte) {
 	opcodeUint16 := bf.ReadUint16()
 	opcode := network.PacketID(opcodeUint16)
 
-	// This shouldn't be needed, but it's better to recover and let the connection die than to panic the server.
+	// This shouldn't be needed, but it's better to recover and fail the request than to panic the server.
+	var mhfPkt mhfpacket.MHFPacket
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Printf("[%s]", s.Name)
-			fmt.Println("Recovered from panic", r)
+			s.handlerPanicked(opcode, mhfPkt, r)
 		}
 	}()
 
+	metricPacketsReceived.Inc(opcode.String())
 	s.logMessage(opcodeUint16, pktGroup, s.Name, "Server")
 
 	if opcode == network.MSG_SYS_LOGOUT {
 		s.rawConn.Close()
 	}
 	// Get the packet parser and handler for this opcode.
-	mhfPkt := mhfpacket.FromOpcode(opcode)
+	mhfPkt = mhfpacket.FromOpcode(opcode)
 	if mhfPkt == nil {
 		fmt.Println("Got opcode which we don't know how to parse, can't parse anymore for this group")
 		return
@@ -194,8 +202,13 @@ func (s *Session) handlePacketGroup(pktGroup []byte) {
 		fmt.Printf("\n!!! [%s] %s NOT IMPLEMENTED !!! \n\n\n", s.Name, opcode)
 		return
 	}
-	// Handle the packet.
-	handlerTable[opcode](s, mhfPkt)
+	// Handle the packet, failed requests get a failure ack instead of leaving the client waiting.
+	start := time.Now()
+	err = handlerTable[opcode](s, mhfPkt)
+	metricHandlerDuration.Observe(time.Since(start).Seconds(), opcode.String())
+	if err != nil {
+		s.handlerFailed(opcode, err)
+	}
 	// If there is more data on the stream that the .Parse method didn't read, then read another packet off it.
 	remainingData := bf.DataFromCurrent()
 	if len(remainingData) >= 2 {
@@ -46,3 +46,13 @@ func Time_static() time.Time {
 	}
 	return TimeStatic
 }
+
+// Time_Adjusted converts a real time to the time the client expects.
+func Time_Adjusted(t time.Time) time.Time {
+	return t.In(time.FixedZone(fmt.Sprintf("UTC+%d", Offset), Offset*60*60)).AddDate(YearAdjust, MonthAdjust, DayAdjust)
+}
+
+// Time_Unadjusted converts a time of the client back to the real time.
+func Time_Unadjusted(t time.Time) time.Time {
+	return t.AddDate(-YearAdjust, -MonthAdjust, -DayAdjust)
+}
@@ -0,0 +1,245 @@
+package channelserver
+
+import (
+	"database/sql"
+	"io"
+	"time"
+
+	"erupe-ce/common/byteframe"
+	"erupe-ce/network/mhfpacket"
+	"github.com/jmoiron/sqlx"
+)
+
+// Warehouse box types.
+const (
+	warehouseItemBox  = 0
+	warehouseEquipBox = 1
+)
+
+const (
+	// warehouseMaxBoxIndex is the last page of each box type.
+	warehouseMaxBoxIndex = 10
+	// warehouseDailyUsages is how many times the warehouse can be used per day.
+	warehouseDailyUsages = 10000
+
+	warehouseItemSize  = 12
+	warehouseEquipSize = 8 + mhfpacket.WarehouseEquipDataSize
+)
+
+func validWarehouseBox(boxType uint8, boxIndex uint8) bool {
+	return (boxType == warehouseItemBox || boxType == warehouseEquipBox) && boxIndex <= warehouseMaxBoxIndex
+}
+
+// warehouseUnknownIndex is the slot of the stacks stored before slots were kept.
+const warehouseUnknownIndex = 0xFFFF
+
+// decodeWarehouseBox reads the stacks of a page stored in the format sent by
+// handleMsgMhfEnumerateWarehouse, followed by the slot of each stack.
+// Truncated data yields the complete stacks only.
+func decodeWarehouseBox(boxType uint8, data []byte) []mhfpacket.WarehouseStack {
+	if len(data) < 4 {
+		return nil
+	}
+	size := warehouseItemSize
+	if boxType == warehouseEquipBox {
+		size = warehouseEquipSize
+	}
+	bf := byteframe.NewByteFrameFromBytes(data)
+	count := int(bf.ReadUint16())
+	_ = bf.ReadUint16() // Zeroed
+	if max := (len(data) - 4) / size; count > max {
+		count = max
+	}
+	stacks := make([]mhfpacket.WarehouseStack, count)
+	for i := range stacks {
+		stacks[i].ID = bf.ReadUint32()
+		if boxType == warehouseEquipBox {
+			stacks[i].EquipType = bf.ReadUint16()
+			stacks[i].ItemID = bf.ReadUint16()
+			stacks[i].Data = bf.ReadBytes(mhfpacket.WarehouseEquipDataSize)
+		} else {
+			stacks[i].ItemID = bf.ReadUint16()
+			stacks[i].Quantity = bf.ReadUint16()
+			_ = bf.ReadUint32() // Unk
+		}
+	}
+	indexed := len(bf.DataFromCurrent()) >= 2*count
+	for i := range stacks {
+		if indexed {
+			stacks[i].Index = bf.ReadUint16()
+		} else {
+			stacks[i].Index = warehouseUnknownIndex
+		}
+	}
+	return stacks
+}
+
+// encodeWarehouseBox writes the stacks of a page as sent to the client.
+func encodeWarehouseBox(boxType uint8, stacks []mhfpacket.WarehouseStack) []byte {
+	bf := byteframe.NewByteFrame()
+	bf.WriteUint16(uint16(len(stacks)))
+	bf.WriteUint16(0)
+	for _, stack := range stacks {
+		bf.WriteUint32(stack.ID)
+		if boxType == warehouseEquipBox {
+			data := make([]byte, mhfpacket.WarehouseEquipDataSize)
+			copy(data, stack.Data)
+			bf.WriteUint16(stack.EquipType)
+			bf.WriteUint16(stack.ItemID)
+			bf.WriteBytes(data)
+		} else {
+			bf.WriteUint16(stack.ItemID)
+			bf.WriteUint16(stack.Quantity)
+			bf.WriteUint32(0)
+		}
+	}
+	return bf.Data()
+}
+
+// storeWarehouseBox writes the stacks of a page as stored, the client format
+// followed by the slot of each stack.
+func storeWarehouseBox(boxType uint8, stacks []mhfpacket.WarehouseStack) []byte {
+	bf := byteframe.NewByteFrameFromBytes(encodeWarehouseBox(boxType, stacks))
+	bf.Seek(0, io.SeekEnd)
+	for _, stack := range stacks {
+		bf.WriteUint16(stack.Index)
+	}
+	return bf.Data()
+}
+
+// mergeWarehouseBox applies the changes sent by the client to the stacks of a
+// page. Changes to a known ID replace the stack, empty ones remove it. The
+// client only learns the IDs of the stacks when enumerating the page again,
+// so a change without ID replaces the stack of the same item in its slot,
+// other unknown IDs are new stacks which get an ID unique to the page.
+func mergeWarehouseBox(boxType uint8, stacks []mhfpacket.WarehouseStack, changes []mhfpacket.WarehouseStack) []mhfpacket.WarehouseStack {
+	var nextID uint32
+	for _, stack := range stacks {
+		if stack.ID > nextID {
+			nextID = stack.ID
+		}
+	}
+	for _, change := range changes {
+		found := false
+		for i := range stacks {
+			if (change.ID != 0 && stacks[i].ID == change.ID) ||
+				(change.ID == 0 && stacks[i].Index == change.Index && (change.ItemID == 0 || stacks[i].ItemID == change.ItemID)) {
+				change.ID = stacks[i].ID
+				stacks[i] = change
+				found = true
+				break
+			}
+		}
+		if !found {
+			nextID++
+			change.ID = nextID
+			stacks = append(stacks, change)
+		}
+	}
+
+	kept := stacks[:0]
+	for _, stack := range stacks {
+		if stack.ItemID == 0 || (boxType == warehouseItemBox && stack.Quantity == 0) {
+			continue
+		}
+		kept = append(kept, stack)
+	}
+	return kept
+}
+
+// warehouseBox is a stored warehouse page.
+type warehouseBox struct {
+	BoxType  uint8  `db:"box_type"`
+	BoxIndex uint8  `db:"box_index"`
+	Name     string `db:"name"`
+}
+
+func loadWarehouseBoxNames(db *sqlx.DB, charID uint32) ([]warehouseBox, error) {
+	var boxes []warehouseBox
+	err := db.Select(&boxes, `
+		SELECT box_type, box_index, name FROM warehouse_boxes
+		WHERE character_id=$1 AND name <> '' ORDER BY box_type, box_index`, charID)
+	return boxes, err
+}
+
+func loadWarehouseBox(db *sqlx.DB, charID uint32, boxType uint8, boxIndex uint8) ([]mhfpacket.WarehouseStack, error) {
+	var data []byte
+	err := db.QueryRow(
+		"SELECT data FROM warehouse_boxes WHERE character_id=$1 AND box_type=$2 AND box_index=$3",
+		charID, boxType, boxIndex,
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return decodeWarehouseBox(boxType, data), nil
+}
+
+func renameWarehouseBox(db *sqlx.DB, charID uint32, boxType uint8, boxIndex uint8, name string) error {
+	_, err := db.Exec(`
+		INSERT INTO warehouse_boxes (character_id, box_type, box_index, name) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (character_id, box_type, box_index) DO UPDATE SET name=excluded.name`,
+		charID, boxType, boxIndex, name,
+	)
+	return err
+}
+
+// updateWarehouseBox merges the changes into a page in a single transaction,
+// so two updates of the same page can't drop each other's stacks.
+func updateWarehouseBox(db *sqlx.DB, charID uint32, boxType uint8, boxIndex uint8, changes []mhfpacket.WarehouseStack) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Makes sure the row exists so it can be locked.
+	_, err = tx.Exec(`
+		INSERT INTO warehouse_boxes (character_id, box_type, box_index) VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`,
+		charID, boxType, boxIndex,
+	)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	err = tx.QueryRow(
+		"SELECT data FROM warehouse_boxes WHERE character_id=$1 AND box_type=$2 AND box_index=$3 FOR UPDATE",
+		charID, boxType, boxIndex,
+	).Scan(&data)
+	if err != nil {
+		return err
+	}
+	stacks := mergeWarehouseBox(boxType, decodeWarehouseBox(boxType, data), changes)
+	_, err = tx.Exec(
+		"UPDATE warehouse_boxes SET data=$4 WHERE character_id=$1 AND box_type=$2 AND box_index=$3",
+		charID, boxType, boxIndex, storeWarehouseBox(boxType, stacks),
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// warehouseUsages returns the usages left today, and when they renew once
+// used up. consume uses the warehouse once.
+func warehouseUsages(db *sqlx.DB, charID uint32, consume bool) (uint16, time.Time, error) {
+	renewal := Time_Current_Midnight().Add(24 * time.Hour)
+	used := 0
+	if consume {
+		used = 1
+	}
+	var usages int
+	err := db.QueryRow(`
+		INSERT INTO warehouse (character_id, usages, usages_renewal) VALUES ($1, $2::int - $3::int, $4)
+		ON CONFLICT (character_id) DO UPDATE SET
+			usages = GREATEST(CASE WHEN warehouse.usages_renewal IS NULL OR warehouse.usages_renewal <= now()
+				THEN $2::int ELSE warehouse.usages END - $3::int, 0),
+			usages_renewal = CASE WHEN warehouse.usages_renewal IS NULL OR warehouse.usages_renewal <= now()
+				THEN $4 ELSE warehouse.usages_renewal END
+		RETURNING usages, usages_renewal`,
+		charID, warehouseDailyUsages, used, renewal,
+	).Scan(&usages, &renewal)
+	return uint16(usages), renewal, err
+}
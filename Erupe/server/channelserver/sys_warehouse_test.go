@@ -0,0 +1,84 @@
+package channelserver
+
+import (
+	"reflect"
+	"testing"
+
+	"erupe-ce/network/mhfpacket"
+)
+
+func TestMergeWarehouseBox(t *testing.T) {
+	stacks := []mhfpacket.WarehouseStack{
+		{ID: 1, ItemID: 100, Quantity: 5},
+		{ID: 2, ItemID: 200, Quantity: 1},
+	}
+	changes := []mhfpacket.WarehouseStack{
+		{ID: 1, Index: 3, ItemID: 100, Quantity: 9}, // Updated
+		{ID: 2, Index: 4, ItemID: 200, Quantity: 0}, // Removed
+		{ID: 0, Index: 5, ItemID: 300, Quantity: 2}, // Added
+	}
+	got := mergeWarehouseBox(warehouseItemBox, stacks, changes)
+	expected := []mhfpacket.WarehouseStack{
+		{ID: 1, Index: 3, ItemID: 100, Quantity: 9},
+		{ID: 3, Index: 5, ItemID: 300, Quantity: 2},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %+v, expected %+v", got, expected)
+	}
+
+	data := storeWarehouseBox(warehouseItemBox, got)
+	if decoded := decodeWarehouseBox(warehouseItemBox, data); !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("items round trip: got %+v, expected %+v", decoded, expected)
+	}
+	// Pages stored without slots keep their stacks.
+	legacy := decodeWarehouseBox(warehouseItemBox, encodeWarehouseBox(warehouseItemBox, got))
+	if len(legacy) != 2 || legacy[0].Index != warehouseUnknownIndex || legacy[1].Quantity != 2 {
+		t.Errorf("unexpected stacks from a page without slots %+v", legacy)
+	}
+}
+
+func TestMergeWarehouseBoxRepeatedNewStack(t *testing.T) {
+	// The client doesn't know the ID of a stack it just stored, and sends it
+	// again without ID when it changes before the page is enumerated again.
+	stacks := mergeWarehouseBox(warehouseItemBox, nil, []mhfpacket.WarehouseStack{{Index: 2, ItemID: 300, Quantity: 2}})
+	stacks = decodeWarehouseBox(warehouseItemBox, storeWarehouseBox(warehouseItemBox, stacks))
+	stacks = mergeWarehouseBox(warehouseItemBox, stacks, []mhfpacket.WarehouseStack{
+		{Index: 2, ItemID: 300, Quantity: 5}, // Same stack
+		{Index: 3, ItemID: 300, Quantity: 1}, // Another stack of the same item
+	})
+	expected := []mhfpacket.WarehouseStack{
+		{ID: 1, Index: 2, ItemID: 300, Quantity: 5},
+		{ID: 2, Index: 3, ItemID: 300, Quantity: 1},
+	}
+	if !reflect.DeepEqual(stacks, expected) {
+		t.Errorf("got %+v, expected %+v", stacks, expected)
+	}
+
+	stacks = mergeWarehouseBox(warehouseItemBox, stacks, []mhfpacket.WarehouseStack{{Index: 2, ItemID: 300, Quantity: 0}})
+	if len(stacks) != 1 || stacks[0].ID != 2 {
+		t.Errorf("expected the emptied stack to be removed, got %+v", stacks)
+	}
+}
+
+func TestWarehouseEquipment(t *testing.T) {
+	equip := make([]byte, mhfpacket.WarehouseEquipDataSize)
+	equip[0] = 0xFF
+	got := mergeWarehouseBox(warehouseEquipBox, nil, []mhfpacket.WarehouseStack{
+		{EquipType: 2, ItemID: 10, Data: equip},
+		{Index: 1, EquipType: 3, ItemID: 0, Data: equip}, // Nothing to store
+	})
+	expected := []mhfpacket.WarehouseStack{{ID: 1, EquipType: 2, ItemID: 10, Data: equip}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %+v, expected %+v", got, expected)
+	}
+
+	data := storeWarehouseBox(warehouseEquipBox, got)
+	if decoded := decodeWarehouseBox(warehouseEquipBox, data); !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("equipment round trip: got %+v, expected %+v", decoded, expected)
+	}
+	// A truncated page keeps its complete stacks only.
+	data = encodeWarehouseBox(warehouseEquipBox, got)
+	if decoded := decodeWarehouseBox(warehouseEquipBox, data[:len(data)-1]); len(decoded) != 0 {
+		t.Errorf("expected no stack from truncated data, got %+v", decoded)
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"io"
 	"net"
 	"sync"
+	"time"
 
 	"erupe-ce/config"
 	"erupe-ce/network"
+	"erupe-ce/server/bus"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
@@ -21,6 +23,8 @@ type Server struct {
 	db             *sqlx.DB
 	listener       net.Listener
 	isShuttingDown bool
+	bus            bus.Bus
+	registry       *bus.Registry
 }
 
 // Config struct allows configuring the server.
@@ -28,6 +32,8 @@ type Config struct {
 	Logger      *zap.Logger
 	DB          *sqlx.DB
 	ErupeConfig *config.Config
+	// Bus carrying the channel heartbeats, a local one is created if nil.
+	Bus bus.Bus
 }
 
 // NewServer creates a new Server type.
@@ -36,6 +42,10 @@ func NewServer(config *Config) *Server {
 		logger:      config.Logger,
 		erupeConfig: config.ErupeConfig,
 		db:          config.DB,
+		bus:         config.Bus,
+	}
+	if s.bus == nil {
+		s.bus = bus.NewLocal(s.logger)
 	}
 	return s
 }
@@ -49,6 +59,7 @@ func (s *Server) Start() error {
 	}
 
 	s.listener = l
+	s.registry = bus.NewRegistry(s.bus, time.Duration(s.erupeConfig.Entrance.HeartbeatTimeout)*time.Second)
 
 	go s.acceptClients()
 
@@ -65,6 +76,7 @@ func (s *Server) Shutdown() {
 
 	// This will cause the acceptor goroutine to error and exit gracefully.
 	s.listener.Close()
+	s.registry.Close()
 }
 
 //acceptClients handles accepting new clients in a loop.
@@ -111,6 +123,7 @@ func (s *Server) handleEntranceServerConnection(conn net.Conn) {
 
 	s.logger.Debug("Got entrance server command:\n", zap.String("raw", hex.Dump(pkt)))
 
+	metricRequests.Inc()
 	data := makeSv2Resp(s.erupeConfig, s)
 	if len(pkt) > 5 {
 		data = append(data, makeUsrResp(pkt, s)...)
@@ -8,32 +8,65 @@ import (
 
 	"erupe-ce/common/byteframe"
 	"erupe-ce/config"
+	"erupe-ce/server/bus"
 	"erupe-ce/server/channelserver"
 )
 
-// Server Entries
-var season uint8
+// channelStatus looks up the status a channel last reported.
+type channelStatus func(serverID uint16) (bus.ChannelStatus, bool)
 
-// Server Channels
-var currentplayers uint16
-
-func encodeServerInfo(config *config.Config, s *Server) []byte {
+// encodeServerInfo encodes the worlds and channels listed to the client,
+// returning the number of worlds. Channels that stopped heartbeating are
+// listed as full, or hidden with entrance.hideStaleChannels.
+func encodeServerInfo(config *config.Config, status channelStatus) ([]byte, uint16) {
 	serverInfos := config.Entrance.Entries
 	bf := byteframe.NewByteFrame()
+	var worlds uint16
 
 	for serverIdx, si := range serverInfos {
-		sid := (4096 + serverIdx * 256) + 16
-		err := s.db.QueryRow("SELECT season FROM servers WHERE server_id=$1", sid).Scan(&season)
-		if err != nil {
-			panic(err)
+		var season uint8
+		channels := byteframe.NewByteFrame()
+		var channelCount uint16
+		for channelIdx, ci := range si.Channels {
+			sid := (4096 + serverIdx*256) + (16 + channelIdx)
+			cs, ok := status(uint16(sid))
+			if ok {
+				season = cs.Season
+			} else {
+				cs.Alive = false
+			}
+			if !cs.Alive && config.Entrance.HideStaleChannels {
+				continue
+			}
+			players := cs.Players
+			if !cs.Alive || cs.Maintenance {
+				players = ci.MaxPlayers
+			}
+			channelCount++
+			channels.WriteUint16(ci.Port)
+			channels.WriteUint16(16 + uint16(channelIdx))
+			channels.WriteUint16(ci.MaxPlayers)
+			channels.WriteUint16(players)
+			channels.WriteUint32(0)
+			channels.WriteUint32(0)
+			channels.WriteUint32(0)
+			channels.WriteUint16(ci.Unk0)
+			channels.WriteUint16(ci.Unk1)
+			channels.WriteUint16(ci.Unk2)
+			channels.WriteUint16(0x3039)
+		}
+		if channelCount == 0 && config.Entrance.HideStaleChannels {
+			continue
 		}
+		worlds++
+
 		if si.IP == "" {
 			si.IP = config.HostIP
 		}
 		bf.WriteUint32(binary.LittleEndian.Uint32(net.ParseIP(si.IP).To4()))
 		bf.WriteUint16(16 + uint16(serverIdx))
 		bf.WriteUint16(0x0000)
-		bf.WriteUint16(uint16(len(si.Channels)))
+		bf.WriteUint16(channelCount)
 		bf.WriteUint8(si.Type)
 		bf.WriteUint8(season)
 		bf.WriteUint8(si.Recommended)
@@ -42,29 +75,11 @@ func encodeServerInfo(config *config.Config, s *Server) []byte {
 		combined = append(combined, stringsupport.UTF8ToSJIS(si.Description)...)
 		bf.WriteBytes(stringsupport.PaddedString(string(combined), 66, false))
 		bf.WriteUint32(si.AllowedClientFlags)
-
-		for channelIdx, ci := range si.Channels {
-			sid = (4096 + serverIdx * 256) + (16 + channelIdx)
-			bf.WriteUint16(ci.Port)
-			bf.WriteUint16(16 + uint16(channelIdx))
-			bf.WriteUint16(ci.MaxPlayers)
-			err := s.db.QueryRow("SELECT current_players FROM servers WHERE server_id=$1", sid).Scan(&currentplayers)
-			if err != nil {
-				panic(err)
-			}
-			bf.WriteUint16(currentplayers)
-			bf.WriteUint32(0)
-			bf.WriteUint32(0)
-			bf.WriteUint32(0)
-			bf.WriteUint16(ci.Unk0)
-			bf.WriteUint16(ci.Unk1)
-			bf.WriteUint16(ci.Unk2)
-			bf.WriteUint16(0x3039)
-		}
+		bf.WriteBytes(channels.Data())
 	}
 	bf.WriteUint32(uint32(channelserver.Time_Current_Adjusted().Unix()))
 	bf.WriteUint32(0x0000003C)
-	return bf.Data()
+	return bf.Data(), worlds
 }
 
 func makeHeader(data []byte, respType string, entryCount uint16, key byte) []byte {
@@ -86,10 +101,9 @@ func makeHeader(data []byte, respType string, entryCount uint16, key byte) []byt
 }
 
 func makeSv2Resp(config *config.Config, s *Server) []byte {
-	serverInfos := config.Entrance.Entries
-	rawServerData := encodeServerInfo(config, s)
+	rawServerData, worlds := encodeServerInfo(config, s.registry.Channel)
 	bf := byteframe.NewByteFrame()
-	bf.WriteBytes(makeHeader(rawServerData, "SV2", uint16(len(serverInfos)), 0x00))
+	bf.WriteBytes(makeHeader(rawServerData, "SV2", worlds, 0x00))
 	return bf.Data()
 }
 
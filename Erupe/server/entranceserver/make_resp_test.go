@@ -0,0 +1,86 @@
+package entranceserver
+
+import (
+	"testing"
+
+	"erupe-ce/common/byteframe"
+	"erupe-ce/config"
+	"erupe-ce/server/bus"
+)
+
+func testEntranceConfig(hideStale bool) *config.Config {
+	return &config.Config{
+		HostIP: "127.0.0.1",
+		Entrance: config.Entrance{
+			HideStaleChannels: hideStale,
+			Entries: []config.EntranceServerInfo{
+				{Name: "World 1", Channels: []config.EntranceChannelInfo{
+					{Port: 54001, MaxPlayers: 100},
+					{Port: 54002, MaxPlayers: 100},
+				}},
+				{Name: "World 2", Channels: []config.EntranceChannelInfo{
+					{Port: 54011, MaxPlayers: 100},
+				}},
+			},
+		},
+	}
+}
+
+// readChannels returns the player count of every channel listed for each world.
+func readChannels(t *testing.T, data []byte, worlds uint16) [][]uint16 {
+	t.Helper()
+	bf := byteframe.NewByteFrameFromBytes(data)
+	var players [][]uint16
+	for i := 0; i < int(worlds); i++ {
+		bf.ReadUint32() // IP
+		bf.ReadUint16() // World ID
+		bf.ReadUint16()
+		count := bf.ReadUint16()
+		bf.ReadBytes(3)  // Type, season, recommended
+		bf.ReadBytes(66) // Name and description
+		bf.ReadUint32()  // Allowed client flags
+		var world []uint16
+		for j := 0; j < int(count); j++ {
+			bf.ReadUint16() // Port
+			bf.ReadUint16() // Channel ID
+			bf.ReadUint16() // Max players
+			world = append(world, bf.ReadUint16())
+			bf.ReadBytes(20)
+		}
+		players = append(players, world)
+	}
+	return players
+}
+
+func TestEncodeServerInfo(t *testing.T) {
+	statuses := map[uint16]bus.ChannelStatus{
+		0x1010: {Players: 12, Alive: true},
+		0x1011: {Players: 3, Alive: true, Maintenance: true},
+		// 0x1110 never reported.
+	}
+	status := func(serverID uint16) (bus.ChannelStatus, bool) {
+		cs, ok := statuses[serverID]
+		return cs, ok
+	}
+
+	data, worlds := encodeServerInfo(testEntranceConfig(false), status)
+	if worlds != 2 {
+		t.Fatalf("listed %d worlds, expected 2", worlds)
+	}
+	players := readChannels(t, data, worlds)
+	if len(players[0]) != 2 || players[0][0] != 12 || players[0][1] != 100 {
+		t.Errorf("world 1 players = %v, expected [12 100]", players[0])
+	}
+	if len(players[1]) != 1 || players[1][0] != 100 {
+		t.Errorf("world 2 players = %v, expected [100]", players[1])
+	}
+
+	data, worlds = encodeServerInfo(testEntranceConfig(true), status)
+	if worlds != 1 {
+		t.Fatalf("listed %d worlds with stale channels hidden, expected 1", worlds)
+	}
+	players = readChannels(t, data, worlds)
+	if len(players[0]) != 2 || players[0][1] != 100 {
+		t.Errorf("world 1 players = %v, expected the channel in maintenance listed as full", players[0])
+	}
+}
@@ -0,0 +1,6 @@
+package entranceserver
+
+import "erupe-ce/common/metrics"
+
+var metricRequests = metrics.NewCounterVec(
+	"erupe_entrance_requests_total", "Server list requests served by the entrance server.")
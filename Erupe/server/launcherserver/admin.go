@@ -0,0 +1,712 @@
+package launcherserver
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"erupe-ce/server/bus"
+	"erupe-ce/server/channelserver"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type adminError struct {
+	Error string `json:"error"`
+}
+
+type adminBroadcastRequest struct {
+	Message string `json:"message"`
+}
+
+type adminRights struct {
+	UserID int    `json:"user_id"`
+	Rights uint32 `json:"rights"`
+}
+
+type adminBanRequest struct {
+	Reason  string     `json:"reason"`
+	Expires *time.Time `json:"expires"` // Omitted for a permanent ban.
+}
+
+type adminInvite struct {
+	Code    string     `json:"code"`
+	Expires *time.Time `json:"expires"`
+}
+
+type adminNotice struct {
+	ID        int        `json:"id" db:"id"`
+	Title     string     `json:"title" db:"title"`
+	Body      string     `json:"body" db:"body"`
+	Priority  int        `json:"priority" db:"priority"`
+	Enabled   bool       `json:"enabled" db:"enabled"`
+	StartTime *time.Time `json:"start_time" db:"start_time"`
+	EndTime   *time.Time `json:"end_time" db:"end_time"`
+}
+
+type adminMaintenanceRequest struct {
+	Maintenance bool `json:"maintenance"`
+}
+
+type adminMailRequest struct {
+	channelserver.MailTarget
+	All     bool                     `json:"all"` // Required to target every character without HR/GR bounds.
+	Subject string                   `json:"subject"`
+	Body    string                   `json:"body"`
+	Items   []channelserver.MailItem `json:"items"`
+	Expires *time.Time               `json:"expires"`
+}
+
+type adminMailResponse struct {
+	Recipients int `json:"recipients"`
+}
+
+type adminTitlesRequest struct {
+	Titles []uint16 `json:"titles"`
+}
+
+type adminAllianceApplicationRequest struct {
+	GuildID uint32                             `json:"guild_id"`
+	Type    channelserver.GuildApplicationType `json:"type"`
+}
+
+type adminAllianceTransferRequest struct {
+	GuildID uint32 `json:"guild_id"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAdminError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, adminError{Error: message})
+}
+
+// adminAuth rejects any request that doesn't carry the configured bearer token.
+func (s *Server) adminAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		expected := s.erupeConfig.AdminAPI.Token
+		if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			s.logger.Warn("Rejected admin API request", zap.String("remoteaddr", r.RemoteAddr), zap.String("path", r.URL.Path))
+			writeAdminError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func adminListSessions(s *Server, w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.presence.Sessions())
+}
+
+func adminKickCharacter(s *Server, w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseUint(mux.Vars(r)["charID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid character id")
+		return
+	}
+	if !s.presence.IsOnline(uint32(charID)) {
+		writeAdminError(w, http.StatusNotFound, "character is not online")
+		return
+	}
+	err = s.bus.Publish(bus.Message{Kind: bus.KindKick, CharIDs: []uint32{uint32(charID)}})
+	if err != nil {
+		s.logger.Error("Failed to kick character", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "bus error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminBroadcast(s *Server, w http.ResponseWriter, r *http.Request) {
+	var req adminBroadcastRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Message == "" {
+		writeAdminError(w, http.StatusBadRequest, "expected a non-empty message")
+		return
+	}
+	err := s.bus.Publish(bus.Message{Kind: bus.KindChat, Text: req.Message})
+	if err != nil {
+		s.logger.Error("Failed to broadcast message", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "bus error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminListChannels(s *Server, w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.registry.Channels())
+}
+
+func adminSetMaintenance(s *Server, w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.ParseUint(mux.Vars(r)["serverID"], 10, 16)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid server id")
+		return
+	}
+	var req adminMaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAdminError(w, http.StatusBadRequest, "expected a maintenance flag")
+		return
+	}
+	if _, ok := s.registry.Channel(uint16(serverID)); !ok {
+		writeAdminError(w, http.StatusNotFound, "channel not found")
+		return
+	}
+	err = s.bus.Publish(bus.Message{Kind: bus.KindMaintenance, ServerID: uint16(serverID), Maintenance: req.Maintenance})
+	if err != nil {
+		s.logger.Error("Failed to set maintenance", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "bus error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminGetRights(s *Server, w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["userID"])
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	resp := adminRights{UserID: userID}
+	err = s.db.QueryRow("SELECT rights FROM users WHERE id=$1", userID).Scan(&resp.Rights)
+	if err == sql.ErrNoRows {
+		writeAdminError(w, http.StatusNotFound, "user not found")
+		return
+	} else if err != nil {
+		s.logger.Error("Failed to read user rights", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func adminSetRights(s *Server, w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["userID"])
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	var req adminRights
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAdminError(w, http.StatusBadRequest, "expected a rights value")
+		return
+	}
+	res, err := s.db.Exec("UPDATE users SET rights=$1 WHERE id=$2", req.Rights, userID)
+	if err != nil {
+		s.logger.Error("Failed to update user rights", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		writeAdminError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	s.logger.Info("Updated user rights", zap.Int("userID", userID), zap.Uint32("rights", req.Rights))
+	writeJSON(w, http.StatusOK, adminRights{UserID: userID, Rights: req.Rights})
+}
+
+func adminBanUser(s *Server, w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseUint(mux.Vars(r)["userID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	var req adminBanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAdminError(w, http.StatusBadRequest, "expected a reason and an optional expiry")
+		return
+	}
+	err = channelserver.BanUser(s.db, s.bus, uint32(userID), 0, req.Expires, req.Reason)
+	if err != nil {
+		s.logger.Error("Failed to ban user", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	s.logger.Info("Banned user", zap.Uint64("userID", userID), zap.String("reason", req.Reason))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminUnbanUser(s *Server, w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseUint(mux.Vars(r)["userID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	unbanned, err := channelserver.UnbanUser(s.db, uint32(userID), 0)
+	if err != nil {
+		s.logger.Error("Failed to unban user", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	if !unbanned {
+		writeAdminError(w, http.StatusNotFound, "user is not banned")
+		return
+	}
+	s.logger.Info("Unbanned user", zap.Uint64("userID", userID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminCreateInvite(s *Server, w http.ResponseWriter, r *http.Request) {
+	var req adminInvite
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAdminError(w, http.StatusBadRequest, "expected an optional code and expiry")
+		return
+	}
+	if req.Code == "" {
+		b := make([]byte, 6)
+		if _, err := rand.Read(b); err != nil {
+			writeAdminError(w, http.StatusInternalServerError, "failed to generate a code")
+			return
+		}
+		req.Code = hex.EncodeToString(b)
+	}
+	_, err := s.db.Exec("INSERT INTO invite_codes (code, expires) VALUES ($1, $2)", req.Code, req.Expires)
+	if err != nil {
+		s.logger.Error("Failed to create invite code", zap.Error(err))
+		writeAdminError(w, http.StatusConflict, "failed to create invite code")
+		return
+	}
+	writeJSON(w, http.StatusCreated, req)
+}
+
+func adminListNotices(s *Server, w http.ResponseWriter, r *http.Request) {
+	notices := []adminNotice{}
+	err := s.db.Select(&notices, "SELECT id, title, body, priority, enabled, start_time, end_time FROM notices ORDER BY priority DESC, id")
+	if err != nil {
+		s.logger.Error("Failed to list notices", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, notices)
+}
+
+func adminCreateNotice(s *Server, w http.ResponseWriter, r *http.Request) {
+	req := adminNotice{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Title == "" {
+		writeAdminError(w, http.StatusBadRequest, "expected a title and body")
+		return
+	}
+	err := s.db.QueryRow(`
+		INSERT INTO notices (title, body, priority, enabled, start_time, end_time)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		req.Title, req.Body, req.Priority, req.Enabled, req.StartTime, req.EndTime,
+	).Scan(&req.ID)
+	if err != nil {
+		s.logger.Error("Failed to create notice", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	writeJSON(w, http.StatusCreated, req)
+}
+
+func adminDeleteNotice(s *Server, w http.ResponseWriter, r *http.Request) {
+	noticeID, err := strconv.Atoi(mux.Vars(r)["noticeID"])
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid notice id")
+		return
+	}
+	res, err := s.db.Exec("DELETE FROM notices WHERE id=$1", noticeID)
+	if err != nil {
+		s.logger.Error("Failed to delete notice", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		writeAdminError(w, http.StatusNotFound, "notice not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminListSnapshots(s *Server, w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseUint(mux.Vars(r)["charID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid character id")
+		return
+	}
+	snapshots, err := channelserver.ListSaveSnapshots(s.db, uint32(charID))
+	if err != nil {
+		s.logger.Error("Failed to list savedata snapshots", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshots)
+}
+
+func adminRestoreSnapshot(s *Server, w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseUint(mux.Vars(r)["charID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid character id")
+		return
+	}
+	snapshotID, err := strconv.Atoi(mux.Vars(r)["snapshotID"])
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid snapshot id")
+		return
+	}
+	// The client would overwrite the restored save on its next save.
+	if s.presence.IsOnline(uint32(charID)) {
+		writeAdminError(w, http.StatusConflict, "character is online, kick it first")
+		return
+	}
+	err = channelserver.RestoreSaveSnapshot(s.db, uint32(charID), snapshotID)
+	if err == channelserver.ErrSnapshotNotFound {
+		writeAdminError(w, http.StatusNotFound, "snapshot not found")
+		return
+	} else if err != nil {
+		s.logger.Error("Failed to restore savedata snapshot", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	s.logger.Info("Restored savedata snapshot", zap.Uint64("charID", charID), zap.Int("snapshotID", snapshotID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminListTitles(s *Server, w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseUint(mux.Vars(r)["charID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid character id")
+		return
+	}
+	titles, err := channelserver.ListTitles(s.db, uint32(charID))
+	if err != nil {
+		s.logger.Error("Failed to list titles", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, titles)
+}
+
+func adminGrantTitles(s *Server, w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseUint(mux.Vars(r)["charID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid character id")
+		return
+	}
+	var req adminTitlesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Titles) == 0 {
+		writeAdminError(w, http.StatusBadRequest, "expected a list of titles")
+		return
+	}
+	err = channelserver.GrantTitles(s.db, uint32(charID), req.Titles)
+	if err == channelserver.ErrUnknownTitle {
+		writeAdminError(w, http.StatusBadRequest, "unknown title")
+		return
+	} else if err != nil {
+		s.logger.Error("Failed to grant titles", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	s.logger.Info("Granted titles", zap.Uint64("charID", charID), zap.Any("titles", req.Titles))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminRevokeTitles(s *Server, w http.ResponseWriter, r *http.Request) {
+	charID, err := strconv.ParseUint(mux.Vars(r)["charID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid character id")
+		return
+	}
+	var req adminTitlesRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAdminError(w, http.StatusBadRequest, "expected a list of titles")
+			return
+		}
+	}
+	err = channelserver.RevokeTitles(s.db, uint32(charID), req.Titles)
+	if err != nil {
+		s.logger.Error("Failed to revoke titles", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	s.logger.Info("Revoked titles", zap.Uint64("charID", charID), zap.Any("titles", req.Titles))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminSendMail(s *Server, w http.ResponseWriter, r *http.Request) {
+	var req adminMailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Subject == "" {
+		writeAdminError(w, http.StatusBadRequest, "expected a subject, a body and a target")
+		return
+	}
+	t := req.MailTarget
+	if t.CharID != 0 && t.GuildID != 0 {
+		writeAdminError(w, http.StatusBadRequest, "expected either a character or a guild")
+		return
+	}
+	filtered := t.MinHR != 0 || t.MaxHR != 0 || t.MinGR != 0 || t.MaxGR != 0
+	if t.CharID == 0 && t.GuildID == 0 && !filtered && !req.All {
+		writeAdminError(w, http.StatusBadRequest, "expected a char_id, a guild_id, HR/GR bounds or all")
+		return
+	}
+	for _, item := range req.Items {
+		if item.ItemID == 0 || item.Amount == 0 {
+			writeAdminError(w, http.StatusBadRequest, "attached items need an item_id and an amount")
+			return
+		}
+	}
+	n, err := channelserver.SendSystemMail(s.db, s.bus, t, req.Subject, req.Body, req.Items, req.Expires)
+	if err == channelserver.ErrNoMailRecipients {
+		writeAdminError(w, http.StatusNotFound, "no character matches the target")
+		return
+	} else if err != nil {
+		s.logger.Error("Failed to send system mail", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	s.logger.Info("Sent system mail", zap.String("subject", req.Subject), zap.Int("recipients", n), zap.Int("items", len(req.Items)))
+	writeJSON(w, http.StatusCreated, adminMailResponse{Recipients: n})
+}
+
+func adminListDivaEvents(s *Server, w http.ResponseWriter, r *http.Request) {
+	events, err := channelserver.ListDivaEvents(s.db)
+	if err != nil {
+		s.logger.Error("Failed to list Diva Defense events", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+func adminCreateDivaEvent(s *Server, w http.ResponseWriter, r *http.Request) {
+	var req channelserver.DivaEvent
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAdminError(w, http.StatusBadRequest, "expected a start_time, interception_time, song_time and end_time")
+		return
+	}
+	err := channelserver.CreateDivaEvent(s.db, &req)
+	if err == channelserver.ErrInvalidDivaEvent {
+		writeAdminError(w, http.StatusBadRequest, "event phases must follow each other")
+		return
+	} else if err != nil {
+		s.logger.Error("Failed to create Diva Defense event", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	s.logger.Info("Scheduled Diva Defense event", zap.Int("eventID", req.ID), zap.Time("start", req.StartTime))
+	writeJSON(w, http.StatusCreated, req)
+}
+
+func adminDeleteDivaEvent(s *Server, w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.Atoi(mux.Vars(r)["eventID"])
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid event id")
+		return
+	}
+	err = channelserver.DeleteDivaEvent(s.db, eventID)
+	if err == channelserver.ErrDivaEventNotFound {
+		writeAdminError(w, http.StatusNotFound, "event not found")
+		return
+	} else if err != nil {
+		s.logger.Error("Failed to delete Diva Defense event", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminListFestaEvents(s *Server, w http.ResponseWriter, r *http.Request) {
+	events, err := channelserver.ListFestaEvents(s.db)
+	if err != nil {
+		s.logger.Error("Failed to list festas", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+func adminCreateFestaEvent(s *Server, w http.ResponseWriter, r *http.Request) {
+	var req channelserver.FestaEvent
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAdminError(w, http.StatusBadRequest, "expected a start_time, trial_time, tally_time, reward_time and end_time")
+		return
+	}
+	err := channelserver.CreateFestaEvent(s.db, &req)
+	if err == channelserver.ErrInvalidFestaEvent {
+		writeAdminError(w, http.StatusBadRequest, "festa phases must follow each other and prizes need a personal or intermediate type and a known title")
+		return
+	} else if err != nil {
+		s.logger.Error("Failed to create festa", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	s.logger.Info("Scheduled festa", zap.Uint32("festaID", req.ID), zap.Time("start", req.StartTime))
+	writeJSON(w, http.StatusCreated, req)
+}
+
+func adminDeleteFestaEvent(s *Server, w http.ResponseWriter, r *http.Request) {
+	festaID, err := strconv.ParseUint(mux.Vars(r)["festaID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid festa id")
+		return
+	}
+	err = channelserver.DeleteFestaEvent(s.db, uint32(festaID))
+	if err == channelserver.ErrFestaEventNotFound {
+		writeAdminError(w, http.StatusNotFound, "festa not found")
+		return
+	} else if err != nil {
+		s.logger.Error("Failed to delete festa", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminListAllianceApplications(s *Server, w http.ResponseWriter, r *http.Request) {
+	allianceID, err := strconv.ParseUint(mux.Vars(r)["allianceID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid alliance id")
+		return
+	}
+	applications, err := channelserver.ListAllianceApplications(s.db, uint32(allianceID))
+	if err != nil {
+		s.logger.Error("Failed to list alliance applications", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, applications)
+}
+
+func adminCreateAllianceApplication(s *Server, w http.ResponseWriter, r *http.Request) {
+	allianceID, err := strconv.ParseUint(mux.Vars(r)["allianceID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid alliance id")
+		return
+	}
+	var req adminAllianceApplicationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.GuildID == 0 ||
+		(req.Type != channelserver.GuildApplicationTypeApplied && req.Type != channelserver.GuildApplicationTypeInvited) {
+		writeAdminError(w, http.StatusBadRequest, "expected a guild_id and a type of applied or invited")
+		return
+	}
+	err = channelserver.CreateAllianceApplication(s.db, uint32(allianceID), req.GuildID, req.Type)
+	if err == channelserver.ErrAllianceNotJoinable {
+		writeAdminError(w, http.StatusConflict, "the alliance is full or the guild cannot join it")
+		return
+	} else if err != nil {
+		s.logger.Error("Failed to create alliance application", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	s.logger.Info("Created alliance application", zap.Uint64("allianceID", allianceID), zap.Uint32("guildID", req.GuildID), zap.String("type", string(req.Type)))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminAcceptAllianceApplication(s *Server, w http.ResponseWriter, r *http.Request) {
+	allianceID, err := strconv.ParseUint(mux.Vars(r)["allianceID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid alliance id")
+		return
+	}
+	guildID, err := strconv.ParseUint(mux.Vars(r)["guildID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid guild id")
+		return
+	}
+	err = channelserver.JoinAlliance(s.db, uint32(allianceID), uint32(guildID))
+	if err == channelserver.ErrAllianceApplicationNotFound {
+		writeAdminError(w, http.StatusNotFound, "application not found")
+		return
+	} else if err == channelserver.ErrAllianceNotJoinable {
+		writeAdminError(w, http.StatusConflict, "the alliance is full or the guild cannot join it")
+		return
+	} else if err != nil {
+		s.logger.Error("Failed to accept alliance application", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	s.logger.Info("Accepted alliance application", zap.Uint64("allianceID", allianceID), zap.Uint64("guildID", guildID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminRejectAllianceApplication(s *Server, w http.ResponseWriter, r *http.Request) {
+	allianceID, err := strconv.ParseUint(mux.Vars(r)["allianceID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid alliance id")
+		return
+	}
+	guildID, err := strconv.ParseUint(mux.Vars(r)["guildID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid guild id")
+		return
+	}
+	err = channelserver.DeleteAllianceApplication(s.db, uint32(allianceID), uint32(guildID))
+	if err == channelserver.ErrAllianceApplicationNotFound {
+		writeAdminError(w, http.StatusNotFound, "application not found")
+		return
+	} else if err != nil {
+		s.logger.Error("Failed to reject alliance application", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminTransferAlliance(s *Server, w http.ResponseWriter, r *http.Request) {
+	allianceID, err := strconv.ParseUint(mux.Vars(r)["allianceID"], 10, 32)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, "invalid alliance id")
+		return
+	}
+	var req adminAllianceTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.GuildID == 0 {
+		writeAdminError(w, http.StatusBadRequest, "expected a guild_id")
+		return
+	}
+	err = channelserver.TransferAlliance(s.db, uint32(allianceID), req.GuildID)
+	if err == channelserver.ErrNotAllianceSubGuild {
+		writeAdminError(w, http.StatusNotFound, "the guild is not a sub guild of the alliance")
+		return
+	} else if err != nil {
+		s.logger.Error("Failed to transfer alliance", zap.Error(err))
+		writeAdminError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	s.logger.Info("Transferred alliance", zap.Uint64("allianceID", allianceID), zap.Uint32("guildID", req.GuildID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) setupAdminRoutes(r *mux.Router) {
+	admin := r.PathPrefix("/api/admin/").Subrouter()
+	admin.Use(s.adminAuth)
+	admin.Handle("/sessions", ServerHandlerFunc{s, adminListSessions}).Methods("GET")
+	admin.Handle("/sessions/{charID:[0-9]+}/kick", ServerHandlerFunc{s, adminKickCharacter}).Methods("POST")
+	admin.Handle("/channels", ServerHandlerFunc{s, adminListChannels}).Methods("GET")
+	admin.Handle("/channels/{serverID:[0-9]+}/maintenance", ServerHandlerFunc{s, adminSetMaintenance}).Methods("PUT")
+	admin.Handle("/broadcast", ServerHandlerFunc{s, adminBroadcast}).Methods("POST")
+	admin.Handle("/users/{userID:[0-9]+}/rights", ServerHandlerFunc{s, adminGetRights}).Methods("GET")
+	admin.Handle("/users/{userID:[0-9]+}/rights", ServerHandlerFunc{s, adminSetRights}).Methods("PUT")
+	admin.Handle("/users/{userID:[0-9]+}/ban", ServerHandlerFunc{s, adminBanUser}).Methods("POST")
+	admin.Handle("/users/{userID:[0-9]+}/ban", ServerHandlerFunc{s, adminUnbanUser}).Methods("DELETE")
+	admin.Handle("/characters/{charID:[0-9]+}/snapshots", ServerHandlerFunc{s, adminListSnapshots}).Methods("GET")
+	admin.Handle("/characters/{charID:[0-9]+}/snapshots/{snapshotID:[0-9]+}/restore", ServerHandlerFunc{s, adminRestoreSnapshot}).Methods("POST")
+	admin.Handle("/characters/{charID:[0-9]+}/titles", ServerHandlerFunc{s, adminListTitles}).Methods("GET")
+	admin.Handle("/characters/{charID:[0-9]+}/titles", ServerHandlerFunc{s, adminGrantTitles}).Methods("POST")
+	admin.Handle("/characters/{charID:[0-9]+}/titles", ServerHandlerFunc{s, adminRevokeTitles}).Methods("DELETE")
+	admin.Handle("/mail", ServerHandlerFunc{s, adminSendMail}).Methods("POST")
+	admin.Handle("/invites", ServerHandlerFunc{s, adminCreateInvite}).Methods("POST")
+	admin.Handle("/notices", ServerHandlerFunc{s, adminListNotices}).Methods("GET")
+	admin.Handle("/notices", ServerHandlerFunc{s, adminCreateNotice}).Methods("POST")
+	admin.Handle("/notices/{noticeID:[0-9]+}", ServerHandlerFunc{s, adminDeleteNotice}).Methods("DELETE")
+	admin.Handle("/diva/events", ServerHandlerFunc{s, adminListDivaEvents}).Methods("GET")
+	admin.Handle("/diva/events", ServerHandlerFunc{s, adminCreateDivaEvent}).Methods("POST")
+	admin.Handle("/diva/events/{eventID:[0-9]+}", ServerHandlerFunc{s, adminDeleteDivaEvent}).Methods("DELETE")
+	admin.Handle("/festa/events", ServerHandlerFunc{s, adminListFestaEvents}).Methods("GET")
+	admin.Handle("/festa/events", ServerHandlerFunc{s, adminCreateFestaEvent}).Methods("POST")
+	admin.Handle("/festa/events/{festaID:[0-9]+}", ServerHandlerFunc{s, adminDeleteFestaEvent}).Methods("DELETE")
+	admin.Handle("/alliances/{allianceID:[0-9]+}/applications", ServerHandlerFunc{s, adminListAllianceApplications}).Methods("GET")
+	admin.Handle("/alliances/{allianceID:[0-9]+}/applications", ServerHandlerFunc{s, adminCreateAllianceApplication}).Methods("POST")
+	admin.Handle("/alliances/{allianceID:[0-9]+}/applications/{guildID:[0-9]+}/accept", ServerHandlerFunc{s, adminAcceptAllianceApplication}).Methods("POST")
+	admin.Handle("/alliances/{allianceID:[0-9]+}/applications/{guildID:[0-9]+}", ServerHandlerFunc{s, adminRejectAllianceApplication}).Methods("DELETE")
+	admin.Handle("/alliances/{allianceID:[0-9]+}/transfer", ServerHandlerFunc{s, adminTransferAlliance}).Methods("POST")
+}
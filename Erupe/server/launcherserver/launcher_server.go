@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"erupe-ce/config"
+	"erupe-ce/server/bus"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/jmoiron/sqlx"
@@ -21,6 +22,8 @@ type Config struct {
 	DB                       *sqlx.DB
 	ErupeConfig              *config.Config
 	UseOriginalLauncherFiles bool
+	// Bus reaching the channel servers, a local one is created if nil.
+	Bus bus.Bus
 }
 
 // Server is the MHF launcher HTTP server.
@@ -32,6 +35,9 @@ type Server struct {
 	httpServer               *http.Server
 	useOriginalLauncherFiles bool
 	isShuttingDown           bool
+	bus                      bus.Bus
+	presence                 *bus.Presence
+	registry                 *bus.Registry
 }
 
 // NewServer creates a new Server type.
@@ -42,6 +48,10 @@ func NewServer(config *Config) *Server {
 		db:                       config.DB,
 		useOriginalLauncherFiles: config.UseOriginalLauncherFiles,
 		httpServer:               &http.Server{},
+		bus:                      config.Bus,
+	}
+	if s.bus == nil {
+		s.bus = bus.NewLocal(s.logger)
 	}
 	return s
 }
@@ -51,6 +61,13 @@ func (s *Server) Start() error {
 	// Set up the routes responsible for serving the launcher HTML, serverlist, unique name check, and JP auth.
 	r := mux.NewRouter()
 
+	// Admin REST API, only reachable with the configured token.
+	if s.erupeConfig.AdminAPI.Enabled {
+		s.presence = bus.NewPresence(s.bus)
+		s.registry = bus.NewRegistry(s.bus, time.Duration(s.erupeConfig.Entrance.HeartbeatTimeout)*time.Second)
+		s.setupAdminRoutes(r)
+	}
+
 	// Universal serverlist.xml route
 	s.setupServerlistRoutes(r)
 
@@ -95,4 +112,8 @@ func (s *Server) Shutdown() {
 		// Just warn because we are shutting down the server anyway.
 		s.logger.Warn("Got error on httpServer shutdown", zap.Error(err))
 	}
+	if s.presence != nil {
+		s.presence.Close()
+		s.registry.Close()
+	}
 }
@@ -1,6 +1,7 @@
 package signserver
 
 import (
+	"database/sql"
 	"time"
 	"strings"
 
@@ -40,26 +41,44 @@ func (s *Server) newUserChara(username string) error {
 	return nil
 }
 
-func (s *Server) registerDBAccount(username string, password string) error {
+// registerDBAccount creates an account with a base new character and returns its ID.
+// When inviteCode is set, the code is consumed in the same transaction and the
+// account is only created if the code was still unused.
+func (s *Server) registerDBAccount(username string, password string, inviteCode string) (int, error) {
 	// Create salted hash of user password
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	_, err = s.db.Exec("INSERT INTO users (username, password) VALUES ($1, $2)", username, string(passwordHash))
+	tx, err := s.db.Begin()
 	if err != nil {
-		return err
+		return 0, err
 	}
+	defer tx.Rollback()
 
 	var id int
-	err = s.db.QueryRow("SELECT id FROM users WHERE username = $1", username).Scan(&id)
+	err = tx.QueryRow("INSERT INTO users (username, password) VALUES ($1, $2) RETURNING id", username, string(passwordHash)).Scan(&id)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	if inviteCode != "" {
+		res, err := tx.Exec(`
+			UPDATE invite_codes SET used_by=$1, used_at=now()
+			WHERE code=$2 AND used_by IS NULL AND (expires IS NULL OR expires > now())`,
+			id, inviteCode,
+		)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return 0, errInvalidInvite
+		}
 	}
 
 	// Create a base new character.
-	_, err = s.db.Exec(`
+	_, err = tx.Exec(`
 		INSERT INTO characters (
 			user_id, is_female, is_new_character, name, unk_desc_string,
 			hrp, gr, weapon_type, last_login)
@@ -68,10 +87,10 @@ func (s *Server) registerDBAccount(username string, password string) error {
 		uint32(time.Now().Unix()),
 	)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return id, tx.Commit()
 }
 
 type character struct {
@@ -95,6 +114,19 @@ func (s *Server) getCharactersForUser(uid int) ([]character, error) {
 	return characters, nil
 }
 
+// getActiveBan reports whether the account is banned. A valid expiry is only
+// returned for temporary bans, permanent bans have a NULL expiry.
+func (s *Server) getActiveBan(uid int) (bool, sql.NullTime, error) {
+	var expires sql.NullTime
+	err := s.db.QueryRow("SELECT expires FROM account_ban WHERE user_id=$1 AND (expires IS NULL OR expires > now())", uid).Scan(&expires)
+	if err == sql.ErrNoRows {
+		return false, expires, nil
+	} else if err != nil {
+		return false, expires, err
+	}
+	return true, expires, nil
+}
+
 func (s *Server) getLastCID(uid int) uint32 {
 	var lastPlayed uint32
 	_ = s.db.QueryRow("SELECT last_character FROM users WHERE id=$1", uid).Scan(&lastPlayed)
@@ -0,0 +1,6 @@
+package signserver
+
+import "erupe-ce/common/metrics"
+
+var metricSignIns = metrics.NewCounterVec(
+	"erupe_sign_ins_total", "Sign-in attempts by result.", "result")
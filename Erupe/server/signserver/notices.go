@@ -0,0 +1,55 @@
+package signserver
+
+import (
+	"strings"
+
+	"erupe-ce/common/byteframe"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// notice is a message shown on the sign-in screen.
+type notice struct {
+	ID    int    `db:"id"`
+	Title string `db:"title"`
+	Body  string `db:"body"`
+}
+
+// maxNotices is the most notices the response can hold.
+const maxNotices = 255
+
+func (s *Server) getActiveNotices() ([]notice, error) {
+	var notices []notice
+	err := s.db.Select(&notices, `
+		SELECT id, title, body FROM notices
+		WHERE enabled
+		AND (start_time IS NULL OR start_time <= now())
+		AND (end_time IS NULL OR end_time > now())
+		ORDER BY priority DESC, id
+		LIMIT $1`, maxNotices)
+	return notices, err
+}
+
+// formatNotice lays a notice out in the client markup: a centered title
+// followed by the body. The body may use the client tags (<C_n>, <BR>...)
+// directly, plain newlines are turned into line breaks.
+func formatNotice(n notice) string {
+	body := strings.ReplaceAll(strings.ReplaceAll(n.Body, "\r\n", "\n"), "\n", "<BR><BODY>")
+	return "<BODY><CENTER><SIZE_3><C_4>" + n.Title + "<BR><BODY><LEFT><SIZE_2><C_7>" + body
+}
+
+// writeNotices writes the notice count followed by every notice as a
+// length prefixed Shift-JIS string. Characters Shift-JIS can't represent are
+// replaced rather than dropping the notice.
+func writeNotices(bf *byteframe.ByteFrame, notices []notice) {
+	if len(notices) > maxNotices {
+		notices = notices[:maxNotices]
+	}
+	e := encoding.ReplaceUnsupported(japanese.ShiftJIS.NewEncoder())
+	bf.WriteUint8(uint8(len(notices)))
+	for _, n := range notices {
+		text, _ := e.String(formatNotice(n))
+		bf.WriteUint32(uint32(len(text) + 1))
+		bf.WriteNullTerminatedBytes([]byte(text))
+	}
+}
@@ -0,0 +1,32 @@
+package signserver
+
+import (
+	"bytes"
+	"testing"
+
+	"erupe-ce/common/byteframe"
+	"erupe-ce/common/stringsupport"
+)
+
+func TestWriteNotices(t *testing.T) {
+	bf := byteframe.NewByteFrame()
+	writeNotices(bf, []notice{
+		{Title: "メンテナンス", Body: "Line 1\nLine 2"},
+		{Title: "Event", Body: "<C_4>Emoji 🙂 replaced"},
+	})
+	bf.Seek(0, 0)
+	if n := bf.ReadUint8(); n != 2 {
+		t.Fatalf("expected 2 notices, got %d", n)
+	}
+
+	text := bf.ReadBytes(uint(bf.ReadUint32()))
+	expected := append(stringsupport.UTF8ToSJIS("<BODY><CENTER><SIZE_3><C_4>メンテナンス<BR><BODY><LEFT><SIZE_2><C_7>Line 1<BR><BODY>Line 2"), 0)
+	if !bytes.Equal(text, expected) {
+		t.Errorf("unexpected first notice %q", text)
+	}
+
+	text = bf.ReadBytes(uint(bf.ReadUint32()))
+	if text[len(text)-1] != 0 || !bytes.Contains(text, []byte("<C_4>Emoji ")) {
+		t.Errorf("unexpected second notice %q", text)
+	}
+}
@@ -0,0 +1,90 @@
+package signserver
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"erupe-ce/config"
+	"go.uber.org/zap"
+)
+
+// Account registration modes, see config.Sign.Registration.
+const (
+	RegistrationOpen   = "open"
+	RegistrationClosed = "closed"
+	RegistrationInvite = "invite"
+)
+
+// inviteSeparator splits the username from the invite code on the first sign-in, e.g. "hunter:K3Y".
+const inviteSeparator = ":"
+
+var errInvalidInvite = errors.New("invalid or already used invite code")
+
+// validateUsername checks a new username against the configured pattern.
+func validateUsername(cfg config.Sign, username string) bool {
+	if username == "" {
+		return false
+	}
+	return cfg.UsernameRegexp == nil || cfg.UsernameRegexp.MatchString(username)
+}
+
+// validatePassword checks a new password against the configured strength rule.
+func validatePassword(cfg config.Sign, password string) bool {
+	if len(password) < cfg.MinPasswordLength {
+		return false
+	}
+	if cfg.PasswordNeedsLetterAndDigit {
+		var letter, digit bool
+		for _, r := range password {
+			if unicode.IsLetter(r) {
+				letter = true
+			} else if unicode.IsDigit(r) {
+				digit = true
+			}
+		}
+		return letter && digit
+	}
+	return true
+}
+
+// registerAccount creates an account for an unknown username according to the
+// registration mode, returning the new account ID or the sign failure to send.
+func (s *Session) registerAccount(username string, password string) (int, RespID) {
+	cfg := s.server.erupeConfig.Sign
+
+	inviteCode := ""
+	switch cfg.Registration {
+	case RegistrationOpen, "":
+	case RegistrationInvite:
+		i := strings.LastIndex(username, inviteSeparator)
+		if i < 0 {
+			s.logger.Info("Rejected registration without invite code", zap.String("reqUsername", username))
+			return 0, SIGN_EAUTH
+		}
+		username, inviteCode = username[:i], username[i+1:]
+	default:
+		s.logger.Info("Rejected registration, registration is closed", zap.String("reqUsername", username))
+		return 0, SIGN_EAUTH
+	}
+
+	if !validateUsername(cfg, username) {
+		s.logger.Info("Rejected registration with invalid username", zap.String("reqUsername", username))
+		return 0, SIGN_EILLEGAL
+	}
+	if !validatePassword(cfg, password) {
+		s.logger.Info("Rejected registration with weak password", zap.String("reqUsername", username))
+		return 0, SIGN_EILLEGAL
+	}
+
+	s.logger.Info("Creating account", zap.String("reqUsername", username))
+	id, err := s.server.registerDBAccount(username, password, inviteCode)
+	if err == errInvalidInvite {
+		s.logger.Info("Rejected registration with invalid invite code", zap.String("reqUsername", username))
+		return 0, SIGN_EAUTH
+	} else if err != nil {
+		s.logger.Info("Error on creating new account", zap.Error(err))
+		return 0, SIGN_EABORT
+	}
+	return id, SIGN_SUCCESS
+}
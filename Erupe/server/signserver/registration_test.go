@@ -0,0 +1,48 @@
+package signserver
+
+import (
+	"regexp"
+	"testing"
+
+	"erupe-ce/config"
+)
+
+func TestValidateUsername(t *testing.T) {
+	cfg := config.Sign{UsernameRegexp: regexp.MustCompile("^[A-Za-z0-9_.-]{3,16}$")}
+	tests := []struct {
+		username string
+		valid    bool
+	}{
+		{"hunter", true},
+		{"hunter_01", true},
+		{"ab", false},
+		{"averyveryverylongname", false},
+		{"bad name", false},
+		{"hunter:code", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := validateUsername(cfg, tt.username); got != tt.valid {
+			t.Errorf("validateUsername(%q) = %t, expected %t", tt.username, got, tt.valid)
+		}
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		cfg      config.Sign
+		password string
+		valid    bool
+	}{
+		{config.Sign{MinPasswordLength: 6}, "secret", true},
+		{config.Sign{MinPasswordLength: 6}, "short", false},
+		{config.Sign{MinPasswordLength: 6, PasswordNeedsLetterAndDigit: true}, "secret", false},
+		{config.Sign{MinPasswordLength: 6, PasswordNeedsLetterAndDigit: true}, "secret1", true},
+		{config.Sign{MinPasswordLength: 6, PasswordNeedsLetterAndDigit: true}, "123456", false},
+	}
+	for _, tt := range tests {
+		if got := validatePassword(tt.cfg, tt.password); got != tt.valid {
+			t.Errorf("validatePassword(%+v, %q) = %t, expected %t", tt.cfg, tt.password, got, tt.valid)
+		}
+	}
+}
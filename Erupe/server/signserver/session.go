@@ -103,22 +103,11 @@ func (s *Session) handleDSGNRequest(bf *byteframe.ByteFrame) error {
 	switch {
 	case err == sql.ErrNoRows:
 		s.logger.Info("Account not found", zap.String("reqUsername", reqUsername))
-		serverRespBytes = makeSignInFailureResp(SIGN_EAUTH)
 
-		// HACK(Andoryuuta): Create a new account if it doesn't exit.
-		s.logger.Info("Creating account", zap.String("reqUsername", reqUsername), zap.String("reqPassword", reqPassword))
-		err = s.server.registerDBAccount(reqUsername, reqPassword)
-		if err != nil {
-			s.logger.Info("Error on creating new account", zap.Error(err))
-			serverRespBytes = makeSignInFailureResp(SIGN_EABORT)
-			break
-		}
-
-		var id int
-		err = s.server.db.QueryRow("SELECT id FROM users WHERE username = $1", reqUsername).Scan(&id)
-		if err != nil {
-			s.logger.Info("Error on querying account id", zap.Error(err))
-			serverRespBytes = makeSignInFailureResp(SIGN_EABORT)
+		// Unknown usernames create a new account, subject to the registration policy.
+		id, respID := s.registerAccount(reqUsername, reqPassword)
+		if respID != SIGN_SUCCESS {
+			serverRespBytes = makeSignInFailureResp(respID)
 			break
 		}
 
@@ -131,6 +120,22 @@ func (s *Session) handleDSGNRequest(bf *byteframe.ByteFrame) error {
 	default:
 		if bcrypt.CompareHashAndPassword([]byte(password), []byte(reqPassword)) == nil {
 			s.logger.Info("Passwords match!")
+			banned, expires, err := s.server.getActiveBan(id)
+			if err != nil {
+				s.logger.Warn("Error checking account ban", zap.Error(err))
+				serverRespBytes = makeSignInFailureResp(SIGN_EABORT)
+				break
+			}
+			if banned {
+				if expires.Valid {
+					s.logger.Info("Rejected suspended account", zap.String("reqUsername", reqUsername), zap.Time("expires", expires.Time))
+					serverRespBytes = makeSignInFailureResp(SIGN_ESUSPEND)
+				} else {
+					s.logger.Info("Rejected banned account", zap.String("reqUsername", reqUsername))
+					serverRespBytes = makeSignInFailureResp(SIGN_EELIMINATE)
+				}
+				break
+			}
 			if newCharaReq {
 				err = s.server.newUserChara(reqUsername)
 				if err != nil {
@@ -154,6 +159,12 @@ func (s *Session) handleDSGNRequest(bf *byteframe.ByteFrame) error {
 
 	}
 
+	if len(serverRespBytes) > 0 && RespID(serverRespBytes[0]) == SIGN_SUCCESS {
+		metricSignIns.Inc("success")
+	} else {
+		metricSignIns.Inc("failure")
+	}
+
 	err = s.cryptConn.SendPacket(serverRespBytes)
 	if err != nil {
 		return err
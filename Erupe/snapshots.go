@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"erupe-ce/config"
+	"erupe-ce/server/bus"
+	"erupe-ce/server/channelserver"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const snapshotsUsage = "usage: erupe snapshots list <char id> | restore [--force] <char id> <snapshot id>"
+
+// presenceSyncWait is how long the channels are given to report their sessions.
+const presenceSyncWait = 2 * time.Second
+
+// runSnapshotsCommand handles the `erupe snapshots` subcommand. Restores are
+// meant for offline characters, a logged in client saves over them, so they
+// are refused for characters online unless forced.
+func runSnapshotsCommand(db *sqlx.DB, erupeConfig *config.Config, logger *zap.Logger, args []string) error {
+	force := false
+	if len(args) > 1 && args[0] == "restore" && args[1] == "--force" {
+		force = true
+		args = append([]string{args[0]}, args[2:]...)
+	}
+	if len(args) < 2 {
+		return errors.New(snapshotsUsage)
+	}
+	charID, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		return errors.New(snapshotsUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		snapshots, err := channelserver.ListSaveSnapshots(db, uint32(charID))
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			fmt.Printf("No snapshots for character %d\n", charID)
+		}
+		for _, snapshot := range snapshots {
+			saveType := "blob"
+			if snapshot.SaveType == 1 {
+				saveType = "diff"
+			}
+			fmt.Printf("%d\t%s\t%s\t%d bytes\n", snapshot.ID, snapshot.CreatedAt.Format("2006-01-02 15:04:05 MST"), saveType, snapshot.Size)
+		}
+		return nil
+	case "restore":
+		if len(args) < 3 {
+			return errors.New(snapshotsUsage)
+		}
+		snapshotID, err := strconv.Atoi(args[2])
+		if err != nil {
+			return errors.New(snapshotsUsage)
+		}
+		if !force {
+			if err = checkOffline(db, erupeConfig, logger, uint32(charID)); err != nil {
+				return err
+			}
+		}
+		err = channelserver.RestoreSaveSnapshot(db, uint32(charID), snapshotID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Restored snapshot %d of character %d\n", snapshotID, charID)
+		return nil
+	default:
+		return errors.New(snapshotsUsage)
+	}
+}
+
+// checkOffline fails unless the channels report the character offline through
+// the bus, which only reaches them with the postgres driver.
+func checkOffline(db *sqlx.DB, erupeConfig *config.Config, logger *zap.Logger, charID uint32) error {
+	if erupeConfig.Bus.Driver != "postgres" {
+		return errors.New("the presence of characters can't be checked with a local bus, make sure the character is offline and pass --force")
+	}
+	serverBus, err := openBus(erupeConfig, db, logger)
+	if err != nil {
+		return err
+	}
+	defer serverBus.Close()
+	presence := bus.NewPresence(serverBus)
+	defer presence.Close()
+	time.Sleep(presenceSyncWait)
+	if presence.IsOnline(charID) {
+		return fmt.Errorf("character %d is online, kick it first", charID)
+	}
+	return nil
+}